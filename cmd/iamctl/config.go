@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile holds the connection settings of one server.
+type Profile struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+	Org    string `yaml:"org,omitempty"`
+
+	// Actor is the Okta user ID sent as the caller identity, for servers
+	// reached without an authenticating gateway.
+	Actor string `yaml:"actor,omitempty"`
+}
+
+// Config is the iamctl config file. It holds tokens, so it is written
+// readable by the owner only.
+type Config struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+func configPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the config directory, use -config: %w", err)
+	}
+	return filepath.Join(dir, "iamctl", "config.yaml"), nil
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	path, err := configPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{Profiles: map[string]*Profile{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+	return cfg, nil
+}
+
+func (c *Config) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// profile returns a copy of the named profile, else of the current one. No
+// profile at all is an empty profile, to be filled by flags.
+func (c *Config) profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	return *profile, nil
+}
+
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var profileCommands = map[string]command{
+	"list":   listProfiles,
+	"set":    setProfile,
+	"use":    useProfile,
+	"delete": deleteProfile,
+}
+
+func listProfiles(args []string) error {
+	flags, opts := newFlags("profiles list", formatTable)
+	if _, err := parse(flags, args, 0, ""); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	type profileRow struct {
+		Name    string `json:"name"`
+		Current bool   `json:"current"`
+		Server  string `json:"server"`
+		Org     string `json:"org,omitempty"`
+	}
+	var rows []profileRow
+	t := table{header: []string{"", "NAME", "SERVER", "ORG"}}
+	for _, name := range cfg.names() {
+		profile := cfg.Profiles[name]
+		rows = append(rows, profileRow{Name: name, Current: name == cfg.Current, Server: profile.Server, Org: profile.Org})
+
+		marker := ""
+		if name == cfg.Current {
+			marker = "*"
+		}
+		t.rows = append(t.rows, []string{marker, name, profile.Server, profile.Org})
+	}
+	return write(opts.output, rows, t)
+}
+
+// setProfile creates or updates a profile. Only the flags given change, and
+// the first profile becomes the current one.
+func setProfile(args []string) error {
+	flags, opts := newFlags("profiles set", formatTable)
+	actor := flags.String("actor", "", "Okta user ID sent as the caller identity")
+	names, err := parse(flags, args, 1, "<name> [-server URL] [-token TOKEN] [-org ORG] [-actor ID]")
+	if err != nil {
+		return err
+	}
+
+	// The -server, -token and -org defaults come from the environment, which
+	// must not end up in the file, so only flags given explicitly are taken.
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	name := names[0]
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		profile = &Profile{}
+		cfg.Profiles[name] = profile
+	}
+	if set["server"] {
+		profile.Server = opts.server
+	}
+	if set["token"] {
+		profile.Token = opts.token
+	}
+	if set["org"] {
+		profile.Org = opts.org
+	}
+	if set["actor"] {
+		profile.Actor = *actor
+	}
+	if profile.Server == "" {
+		return errors.New("-server is required for a new profile")
+	}
+	if cfg.Current == "" {
+		cfg.Current = name
+	}
+
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Profile %q saved to %s\n", name, cfg.path)
+	return nil
+}
+
+func useProfile(args []string) error {
+	flags, opts := newFlags("profiles use", formatTable)
+	names, err := parse(flags, args, 1, "<name>")
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Profiles[names[0]]; !ok {
+		return fmt.Errorf("unknown profile %q", names[0])
+	}
+	cfg.Current = names[0]
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Using profile %q\n", names[0])
+	return nil
+}
+
+func deleteProfile(args []string) error {
+	flags, opts := newFlags("profiles delete", formatTable)
+	names, err := parse(flags, args, 1, "<name>")
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Profiles[names[0]]; !ok {
+		return fmt.Errorf("unknown profile %q", names[0])
+	}
+	delete(cfg.Profiles, names[0])
+	if cfg.Current == names[0] {
+		cfg.Current = ""
+	}
+	return cfg.save()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+var exportCommands = map[string]command{
+	"users":  exportUsers,
+	"groups": exportGroups,
+	"roles":  exportRoles,
+}
+
+const exportUsage = "[-file PATH] [-o json|yaml|table]"
+
+// export writes value to file, or to stdout when file is empty.
+func export(file, format string, value any, t table) error {
+	var w io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeTo(w, format, value, t); err != nil {
+		return err
+	}
+	if file != "" {
+		fmt.Fprintf(os.Stderr, "Exported to %s\n", file)
+	}
+	return nil
+}
+
+// exportUsers writes every user with the roles assigned to it.
+func exportUsers(args []string) error {
+	flags, opts := newFlags("export users", formatJSON)
+	file := flags.String("file", "", "file to write, stdout by default")
+	if _, err := parse(flags, args, 0, exportUsage); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	users, err := collect(iam.ListUsers(ctx))
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		roles, err := collect(iam.ListUserRoles(ctx, user.ID))
+		if err != nil {
+			return fmt.Errorf("failed to export roles of user %s: %w", user.ID, err)
+		}
+		user.Roles = values(roles)
+	}
+	return export(*file, opts.output, users, usersTable(users))
+}
+
+// exportGroups writes every group with its members and roles.
+func exportGroups(args []string) error {
+	flags, opts := newFlags("export groups", formatJSON)
+	file := flags.String("file", "", "file to write, stdout by default")
+	if _, err := parse(flags, args, 0, exportUsage); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	groups, err := collect(iam.ListGroups(ctx))
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		members, err := collect(iam.ListGroupMembers(ctx, group.ID))
+		if err != nil {
+			return fmt.Errorf("failed to export members of group %s: %w", group.ID, err)
+		}
+		group.Members = values(members)
+
+		roles, err := collect(iam.ListGroupRoles(ctx, group.ID))
+		if err != nil {
+			return fmt.Errorf("failed to export roles of group %s: %w", group.ID, err)
+		}
+		group.Roles = values(roles)
+	}
+	return export(*file, opts.output, groups, groupsTable(groups))
+}
+
+func exportRoles(args []string) error {
+	flags, opts := newFlags("export roles", formatJSON)
+	file := flags.String("file", "", "file to write, stdout by default")
+	if _, err := parse(flags, args, 0, exportUsage); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	roles, err := collect(iam.ListRoles(context.Background()))
+	if err != nil {
+		return err
+	}
+	return export(*file, opts.output, roles, rolesTable(roles))
+}
+
+func values[T any](pointers []*T) []T {
+	result := make([]T, len(pointers))
+	for i, pointer := range pointers {
+		result[i] = *pointer
+	}
+	return result
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iamBelugaa/iam/pkg/client"
+)
+
+var groupCommands = map[string]command{
+	"list":   listGroups,
+	"get":    getGroup,
+	"create": createGroup,
+	"update": updateGroup,
+	"delete": deleteGroup,
+	"members": func(args []string) error {
+		return dispatch("groups members", memberCommands, args)
+	},
+}
+
+var memberCommands = map[string]command{
+	"list":   listMembers,
+	"add":    addMember,
+	"remove": removeMember,
+}
+
+func groupsTable(groups []*client.Group) table {
+	t := table{header: []string{"ID", "NAME", "TYPE", "DESCRIPTION", "MEMBERS"}}
+	for _, group := range groups {
+		members := ""
+		if group.Members != nil {
+			members = fmt.Sprint(len(group.Members))
+		}
+		t.rows = append(t.rows, []string{group.ID, group.Name, group.Type, group.Description, members})
+	}
+	return t
+}
+
+func grantsTable(grants []*client.Grant) table {
+	t := table{header: []string{"ID", "USER", "RESOURCE", "STATUS", "EXPIRES"}}
+	for _, grant := range grants {
+		t.rows = append(t.rows, []string{
+			grant.ID, grant.UserID, grant.ResourceType + "/" + grant.ResourceID, grant.Status, formatTime(grant.ExpiresAt),
+		})
+	}
+	return t
+}
+
+func listGroups(args []string) error {
+	flags, opts := newFlags("groups list", formatTable)
+	if _, err := parse(flags, args, 0, ""); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	groups, err := collect(iam.ListGroups(context.Background()))
+	if err != nil {
+		return err
+	}
+	return write(opts.output, groups, groupsTable(groups))
+}
+
+func getGroup(args []string) error {
+	flags, opts := newFlags("groups get", formatTable)
+	ids, err := parse(flags, args, 1, "<group-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	group, err := iam.GetGroup(context.Background(), ids[0])
+	if err != nil {
+		return err
+	}
+	return write(opts.output, group, groupsTable([]*client.Group{group}))
+}
+
+func createGroup(args []string) error {
+	flags, opts := newFlags("groups create", formatTable)
+	req := &client.CreateGroupRequest{}
+	flags.StringVar(&req.Name, "name", "", "group name (required)")
+	flags.StringVar(&req.Description, "description", "", "group description")
+	if _, err := parse(flags, args, 0, "-name NAME [-description TEXT]"); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return errors.New("-name is required")
+	}
+
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+	group, err := iam.CreateGroup(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return write(opts.output, group, groupsTable([]*client.Group{group}))
+}
+
+// updateGroup changes only the fields given as flags, with a merge patch.
+func updateGroup(args []string) error {
+	flags, opts := newFlags("groups update", formatTable)
+	name := flags.String("name", "", "group name")
+	description := flags.String("description", "", "group description")
+	ids, err := parse(flags, args, 1, "<group-id> [-name NAME] [-description TEXT]")
+	if err != nil {
+		return err
+	}
+
+	patch := map[string]any{}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			patch["name"] = *name
+		case "description":
+			patch["description"] = *description
+		}
+	})
+	if len(patch) == 0 {
+		return errors.New("nothing to update, give -name or -description")
+	}
+
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+	group, err := iam.PatchGroup(context.Background(), ids[0], patch)
+	if err != nil {
+		return err
+	}
+	return write(opts.output, group, groupsTable([]*client.Group{group}))
+}
+
+func deleteGroup(args []string) error {
+	flags, opts := newFlags("groups delete", formatTable)
+	yes := flags.Bool("yes", false, "delete without asking for confirmation")
+	ids, err := parse(flags, args, 1, "<group-id> [-yes]")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	if !confirm(*yes, fmt.Sprintf("Delete group %s?", ids[0])) {
+		return errCancelled
+	}
+	if err := iam.DeleteGroup(context.Background(), ids[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Group %s deleted\n", ids[0])
+	return nil
+}
+
+func listMembers(args []string) error {
+	flags, opts := newFlags("groups members list", formatTable)
+	ids, err := parse(flags, args, 1, "<group-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	users, err := collect(iam.ListGroupMembers(context.Background(), ids[0]))
+	if err != nil {
+		return err
+	}
+	return write(opts.output, users, usersTable(users))
+}
+
+func addMember(args []string) error {
+	flags, opts := newFlags("groups members add", formatTable)
+	expires := flags.String("expires", "", "RFC 3339 time the membership ends")
+	duration := flags.Duration("for", 0, "how long the membership lasts, e.g. 8h")
+	ids, err := parse(flags, args, 2, "<group-id> <user-id> [-expires TIME | -for DURATION]")
+	if err != nil {
+		return err
+	}
+	assignment, err := assignmentOptions(*expires, *duration)
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	grant, err := iam.AddUserToGroup(context.Background(), ids[0], ids[1], assignment)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		fmt.Fprintf(os.Stderr, "User %s added to group %s\n", ids[1], ids[0])
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "User %s added to group %s until %s\n", ids[1], ids[0], grant.ExpiresAt.Format(time.RFC3339))
+	return write(opts.output, grant, grantsTable([]*client.Grant{grant}))
+}
+
+func removeMember(args []string) error {
+	flags, opts := newFlags("groups members remove", formatTable)
+	ids, err := parse(flags, args, 2, "<group-id> <user-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	if err := iam.RemoveUserFromGroup(context.Background(), ids[0], ids[1]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "User %s removed from group %s\n", ids[1], ids[0])
+	return nil
+}
@@ -0,0 +1,227 @@
+// Command iamctl manages users, groups and roles through the IAM platform API.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/iam/pkg/client"
+)
+
+// command runs a leaf command with the arguments that follow its name.
+type command func(args []string) error
+
+// commands maps the first argument to a command group.
+var commands = map[string]map[string]command{
+	"users":    userCommands,
+	"groups":   groupCommands,
+	"roles":    roleCommands,
+	"export":   exportCommands,
+	"profiles": profileCommands,
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return nil
+	}
+
+	group, ok := commands[args[0]]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return dispatch(args[0], group, args[1:])
+}
+
+// dispatch runs the subcommand of group named by the first argument.
+func dispatch(name string, group map[string]command, args []string) error {
+	names := make([]string, 0, len(group))
+	for subcommand := range group {
+		names = append(names, subcommand)
+	}
+	slices.Sort(names)
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: iamctl %s <%s>", name, strings.Join(names, "|"))
+	}
+	run, ok := group[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, expected one of %s", name+" "+args[0], strings.Join(names, ", "))
+	}
+	return run(args[1:])
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `iamctl manages users, groups and roles through the IAM platform API.
+
+Usage:
+  iamctl users list|get|create|update|delete|activate|deactivate|suspend|unsuspend|roles
+  iamctl groups list|get|create|update|delete
+  iamctl groups members list|add|remove
+  iamctl roles list|get|assign|unassign
+  iamctl export users|groups|roles
+  iamctl profiles list|set|use|delete
+
+Common flags, accepted by every command:
+  -profile   profile to use, IAMCTL_PROFILE or the current profile by default
+  -server    API address, overrides the profile (IAMCTL_SERVER)
+  -token     bearer token, overrides the profile (IAMCTL_TOKEN)
+  -org       Okta org, overrides the profile (IAMCTL_ORG)
+  -o         output format: table, json or yaml
+  -config    config file, IAMCTL_CONFIG or iamctl/config.yaml in the user config directory
+`)
+}
+
+// options are the flags every command accepts.
+type options struct {
+	profile    string
+	server     string
+	token      string
+	org        string
+	output     string
+	configPath string
+}
+
+func newFlags(name, defaultOutput string) (*flag.FlagSet, *options) {
+	opts := &options{}
+	flags := flag.NewFlagSet("iamctl "+name, flag.ContinueOnError)
+	flags.StringVar(&opts.profile, "profile", os.Getenv("IAMCTL_PROFILE"), "profile to use")
+	flags.StringVar(&opts.server, "server", os.Getenv("IAMCTL_SERVER"), "API address")
+	flags.StringVar(&opts.token, "token", os.Getenv("IAMCTL_TOKEN"), "bearer token")
+	flags.StringVar(&opts.org, "org", os.Getenv("IAMCTL_ORG"), "Okta org")
+	flags.StringVar(&opts.output, "o", defaultOutput, "output format: table, json or yaml")
+	flags.StringVar(&opts.configPath, "config", os.Getenv("IAMCTL_CONFIG"), "config file")
+	return flags, opts
+}
+
+// parse parses flags wherever they appear among the arguments and returns
+// the positional arguments, which must number exactly want.
+func parse(flags *flag.FlagSet, args []string, want int, usage string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) != want {
+		return nil, fmt.Errorf("usage: %s %s", flags.Name(), usage)
+	}
+	return positional, nil
+}
+
+// client returns an API client for the selected profile and flags.
+func (o *options) client() (*client.Client, error) {
+	cfg, err := loadConfig(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := cfg.profile(o.profile)
+	if err != nil {
+		return nil, err
+	}
+	if o.server != "" {
+		profile.Server = o.server
+	}
+	if o.token != "" {
+		profile.Token = o.token
+	}
+	if o.org != "" {
+		profile.Org = o.org
+	}
+	if profile.Server == "" {
+		return nil, errors.New("no server configured, use -server or iamctl profiles set")
+	}
+
+	clientCfg := client.Config{
+		BaseURL:   profile.Server,
+		Org:       profile.Org,
+		UserAgent: "iamctl",
+		Retry:     client.DefaultRetryPolicy,
+	}
+	var auths []client.Authenticator
+	if profile.Token != "" {
+		auths = append(auths, client.BearerToken(profile.Token))
+	}
+	if profile.Actor != "" {
+		auths = append(auths, client.Actor(profile.Actor))
+	}
+	if len(auths) > 0 {
+		clientCfg.Auth = client.AuthenticatorFunc(func(req *http.Request) error {
+			for _, auth := range auths {
+				if err := auth.Authenticate(req); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return client.New(clientCfg)
+}
+
+// collect gathers the items of a list iterator.
+func collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	result := []T{}
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// assignmentOptions turns the -expires and -for flags into the options of a
+// time-bound assignment, or nil for a permanent one.
+func assignmentOptions(expires string, duration time.Duration) (*client.AssignmentOptions, error) {
+	switch {
+	case expires != "" && duration != 0:
+		return nil, errors.New("use either -expires or -for")
+	case expires != "":
+		expiresAt, err := time.Parse(time.RFC3339, expires)
+		if err != nil {
+			return nil, errors.New("-expires must be an RFC 3339 timestamp")
+		}
+		return &client.AssignmentOptions{ExpiresAt: &expiresAt}, nil
+	case duration != 0:
+		expiresAt := time.Now().Add(duration).UTC()
+		return &client.AssignmentOptions{ExpiresAt: &expiresAt}, nil
+	}
+	return nil, nil
+}
+
+// confirm asks before a destructive change unless yes is set.
+func confirm(yes bool, question string) bool {
+	if yes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s Only 'yes' will be accepted: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
+var errCancelled = errors.New("cancelled")
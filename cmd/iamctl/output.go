@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// table is the table output of a command.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// write prints value to stdout as JSON or YAML, or t as a table.
+func write(format string, value any, t table) error {
+	return writeTo(os.Stdout, format, value, t)
+}
+
+func writeTo(w io.Writer, format string, value any, t table) error {
+	switch format {
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+
+	case formatYAML:
+		// Go through JSON so the output uses the API's field names, and
+		// decode into a node so the fields keep their order.
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		blockStyle(&node)
+
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unknown output format %q, expected table, json or yaml", format)
+}
+
+// blockStyle drops the flow style and quoting that JSON input leaves on the
+// nodes, so they are written as plain YAML.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.DateTime)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iamBelugaa/iam/pkg/client"
+)
+
+var roleCommands = map[string]command{
+	"list":     listRoles,
+	"get":      getRole,
+	"assign":   assignRole,
+	"unassign": unassignRole,
+}
+
+func rolesTable(roles []*client.Role) table {
+	t := table{header: []string{"ID", "NAME", "TYPE", "DESCRIPTION"}}
+	for _, role := range roles {
+		t.rows = append(t.rows, []string{role.ID, role.Name, role.Type, role.Description})
+	}
+	return t
+}
+
+func listRoles(args []string) error {
+	flags, opts := newFlags("roles list", formatTable)
+	if _, err := parse(flags, args, 0, ""); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	roles, err := collect(iam.ListRoles(context.Background()))
+	if err != nil {
+		return err
+	}
+	return write(opts.output, roles, rolesTable(roles))
+}
+
+func getRole(args []string) error {
+	flags, opts := newFlags("roles get", formatTable)
+	ids, err := parse(flags, args, 1, "<role-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	role, err := iam.GetRole(context.Background(), ids[0])
+	if err != nil {
+		return err
+	}
+	return write(opts.output, role, rolesTable([]*client.Role{role}))
+}
+
+// principal checks that exactly one of the -user and -group flags names who
+// a role is assigned to.
+func principal(user, group string) error {
+	if (user == "") == (group == "") {
+		return errors.New("give exactly one of -user and -group")
+	}
+	return nil
+}
+
+func assignRole(args []string) error {
+	flags, opts := newFlags("roles assign", formatTable)
+	user := flags.String("user", "", "user to assign the role to")
+	group := flags.String("group", "", "group to assign the role to")
+	expires := flags.String("expires", "", "RFC 3339 time the assignment ends, users only")
+	duration := flags.Duration("for", 0, "how long the assignment lasts, e.g. 8h, users only")
+	ids, err := parse(flags, args, 1, "<role-id> -user ID [-expires TIME | -for DURATION] | -group ID")
+	if err != nil {
+		return err
+	}
+	if err := principal(*user, *group); err != nil {
+		return err
+	}
+	assignment, err := assignmentOptions(*expires, *duration)
+	if err != nil {
+		return err
+	}
+	if assignment != nil && *group != "" {
+		return errors.New("group role assignments cannot expire")
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if *group != "" {
+		if err := iam.AssignRoleToGroup(ctx, *group, ids[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Role %s assigned to group %s\n", ids[0], *group)
+		return nil
+	}
+
+	grant, err := iam.AssignRoleToUser(ctx, *user, ids[0], assignment)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		fmt.Fprintf(os.Stderr, "Role %s assigned to user %s\n", ids[0], *user)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Role %s assigned to user %s until %s\n", ids[0], *user, grant.ExpiresAt.Format(time.RFC3339))
+	return write(opts.output, grant, grantsTable([]*client.Grant{grant}))
+}
+
+func unassignRole(args []string) error {
+	flags, opts := newFlags("roles unassign", formatTable)
+	user := flags.String("user", "", "user to unassign the role from")
+	group := flags.String("group", "", "group to unassign the role from")
+	ids, err := parse(flags, args, 1, "<role-id> -user ID | -group ID")
+	if err != nil {
+		return err
+	}
+	if err := principal(*user, *group); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if *group != "" {
+		if err := iam.UnassignRoleFromGroup(ctx, *group, ids[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Role %s unassigned from group %s\n", ids[0], *group)
+		return nil
+	}
+
+	if err := iam.UnassignRoleFromUser(ctx, *user, ids[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Role %s unassigned from user %s\n", ids[0], *user)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iamBelugaa/iam/pkg/client"
+)
+
+var userCommands = map[string]command{
+	"list":       listUsers,
+	"get":        getUser,
+	"create":     createUser,
+	"update":     updateUser,
+	"delete":     deleteUser,
+	"activate":   userLifecycle("activate", "activated", (*client.Client).ActivateUser),
+	"deactivate": userLifecycle("deactivate", "deactivated", (*client.Client).DeactivateUser),
+	"suspend":    userLifecycle("suspend", "suspended", (*client.Client).SuspendUser),
+	"unsuspend":  userLifecycle("unsuspend", "unsuspended", (*client.Client).UnsuspendUser),
+	"roles":      listUserRoles,
+}
+
+func usersTable(users []*client.User) table {
+	t := table{header: []string{"ID", "LOGIN", "EMAIL", "NAME", "STATUS", "CREATED"}}
+	for _, user := range users {
+		t.rows = append(t.rows, []string{
+			user.ID, user.Login, user.Email, strings.TrimSpace(user.FirstName + " " + user.LastName),
+			user.Status, formatTime(user.Created),
+		})
+	}
+	return t
+}
+
+func listUsers(args []string) error {
+	flags, opts := newFlags("users list", formatTable)
+	if _, err := parse(flags, args, 0, ""); err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	users, err := collect(iam.ListUsers(context.Background()))
+	if err != nil {
+		return err
+	}
+	return write(opts.output, users, usersTable(users))
+}
+
+func getUser(args []string) error {
+	flags, opts := newFlags("users get", formatTable)
+	ids, err := parse(flags, args, 1, "<user-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	user, err := iam.GetUser(context.Background(), ids[0])
+	if err != nil {
+		return err
+	}
+	return write(opts.output, user, usersTable([]*client.User{user}))
+}
+
+func createUser(args []string) error {
+	flags, opts := newFlags("users create", formatTable)
+	req := &client.CreateUserRequest{}
+	flags.StringVar(&req.Email, "email", "", "email address (required)")
+	flags.StringVar(&req.Login, "login", "", "login, the email address by default")
+	flags.StringVar(&req.FirstName, "first-name", "", "first name (required)")
+	flags.StringVar(&req.LastName, "last-name", "", "last name (required)")
+	flags.StringVar(&req.Password, "password", "", "initial password")
+	flags.BoolVar(&req.Activate, "activate", false, "activate the user right away")
+	if _, err := parse(flags, args, 0, "-email EMAIL -first-name NAME -last-name NAME [-login LOGIN] [-activate]"); err != nil {
+		return err
+	}
+	if req.Email == "" || req.FirstName == "" || req.LastName == "" {
+		return errors.New("-email, -first-name and -last-name are required")
+	}
+	if req.Login == "" {
+		req.Login = req.Email
+	}
+
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+	user, err := iam.CreateUser(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return write(opts.output, user, usersTable([]*client.User{user}))
+}
+
+// updateUser changes only the fields given as flags, with a merge patch.
+func updateUser(args []string) error {
+	flags, opts := newFlags("users update", formatTable)
+	fields := map[string]*string{
+		"email":      flags.String("email", "", "email address"),
+		"login":      flags.String("login", "", "login"),
+		"first-name": flags.String("first-name", "", "first name"),
+		"last-name":  flags.String("last-name", "", "last name"),
+	}
+	ids, err := parse(flags, args, 1, "<user-id> [-email EMAIL] [-login LOGIN] [-first-name NAME] [-last-name NAME]")
+	if err != nil {
+		return err
+	}
+
+	jsonNames := map[string]string{"email": "email", "login": "login", "first-name": "firstName", "last-name": "lastName"}
+	patch := map[string]any{}
+	flags.Visit(func(f *flag.Flag) {
+		if value, ok := fields[f.Name]; ok {
+			patch[jsonNames[f.Name]] = *value
+		}
+	})
+	if len(patch) == 0 {
+		return errors.New("nothing to update, give at least one of -email, -login, -first-name and -last-name")
+	}
+
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+	user, err := iam.PatchUser(context.Background(), ids[0], patch)
+	if err != nil {
+		return err
+	}
+	return write(opts.output, user, usersTable([]*client.User{user}))
+}
+
+func deleteUser(args []string) error {
+	flags, opts := newFlags("users delete", formatTable)
+	yes := flags.Bool("yes", false, "delete without asking for confirmation")
+	ids, err := parse(flags, args, 1, "<user-id> [-yes]")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	if !confirm(*yes, fmt.Sprintf("Deactivate and delete user %s?", ids[0])) {
+		return errCancelled
+	}
+	if err := iam.DeleteUser(context.Background(), ids[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "User %s deleted\n", ids[0])
+	return nil
+}
+
+// userLifecycle returns the command of a lifecycle action such as suspend.
+func userLifecycle(action, done string, call func(*client.Client, context.Context, string) error) command {
+	return func(args []string) error {
+		flags, opts := newFlags("users "+action, formatTable)
+		ids, err := parse(flags, args, 1, "<user-id>")
+		if err != nil {
+			return err
+		}
+		iam, err := opts.client()
+		if err != nil {
+			return err
+		}
+
+		if err := call(iam, context.Background(), ids[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "User %s %s\n", ids[0], done)
+		return nil
+	}
+}
+
+func listUserRoles(args []string) error {
+	flags, opts := newFlags("users roles", formatTable)
+	ids, err := parse(flags, args, 1, "<user-id>")
+	if err != nil {
+		return err
+	}
+	iam, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	roles, err := collect(iam.ListUserRoles(context.Background(), ids[0]))
+	if err != nil {
+		return err
+	}
+	return write(opts.output, roles, rolesTable(roles))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	snapshot_service "github.com/iamBelugaa/iam/internal/services/snapshot"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+// subcommands maps the first argument of the server binary to a command.
+var subcommands = map[string]func(log *zap.SugaredLogger, command string, args []string) error{
+	"plan":  runDesiredState,
+	"apply": runDesiredState,
+	"diff":  runDiff,
+}
+
+// cliServices are the services the subcommands use.
+type cliServices struct {
+	desiredState *desired_state_service.Service
+	snapshots    *snapshot_service.Service
+}
+
+// newCLIServices builds the services of the subcommands without starting the
+// HTTP server or any background job. Changes still go through the
+// separation-of-duties guard.
+func newCLIServices(log *zap.SugaredLogger, orgName string) (*cliServices, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	orgCfg := cfg.Okta
+	if orgName != "" {
+		if orgCfg = cfg.Org(orgName); orgCfg == nil {
+			return nil, fmt.Errorf("unknown org %q", orgName)
+		}
+	}
+	storage := cfg.Storage.ForOrg(orgCfg.Name)
+
+	oktaClient, err := okta.NewClient(orgCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	auditStore, err := store.New[models.AuditEntry](storage.Path("audit"))
+	if err != nil {
+		return nil, err
+	}
+
+	sodRuleStore, err := store.New[models.SoDRule](storage.Path("sod_rules"))
+	if err != nil {
+		return nil, err
+	}
+
+	sodExceptionStore, err := store.New[models.SoDException](storage.Path("sod_exceptions"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots hold a whole org, so each archive is a file of its own and
+	// the snapshot store only indexes their summaries.
+	snapshotStore, err := store.New[models.SnapshotSummary](storage.Path("snapshot_index"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotArchives, err := store.NewFiles[models.Snapshot](storage.DirPath("snapshots"))
+	if err != nil {
+		return nil, err
+	}
+
+	usersService := user_service.New(log, oktaClient.SDK())
+	groupsService := group_service.New(log, oktaClient.SDK())
+	rolesService := role_service.New(log, oktaClient.SDK())
+	auditService := audit_service.New(log, auditStore)
+	sodService := sod_service.New(
+		log, sodRuleStore, sodExceptionStore, auditService, usersService, rolesService, groupsService,
+	)
+	rolesService.UseGuard(sodService)
+	groupsService.UseGuard(sodService)
+	desiredStateService := desired_state_service.New(log, usersService, groupsService, rolesService)
+	snapshotsService := snapshot_service.New(
+		log, cfg.Snapshots, orgCfg.Domain, snapshotStore, snapshotArchives,
+		usersService, groupsService, rolesService, desiredStateService,
+	)
+
+	return &cliServices{desiredState: desiredStateService, snapshots: snapshotsService}, nil
+}
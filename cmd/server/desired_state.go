@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+)
+
+// runDesiredState implements the "plan" and "apply" subcommands. Plans and
+// progress go to stdout, logs stay on stderr.
+func runDesiredState(log *zap.SugaredLogger, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	file := flags.String("f", "iam.yaml", "desired state file, - for stdin")
+	prune := flags.Bool("prune", false, "delete groups and custom roles missing from the file")
+	autoApprove := flags.Bool("auto-approve", false, "apply without asking for confirmation")
+	org := flags.String("org", "", "org to plan against, the default org when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read desired state: %w", err)
+	}
+
+	state, err := desired_state_service.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	services, err := newCLIServices(log, *org)
+	if err != nil {
+		return err
+	}
+	svc := services.desiredState
+
+	ctx := context.Background()
+	plan, err := svc.Plan(ctx, state, *prune)
+	if err != nil {
+		return err
+	}
+
+	printPlan(plan)
+	if command == "plan" || len(plan.Changes) == 0 {
+		return nil
+	}
+
+	if !*autoApprove && !confirm("Apply these changes?") {
+		fmt.Println("Apply cancelled.")
+		return nil
+	}
+
+	result, err := svc.Apply(ctx, state, *prune, func(result models.ChangeResult) {
+		line := fmt.Sprintf("[%d/%d] %s: %s", result.Index, result.Total, result.Status, result.Change.Description)
+		if result.Error != "" {
+			line += " (" + result.Error + ")"
+		}
+		fmt.Println(line)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nApply complete: %d applied, %d failed, %d skipped.\n", result.Applied, result.Failed, result.Skipped)
+	if result.Failed > 0 {
+		return errors.New("desired state was not fully applied")
+	}
+	return nil
+}
+
+func printPlan(plan *models.Plan) {
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes. Okta matches the desired state.")
+		return
+	}
+
+	symbols := map[string]string{
+		models.ChangeActionCreate:   "+",
+		models.ChangeActionAdd:      "+",
+		models.ChangeActionAssign:   "+",
+		models.ChangeActionUpdate:   "~",
+		models.ChangeActionDelete:   "-",
+		models.ChangeActionRemove:   "-",
+		models.ChangeActionUnassign: "-",
+	}
+
+	fmt.Println("Planned changes:")
+	for _, change := range plan.Changes {
+		fmt.Printf("  %s %s\n", symbols[change.Action], change.Description)
+	}
+
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete, %d to add, %d to remove, %d to assign, %d to unassign.\n",
+		plan.Summary[models.ChangeActionCreate], plan.Summary[models.ChangeActionUpdate],
+		plan.Summary[models.ChangeActionDelete], plan.Summary[models.ChangeActionAdd],
+		plan.Summary[models.ChangeActionRemove], plan.Summary[models.ChangeActionAssign],
+		plan.Summary[models.ChangeActionUnassign],
+	)
+}
+
+func confirm(question string) bool {
+	fmt.Printf("\n%s Only 'yes' will be accepted: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
@@ -1,24 +1,26 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/config"
 	"github.com/iamBelugaa/iam/internal/handlers"
-	group_service "github.com/iamBelugaa/iam/internal/services/group"
-	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	rpc_handlers "github.com/iamBelugaa/iam/internal/handlers/rpc"
+	"github.com/iamBelugaa/iam/pkg/health"
 	"github.com/iamBelugaa/iam/pkg/logger"
-	"github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/tracing"
 )
 
 func main() {
@@ -33,6 +35,18 @@ func main() {
 		log.Fatalw("error loading envs", "error", err)
 	}
 
+	// Subcommands run against Okta directly instead of starting the server.
+	if len(os.Args) > 1 {
+		if command, ok := subcommands[os.Args[1]]; ok {
+			if err := command(log, os.Args[1], os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				log.Sync()
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	log.Infow("Starting Flexera IAM Platform...")
 
 	if err := run(log); err != nil {
@@ -51,27 +65,46 @@ func run(log *zap.SugaredLogger) error {
 	}
 	log.Infow("Configuration loaded successfully")
 
-	oktaClient, err := okta.NewClient(cfg.Okta)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Infow("failed to flush traces", "error", err)
+		}
+	}()
+	log.Infow("Tracing configured", "exporter", cfg.Tracing.Exporter)
+
+	notifier := notify.NewLogNotifier(log)
+	if cfg.Notification.WebhookURL != "" {
+		notifier = notify.Multi(notifier, notify.NewWebhookNotifier(cfg.Notification.WebhookURL))
+	}
 
-	if err := oktaClient.TestConnection(context.Background()); err != nil {
-		return err
+	securityNotifier := notify.NewLogNotifier(log)
+	if url := cmp.Or(cfg.BreakGlass.SecurityWebhookURL, cfg.Notification.WebhookURL); url != "" {
+		securityNotifier = notify.Multi(securityNotifier, notify.NewWebhookNotifier(url))
 	}
-	log.Infow("Okta service initialized successfully")
 
-	router := chi.NewRouter()
-	usersService := user_service.New(log, oktaClient.SDK())
-	groupsService := group_service.New(log, oktaClient.SDK())
+	// Background jobs stop when the server shuts down.
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	checker := health.New(log, cfg.Health.CheckTimeout)
+
+	orgs := make([]*handlers.Org, 0, len(cfg.Orgs))
+	for _, orgCfg := range cfg.Orgs {
+		org, err := setupOrg(jobsCtx, log.With("org", orgCfg.Name), cfg, orgCfg, notifier, securityNotifier, checker)
+		if err != nil {
+			return fmt.Errorf("org %s: %w", orgCfg.Name, err)
+		}
+		orgs = append(orgs, org)
+	}
+	router := handlers.NewRouter(log, cfg, orgs, checker)
 
-	handlers.Setup(&handlers.Config{
-		Config:        cfg,
-		Log:           log,
-		Router:        router,
-		UsersService:  usersService,
-		GroupsService: groupsService,
-	})
+	go checker.Run(jobsCtx)
 
 	server := http.Server{
 		Handler:      router,
@@ -92,6 +125,25 @@ func run(log *zap.SugaredLogger) error {
 		}
 	}()
 
+	// The gRPC API is served on its own port when one is configured.
+	var grpcServer *rpc_handlers.Server
+	if cfg.Server.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("could not listen for gRPC: %w", err)
+		}
+
+		grpcServer = handlers.NewGRPCServer(log, cfg, orgs, checker)
+		go grpcServer.WatchHealth(jobsCtx)
+
+		go func() {
+			log.Infow("gRPC server starting", "address", listener.Addr().String())
+			if err := grpcServer.Serve(listener); err != nil {
+				serverErrors <- fmt.Errorf("gRPC: %w", err)
+			}
+		}()
+	}
+
 	select {
 	case err := <-serverErrors:
 		return fmt.Errorf("server error: %w", err)
@@ -103,6 +155,10 @@ func run(log *zap.SugaredLogger) error {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
 		defer cancel()
 
+		if grpcServer != nil {
+			stopGRPC(ctx, grpcServer)
+		}
+
 		if err := server.Shutdown(ctx); err != nil {
 			return fmt.Errorf("could not stop server gracefully: %w", err)
 		}
@@ -110,3 +166,19 @@ func run(log *zap.SugaredLogger) error {
 
 	return nil
 }
+
+// stopGRPC waits for the gRPC calls in progress to finish, and cancels them
+// once ctx is done.
+func stopGRPC(ctx context.Context, server *rpc_handlers.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
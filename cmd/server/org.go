@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/handlers"
+	rpc_handlers "github.com/iamBelugaa/iam/internal/handlers/rpc"
+	"github.com/iamBelugaa/iam/internal/models"
+	access_request_service "github.com/iamBelugaa/iam/internal/services/access_request"
+	access_review_service "github.com/iamBelugaa/iam/internal/services/access_review"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	break_glass_service "github.com/iamBelugaa/iam/internal/services/break_glass"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+	drift_service "github.com/iamBelugaa/iam/internal/services/drift"
+	factor_service "github.com/iamBelugaa/iam/internal/services/factor"
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	group_rule_service "github.com/iamBelugaa/iam/internal/services/group_rule"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	session_service "github.com/iamBelugaa/iam/internal/services/session"
+	snapshot_service "github.com/iamBelugaa/iam/internal/services/snapshot"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/health"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+// setupOrg connects to one Okta org, builds its services and routes, registers
+// its health checks and starts its background jobs. Every org keeps its
+// records in its own stores. Only an unreachable default org stops the
+// server, unless degraded startup is allowed; other orgs are served and
+// reported unhealthy until they recover.
+func setupOrg(
+	ctx context.Context,
+	log *zap.SugaredLogger,
+	cfg *config.Config,
+	orgCfg *config.OktaConfig,
+	notifier notify.Notifier,
+	securityNotifier notify.Notifier,
+	checker *health.Checker,
+) (*handlers.Org, error) {
+	oktaClient, err := okta.NewClient(orgCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	isDefault := orgCfg == cfg.Okta
+	if err := oktaClient.TestConnection(ctx); err != nil {
+		if isDefault && !cfg.Health.AllowDegradedStartup {
+			return nil, err
+		}
+		log.Infow("Okta org is unreachable, serving it as unhealthy", zap.Error(err))
+	} else {
+		log.Infow("Okta service initialized successfully")
+	}
+
+	checker.Register(health.Check{
+		Name:     "okta/" + orgCfg.Name,
+		Interval: orgCfg.HealthInterval,
+		Critical: isDefault,
+		Run:      oktaClient.TestConnection,
+	})
+	checker.Register(health.Check{
+		Name:     "okta/" + orgCfg.Name + "/rate_limit",
+		Interval: orgCfg.HealthInterval,
+		Run: func(context.Context) error {
+			bucket, headroom := oktaClient.RateLimitHeadroom()
+			if headroom < cfg.Health.MinRateLimitHeadroom {
+				return health.Degraded(fmt.Errorf("%.0f%% of the rate limit of %s is left", headroom*100, bucket))
+			}
+			return nil
+		},
+	})
+
+	storage := cfg.Storage.ForOrg(orgCfg.Name)
+
+	accessRequestStore, err := store.New[models.AccessRequest](storage.Path("access_requests"))
+	if err != nil {
+		return nil, err
+	}
+
+	grantStore, err := store.New[models.Grant](storage.Path("grants"))
+	if err != nil {
+		return nil, err
+	}
+
+	auditStore, err := store.New[models.AuditEntry](storage.Path("audit"))
+	if err != nil {
+		return nil, err
+	}
+
+	elevationStore, err := store.New[models.Elevation](storage.Path("elevations"))
+	if err != nil {
+		return nil, err
+	}
+
+	campaignStore, err := store.New[models.Campaign](storage.Path("access_reviews"))
+	if err != nil {
+		return nil, err
+	}
+
+	sodRuleStore, err := store.New[models.SoDRule](storage.Path("sod_rules"))
+	if err != nil {
+		return nil, err
+	}
+
+	sodExceptionStore, err := store.New[models.SoDException](storage.Path("sod_exceptions"))
+	if err != nil {
+		return nil, err
+	}
+
+	baselineStore, err := store.New[models.Baseline](storage.Path("drift_baselines"))
+	if err != nil {
+		return nil, err
+	}
+
+	driftStore, err := store.New[models.DriftItem](storage.Path("drift_items"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots hold a whole org, so each archive is a file of its own and
+	// the snapshot store only indexes their summaries.
+	snapshotStore, err := store.New[models.SnapshotSummary](storage.Path("snapshot_index"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotArchives, err := store.NewFiles[models.Snapshot](storage.DirPath("snapshots"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Security alerts go through a durable outbox so they are delivered even if
+	// the process restarts or the webhook is down.
+	securityOutboxStore, err := store.New[notify.OutboxItem](storage.Path("security_outbox"))
+	if err != nil {
+		return nil, err
+	}
+	securityOutbox := notify.NewOutbox(
+		log, securityOutboxStore, securityNotifier, cfg.BreakGlass.CheckInterval, cfg.BreakGlass.NotifyMaxAttempts,
+	)
+
+	if storage.Dir != "" {
+		stores := []interface {
+			Name() string
+			Check() error
+		}{
+			accessRequestStore, grantStore, auditStore, elevationStore, campaignStore, sodRuleStore,
+			sodExceptionStore, baselineStore, driftStore, snapshotStore, snapshotArchives, securityOutboxStore,
+		}
+		for _, s := range stores {
+			checker.Register(health.Check{
+				Name:     "store/" + orgCfg.Name + "/" + s.Name(),
+				Interval: cfg.Health.StoreCheckInterval,
+				Critical: true,
+				Run:      func(context.Context) error { return s.Check() },
+			})
+		}
+	}
+
+	router := chi.NewRouter()
+	usersService := user_service.New(log, oktaClient.SDK())
+	usersService.UsePasswordPolicy(cfg.Passwords)
+	groupsService := group_service.New(log, oktaClient.SDK())
+	rolesService := role_service.New(log, oktaClient.SDK())
+	groupRulesService := group_rule_service.New(log, oktaClient.SDK())
+	grantsService := grant_service.New(
+		log, cfg.Grants, grantStore, notifier, rolesService, groupsService,
+	)
+	auditService := audit_service.New(log, auditStore)
+	factorsService := factor_service.New(log, oktaClient.SDK(), auditService)
+	sessionsService := session_service.New(log, oktaClient.SDK(), auditService, usersService)
+	sodService := sod_service.New(
+		log, sodRuleStore, sodExceptionStore, auditService, usersService, rolesService, groupsService,
+	)
+	rolesService.UseGuard(sodService)
+	groupsService.UseGuard(sodService)
+	breakGlassService := break_glass_service.New(
+		log, cfg.BreakGlass, elevationStore, securityOutbox, auditService, rolesService,
+	)
+	accessRequestsService := access_request_service.New(
+		log, cfg.AccessRequests, accessRequestStore, notifier, rolesService, groupsService,
+	)
+	accessReviewsService := access_review_service.New(
+		log, campaignStore, notifier, auditService, usersService, rolesService, groupsService,
+	)
+	accessReviewsService.Recover(ctx)
+	desiredStateService := desired_state_service.New(log, usersService, groupsService, rolesService)
+	driftService := drift_service.New(
+		log, cfg.Drift.CheckInterval, baselineStore, driftStore, notifier, auditService, groupsService, rolesService,
+	)
+	snapshotsService := snapshot_service.New(
+		log, cfg.Snapshots, orgCfg.Domain, snapshotStore, snapshotArchives,
+		usersService, groupsService, rolesService, desiredStateService,
+	)
+
+	handlers.Setup(&handlers.Config{
+		Config:        cfg,
+		Log:           log,
+		Router:        router,
+		UsersService:  usersService,
+		GroupsService: groupsService,
+		RolesService:  rolesService,
+
+		GroupRulesService:     groupRulesService,
+		GrantsService:         grantsService,
+		AccessRequestsService: accessRequestsService,
+		AccessReviewsService:  accessReviewsService,
+		AuditService:          auditService,
+		BreakGlassService:     breakGlassService,
+		SoDService:            sodService,
+		DesiredStateService:   desiredStateService,
+		DriftService:          driftService,
+		SnapshotsService:      snapshotsService,
+		FactorsService:        factorsService,
+		SessionsService:       sessionsService,
+	})
+
+	go grantsService.Run(ctx)
+	go accessRequestsService.Run(ctx)
+	go securityOutbox.Run(ctx)
+	go breakGlassService.Run(ctx)
+	go driftService.Run(ctx)
+	go snapshotsService.Run(ctx)
+
+	return &handlers.Org{
+		Client: oktaClient,
+		Router: router,
+		Services: &rpc_handlers.Services{
+			Users:  usersService,
+			Groups: groupsService,
+			Roles:  rolesService,
+			Grants: grantsService,
+			SoD:    sodService,
+		},
+	}, nil
+}
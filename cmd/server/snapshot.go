@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	snapshot_service "github.com/iamBelugaa/iam/internal/services/snapshot"
+)
+
+// runDiff implements the "diff" subcommand. Each side is a stored snapshot
+// ID, "live", or the path of a downloaded archive.
+func runDiff(log *zap.SugaredLogger, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	from := flags.String("from", "", "snapshot ID, archive file or live")
+	to := flags.String("to", snapshot_service.Live, "snapshot ID, archive file or live")
+	asJSON := flags.Bool("json", false, "print the diff as JSON")
+	org := flags.String("org", "", "org of stored and live snapshots, the default org when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		return errors.New("-from is required")
+	}
+
+	var services *cliServices
+	load := func(ref string) (*models.Snapshot, error) {
+		if data, err := os.ReadFile(ref); err == nil {
+			return snapshot_service.ParseArchive(data)
+		}
+
+		if services == nil {
+			var err error
+			if services, err = newCLIServices(log, *org); err != nil {
+				return nil, err
+			}
+		}
+		return services.snapshots.Resolve(context.Background(), ref)
+	}
+
+	fromSnapshot, err := load(*from)
+	if err != nil {
+		return err
+	}
+	toSnapshot, err := load(*to)
+	if err != nil {
+		return err
+	}
+
+	diff := snapshot_service.DiffSnapshots(fromSnapshot, toSnapshot)
+	if !*asJSON {
+		return snapshot_service.WriteDiff(os.Stdout, diff)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	return nil
+}
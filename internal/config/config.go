@@ -1,13 +1,34 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultOrgName names the org of a single-org deployment.
+const DefaultOrgName = "default"
+
 type Config struct {
-	Okta   *OktaConfig
-	Server *ServerConfig
+	// Okta is the default org. Orgs lists every org, including the default.
+	Okta           *OktaConfig
+	Orgs           []*OktaConfig
+	Server         *ServerConfig
+	Storage        *StorageConfig
+	Notification   *NotificationConfig
+	AccessRequests *AccessRequestConfig
+	Grants         *GrantConfig
+	BreakGlass     *BreakGlassConfig
+	Drift          *DriftConfig
+	Snapshots      *SnapshotConfig
+	Tracing        *TracingConfig
+	Health         *HealthConfig
+	GraphQL        *GraphQLConfig
+	Passwords      *PasswordPolicyConfig
 }
 
 type ServerConfig struct {
@@ -15,13 +36,160 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// ValidateRequests rejects API requests that do not match the OpenAPI
+	// specification before they reach a handler.
+	ValidateRequests bool
+
+	// GRPCPort serves the gRPC API on a separate port. It is disabled when
+	// empty. GRPCReflection registers the reflection service on it.
+	GRPCPort       string
+	GRPCReflection bool
+
+	// ExpandConcurrency bounds the lookups a GET request with ?expand makes
+	// at a time.
+	ExpandConcurrency int
 }
 
 type OktaConfig struct {
+	Name     string
 	Domain   string
 	APIToken string
 	Issuer   string
 	Audience string
+
+	// RateLimit caps the requests per second sent to the org and
+	// MaxConcurrent the requests in flight. Zero means no limit.
+	RateLimit      float64
+	MaxConcurrent  int
+	RequestTimeout time.Duration
+	HealthInterval time.Duration
+}
+
+type StorageConfig struct {
+	// Dir holds the JSON files of the local stores. Empty keeps data in memory.
+	Dir string
+}
+
+// ForOrg returns the storage of an org. The default org keeps its records in
+// Dir, every other org in a subdirectory named after it.
+func (c *StorageConfig) ForOrg(name string) *StorageConfig {
+	if c.Dir == "" || name == DefaultOrgName {
+		return c
+	}
+	return &StorageConfig{Dir: filepath.Join(c.Dir, name)}
+}
+
+// Path returns the file backing the named store, or an empty string when
+// storage is in-memory.
+func (c *StorageConfig) Path(name string) string {
+	if c.Dir == "" {
+		return ""
+	}
+	return filepath.Join(c.Dir, name+".json")
+}
+
+// DirPath returns the directory backing the named store of one file per
+// record, or an empty string when storage is in-memory.
+func (c *StorageConfig) DirPath(name string) string {
+	if c.Dir == "" {
+		return ""
+	}
+	return filepath.Join(c.Dir, name)
+}
+
+type NotificationConfig struct {
+	WebhookURL string
+}
+
+type AccessRequestConfig struct {
+	TTL            time.Duration
+	SweepInterval  time.Duration
+	Admins         []string
+	RoleApprovers  map[string][]string
+	GroupApprovers map[string][]string
+}
+
+type GrantConfig struct {
+	CheckInterval time.Duration
+
+	// MaxRevokeAttempts is how often revoking an expired grant is tried
+	// before it is marked FAILED and an alert is sent.
+	MaxRevokeAttempts int
+}
+
+type BreakGlassConfig struct {
+	RoleID             string
+	EligibleUsers      []string
+	DefaultDuration    time.Duration
+	MaxDuration        time.Duration
+	CheckInterval      time.Duration
+	SecurityWebhookURL string
+	SecurityRecipients []string
+	// NotifyMaxAttempts is the number of delivery attempts of a security
+	// alert before it is given up.
+	NotifyMaxAttempts int
+}
+
+type DriftConfig struct {
+	CheckInterval time.Duration
+}
+
+type SnapshotConfig struct {
+	// Interval between scheduled snapshots. Zero disables them.
+	Interval  time.Duration
+	Retention int
+}
+
+// PasswordPolicyConfig is checked locally before a new password is sent to
+// Okta, so callers learn every rule a password breaks at once. The org's own
+// Okta password policy still applies.
+type PasswordPolicyConfig struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// BannedWords may not appear in a password, ignoring case. The name and
+	// login of the user are always banned.
+	BannedWords []string
+}
+
+type TracingConfig struct {
+	// Exporter is one of none, otlp, stdout or file. File is the path the
+	// file exporter appends spans to.
+	Exporter    string
+	File        string
+	ServiceName string
+	SampleRatio float64
+}
+
+type HealthConfig struct {
+	// AllowDegradedStartup starts the server even when the default org is
+	// unreachable. It is then reported not ready until the org recovers.
+	AllowDegradedStartup bool
+	CheckTimeout         time.Duration
+	StoreCheckInterval   time.Duration
+
+	// MinRateLimitHeadroom is the share of an Okta rate limit bucket below
+	// which the org is reported degraded.
+	MinRateLimitHeadroom float64
+}
+
+type GraphQLConfig struct {
+	// MaxDepth limits how deeply fields may be nested in a query.
+	MaxDepth int
+
+	// MaxCost limits the estimated number of objects a query resolves.
+	// Every object field costs one and list fields count their first
+	// argument, or ListSize without one. ListSize is also the page size of
+	// the root users, groups and roles lists.
+	MaxCost  int
+	ListSize int
+
+	// MaxConcurrency bounds the Okta lookups a query makes at a time.
+	MaxConcurrency int
 }
 
 type FrontendConfig struct {
@@ -39,18 +207,204 @@ func Load() (*Config, error) {
 			ReadTimeout:  getDurationOrDefault("READ_TIMEOUT", "10s"),
 			WriteTimeout: getDurationOrDefault("WRITE_TIMEOUT", "10s"),
 			IdleTimeout:  getDurationOrDefault("IDLE_TIMEOUT", "120s"),
+
+			ValidateRequests: getBoolOrDefault("OPENAPI_VALIDATE_REQUESTS", false),
+
+			GRPCPort:       os.Getenv("GRPC_PORT"),
+			GRPCReflection: getBoolOrDefault("GRPC_REFLECTION", true),
+
+			ExpandConcurrency: getIntOrDefault("EXPAND_MAX_CONCURRENCY", 4),
+		},
+		Storage: &StorageConfig{
+			Dir: getEnvOrDefault("STORAGE_DIR", "data"),
 		},
-		Okta: &OktaConfig{
-			Domain:   os.Getenv("OKTA_DOMAIN"),
-			Issuer:   os.Getenv("OKTA_ISSUER"),
-			Audience: os.Getenv("OKTA_AUDIENCE"),
-			APIToken: os.Getenv("OKTA_API_TOKEN"),
+		Notification: &NotificationConfig{
+			WebhookURL: os.Getenv("NOTIFICATION_WEBHOOK_URL"),
+		},
+		AccessRequests: &AccessRequestConfig{
+			TTL:            getDurationOrDefault("ACCESS_REQUEST_TTL", "168h"),
+			SweepInterval:  getDurationOrDefault("ACCESS_REQUEST_SWEEP_INTERVAL", "5m"),
+			Admins:         getList("ACCESS_REQUEST_ADMINS"),
+			RoleApprovers:  getListMap("ACCESS_REQUEST_ROLE_APPROVERS"),
+			GroupApprovers: getListMap("ACCESS_REQUEST_GROUP_APPROVERS"),
+		},
+		Grants: &GrantConfig{
+			CheckInterval:     getDurationOrDefault("GRANT_CHECK_INTERVAL", "1m"),
+			MaxRevokeAttempts: getIntOrDefault("GRANT_MAX_REVOKE_ATTEMPTS", 5),
+		},
+		BreakGlass: &BreakGlassConfig{
+			RoleID:             os.Getenv("BREAK_GLASS_ROLE_ID"),
+			EligibleUsers:      getList("BREAK_GLASS_ELIGIBLE_USERS"),
+			DefaultDuration:    getDurationOrDefault("BREAK_GLASS_DEFAULT_DURATION", "1h"),
+			MaxDuration:        getDurationOrDefault("BREAK_GLASS_MAX_DURATION", "4h"),
+			CheckInterval:      getDurationOrDefault("BREAK_GLASS_CHECK_INTERVAL", "30s"),
+			SecurityWebhookURL: os.Getenv("BREAK_GLASS_SECURITY_WEBHOOK_URL"),
+			SecurityRecipients: getList("BREAK_GLASS_SECURITY_RECIPIENTS"),
+			NotifyMaxAttempts:  getIntOrDefault("BREAK_GLASS_NOTIFY_MAX_ATTEMPTS", 20),
+		},
+		Drift: &DriftConfig{
+			CheckInterval: getDurationOrDefault("DRIFT_CHECK_INTERVAL", "15m"),
+		},
+		Snapshots: &SnapshotConfig{
+			Interval:  getDurationOrDefault("SNAPSHOT_INTERVAL", "24h"),
+			Retention: getIntOrDefault("SNAPSHOT_RETENTION", 30),
+		},
+		Passwords: &PasswordPolicyConfig{
+			MinLength:        getIntOrDefault("PASSWORD_MIN_LENGTH", 12),
+			RequireUppercase: getBoolOrDefault("PASSWORD_REQUIRE_UPPERCASE", true),
+			RequireLowercase: getBoolOrDefault("PASSWORD_REQUIRE_LOWERCASE", true),
+			RequireDigit:     getBoolOrDefault("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    getBoolOrDefault("PASSWORD_REQUIRE_SYMBOL", false),
+			BannedWords:      getList("PASSWORD_BANNED_WORDS"),
+		},
+		Tracing: &TracingConfig{
+			Exporter:    getEnvOrDefault("TRACING_EXPORTER", "none"),
+			File:        getEnvOrDefault("TRACING_FILE", "traces.jsonl"),
+			ServiceName: getEnvOrDefault("TRACING_SERVICE_NAME", "flexera-iam"),
+			SampleRatio: 1,
+		},
+		Health: &HealthConfig{
+			AllowDegradedStartup: getBoolOrDefault("ALLOW_DEGRADED_STARTUP", false),
+			CheckTimeout:         getDurationOrDefault("HEALTH_CHECK_TIMEOUT", "5s"),
+			StoreCheckInterval:   getDurationOrDefault("HEALTH_STORE_CHECK_INTERVAL", "30s"),
+			MinRateLimitHeadroom: getFloatOrDefault("HEALTH_MIN_RATE_LIMIT_HEADROOM", 0.1),
+		},
+		GraphQL: &GraphQLConfig{
+			MaxDepth:       getIntOrDefault("GRAPHQL_MAX_DEPTH", 6),
+			MaxCost:        getIntOrDefault("GRAPHQL_MAX_COST", 1000),
+			ListSize:       getIntOrDefault("GRAPHQL_LIST_SIZE", 10),
+			MaxConcurrency: getIntOrDefault("GRAPHQL_MAX_CONCURRENCY", 8),
 		},
 	}
 
+	if value := os.Getenv("TRACING_SAMPLE_RATIO"); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("TRACING_SAMPLE_RATIO must be a number between 0 and 1")
+		}
+		config.Tracing.SampleRatio = ratio
+	}
+
+	// Intervals drive tickers, which panic on anything but a positive value.
+	for _, setting := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"ACCESS_REQUEST_TTL", config.AccessRequests.TTL},
+		{"ACCESS_REQUEST_SWEEP_INTERVAL", config.AccessRequests.SweepInterval},
+		{"GRANT_CHECK_INTERVAL", config.Grants.CheckInterval},
+		{"BREAK_GLASS_DEFAULT_DURATION", config.BreakGlass.DefaultDuration},
+		{"BREAK_GLASS_MAX_DURATION", config.BreakGlass.MaxDuration},
+		{"BREAK_GLASS_CHECK_INTERVAL", config.BreakGlass.CheckInterval},
+		{"DRIFT_CHECK_INTERVAL", config.Drift.CheckInterval},
+		{"HEALTH_CHECK_TIMEOUT", config.Health.CheckTimeout},
+		{"HEALTH_STORE_CHECK_INTERVAL", config.Health.StoreCheckInterval},
+	} {
+		if setting.value <= 0 {
+			return nil, fmt.Errorf("%s must be a positive duration", setting.key)
+		}
+	}
+	if config.Snapshots.Interval < 0 {
+		return nil, fmt.Errorf("SNAPSHOT_INTERVAL must not be negative")
+	}
+
+	if config.Grants.MaxRevokeAttempts < 1 {
+		return nil, fmt.Errorf("GRANT_MAX_REVOKE_ATTEMPTS must be at least 1")
+	}
+	if config.BreakGlass.NotifyMaxAttempts < 1 {
+		return nil, fmt.Errorf("BREAK_GLASS_NOTIFY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	// Break-glass fails closed: an empty allowlist would otherwise leave the
+	// emergency role to anyone who can set the caller header.
+	if config.BreakGlass.RoleID != "" && len(config.BreakGlass.EligibleUsers) == 0 {
+		return nil, fmt.Errorf("BREAK_GLASS_ELIGIBLE_USERS must list at least one user when BREAK_GLASS_ROLE_ID is set")
+	}
+
+	orgs, err := loadOrgs()
+	if err != nil {
+		return nil, err
+	}
+	config.Orgs = orgs
+	config.Okta = orgs[0]
+
+	if name := os.Getenv("OKTA_DEFAULT_ORG"); name != "" {
+		if config.Okta = config.Org(name); config.Okta == nil {
+			return nil, fmt.Errorf("OKTA_DEFAULT_ORG %q is not listed in OKTA_ORGS", name)
+		}
+	}
+
 	return config, nil
 }
 
+// Org returns the named org, or nil if there is none.
+func (c *Config) Org(name string) *OktaConfig {
+	for _, org := range c.Orgs {
+		if org.Name == name {
+			return org
+		}
+	}
+	return nil
+}
+
+// loadOrgs reads the orgs named in OKTA_ORGS from OKTA_<NAME>_* variables,
+// e.g. OKTA_STAGING_DOMAIN. Without OKTA_ORGS there is a single org named
+// "default" configured by the plain OKTA_* variables.
+func loadOrgs() ([]*OktaConfig, error) {
+	names := getList("OKTA_ORGS")
+	if len(names) == 0 {
+		org := loadOrg(DefaultOrgName, "OKTA_")
+		if err := validateOrg(org, "OKTA_"); err != nil {
+			return nil, err
+		}
+		return []*OktaConfig{org}, nil
+	}
+
+	orgs := make([]*OktaConfig, 0, len(names))
+	for _, name := range names {
+		if slices.ContainsFunc(orgs, func(org *OktaConfig) bool { return org.Name == name }) {
+			return nil, fmt.Errorf("org %q is listed twice in OKTA_ORGS", name)
+		}
+
+		prefix := "OKTA_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "_"
+		org := loadOrg(name, prefix)
+		if org.Domain == "" || org.APIToken == "" {
+			return nil, fmt.Errorf("org %q needs %sDOMAIN and %sAPI_TOKEN", name, prefix, prefix)
+		}
+		if err := validateOrg(org, prefix); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+func loadOrg(name, prefix string) *OktaConfig {
+	rateLimit, _ := strconv.ParseFloat(os.Getenv(prefix+"RATE_LIMIT"), 64)
+
+	return &OktaConfig{
+		Name:           name,
+		Domain:         os.Getenv(prefix + "DOMAIN"),
+		Issuer:         os.Getenv(prefix + "ISSUER"),
+		Audience:       os.Getenv(prefix + "AUDIENCE"),
+		APIToken:       os.Getenv(prefix + "API_TOKEN"),
+		RateLimit:      rateLimit,
+		MaxConcurrent:  getIntOrDefault(prefix+"MAX_CONCURRENT", 0),
+		RequestTimeout: getDurationOrDefault(prefix+"REQUEST_TIMEOUT", "30s"),
+		HealthInterval: getDurationOrDefault(prefix+"HEALTH_INTERVAL", "1m"),
+	}
+}
+
+func validateOrg(org *OktaConfig, prefix string) error {
+	if org.HealthInterval <= 0 {
+		return fmt.Errorf("%sHEALTH_INTERVAL must be a positive duration", prefix)
+	}
+	if org.RequestTimeout < 0 {
+		return fmt.Errorf("%sREQUEST_TIMEOUT must not be negative", prefix)
+	}
+	return nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -67,3 +421,53 @@ func getDurationOrDefault(key, defaultValue string) time.Duration {
 	duration, _ := time.ParseDuration(defaultValue)
 	return duration
 }
+
+func getIntOrDefault(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getFloatOrDefault(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getBoolOrDefault(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getList parses a comma separated list, e.g. "a,b,c".
+func getList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getListMap parses semicolon separated entries of the form "key=a,b", e.g.
+// "role1=user1,user2;role2=user3".
+func getListMap(key string) map[string][]string {
+	result := map[string][]string{}
+	for _, entry := range strings.Split(os.Getenv(key), ";") {
+		name, values, ok := strings.Cut(entry, "=")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			continue
+		}
+		for _, value := range strings.Split(values, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				result[name] = append(result[name], value)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package access_request_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	access_request_service "github.com/iamBelugaa/iam/internal/services/access_request"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log               *zap.SugaredLogger
+	accessRequestsSvc *access_request_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *access_request_service.Service) *Handler {
+	return &Handler{log: log, accessRequestsSvc: svc}
+}
+
+func (h *Handler) CreateAccessRequest(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create access request received")
+
+	var req models.CreateAccessRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode create access request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body - please check your JSON format", http.StatusBadRequest)
+		return
+	}
+
+	accessRequest, err := h.accessRequestsSvc.CreateAccessRequest(r.Context(), actor.FromContext(r.Context()), &req)
+	if err != nil {
+		h.log.Infow("Failed to create access request", zap.Error(err), "resourceId", req.ResourceID)
+		h.respondWithServiceError(w, err, "Failed to create access request")
+		return
+	}
+
+	h.log.Infow("Access request created successfully", "accessRequestId", accessRequest.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Access request submitted successfully", accessRequest)
+}
+
+func (h *Handler) GetAccessRequests(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get access requests request received")
+
+	query := r.URL.Query()
+	accessRequests := h.accessRequestsSvc.GetAccessRequests(r.Context(), access_request_service.Filter{
+		Status:   query.Get("status"),
+		UserID:   query.Get("userId"),
+		Approver: query.Get("approver"),
+	})
+
+	h.log.Infow("Access requests retrieved successfully", "count", len(accessRequests))
+	response.RespondSuccess(w, http.StatusOK, "Success", accessRequests)
+}
+
+func (h *Handler) GetAccessRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "requestID")
+	if requestID == "" {
+		h.respondWithError(w, "Access request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get access request received", "accessRequestId", requestID)
+
+	accessRequest, err := h.accessRequestsSvc.GetAccessRequest(r.Context(), requestID)
+	if err != nil {
+		h.log.Infow("Failed to get access request", zap.Error(err), "accessRequestId", requestID)
+		h.respondWithServiceError(w, err, "Failed to retrieve access request")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", accessRequest)
+}
+
+func (h *Handler) ApproveAccessRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "requestID")
+	if requestID == "" {
+		h.respondWithError(w, "Access request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	approverID := actor.FromContext(r.Context())
+	if approverID == "" {
+		h.respondWithError(w, "Caller identity is required to approve", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Approve access request received", "accessRequestId", requestID, "approverId", approverID)
+
+	var decision models.AccessRequestDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode access request decision", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	accessRequest, err := h.accessRequestsSvc.ApproveAccessRequest(r.Context(), requestID, approverID, decision.Comment)
+	if err != nil {
+		h.log.Infow("Failed to approve access request", zap.Error(err), "accessRequestId", requestID)
+		if accessRequest != nil {
+			response.RespondError(w, http.StatusBadGateway, "FULFILLMENT_FAILED",
+				"Access request approved but granting access failed - approve again to retry", accessRequest,
+			)
+			return
+		}
+		h.respondWithServiceError(w, err, "Failed to approve access request")
+		return
+	}
+
+	h.log.Infow("Access request approved successfully", "accessRequestId", requestID)
+	response.RespondSuccess(w, http.StatusOK, "Access request approved and fulfilled", accessRequest)
+}
+
+func (h *Handler) DenyAccessRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "requestID")
+	if requestID == "" {
+		h.respondWithError(w, "Access request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	approverID := actor.FromContext(r.Context())
+	if approverID == "" {
+		h.respondWithError(w, "Caller identity is required to deny", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Deny access request received", "accessRequestId", requestID, "approverId", approverID)
+
+	var decision models.AccessRequestDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode access request decision", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	accessRequest, err := h.accessRequestsSvc.DenyAccessRequest(r.Context(), requestID, approverID, decision.Comment)
+	if err != nil {
+		h.log.Infow("Failed to deny access request", zap.Error(err), "accessRequestId", requestID)
+		h.respondWithServiceError(w, err, "Failed to deny access request")
+		return
+	}
+
+	h.log.Infow("Access request denied successfully", "accessRequestId", requestID)
+	response.RespondSuccess(w, http.StatusOK, "Access request denied", accessRequest)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, access_request_service.ErrInvalidRequest):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, access_request_service.ErrNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, access_request_service.ErrNotApprover):
+		h.respondWithError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, access_request_service.ErrNotPending):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,233 @@
+package access_review_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	access_review_service "github.com/iamBelugaa/iam/internal/services/access_review"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log             *zap.SugaredLogger
+	accessReviewSvc *access_review_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *access_review_service.Service) *Handler {
+	return &Handler{log: log, accessReviewSvc: svc}
+}
+
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create access review campaign request received")
+
+	var req models.CreateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode create campaign request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body - please check your JSON format", http.StatusBadRequest)
+		return
+	}
+
+	createdBy := actor.FromContext(r.Context())
+	if createdBy == "" {
+		h.respondWithError(w, "Caller identity is required to start a campaign", http.StatusUnauthorized)
+		return
+	}
+
+	campaign, err := h.accessReviewSvc.CreateCampaign(r.Context(), createdBy, &req)
+	if err != nil {
+		h.log.Infow("Failed to create campaign", zap.Error(err), "name", req.Name)
+		h.respondWithServiceError(w, err, "Failed to create campaign")
+		return
+	}
+
+	h.log.Infow("Campaign created successfully", "campaignId", campaign.ID)
+	response.RespondSuccess(w, http.StatusAccepted, "Campaign created - assignments are being snapshotted", campaign)
+}
+
+func (h *Handler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get access review campaigns request received")
+
+	campaigns := h.accessReviewSvc.GetCampaigns(r.Context())
+
+	h.log.Infow("Campaigns retrieved successfully", "count", len(campaigns))
+	response.RespondSuccess(w, http.StatusOK, "Success", campaigns)
+}
+
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	if campaignID == "" {
+		h.respondWithError(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get campaign request received", "campaignId", campaignID)
+
+	campaign, err := h.accessReviewSvc.GetCampaign(r.Context(), campaignID)
+	if err != nil {
+		h.respondWithServiceError(w, err, "Failed to retrieve campaign")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", campaign)
+}
+
+func (h *Handler) GetItems(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	if campaignID == "" {
+		h.respondWithError(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	h.log.Infow("Get review items request received", "campaignId", campaignID)
+
+	items, err := h.accessReviewSvc.GetItems(
+		r.Context(), campaignID, query.Get("reviewer"), query.Get("pending") == "true",
+	)
+	if err != nil {
+		h.respondWithServiceError(w, err, "Failed to retrieve review items")
+		return
+	}
+
+	h.log.Infow("Review items retrieved successfully", "campaignId", campaignID, "count", len(items))
+	response.RespondSuccess(w, http.StatusOK, "Success", items)
+}
+
+func (h *Handler) DecideItem(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	itemID := chi.URLParam(r, "itemID")
+
+	if campaignID == "" || itemID == "" {
+		h.respondWithError(w, "Both Campaign ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	reviewerID := actor.FromContext(r.Context())
+	if reviewerID == "" {
+		h.respondWithError(w, "Caller identity is required to review", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Review decision request received", "campaignId", campaignID, "itemId", itemID)
+
+	var req models.ReviewDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode review decision", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.accessReviewSvc.DecideItem(r.Context(), campaignID, itemID, reviewerID, &req)
+	if err != nil {
+		h.log.Infow("Failed to record review decision", zap.Error(err), "campaignId", campaignID, "itemId", itemID)
+		h.respondWithServiceError(w, err, "Failed to record decision")
+		return
+	}
+
+	h.log.Infow("Review decision recorded successfully", "campaignId", campaignID, "itemId", itemID)
+	response.RespondSuccess(w, http.StatusOK, "Decision recorded successfully", item)
+}
+
+func (h *Handler) CloseCampaign(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	if campaignID == "" {
+		h.respondWithError(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	closedBy := actor.FromContext(r.Context())
+	if closedBy == "" {
+		h.respondWithError(w, "Caller identity is required to close a campaign", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Close campaign request received", "campaignId", campaignID)
+
+	campaign, err := h.accessReviewSvc.CloseCampaign(r.Context(), campaignID, closedBy)
+	if err != nil {
+		h.log.Infow("Failed to close campaign", zap.Error(err), "campaignId", campaignID)
+		h.respondWithServiceError(w, err, "Failed to close campaign")
+		return
+	}
+
+	h.log.Infow("Campaign closed successfully", "campaignId", campaignID)
+	response.RespondSuccess(w, http.StatusOK, "Campaign closed and revocations applied", campaign)
+}
+
+func (h *Handler) SignOff(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	if campaignID == "" {
+		h.respondWithError(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	signedOffBy := actor.FromContext(r.Context())
+	if signedOffBy == "" {
+		h.respondWithError(w, "Caller identity is required to sign off", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Sign off campaign request received", "campaignId", campaignID)
+
+	var req models.SignOffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode sign off request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.accessReviewSvc.SignOff(r.Context(), campaignID, signedOffBy, req.Comment)
+	if err != nil {
+		h.log.Infow("Failed to sign off campaign", zap.Error(err), "campaignId", campaignID)
+		h.respondWithServiceError(w, err, "Failed to sign off campaign")
+		return
+	}
+
+	h.log.Infow("Campaign signed off successfully", "campaignId", campaignID)
+	response.RespondSuccess(w, http.StatusOK, "Campaign signed off successfully", report)
+}
+
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	campaignID := chi.URLParam(r, "campaignID")
+	if campaignID == "" {
+		h.respondWithError(w, "Campaign ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get campaign report request received", "campaignId", campaignID)
+
+	report, err := h.accessReviewSvc.GetReport(r.Context(), campaignID)
+	if err != nil {
+		h.respondWithServiceError(w, err, "Failed to build campaign report")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", report)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, access_review_service.ErrInvalidRequest):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, access_review_service.ErrNotFound), errors.Is(err, access_review_service.ErrItemNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, access_review_service.ErrNotReviewer), errors.Is(err, access_review_service.ErrNotAllowed),
+		errors.Is(err, access_review_service.ErrSelfCertified):
+		h.respondWithError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, access_review_service.ErrWrongStatus):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,49 @@
+package audit_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log      *zap.SugaredLogger
+	auditSvc *audit_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *audit_service.Service) *Handler {
+	return &Handler{log: log, auditSvc: svc}
+}
+
+func (h *Handler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get audit entries request received")
+
+	query := r.URL.Query()
+	filter := audit_service.Filter{
+		Actor:    query.Get("actor"),
+		Action:   query.Get("action"),
+		TargetID: query.Get("targetId"),
+	}
+
+	if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			h.respondWithError(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = parsed
+	}
+
+	entries := h.auditSvc.GetAuditEntries(r.Context(), filter)
+
+	h.log.Infow("Audit entries retrieved successfully", "count", len(entries))
+	response.RespondSuccess(w, http.StatusOK, "Success", entries)
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
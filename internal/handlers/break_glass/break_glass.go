@@ -0,0 +1,126 @@
+package break_glass_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	break_glass_service "github.com/iamBelugaa/iam/internal/services/break_glass"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log           *zap.SugaredLogger
+	breakGlassSvc *break_glass_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *break_glass_service.Service) *Handler {
+	return &Handler{log: log, breakGlassSvc: svc}
+}
+
+func (h *Handler) Elevate(w http.ResponseWriter, r *http.Request) {
+	userID := actor.FromContext(r.Context())
+	if userID == "" {
+		h.respondWithError(w, "Caller identity is required to break glass", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Break-glass request received", "userId", userID)
+
+	var req models.CreateElevationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode break-glass request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body - please check your JSON format", http.StatusBadRequest)
+		return
+	}
+
+	elevation, err := h.breakGlassSvc.Elevate(r.Context(), userID, &req)
+	if err != nil {
+		h.log.Infow("Failed to break glass", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to elevate - security has been notified")
+		return
+	}
+
+	h.log.Infow("Break-glass elevation granted", "elevationId", elevation.ID, "userId", userID)
+	response.RespondSuccess(w, http.StatusCreated, "Emergency role granted until "+elevation.ExpiresAt.Format(time.RFC3339), elevation)
+}
+
+func (h *Handler) GetElevations(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get elevations request received")
+
+	elevations := h.breakGlassSvc.GetElevations(r.Context(), r.URL.Query().Get("status"))
+
+	h.log.Infow("Elevations retrieved successfully", "count", len(elevations))
+	response.RespondSuccess(w, http.StatusOK, "Success", elevations)
+}
+
+func (h *Handler) GetElevation(w http.ResponseWriter, r *http.Request) {
+	elevationID := chi.URLParam(r, "elevationID")
+	if elevationID == "" {
+		h.respondWithError(w, "Elevation ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get elevation request received", "elevationId", elevationID)
+
+	elevation, err := h.breakGlassSvc.GetElevation(r.Context(), elevationID)
+	if err != nil {
+		h.respondWithServiceError(w, err, "Failed to retrieve elevation")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", elevation)
+}
+
+func (h *Handler) RevokeElevation(w http.ResponseWriter, r *http.Request) {
+	elevationID := chi.URLParam(r, "elevationID")
+	if elevationID == "" {
+		h.respondWithError(w, "Elevation ID is required", http.StatusBadRequest)
+		return
+	}
+
+	revokedBy := actor.FromContext(r.Context())
+	if revokedBy == "" {
+		h.respondWithError(w, "Caller identity is required to revoke", http.StatusUnauthorized)
+		return
+	}
+
+	h.log.Infow("Revoke elevation request received", "elevationId", elevationID, "revokedBy", revokedBy)
+
+	elevation, err := h.breakGlassSvc.RevokeElevation(r.Context(), elevationID, revokedBy)
+	if err != nil {
+		h.log.Infow("Failed to revoke elevation", zap.Error(err), "elevationId", elevationID)
+		h.respondWithServiceError(w, err, "Failed to revoke elevation - it will be retried automatically")
+		return
+	}
+
+	h.log.Infow("Elevation revoked successfully", "elevationId", elevationID)
+	response.RespondSuccess(w, http.StatusOK, "Elevation revoked successfully", elevation)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, break_glass_service.ErrInvalidRequest):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, break_glass_service.ErrNotEligible):
+		h.respondWithError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, break_glass_service.ErrNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, break_glass_service.ErrAlreadyElevated), errors.Is(err, break_glass_service.ErrNotActive):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, break_glass_service.ErrNotConfigured):
+		h.respondWithError(w, err.Error(), http.StatusNotImplemented)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,145 @@
+package desired_state_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+// ContentTypeNDJSON selects streamed apply progress, one ChangeResult per line.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+type Handler struct {
+	log             *zap.SugaredLogger
+	desiredStateSvc *desired_state_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *desired_state_service.Service) *Handler {
+	return &Handler{log: log, desiredStateSvc: svc}
+}
+
+func (h *Handler) Plan(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Plan desired state request received")
+
+	state, prune, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+
+	plan, err := h.desiredStateSvc.Plan(r.Context(), state, prune)
+	if err != nil {
+		h.log.Infow("Failed to plan desired state", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to plan desired state")
+		return
+	}
+
+	h.log.Infow("Desired state planned successfully", "changes", len(plan.Changes))
+	response.RespondSuccess(w, http.StatusOK, "Success", plan)
+}
+
+func (h *Handler) Apply(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Apply desired state request received")
+
+	state, prune, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), ContentTypeNDJSON) {
+		result, err := h.desiredStateSvc.Apply(r.Context(), state, prune, nil)
+		if err != nil {
+			h.log.Infow("Failed to apply desired state", zap.Error(err))
+			h.respondWithServiceError(w, err, "Failed to apply desired state")
+			return
+		}
+
+		h.log.Infow("Desired state applied", "applied", result.Applied, "failed", result.Failed)
+		if result.Failed > 0 {
+			response.RespondSuccess(w, http.StatusOK, "Desired state partially applied", result)
+			return
+		}
+		response.RespondSuccess(w, http.StatusOK, "Desired state applied successfully", result)
+		return
+	}
+
+	// Streaming starts with the first result, so planning errors are still
+	// reported as a regular error response.
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+
+	_, err := h.desiredStateSvc.Apply(r.Context(), state, prune, func(result models.ChangeResult) {
+		if !started {
+			w.Header().Set("Content-Type", ContentTypeNDJSON)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if err := encoder.Encode(result); err != nil {
+			h.log.Infow("Failed to stream apply progress", zap.Error(err))
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if err != nil {
+		h.log.Infow("Failed to apply desired state", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to apply desired state")
+		return
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", ContentTypeNDJSON)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// decode reads a YAML or JSON desired state and the prune query parameter.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request) (*models.DesiredState, bool, bool) {
+	prune := false
+	if value := r.URL.Query().Get("prune"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.respondWithError(w, "prune must be true or false", http.StatusBadRequest)
+			return nil, false, false
+		}
+		prune = parsed
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Infow("Failed to read desired state request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false, false
+	}
+
+	state, err := desired_state_service.Parse(body)
+	if err != nil {
+		h.log.Infow("Failed to parse desired state", zap.Error(err))
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+		return nil, false, false
+	}
+
+	return state, prune, true
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, desired_state_service.ErrInvalidState):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,115 @@
+package drift_handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	drift_service "github.com/iamBelugaa/iam/internal/services/drift"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log      *zap.SugaredLogger
+	driftSvc *drift_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *drift_service.Service) *Handler {
+	return &Handler{log: log, driftSvc: svc}
+}
+
+func (h *Handler) GetDriftItems(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get drift items request received")
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = models.DriftStatusOpen
+	}
+
+	items := h.driftSvc.GetDriftItems(r.Context(), drift_service.Filter{
+		Status: status,
+		Object: query.Get("object"),
+	})
+
+	h.log.Infow("Drift items retrieved successfully", "count", len(items))
+	response.RespondSuccess(w, http.StatusOK, "Success", items)
+}
+
+func (h *Handler) GetDriftItem(w http.ResponseWriter, r *http.Request) {
+	itemID := chi.URLParam(r, "itemID")
+	if itemID == "" {
+		h.respondWithError(w, "Drift item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get drift item request received", "itemId", itemID)
+
+	item, err := h.driftSvc.GetDriftItem(r.Context(), itemID)
+	if err != nil {
+		h.log.Infow("Failed to get drift item", zap.Error(err), "itemId", itemID)
+		h.respondWithServiceError(w, err, "Failed to retrieve drift item")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", item)
+}
+
+func (h *Handler) GetBaseline(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get drift baseline request received")
+
+	baseline, err := h.driftSvc.GetBaseline(r.Context())
+	if err != nil {
+		h.log.Infow("Failed to get drift baseline", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to retrieve baseline")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", baseline)
+}
+
+func (h *Handler) ApproveBaseline(w http.ResponseWriter, r *http.Request) {
+	approvedBy := actor.FromContext(r.Context())
+	h.log.Infow("Approve drift baseline request received", "approvedBy", approvedBy)
+
+	baseline, err := h.driftSvc.ApproveBaseline(r.Context(), approvedBy)
+	if err != nil {
+		h.log.Infow("Failed to approve drift baseline", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to approve baseline")
+		return
+	}
+
+	h.log.Infow("Drift baseline approved successfully", "baselineId", baseline.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Baseline approved successfully", baseline)
+}
+
+func (h *Handler) CheckDrift(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Check drift request received")
+
+	check, err := h.driftSvc.Check(r.Context())
+	if err != nil {
+		h.log.Infow("Failed to check for drift", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to check for drift")
+		return
+	}
+
+	h.log.Infow("Drift checked successfully", "open", len(check.Open), "detected", check.Detected)
+	response.RespondSuccess(w, http.StatusOK, "Success", check)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, drift_service.ErrNoBaseline), errors.Is(err, drift_service.ErrItemNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
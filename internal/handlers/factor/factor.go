@@ -0,0 +1,208 @@
+package factor_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	factor_service "github.com/iamBelugaa/iam/internal/services/factor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log        *zap.SugaredLogger
+	factorsSvc *factor_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *factor_service.Service) *Handler {
+	return &Handler{log: log, factorsSvc: svc}
+}
+
+func (h *Handler) GetFactors(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get user factors request received", "userId", userID)
+
+	factors, err := h.factorsSvc.GetFactors(r.Context(), userID)
+	if err != nil {
+		h.log.Infow("Failed to get user factors", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to retrieve user factors")
+		return
+	}
+
+	h.log.Infow("User factors retrieved successfully", "userId", userID, "count", len(factors))
+	response.RespondSuccess(w, http.StatusOK, "Success", factors)
+}
+
+func (h *Handler) GetFactor(w http.ResponseWriter, r *http.Request) {
+	userID, factorID := chi.URLParam(r, "userID"), chi.URLParam(r, "factorID")
+	if userID == "" || factorID == "" {
+		h.respondWithError(w, "User ID and factor ID are required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get user factor request received", "userId", userID, "factorId", factorID)
+
+	factor, err := h.factorsSvc.GetFactor(r.Context(), userID, factorID)
+	if err != nil {
+		h.log.Infow("Failed to get user factor", zap.Error(err), "userId", userID, "factorId", factorID)
+		h.respondWithServiceError(w, err, "Failed to retrieve user factor")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", factor)
+}
+
+func (h *Handler) EnrollFactor(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Enroll user factor request received", "userId", userID)
+
+	var req models.EnrollFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode enroll factor request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.FactorType == "" || req.Provider == "" {
+		h.respondWithError(w, "factorType and provider are required", http.StatusBadRequest)
+		return
+	}
+
+	factor, err := h.factorsSvc.EnrollFactor(r.Context(), userID, &req)
+	if err != nil {
+		h.log.Infow("Failed to enroll user factor", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to enroll factor")
+		return
+	}
+
+	h.log.Infow("User factor enrolled successfully", "userId", userID, "factorId", factor.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Factor enrolled successfully", factor)
+}
+
+func (h *Handler) ActivateFactor(w http.ResponseWriter, r *http.Request) {
+	userID, factorID := chi.URLParam(r, "userID"), chi.URLParam(r, "factorID")
+	if userID == "" || factorID == "" {
+		h.respondWithError(w, "User ID and factor ID are required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Activate user factor request received", "userId", userID, "factorId", factorID)
+
+	var req models.ActivateFactorRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Infow("Failed to decode activate factor request", zap.Error(err))
+			h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	factor, err := h.factorsSvc.ActivateFactor(r.Context(), userID, factorID, &req)
+	if err != nil {
+		h.log.Infow("Failed to activate user factor", zap.Error(err), "userId", userID, "factorId", factorID)
+		h.respondWithServiceError(w, err, "Failed to activate factor")
+		return
+	}
+
+	h.log.Infow("User factor activated successfully", "userId", userID, "factorId", factorID)
+	response.RespondSuccess(w, http.StatusOK, "Factor activated successfully", factor)
+}
+
+func (h *Handler) VerifyFactor(w http.ResponseWriter, r *http.Request) {
+	userID, factorID := chi.URLParam(r, "userID"), chi.URLParam(r, "factorID")
+	if userID == "" || factorID == "" {
+		h.respondWithError(w, "User ID and factor ID are required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Verify user factor request received", "userId", userID, "factorId", factorID)
+
+	var req models.VerifyFactorRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Infow("Failed to decode verify factor request", zap.Error(err))
+			h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	verification, err := h.factorsSvc.VerifyFactor(r.Context(), userID, factorID, &req)
+	if err != nil {
+		h.log.Infow("Failed to verify user factor", zap.Error(err), "userId", userID, "factorId", factorID)
+		h.respondWithServiceError(w, err, "Failed to verify factor")
+		return
+	}
+
+	h.log.Infow("User factor verified", "userId", userID, "factorId", factorID,
+		"factorResult", verification.FactorResult,
+	)
+	response.RespondSuccess(w, http.StatusOK, "Success", verification)
+}
+
+func (h *Handler) ResetFactor(w http.ResponseWriter, r *http.Request) {
+	userID, factorID := chi.URLParam(r, "userID"), chi.URLParam(r, "factorID")
+	if userID == "" || factorID == "" {
+		h.respondWithError(w, "User ID and factor ID are required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Reset user factor request received", "userId", userID, "factorId", factorID)
+
+	if err := h.factorsSvc.ResetFactor(r.Context(), userID, factorID); err != nil {
+		h.log.Infow("Failed to reset user factor", zap.Error(err), "userId", userID, "factorId", factorID)
+		h.respondWithServiceError(w, err, "Failed to reset factor")
+		return
+	}
+
+	h.log.Infow("User factor reset successfully", "userId", userID, "factorId", factorID)
+	response.RespondSuccess(w, http.StatusOK, "Factor reset successfully", nil)
+}
+
+func (h *Handler) ResetFactors(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Reset all user factors request received", "userId", userID)
+
+	if err := h.factorsSvc.ResetFactors(r.Context(), userID); err != nil {
+		h.log.Infow("Failed to reset all user factors", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to reset factors")
+		return
+	}
+
+	h.log.Infow("All user factors reset successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "All factors reset successfully", nil)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, factor_service.ErrNotFound):
+		h.respondWithError(w, "Factor not found", http.StatusNotFound)
+	case errors.Is(err, factor_service.ErrInvalidPassCode):
+		h.respondWithError(w, "Invalid passcode", http.StatusBadRequest)
+	case errors.Is(err, factor_service.ErrInvalidFactor):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
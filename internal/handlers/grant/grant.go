@@ -0,0 +1,105 @@
+package grant_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log       *zap.SugaredLogger
+	grantsSvc *grant_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *grant_service.Service) *Handler {
+	return &Handler{log: log, grantsSvc: svc}
+}
+
+func (h *Handler) GetGrants(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get grants request received")
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = models.GrantStatusActive
+	}
+
+	grants := h.grantsSvc.GetGrants(r.Context(), grant_service.Filter{
+		Status:       status,
+		UserID:       query.Get("userId"),
+		ResourceType: query.Get("resourceType"),
+	})
+
+	h.log.Infow("Grants retrieved successfully", "count", len(grants))
+	response.RespondSuccess(w, http.StatusOK, "Success", grants)
+}
+
+func (h *Handler) GetGrant(w http.ResponseWriter, r *http.Request) {
+	grantID := chi.URLParam(r, "grantID")
+	if grantID == "" {
+		h.respondWithError(w, "Grant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get grant request received", "grantId", grantID)
+
+	grant, err := h.grantsSvc.GetGrant(r.Context(), grantID)
+	if err != nil {
+		h.log.Infow("Failed to get grant", zap.Error(err), "grantId", grantID)
+		h.respondWithServiceError(w, err, "Failed to retrieve grant")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", grant)
+}
+
+func (h *Handler) ExtendGrant(w http.ResponseWriter, r *http.Request) {
+	grantID := chi.URLParam(r, "grantID")
+	if grantID == "" {
+		h.respondWithError(w, "Grant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Extend grant request received", "grantId", grantID)
+
+	var req models.ExtendGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode extend grant request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	grant, err := h.grantsSvc.ExtendGrant(r.Context(), grantID, req.ExpiresAt)
+	if err != nil {
+		h.log.Infow("Failed to extend grant", zap.Error(err), "grantId", grantID)
+		h.respondWithServiceError(w, err, "Failed to extend grant")
+		return
+	}
+
+	h.log.Infow("Grant extended successfully", "grantId", grantID)
+	response.RespondSuccess(w, http.StatusOK, "Grant extended successfully", grant)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, grant_service.ErrNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, grant_service.ErrInvalidExpiry):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, grant_service.ErrNotActive):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,128 @@
+package graphql_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+)
+
+type Handler struct {
+	log       *zap.SugaredLogger
+	cfg       *config.GraphQLConfig
+	schema    graphql.Schema
+	usersSvc  *user_service.Service
+	groupsSvc *group_service.Service
+	rolesSvc  *role_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	cfg *config.GraphQLConfig,
+	usersSvc *user_service.Service,
+	groupsSvc *group_service.Service,
+	rolesSvc *role_service.Service,
+) *Handler {
+	schema, err := newSchema()
+	if err != nil {
+		// The schema is static, so this only fails on a programming error.
+		panic(err)
+	}
+	return &Handler{
+		log:       log,
+		cfg:       cfg,
+		schema:    schema,
+		usersSvc:  usersSvc,
+		groupsSvc: groupsSvc,
+		rolesSvc:  rolesSvc,
+	}
+}
+
+// Query executes a GraphQL query. Queries that do not parse, are invalid or
+// exceed the depth or cost limits are rejected with 400 before any Okta call
+// is made. Errors of single fields are reported next to the data of the rest.
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("GraphQL request received")
+
+	var req models.GraphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode GraphQL request", zap.Error(err))
+		h.respondWithErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(
+			errors.New("Invalid request body - please check your JSON format"),
+		))
+		return
+	}
+	if req.Query == "" {
+		h.respondWithErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(errors.New("Query is required")))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		h.log.Infow("Failed to parse GraphQL query", zap.Error(err))
+		h.respondWithErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		h.log.Infow("GraphQL query is invalid", "errors", len(validation.Errors))
+		h.respondWithErrors(w, http.StatusBadRequest, validation.Errors)
+		return
+	}
+
+	if err := checkLimits(&h.schema, doc, req.Variables, h.cfg.MaxDepth, h.cfg.MaxCost, h.cfg.ListSize); err != nil {
+		var limitErr *limitError
+		errors.As(err, &limitErr)
+		h.log.Infow("GraphQL query exceeds the limits", zap.Error(err))
+		h.respondWithErrors(w, http.StatusBadRequest, []gqlerrors.FormattedError{{
+			Message:    limitErr.message,
+			Extensions: map[string]any{"code": limitErr.code},
+		}})
+		return
+	}
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, loadersKey{}, h.newLoaders(ctx))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+
+	h.log.Infow("GraphQL query executed", "operation", req.OperationName, "errors", len(result.Errors))
+	h.respond(w, http.StatusOK, result)
+}
+
+// respondWithErrors rejects a request that was not executed. Its response
+// has no data, not even null.
+func (h *Handler) respondWithErrors(w http.ResponseWriter, status int, errs []gqlerrors.FormattedError) {
+	h.respond(w, status, struct {
+		Errors []gqlerrors.FormattedError `json:"errors"`
+	}{errs})
+}
+
+// respond writes a GraphQL result as is, without the envelope of the REST
+// API, so GraphQL clients can read it.
+func (h *Handler) respond(w http.ResponseWriter, status int, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.log.Infow("Failed to write GraphQL response", zap.Error(err))
+	}
+}
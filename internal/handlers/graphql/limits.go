@@ -0,0 +1,182 @@
+package graphql_handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// limitError is a query rejected before execution for being too deep or too
+// expensive.
+type limitError struct {
+	code    string
+	message string
+}
+
+func (e *limitError) Error() string {
+	return e.message
+}
+
+// measure walks the selections of a validated document to find its depth and
+// estimated cost. Introspection fields are not counted, so tools can load the
+// schema.
+type measure struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	listSize  int
+}
+
+// checkLimits rejects a document whose operations nest fields deeper than
+// maxDepth or cost more than maxCost. variables are the request's, which may
+// set the first argument of a list.
+func checkLimits(
+	schema *graphql.Schema, doc *ast.Document, variables map[string]any, maxDepth, maxCost, listSize int,
+) error {
+	m := &measure{
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		listSize:  listSize,
+	}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok || operation.Operation != ast.OperationTypeQuery {
+			continue
+		}
+
+		depth, cost := m.selections(operation.SelectionSet, schema.QueryType(), 0, map[string]bool{})
+		if maxDepth > 0 && depth > maxDepth {
+			return &limitError{
+				code:    "QUERY_TOO_DEEP",
+				message: fmt.Sprintf("Query depth %d exceeds the limit of %d", depth, maxDepth),
+			}
+		}
+		if maxCost > 0 && cost > maxCost {
+			return &limitError{
+				code:    "QUERY_TOO_COMPLEX",
+				message: fmt.Sprintf("Query cost %d exceeds the limit of %d", cost, maxCost),
+			}
+		}
+	}
+	return nil
+}
+
+// selections returns the deepest nesting below set and its cost. An object
+// field costs one, plus the cost of its selections. A list field counts as
+// many objects as its first argument allows, or listSize without one.
+func (m *measure) selections(set *ast.SelectionSet, parent *graphql.Object, depth int, spread map[string]bool) (int, int) {
+	if set == nil || parent == nil {
+		return depth, 0
+	}
+
+	maxDepth, cost := depth, 0
+	add := func(d, c int) {
+		maxDepth = max(maxDepth, d)
+		cost += c
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+			field, ok := parent.Fields()[name]
+			if !ok {
+				continue
+			}
+
+			object, isList := unwrap(field.Type)
+			if object == nil {
+				add(depth+1, 0)
+				continue
+			}
+			childDepth, childCost := m.selections(selection.SelectionSet, object, depth+1, spread)
+			if isList {
+				add(childDepth, m.size(selection)*(1+childCost))
+			} else {
+				add(childDepth, 1+childCost)
+			}
+
+		case *ast.InlineFragment:
+			add(m.selections(selection.SelectionSet, m.condition(selection.TypeCondition, parent), depth, spread))
+
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || spread[name] {
+				continue
+			}
+			spread[name] = true
+			add(m.selections(fragment.SelectionSet, m.condition(fragment.TypeCondition, parent), depth, spread))
+			delete(spread, name)
+		}
+	}
+	return maxDepth, cost
+}
+
+// size returns the number of objects a list field counts: its first
+// argument, given literally or as a variable, and listSize otherwise, which
+// is also the page size of a root list without first.
+func (m *measure) size(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return max(n, 0)
+			}
+		case *ast.Variable:
+			switch n := m.variables[value.Name.Value].(type) {
+			case float64:
+				return max(int(n), 0)
+			case int:
+				return max(n, 0)
+			}
+		}
+	}
+	return m.listSize
+}
+
+// condition returns the object type a fragment applies to.
+func (m *measure) condition(named *ast.Named, parent *graphql.Object) *graphql.Object {
+	if named == nil {
+		return parent
+	}
+	if object, ok := m.schema.Type(named.Name.Value).(*graphql.Object); ok {
+		return object
+	}
+	return parent
+}
+
+// unwrap returns the object type of a field, if it has one, and whether the
+// field is a list.
+func unwrap(t graphql.Type) (*graphql.Object, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			isList = true
+			t = wrapped.OfType
+		case *graphql.Object:
+			return wrapped, isList
+		default:
+			return nil, isList
+		}
+	}
+}
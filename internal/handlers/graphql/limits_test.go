@@ -0,0 +1,174 @@
+package graphql_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+)
+
+func TestCheckLimits(t *testing.T) {
+	schema, err := newSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		maxDepth  int
+		maxCost   int
+		code      string
+	}{
+		{
+			name:     "within limits",
+			query:    `{ user(id: "00u1") { email manager { email } } }`,
+			maxDepth: 3,
+			maxCost:  2,
+		},
+		{
+			name:     "too deep",
+			query:    `{ user(id: "00u1") { manager { manager { manager { email } } } } }`,
+			maxDepth: 4,
+			maxCost:  100,
+			code:     "QUERY_TOO_DEEP",
+		},
+		{
+			name:     "too deep through a fragment",
+			query:    `{ user(id: "00u1") { ...chain } } fragment chain on User { manager { manager { email } } }`,
+			maxDepth: 3,
+			maxCost:  100,
+			code:     "QUERY_TOO_DEEP",
+		},
+		{
+			name:     "too deep through an inline fragment",
+			query:    `{ user(id: "00u1") { ... on User { manager { manager { email } } } } }`,
+			maxDepth: 3,
+			maxCost:  100,
+			code:     "QUERY_TOO_DEEP",
+		},
+		{
+			// users, groups and members each count ten objects:
+			// 10 * (1 + 10 * (1 + 10)) = 1110.
+			name:     "too complex",
+			query:    `{ users { groups { members { email } } } }`,
+			maxDepth: 10,
+			maxCost:  1109,
+			code:     "QUERY_TOO_COMPLEX",
+		},
+		{
+			name:     "cost at the limit",
+			query:    `{ users { groups { members { email } } } }`,
+			maxDepth: 10,
+			maxCost:  1110,
+		},
+		{
+			// The root list returns at most first users, so it counts
+			// 100 * (1 + 10) = 1100.
+			name:     "first sizes a root list",
+			query:    `{ users(first: 100) { groups { name } } }`,
+			maxDepth: 10,
+			maxCost:  1099,
+			code:     "QUERY_TOO_COMPLEX",
+		},
+		{
+			name:      "first from a variable",
+			query:     `query Users($first: Int) { users(first: $first) { groups { name } } }`,
+			variables: map[string]any{"first": float64(100)},
+			maxDepth:  10,
+			maxCost:   1099,
+			code:      "QUERY_TOO_COMPLEX",
+		},
+		{
+			// A root list without first returns one page of ten users.
+			name:     "root list without first",
+			query:    `{ users { groups { name } } }`,
+			maxDepth: 10,
+			maxCost:  110,
+		},
+		{
+			name:     "small first",
+			query:    `{ users(first: 2, after: "00u1") { groups { name } } }`,
+			maxDepth: 10,
+			maxCost:  22,
+		},
+		{
+			name:     "introspection is not counted",
+			query:    `{ __schema { types { name fields { name type { name ofType { name } } } } } }`,
+			maxDepth: 1,
+			maxCost:  1,
+		},
+		{
+			name:     "zero disables the limits",
+			query:    `{ users { groups { members { manager { manager { email } } } } } }`,
+			maxDepth: 0,
+			maxCost:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+				t.Fatalf("invalid query: %v", validation.Errors)
+			}
+
+			err = checkLimits(&schema, doc, tt.variables, tt.maxDepth, tt.maxCost, 10)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var limitErr *limitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("error = %v, want a limit error", err)
+			}
+			if limitErr.code != tt.code {
+				t.Fatalf("code = %s, want %s", limitErr.code, tt.code)
+			}
+		})
+	}
+}
+
+func TestQueryRejectsOverLimitBeforeExecution(t *testing.T) {
+	// No services are wired in, so executing the query would panic.
+	handler := New(zap.NewNop().Sugar(), &config.GraphQLConfig{MaxDepth: 2, MaxCost: 100, ListSize: 10}, nil, nil, nil)
+
+	body := `{"query": "{ user(id: \"00u1\") { manager { manager { email } } } }"}`
+	w := httptest.NewRecorder()
+	handler.Query(w, httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Data != nil {
+		t.Fatalf("data = %s, want none", result.Data)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "QUERY_TOO_DEEP" {
+		t.Fatalf("errors = %+v, want one QUERY_TOO_DEEP", result.Errors)
+	}
+}
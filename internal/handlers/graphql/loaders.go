@@ -0,0 +1,102 @@
+package graphql_handlers
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dataloader"
+)
+
+// loaders are the lookups of one GraphQL request. Users, groups and roles
+// listed by a root field are primed, so asking for one of them again costs
+// no Okta call.
+type loaders struct {
+	h   *Handler
+	ctx context.Context
+
+	user  *dataloader.Loader[string, *models.User]
+	group *dataloader.Loader[string, *models.Group]
+	role  *dataloader.Loader[string, *models.Role]
+
+	userGroups      *dataloader.Loader[string, []*models.Group]
+	userRoles       *dataloader.Loader[string, []*models.Role]
+	groupMembers    *dataloader.Loader[string, []*models.User]
+	groupRoles      *dataloader.Loader[string, []*models.Role]
+	rolePermissions *dataloader.Loader[string, []*models.Permission]
+}
+
+type loadersKey struct{}
+
+func (h *Handler) newLoaders(ctx context.Context) *loaders {
+	n := h.cfg.MaxConcurrency
+	return &loaders{
+		h:   h,
+		ctx: ctx,
+
+		user:  dataloader.New(ctx, n, logged(h, "user", h.usersSvc.GetUser)),
+		group: dataloader.New(ctx, n, logged(h, "group", h.groupsSvc.GetGroup)),
+		role:  dataloader.New(ctx, n, logged(h, "role", h.rolesSvc.GetRole)),
+
+		userGroups:      dataloader.New(ctx, n, logged(h, "groups of user", h.usersSvc.GetUserGroups)),
+		userRoles:       dataloader.New(ctx, n, logged(h, "roles of user", h.rolesSvc.GetUserRoles)),
+		groupMembers:    dataloader.New(ctx, n, logged(h, "members of group", h.groupsSvc.GetGroupMembers)),
+		groupRoles:      dataloader.New(ctx, n, logged(h, "roles of group", h.rolesSvc.GetGroupRoles)),
+		rolePermissions: dataloader.New(ctx, n, logged(h, "permissions of role", h.rolesSvc.GetRolePermissions)),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// logged logs a failed lookup and reports it to the client without the
+// details of the Okta error, as the REST handlers do.
+func logged[V any](h *Handler, what string, fetch func(ctx context.Context, id string) (V, error)) func(context.Context, string) (V, error) {
+	return func(ctx context.Context, id string) (V, error) {
+		value, err := fetch(ctx, id)
+		if err != nil {
+			h.log.Infow("Failed to resolve GraphQL field", zap.Error(err), "lookup", what, "id", id)
+			return value, errors.New("Failed to retrieve " + what + " " + id)
+		}
+		return value, nil
+	}
+}
+
+func (l *loaders) users() ([]*models.User, error) {
+	users, err := l.h.usersSvc.GetUsers(l.ctx)
+	if err != nil {
+		l.h.log.Infow("Failed to resolve GraphQL field", zap.Error(err), "lookup", "users")
+		return nil, errors.New("Failed to retrieve users")
+	}
+	for _, user := range users {
+		l.user.Prime(user.ID, user)
+	}
+	return users, nil
+}
+
+func (l *loaders) groups() ([]*models.Group, error) {
+	groups, err := l.h.groupsSvc.GetGroups(l.ctx)
+	if err != nil {
+		l.h.log.Infow("Failed to resolve GraphQL field", zap.Error(err), "lookup", "groups")
+		return nil, errors.New("Failed to retrieve groups")
+	}
+	for _, group := range groups {
+		l.group.Prime(group.ID, group)
+	}
+	return groups, nil
+}
+
+func (l *loaders) roles() ([]*models.Role, error) {
+	roles, err := l.h.rolesSvc.GetRoles(l.ctx)
+	if err != nil {
+		l.h.log.Infow("Failed to resolve GraphQL field", zap.Error(err), "lookup", "roles")
+		return nil, errors.New("Failed to retrieve roles")
+	}
+	for _, role := range roles {
+		l.role.Prime(role.ID, role)
+	}
+	return roles, nil
+}
@@ -0,0 +1,251 @@
+package graphql_handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/iamBelugaa/iam/internal/models"
+)
+
+// jsonScalar passes profile attributes through as JSON values.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "An arbitrary JSON value, such as the custom attributes of a profile.",
+	Serialize:   func(value any) any { return value },
+	ParseValue:  func(value any) any { return value },
+	ParseLiteral: func(valueAST ast.Value) any {
+		return valueAST.GetValue()
+	},
+})
+
+// listOf is a non-null list of non-null items.
+func listOf(item graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item)))
+}
+
+var idArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+}
+
+// pageArgs page through a root list. The cost of a query counts first
+// objects for the list, so it is bounded however large the org is.
+var pageArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "The number of items to return, GRAPHQL_LIST_SIZE by default.",
+	},
+	"after": &graphql.ArgumentConfig{
+		Type:        graphql.ID,
+		Description: "The ID of the last item of the previous page.",
+	},
+}
+
+// newSchema describes users, groups, roles and permissions with the
+// relationships between them. Fields return thunks of request-scoped loaders,
+// so the lookups of one level of a query are made together and each only once.
+func newSchema() (graphql.Schema, error) {
+	permissionType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Permission",
+		Description: "An action a custom role allows on a resource, e.g. okta.users.manage.",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.String},
+			"resource":    &graphql.Field{Type: graphql.String},
+			"action":      &graphql.Field{Type: graphql.String},
+			"created":     &graphql.Field{Type: graphql.DateTime},
+			"lastUpdated": &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	roleType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Role",
+		Description: "A set of permissions assigned to users or groups.",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+			"type":        &graphql.Field{Type: graphql.String},
+			"created":     &graphql.Field{Type: graphql.DateTime},
+			"lastUpdated": &graphql.Field{Type: graphql.DateTime},
+			"permissions": &graphql.Field{
+				Type:        listOf(permissionType),
+				Description: "Permissions of a custom role. Standard roles have none listed.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					role := p.Source.(*models.Role)
+					if role.Type != models.RoleTypeCustom {
+						return []*models.Permission{}, nil
+					}
+					return thunk(loadersFrom(p.Context).rolePermissions.Load(role.Name)), nil
+				},
+			},
+		},
+	})
+
+	groupType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Group",
+		Description: "A collection of users with similar access needs.",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+			"type":        &graphql.Field{Type: graphql.String},
+			"created":     &graphql.Field{Type: graphql.DateTime},
+			"lastUpdated": &graphql.Field{Type: graphql.DateTime},
+			"profile":     &graphql.Field{Type: jsonScalar},
+			"roles": &graphql.Field{
+				Type: listOf(roleType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).groupRoles.Load(p.Source.(*models.Group).ID)), nil
+				},
+			},
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "A person who can access the system.",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"email":       &graphql.Field{Type: graphql.String},
+			"firstName":   &graphql.Field{Type: graphql.String},
+			"lastName":    &graphql.Field{Type: graphql.String},
+			"login":       &graphql.Field{Type: graphql.String},
+			"status":      &graphql.Field{Type: graphql.String},
+			"managerId":   &graphql.Field{Type: graphql.String},
+			"created":     &graphql.Field{Type: graphql.DateTime},
+			"activated":   &graphql.Field{Type: graphql.DateTime},
+			"lastLogin":   &graphql.Field{Type: graphql.DateTime},
+			"lastUpdated": &graphql.Field{Type: graphql.DateTime},
+			"profile":     &graphql.Field{Type: jsonScalar},
+			"groups": &graphql.Field{
+				Type: listOf(groupType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).userGroups.Load(p.Source.(*models.User).ID)), nil
+				},
+			},
+			"roles": &graphql.Field{
+				Type:        listOf(roleType),
+				Description: "Roles assigned to the user directly.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).userRoles.Load(p.Source.(*models.User).ID)), nil
+				},
+			},
+		},
+	})
+
+	// Fields that refer back to types defined above.
+	userType.AddFieldConfig("manager", &graphql.Field{
+		Type: userType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			managerID := p.Source.(*models.User).ManagerID
+			if managerID == "" {
+				return nil, nil
+			}
+			return thunk(loadersFrom(p.Context).user.Load(managerID)), nil
+		},
+	})
+	groupType.AddFieldConfig("members", &graphql.Field{
+		Type: listOf(userType),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return thunk(loadersFrom(p.Context).groupMembers.Load(p.Source.(*models.Group).ID)), nil
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).user.Load(p.Args["id"].(string))), nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: listOf(userType),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					loaders := loadersFrom(p.Context)
+					users, err := loaders.users()
+					if err != nil {
+						return nil, err
+					}
+					return page(p, users, func(item *models.User) string { return item.ID }, loaders.h.cfg.ListSize)
+				},
+			},
+			"group": &graphql.Field{
+				Type: groupType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).group.Load(p.Args["id"].(string))), nil
+				},
+			},
+			"groups": &graphql.Field{
+				Type: listOf(groupType),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					loaders := loadersFrom(p.Context)
+					groups, err := loaders.groups()
+					if err != nil {
+						return nil, err
+					}
+					return page(p, groups, func(item *models.Group) string { return item.ID }, loaders.h.cfg.ListSize)
+				},
+			},
+			"role": &graphql.Field{
+				Type: roleType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return thunk(loadersFrom(p.Context).role.Load(p.Args["id"].(string))), nil
+				},
+			},
+			"roles": &graphql.Field{
+				Type: listOf(roleType),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					loaders := loadersFrom(p.Context)
+					roles, err := loaders.roles()
+					if err != nil {
+						return nil, err
+					}
+					return page(p, roles, func(item *models.Role) string { return item.ID }, loaders.h.cfg.ListSize)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// page returns the first items after the item whose ID is the after
+// argument.
+func page[T any](p graphql.ResolveParams, items []T, id func(T) string, listSize int) ([]T, error) {
+	first := listSize
+	if value, ok := p.Args["first"].(int); ok {
+		first = value
+	}
+	if first < 1 {
+		return nil, errors.New("Argument first must be at least 1")
+	}
+
+	if after, ok := p.Args["after"].(string); ok {
+		index := slices.IndexFunc(items, func(item T) bool { return id(item) == after })
+		if index < 0 {
+			return nil, fmt.Errorf("Argument after %q is not the ID of an item in the list", after)
+		}
+		items = items[index+1:]
+	}
+	return items[:min(first, len(items))], nil
+}
+
+// thunk adapts a loader thunk to the signature the executor resolves lazily.
+func thunk[V any](load func() (V, error)) func() (any, error) {
+	return func() (any, error) {
+		return load()
+	}
+}
@@ -0,0 +1,45 @@
+package graphql_handlers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestPage(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []string
+		err  bool
+	}{
+		{name: "default size", args: map[string]any{}, want: []string{"a", "b", "c"}},
+		{name: "first", args: map[string]any{"first": 2}, want: []string{"a", "b"}},
+		{name: "after", args: map[string]any{"first": 2, "after": "b"}, want: []string{"c", "d"}},
+		{name: "last page", args: map[string]any{"after": "d"}, want: []string{"e"}},
+		{name: "past the end", args: map[string]any{"after": "e"}, want: []string{}},
+		{name: "unknown cursor", args: map[string]any{"after": "z"}, err: true},
+		{name: "zero", args: map[string]any{"first": 0}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := page(graphql.ResolveParams{Args: tt.args}, items, func(item string) string { return item }, 3)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("page = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,25 +1,48 @@
 package group_handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/models"
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
 	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/expand"
+	"github.com/iamBelugaa/iam/pkg/patch"
 	"github.com/iamBelugaa/iam/pkg/response"
 )
 
 type Handler struct {
 	log       *zap.SugaredLogger
 	groupsSvc *group_service.Service
+	grantsSvc *grant_service.Service
+	rolesSvc  *role_service.Service
+
+	// expandConcurrency bounds the lookups of ?expand made at a time.
+	expandConcurrency int
 }
 
-func New(log *zap.SugaredLogger, svc *group_service.Service) *Handler {
-	return &Handler{log: log, groupsSvc: svc}
+func New(
+	log *zap.SugaredLogger,
+	svc *group_service.Service,
+	grantsSvc *grant_service.Service,
+	rolesSvc *role_service.Service,
+	expandConcurrency int,
+) *Handler {
+	return &Handler{
+		log: log, groupsSvc: svc, grantsSvc: grantsSvc, rolesSvc: rolesSvc, expandConcurrency: expandConcurrency,
+	}
 }
 
 func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +91,12 @@ func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
 
 	h.log.Infow("Get group request received", "groupId", groupID)
 
+	expansions, err := expand.Parse(r, "members", "roles")
+	if err != nil {
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	group, err := h.groupsSvc.GetGroup(r.Context(), groupID)
 	if err != nil {
 		h.log.Infow("Failed to get group", zap.Error(err), "groupId", groupID)
@@ -75,6 +104,12 @@ func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.expandGroup(r.Context(), group, expansions); err != nil {
+		h.log.Infow("Failed to expand group", zap.Error(err), "groupId", groupID, "expand", expansions)
+		h.respondWithError(w, "Failed to retrieve related resources of group", http.StatusInternalServerError)
+		return
+	}
+
 	h.log.Infow("Group retrieved successfully", zap.String("groupId", groupID))
 	response.RespondSuccess(w, http.StatusOK, "Success", group)
 }
@@ -106,6 +141,46 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	response.RespondSuccess(w, http.StatusOK, "Group updated successfully", group)
 }
 
+func (h *Handler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	groupID := chi.URLParam(r, "groupID")
+	if groupID == "" {
+		h.respondWithError(w, "Group ID is required", http.StatusBadRequest)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	h.log.Infow("Patch group request received", "groupId", groupID, "contentType", contentType)
+
+	if !patch.Supported(contentType) {
+		h.respondWithError(w, "Unsupported patch format - use application/merge-patch+json or application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Infow("Failed to read patch group request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupsSvc.PatchGroup(r.Context(), groupID, contentType, body)
+	if err != nil {
+		h.log.Infow("Failed to patch group", zap.Error(err), "groupId", groupID)
+		switch {
+		case errors.Is(err, patch.ErrTestFailed):
+			h.respondWithError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, patch.ErrInvalidPatch):
+			h.respondWithError(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.respondWithError(w, "Failed to patch group", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.log.Infow("Group patched successfully", "groupId", groupID)
+	response.RespondSuccess(w, http.StatusOK, "Group updated successfully", group)
+}
+
 func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	groupID := chi.URLParam(r, "groupID")
 	if groupID == "" {
@@ -156,14 +231,45 @@ func (h *Handler) AddUserToGroup(w http.ResponseWriter, r *http.Request) {
 
 	h.log.Infow("Add user to group request received", "groupId", groupID, "userId", userID)
 
+	var opts models.AssignmentOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode add user to group request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		h.respondWithError(w, "expiresAt must be in the future", http.StatusBadRequest)
+		return
+	}
+
 	if err := h.groupsSvc.AddUserToGroup(r.Context(), groupID, userID); err != nil {
 		h.log.Infow("Failed to add user to group", zap.Error(err), "groupId", groupID, "userId", userID)
-		h.respondWithError(w, "Failed to add user to group", http.StatusInternalServerError)
+		h.respondWithAssignmentError(w, err, "Failed to add user to group")
+		return
+	}
+
+	if opts.ExpiresAt == nil {
+		if err := h.grantsSvc.ForgetGrant(r.Context(), userID, models.ResourceTypeGroup, groupID); err != nil {
+			h.log.Infow("Failed to clear time-bound grant", zap.Error(err), "groupId", groupID, "userId", userID)
+		}
+
+		h.log.Infow("User added to group successfully", "groupId", groupID, "userId", userID)
+		response.RespondSuccess(w, http.StatusOK, "User added to group successfully", nil)
 		return
 	}
 
-	h.log.Infow("User added to group successfully", "groupId", groupID, "userId", userID)
-	response.RespondSuccess(w, http.StatusOK, "User added to group successfully", nil)
+	grant, err := h.grantsSvc.RecordGrant(
+		r.Context(), actor.FromContext(r.Context()), userID, models.ResourceTypeGroup, groupID, "", *opts.ExpiresAt,
+	)
+	if err != nil {
+		h.log.Infow("Failed to record time-bound grant", zap.Error(err), "groupId", groupID, "userId", userID)
+		h.respondWithError(w, "User added to group but the membership expiry could not be recorded", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("User added to group successfully", "groupId", groupID, "userId", userID, "expiresAt", grant.ExpiresAt)
+	response.RespondSuccess(w, http.StatusOK, "User added to group until "+grant.ExpiresAt.Format(time.RFC3339), grant)
 }
 
 func (h *Handler) RemoveUserFromGroup(w http.ResponseWriter, r *http.Request) {
@@ -183,10 +289,52 @@ func (h *Handler) RemoveUserFromGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.grantsSvc.ForgetGrant(r.Context(), userID, models.ResourceTypeGroup, groupID); err != nil {
+		h.log.Infow("Failed to clear time-bound grant", zap.Error(err), "groupId", groupID, "userId", userID)
+	}
+
 	h.log.Infow("User removed from group successfully", "groupId", groupID, "userId", userID)
 	response.RespondSuccess(w, http.StatusOK, "User removed from group successfully", nil)
 }
 
+func (h *Handler) respondWithAssignmentError(w http.ResponseWriter, err error, fallback string) {
+	var violation *sod_service.ViolationError
+	if errors.As(err, &violation) {
+		response.RespondError(w, http.StatusConflict, "SOD_VIOLATION", violation.Error(), violation.Rule)
+		return
+	}
+	h.respondWithError(w, fallback, http.StatusInternalServerError)
+}
+
+// expandGroup fills the members and roles of group named in expansions. Each
+// relationship writes only its own field, so they are fetched concurrently.
+func (h *Handler) expandGroup(ctx context.Context, group *models.Group, expansions []string) error {
+	return expand.Load(ctx, h.expandConcurrency, expansions, map[string]func(context.Context) error{
+		"members": func(ctx context.Context) error {
+			members, err := h.groupsSvc.GetGroupMembers(ctx, group.ID)
+			if err != nil {
+				return err
+			}
+			group.Members = make([]models.User, 0, len(members))
+			for _, member := range members {
+				group.Members = append(group.Members, *member)
+			}
+			return nil
+		},
+		"roles": func(ctx context.Context) error {
+			roles, err := h.rolesSvc.GetGroupRoles(ctx, group.ID)
+			if err != nil {
+				return err
+			}
+			group.Roles = make([]models.Role, 0, len(roles))
+			for _, role := range roles {
+				group.Roles = append(group.Roles, *role)
+			}
+			return nil
+		},
+	})
+}
+
 func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
 	response.RespondError(w, statusCode, "API_ERROR", message, nil)
 }
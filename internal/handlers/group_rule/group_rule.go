@@ -0,0 +1,235 @@
+package group_rule_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	group_rule_service "github.com/iamBelugaa/iam/internal/services/group_rule"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log           *zap.SugaredLogger
+	groupRulesSvc *group_rule_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *group_rule_service.Service) *Handler {
+	return &Handler{log: log, groupRulesSvc: svc}
+}
+
+func (h *Handler) CreateGroupRule(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create group rule request received")
+
+	var req models.CreateGroupRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode create group rule request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.groupRulesSvc.CreateGroupRule(r.Context(), &req)
+	if err != nil {
+		h.log.Infow("Failed to create group rule", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to create group rule")
+		return
+	}
+
+	h.log.Infow("Group rule created successfully", "ruleId", rule.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Group rule created successfully", rule)
+}
+
+func (h *Handler) GetGroupRules(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get group rules request received")
+
+	rules, err := h.groupRulesSvc.GetGroupRules(r.Context(), r.URL.Query().Get("search"))
+	if err != nil {
+		h.log.Infow("Failed to get group rules", zap.Error(err))
+		h.respondWithError(w, "Failed to retrieve group rules", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Group rules retrieved successfully", "count", len(rules))
+	response.RespondSuccess(w, http.StatusOK, "Success", rules)
+}
+
+func (h *Handler) GetGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get group rule request received", "ruleId", ruleID)
+
+	rule, err := h.groupRulesSvc.GetGroupRule(r.Context(), ruleID)
+	if err != nil {
+		h.log.Infow("Failed to get group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithError(w, "Failed to retrieve group rule", http.StatusInternalServerError)
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", rule)
+}
+
+func (h *Handler) UpdateGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Update group rule request received", "ruleId", ruleID)
+
+	var req models.UpdateGroupRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode update group rule request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.groupRulesSvc.UpdateGroupRule(r.Context(), ruleID, &req)
+	if err != nil {
+		h.log.Infow("Failed to update group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithServiceError(w, err, "Failed to update group rule")
+		return
+	}
+
+	h.log.Infow("Group rule updated successfully", "ruleId", ruleID)
+	response.RespondSuccess(w, http.StatusOK, "Group rule updated successfully", rule)
+}
+
+func (h *Handler) DeleteGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	removeUsers := false
+	if value := r.URL.Query().Get("removeUsers"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.respondWithError(w, "removeUsers must be true or false", http.StatusBadRequest)
+			return
+		}
+		removeUsers = parsed
+	}
+
+	h.log.Infow("Delete group rule request received", "ruleId", ruleID, "removeUsers", removeUsers)
+
+	if err := h.groupRulesSvc.DeleteGroupRule(r.Context(), ruleID, removeUsers); err != nil {
+		h.log.Infow("Failed to delete group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithError(w, "Failed to delete group rule", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Group rule deleted successfully", "ruleId", ruleID)
+	response.RespondSuccess(w, http.StatusOK, "Group rule deleted successfully", nil)
+}
+
+func (h *Handler) ActivateGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Activate group rule request received", "ruleId", ruleID)
+
+	if err := h.groupRulesSvc.ActivateGroupRule(r.Context(), ruleID); err != nil {
+		h.log.Infow("Failed to activate group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithError(w, "Failed to activate group rule", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Group rule activated successfully", "ruleId", ruleID)
+	response.RespondSuccess(w, http.StatusOK, "Group rule activated successfully", nil)
+}
+
+func (h *Handler) DeactivateGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Deactivate group rule request received", "ruleId", ruleID)
+
+	if err := h.groupRulesSvc.DeactivateGroupRule(r.Context(), ruleID); err != nil {
+		h.log.Infow("Failed to deactivate group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithError(w, "Failed to deactivate group rule", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Group rule deactivated successfully", "ruleId", ruleID)
+	response.RespondSuccess(w, http.StatusOK, "Group rule deactivated successfully", nil)
+}
+
+func (h *Handler) ValidateExpression(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Validate group rule expression request received")
+
+	var req models.ValidateExpressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode validate expression request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result := h.groupRulesSvc.ValidateExpression(r.Context(), req.Expression)
+	response.RespondSuccess(w, http.StatusOK, "Success", result)
+}
+
+func (h *Handler) PreviewExpression(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Preview group rule expression request received")
+	h.preview(w, r, "")
+}
+
+func (h *Handler) PreviewGroupRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Group rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Preview group rule request received", "ruleId", ruleID)
+	h.preview(w, r, ruleID)
+}
+
+func (h *Handler) preview(w http.ResponseWriter, r *http.Request, ruleID string) {
+	var req models.PreviewGroupRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode preview request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.groupRulesSvc.PreviewGroupRule(r.Context(), ruleID, &req)
+	if err != nil {
+		h.log.Infow("Failed to preview group rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithServiceError(w, err, "Failed to preview group rule")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", preview)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, group_rule_service.ErrInvalidRule):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, group_rule_service.ErrRuleActive):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
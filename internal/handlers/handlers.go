@@ -1,17 +1,59 @@
 package handlers
 
 import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/config"
+	access_request_handlers "github.com/iamBelugaa/iam/internal/handlers/access_request"
+	access_review_handlers "github.com/iamBelugaa/iam/internal/handlers/access_review"
+	audit_handlers "github.com/iamBelugaa/iam/internal/handlers/audit"
+	break_glass_handlers "github.com/iamBelugaa/iam/internal/handlers/break_glass"
+	desired_state_handlers "github.com/iamBelugaa/iam/internal/handlers/desired_state"
+	drift_handlers "github.com/iamBelugaa/iam/internal/handlers/drift"
+	factor_handlers "github.com/iamBelugaa/iam/internal/handlers/factor"
+	grant_handlers "github.com/iamBelugaa/iam/internal/handlers/grant"
+	graphql_handlers "github.com/iamBelugaa/iam/internal/handlers/graphql"
 	group_handlers "github.com/iamBelugaa/iam/internal/handlers/group"
+	group_rule_handlers "github.com/iamBelugaa/iam/internal/handlers/group_rule"
+	health_handlers "github.com/iamBelugaa/iam/internal/handlers/health"
+	org_handlers "github.com/iamBelugaa/iam/internal/handlers/org"
 	role_handlers "github.com/iamBelugaa/iam/internal/handlers/role"
+	rpc_handlers "github.com/iamBelugaa/iam/internal/handlers/rpc"
+	session_handlers "github.com/iamBelugaa/iam/internal/handlers/session"
+	snapshot_handlers "github.com/iamBelugaa/iam/internal/handlers/snapshot"
+	sod_handlers "github.com/iamBelugaa/iam/internal/handlers/sod"
 	user_handlers "github.com/iamBelugaa/iam/internal/handlers/user"
+	access_request_service "github.com/iamBelugaa/iam/internal/services/access_request"
+	access_review_service "github.com/iamBelugaa/iam/internal/services/access_review"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	break_glass_service "github.com/iamBelugaa/iam/internal/services/break_glass"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+	drift_service "github.com/iamBelugaa/iam/internal/services/drift"
+	factor_service "github.com/iamBelugaa/iam/internal/services/factor"
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
 	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	group_rule_service "github.com/iamBelugaa/iam/internal/services/group_rule"
 	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	session_service "github.com/iamBelugaa/iam/internal/services/session"
+	snapshot_service "github.com/iamBelugaa/iam/internal/services/snapshot"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
 	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/health"
+	"github.com/iamBelugaa/iam/pkg/metrics"
+	"github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/openapi"
+	"github.com/iamBelugaa/iam/pkg/org"
+	"github.com/iamBelugaa/iam/pkg/response"
+	"github.com/iamBelugaa/iam/pkg/tracing"
 )
 
 const (
@@ -25,81 +67,342 @@ type Config struct {
 	UsersService  *user_service.Service
 	GroupsService *group_service.Service
 	RolesService  *role_service.Service
+
+	GroupRulesService     *group_rule_service.Service
+	GrantsService         *grant_service.Service
+	AccessRequestsService *access_request_service.Service
+	AccessReviewsService  *access_review_service.Service
+	AuditService          *audit_service.Service
+	BreakGlassService     *break_glass_service.Service
+	SoDService            *sod_service.Service
+	DesiredStateService   *desired_state_service.Service
+	DriftService          *drift_service.Service
+	SnapshotsService      *snapshot_service.Service
+	FactorsService        *factor_service.Service
+	SessionsService       *session_service.Service
 }
 
-func Setup(cfg *Config) {
+// Org is a configured Okta org with the routes of its services and the
+// services the gRPC API calls.
+type Org struct {
+	Client   *okta.Client
+	Router   *chi.Mux
+	Services *rpc_handlers.Services
+}
+
+// NewRouter returns the API router. A request is served by the org named in
+// its /api/v1/orgs/{org} path prefix, else by the org in the X-Okta-Org
+// header, else by the default org.
+func NewRouter(log *zap.SugaredLogger, cfg *config.Config, orgs []*Org, checker *health.Checker) *chi.Mux {
+	router := chi.NewRouter()
+	defaultOrg := cfg.Okta.Name
+
+	// Every org registers the same routes, so the first describes them all.
+	spec := NewSpec(log, orgs[0].Router)
+
 	// Standard middleware for RealIP, RequestID, Logger, Recoverer etc.
-	cfg.Router.Use(middleware.RealIP)
-	cfg.Router.Use(middleware.RequestID)
-	cfg.Router.Use(middleware.Logger)
-	cfg.Router.Use(middleware.Recoverer)
-
-	userHandlers := user_handlers.New(cfg.Log, cfg.UsersService)
-	groupHandlers := group_handlers.New(cfg.Log, cfg.GroupsService)
-	roleHandlers := role_handlers.New(cfg.Log, cfg.RolesService)
-
-	cfg.Router.Route(APIVersion1URL, func(r chi.Router) {
-		// User management endpoints.
-		r.Route("/users", func(r chi.Router) {
-			r.Get("/", userHandlers.GetUsers)
-			r.Post("/", userHandlers.CreateUser)
-
-			r.Route("/{userID}", func(r chi.Router) {
-				r.Get("/", userHandlers.GetUser)
-				r.Put("/", userHandlers.UpdateUser)
-				r.Delete("/", userHandlers.DeleteUser)
-
-				// User lifecycle actions.
-				r.Post("/activate", userHandlers.ActivateUser)
-				r.Post("/deactivate", userHandlers.DeactivateUser)
-				r.Post("/suspend", userHandlers.SuspendUser)
-				r.Post("/unsuspend", userHandlers.UnSuspendUser)
-
-				// User roles sub-resource.
-				r.Route("/roles", func(r chi.Router) {
-					r.Get("/", roleHandlers.GetUserRoles)
-					r.Put("/{roleID}", roleHandlers.AssignRoleToUser)
-					r.Delete("/{roleID}", roleHandlers.UnassignRoleFromUser)
-				})
+	router.Use(middleware.RealIP)
+	router.Use(middleware.RequestID)
+	router.Use(tracing.Middleware)
+	router.Use(metrics.Middleware)
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+	router.Use(actor.Middleware)
+	router.Use(dryrun.Middleware)
+	if cfg.Server.ValidateRequests {
+		router.Use(openapi.NewValidator(spec).Middleware(specPath))
+	}
+
+	byName := make(map[string]*Org, len(orgs))
+	clients := make([]*okta.Client, 0, len(orgs))
+	for _, o := range orgs {
+		byName[o.Client.Name()] = o
+		clients = append(clients, o.Client)
+	}
+
+	serve := func(w http.ResponseWriter, r *http.Request, name string) {
+		target, ok := byName[name]
+		if !ok {
+			response.RespondError(w, http.StatusNotFound, "API_ERROR", fmt.Sprintf("Unknown org '%s'", name), nil)
+			return
+		}
+		target.Router.ServeHTTP(w, r.WithContext(org.WithName(r.Context(), name)))
+	}
+
+	orgHandlers := org_handlers.New(log, clients, defaultOrg)
+	healthHandlers := health_handlers.New(log, checker)
+
+	// OpenAPI specification of the API.
+	router.Get(OpenAPIURL, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", openapi.ContentTypeJSON)
+		json.NewEncoder(w).Encode(spec)
+	})
+
+	// Prometheus metrics for the whole deployment.
+	router.Handle("/metrics", metrics.Handler())
+
+	// Liveness and readiness probes.
+	router.Get("/healthz", healthHandlers.Liveness)
+	router.Get("/readyz", healthHandlers.Readiness)
+
+	router.Route(APIVersion1URL, func(r chi.Router) {
+		// Org endpoints.
+		r.Get("/orgs", orgHandlers.GetOrgs)
+
+		r.Mount("/orgs/{org}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serve(w, r, chi.URLParam(r, "org"))
+		}))
+		r.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serve(w, r, cmp.Or(r.Header.Get(org.Header), defaultOrg))
+		}))
+	})
+
+	return router
+}
+
+// NewGRPCServer returns the gRPC API server. Like the REST API, a call is
+// served by the org named in its x-okta-org metadata, else by the default org.
+func NewGRPCServer(log *zap.SugaredLogger, cfg *config.Config, orgs []*Org, checker *health.Checker) *rpc_handlers.Server {
+	byName := make(map[string]*rpc_handlers.Services, len(orgs))
+	for _, o := range orgs {
+		byName[o.Client.Name()] = o.Services
+	}
+	return rpc_handlers.New(log, byName, cfg.Okta.Name, cfg.Server.GRPCReflection, checker)
+}
+
+// Setup registers the API routes of one org on cfg.Router, relative to the
+// API version prefix.
+func Setup(cfg *Config) {
+	expandConcurrency := cfg.Config.Server.ExpandConcurrency
+	userHandlers := user_handlers.New(cfg.Log, cfg.UsersService, cfg.RolesService, expandConcurrency)
+	groupHandlers := group_handlers.New(cfg.Log, cfg.GroupsService, cfg.GrantsService, cfg.RolesService, expandConcurrency)
+	roleHandlers := role_handlers.New(cfg.Log, cfg.RolesService, cfg.GrantsService)
+	groupRuleHandlers := group_rule_handlers.New(cfg.Log, cfg.GroupRulesService)
+	grantHandlers := grant_handlers.New(cfg.Log, cfg.GrantsService)
+	accessRequestHandlers := access_request_handlers.New(cfg.Log, cfg.AccessRequestsService)
+	accessReviewHandlers := access_review_handlers.New(cfg.Log, cfg.AccessReviewsService)
+	breakGlassHandlers := break_glass_handlers.New(cfg.Log, cfg.BreakGlassService)
+	auditHandlers := audit_handlers.New(cfg.Log, cfg.AuditService)
+	sodHandlers := sod_handlers.New(cfg.Log, cfg.SoDService)
+	desiredStateHandlers := desired_state_handlers.New(cfg.Log, cfg.DesiredStateService)
+	driftHandlers := drift_handlers.New(cfg.Log, cfg.DriftService)
+	snapshotHandlers := snapshot_handlers.New(cfg.Log, cfg.SnapshotsService)
+	factorHandlers := factor_handlers.New(cfg.Log, cfg.FactorsService)
+	sessionHandlers := session_handlers.New(cfg.Log, cfg.SessionsService)
+	graphqlHandlers := graphql_handlers.New(
+		cfg.Log, cfg.Config.GraphQL, cfg.UsersService, cfg.GroupsService, cfg.RolesService,
+	)
+
+	r := cfg.Router
+
+	// User management endpoints.
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", userHandlers.GetUsers)
+		r.Post("/", userHandlers.CreateUser)
+
+		r.Route("/{userID}", func(r chi.Router) {
+			r.Get("/", userHandlers.GetUser)
+			r.Put("/", userHandlers.UpdateUser)
+			r.Patch("/", userHandlers.PatchUser)
+			r.Delete("/", userHandlers.DeleteUser)
+
+			// User lifecycle actions.
+			r.Post("/activate", userHandlers.ActivateUser)
+			r.Post("/deactivate", userHandlers.DeactivateUser)
+			r.Post("/suspend", userHandlers.SuspendUser)
+			r.Post("/unsuspend", userHandlers.UnSuspendUser)
+			r.Post("/contain", sessionHandlers.ContainUser)
+
+			// User sessions and the OAuth grants apps hold for the user.
+			r.Delete("/sessions", sessionHandlers.ClearSessions)
+			r.Get("/app-grants", sessionHandlers.GetAppGrants)
+
+			// User password management.
+			r.Route("/password", func(r chi.Router) {
+				r.Put("/", userHandlers.SetPassword)
+				r.Post("/change", userHandlers.ChangePassword)
+				r.Post("/expire", userHandlers.ExpirePassword)
+				r.Post("/reset", userHandlers.ResetPassword)
 			})
-		})
 
-		// Group management endpoints.
-		r.Route("/groups", func(r chi.Router) {
-			r.Get("/", groupHandlers.GetGroups)
-			r.Post("/", groupHandlers.CreateGroup)
-
-			r.Route("/{groupID}", func(r chi.Router) {
-				r.Get("/", groupHandlers.GetGroup)
-				r.Put("/", groupHandlers.UpdateGroup)
-				r.Delete("/", groupHandlers.DeleteGroup)
-
-				// Group members sub-resource.
-				r.Route("/members", func(r chi.Router) {
-					r.Get("/", groupHandlers.GetGroupMembers)
-					r.Put("/{userID}", groupHandlers.AddUserToGroup)
-					r.Delete("/{userID}", groupHandlers.RemoveUserFromGroup)
-				})
+			// User MFA factors sub-resource.
+			r.Route("/factors", func(r chi.Router) {
+				r.Get("/", factorHandlers.GetFactors)
+				r.Post("/", factorHandlers.EnrollFactor)
+				r.Delete("/", factorHandlers.ResetFactors)
 
-				// Group roles sub-resource.
-				r.Route("/roles", func(r chi.Router) {
-					r.Get("/", roleHandlers.GetGroupRoles)
-					r.Put("/{roleID}", roleHandlers.AssignRoleToGroup)
-					r.Delete("/{roleID}", roleHandlers.UnassignRoleFromGroup)
+				r.Route("/{factorID}", func(r chi.Router) {
+					r.Get("/", factorHandlers.GetFactor)
+					r.Delete("/", factorHandlers.ResetFactor)
+					r.Post("/activate", factorHandlers.ActivateFactor)
+					r.Post("/verify", factorHandlers.VerifyFactor)
 				})
 			})
+
+			// User roles sub-resource.
+			r.Route("/roles", func(r chi.Router) {
+				r.Get("/", roleHandlers.GetUserRoles)
+				r.Put("/{roleID}", roleHandlers.AssignRoleToUser)
+				r.Delete("/{roleID}", roleHandlers.UnassignRoleFromUser)
+			})
 		})
+	})
 
-		// Role management endpoints.
-		r.Route("/roles", func(r chi.Router) {
-			r.Get("/", roleHandlers.GetRoles)
-			r.Post("/", roleHandlers.CreateRole)
+	// Group management endpoints.
+	r.Route("/groups", func(r chi.Router) {
+		r.Get("/", groupHandlers.GetGroups)
+		r.Post("/", groupHandlers.CreateGroup)
 
-			r.Route("/{roleID}", func(r chi.Router) {
-				r.Get("/", roleHandlers.GetRole)
-				r.Put("/", roleHandlers.UpdateRole)
-				r.Delete("/", roleHandlers.DeleteRole)
+		r.Route("/{groupID}", func(r chi.Router) {
+			r.Get("/", groupHandlers.GetGroup)
+			r.Put("/", groupHandlers.UpdateGroup)
+			r.Patch("/", groupHandlers.PatchGroup)
+			r.Delete("/", groupHandlers.DeleteGroup)
+
+			// Group members sub-resource.
+			r.Route("/members", func(r chi.Router) {
+				r.Get("/", groupHandlers.GetGroupMembers)
+				r.Put("/{userID}", groupHandlers.AddUserToGroup)
+				r.Delete("/{userID}", groupHandlers.RemoveUserFromGroup)
+			})
+
+			// Group roles sub-resource.
+			r.Route("/roles", func(r chi.Router) {
+				r.Get("/", roleHandlers.GetGroupRoles)
+				r.Put("/{roleID}", roleHandlers.AssignRoleToGroup)
+				r.Delete("/{roleID}", roleHandlers.UnassignRoleFromGroup)
 			})
 		})
 	})
+
+	// Group rule endpoints.
+	r.Route("/group-rules", func(r chi.Router) {
+		r.Get("/", groupRuleHandlers.GetGroupRules)
+		r.Post("/", groupRuleHandlers.CreateGroupRule)
+		r.Post("/validate", groupRuleHandlers.ValidateExpression)
+		r.Post("/preview", groupRuleHandlers.PreviewExpression)
+
+		r.Route("/{ruleID}", func(r chi.Router) {
+			r.Get("/", groupRuleHandlers.GetGroupRule)
+			r.Put("/", groupRuleHandlers.UpdateGroupRule)
+			r.Delete("/", groupRuleHandlers.DeleteGroupRule)
+			r.Post("/activate", groupRuleHandlers.ActivateGroupRule)
+			r.Post("/deactivate", groupRuleHandlers.DeactivateGroupRule)
+			r.Post("/preview", groupRuleHandlers.PreviewGroupRule)
+		})
+	})
+
+	// Role management endpoints.
+	r.Route("/roles", func(r chi.Router) {
+		r.Get("/", roleHandlers.GetRoles)
+		r.Post("/", roleHandlers.CreateRole)
+
+		r.Route("/{roleID}", func(r chi.Router) {
+			r.Get("/", roleHandlers.GetRole)
+			r.Put("/", roleHandlers.UpdateRole)
+			r.Delete("/", roleHandlers.DeleteRole)
+		})
+	})
+
+	// Time-bound role assignment and group membership endpoints.
+	r.Route("/grants", func(r chi.Router) {
+		r.Get("/", grantHandlers.GetGrants)
+
+		r.Route("/{grantID}", func(r chi.Router) {
+			r.Get("/", grantHandlers.GetGrant)
+			r.Post("/extend", grantHandlers.ExtendGrant)
+		})
+	})
+
+	// Access request workflow endpoints.
+	r.Route("/access-requests", func(r chi.Router) {
+		r.Get("/", accessRequestHandlers.GetAccessRequests)
+		r.Post("/", accessRequestHandlers.CreateAccessRequest)
+
+		r.Route("/{requestID}", func(r chi.Router) {
+			r.Get("/", accessRequestHandlers.GetAccessRequest)
+			r.Post("/approve", accessRequestHandlers.ApproveAccessRequest)
+			r.Post("/deny", accessRequestHandlers.DenyAccessRequest)
+		})
+	})
+
+	// Access review and certification campaign endpoints.
+	r.Route("/access-reviews", func(r chi.Router) {
+		r.Get("/", accessReviewHandlers.GetCampaigns)
+		r.Post("/", accessReviewHandlers.CreateCampaign)
+
+		r.Route("/{campaignID}", func(r chi.Router) {
+			r.Get("/", accessReviewHandlers.GetCampaign)
+			r.Get("/items", accessReviewHandlers.GetItems)
+			r.Post("/items/{itemID}/decision", accessReviewHandlers.DecideItem)
+			r.Post("/close", accessReviewHandlers.CloseCampaign)
+			r.Post("/sign-off", accessReviewHandlers.SignOff)
+			r.Get("/report", accessReviewHandlers.GetReport)
+		})
+	})
+
+	// Break-glass emergency elevation endpoints.
+	r.Route("/break-glass", func(r chi.Router) {
+		r.Get("/", breakGlassHandlers.GetElevations)
+		r.Post("/", breakGlassHandlers.Elevate)
+
+		r.Route("/{elevationID}", func(r chi.Router) {
+			r.Get("/", breakGlassHandlers.GetElevation)
+			r.Post("/revoke", breakGlassHandlers.RevokeElevation)
+		})
+	})
+
+	// Separation-of-duties endpoints.
+	r.Route("/sod", func(r chi.Router) {
+		r.Get("/violations", sodHandlers.GetViolations)
+
+		r.Route("/rules", func(r chi.Router) {
+			r.Get("/", sodHandlers.GetRules)
+			r.Post("/", sodHandlers.CreateRule)
+			r.Get("/{ruleID}", sodHandlers.GetRule)
+			r.Delete("/{ruleID}", sodHandlers.DeleteRule)
+		})
+
+		r.Route("/exceptions", func(r chi.Router) {
+			r.Get("/", sodHandlers.GetExceptions)
+			r.Post("/", sodHandlers.CreateException)
+			r.Delete("/{exceptionID}", sodHandlers.DeleteException)
+		})
+	})
+
+	// Declarative configuration endpoints.
+	r.Route("/desired-state", func(r chi.Router) {
+		r.Post("/plan", desiredStateHandlers.Plan)
+		r.Post("/apply", desiredStateHandlers.Apply)
+	})
+
+	// Drift detection endpoints.
+	r.Route("/drift", func(r chi.Router) {
+		r.Get("/", driftHandlers.GetDriftItems)
+		r.Post("/check", driftHandlers.CheckDrift)
+		r.Get("/baseline", driftHandlers.GetBaseline)
+		r.Post("/baseline", driftHandlers.ApproveBaseline)
+		r.Get("/{itemID}", driftHandlers.GetDriftItem)
+	})
+
+	// Snapshot and restore endpoints.
+	r.Route("/snapshots", func(r chi.Router) {
+		r.Get("/", snapshotHandlers.GetSnapshots)
+		r.Post("/", snapshotHandlers.CreateSnapshot)
+		r.Post("/import", snapshotHandlers.ImportSnapshot)
+		r.Get("/diff", snapshotHandlers.DiffSnapshots)
+
+		r.Route("/{snapshotID}", func(r chi.Router) {
+			r.Get("/", snapshotHandlers.GetSnapshot)
+			r.Delete("/", snapshotHandlers.DeleteSnapshot)
+			r.Get("/archive", snapshotHandlers.DownloadSnapshot)
+			r.Post("/restore", snapshotHandlers.RestoreSnapshot)
+		})
+	})
+
+	// Audit trail endpoints.
+	r.Get("/audit", auditHandlers.GetAuditEntries)
+
+	// GraphQL endpoint for users, groups, roles and their relationships.
+	r.Post("/graphql", graphqlHandlers.Query)
 }
@@ -0,0 +1,37 @@
+package health_handlers
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/pkg/health"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log     *zap.SugaredLogger
+	checker *health.Checker
+}
+
+func New(log *zap.SugaredLogger, checker *health.Checker) *Handler {
+	return &Handler{log: log, checker: checker}
+}
+
+// Liveness reports that the process is serving requests. It checks no
+// dependencies, so an Okta outage never gets the process restarted.
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.RespondSuccess(w, http.StatusOK, "Alive", map[string]string{"status": health.StatusUp})
+}
+
+// Readiness reports the last probe of every component. It answers 503 while
+// a critical component is down or not yet probed.
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Report()
+	if !report.Ready {
+		response.RespondError(w, http.StatusServiceUnavailable, "NOT_READY", "Service is not ready", report)
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Ready", report)
+}
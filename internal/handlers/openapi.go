@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/expand"
+	"github.com/iamBelugaa/iam/pkg/openapi"
+	"github.com/iamBelugaa/iam/pkg/org"
+	"github.com/iamBelugaa/iam/pkg/patch"
+)
+
+const (
+	OpenAPIURL = "/openapi.json"
+)
+
+var (
+	orgHeaderParam = openapi.Param{
+		Name: org.Header, In: "header",
+		Description: "Okta org to serve the request from, instead of the default org",
+	}
+	dryRunParam = openapi.Param{
+		Name: dryrun.QueryParam, In: "query", Type: "boolean",
+		Description: "Plan the changes without making them",
+	}
+)
+
+func optional(schema any) *openapi.Body {
+	return &openapi.Body{Schema: schema, Optional: true}
+}
+
+func body(schema any, contentTypes ...string) *openapi.Body {
+	return &openapi.Body{Schema: schema, ContentTypes: contentTypes}
+}
+
+func query(name, description string, enum ...string) openapi.Param {
+	return openapi.Param{Name: name, In: "query", Description: description, Enum: enum}
+}
+
+// routeDocs documents the routes registered by Setup, keyed by method and
+// path relative to the API version prefix.
+var routeDocs = []openapi.Route{
+	// Users.
+	{Method: http.MethodGet, Path: "/users", Tag: "Users", Summary: "List users", Response: []*models.User{}},
+	{Method: http.MethodPost, Path: "/users", Tag: "Users", Summary: "Create a user",
+		Request: body(models.CreateUserRequest{}), Status: http.StatusCreated, Response: models.User{}},
+	{Method: http.MethodGet, Path: "/users/{userID}", Tag: "Users", Summary: "Get a user",
+		Params:   []openapi.Param{query(expand.QueryParam, "Related resources to include, separated by commas: groups, roles")},
+		Response: models.User{}},
+	{Method: http.MethodPut, Path: "/users/{userID}", Tag: "Users", Summary: "Update a user",
+		Request: body(models.UpdateUserRequest{}), Response: models.User{}},
+	{Method: http.MethodPatch, Path: "/users/{userID}", Tag: "Users", Summary: "Partially update a user",
+		Request: patchBody(models.UserPatchDocument{}), Response: models.User{}},
+	{Method: http.MethodDelete, Path: "/users/{userID}", Tag: "Users", Summary: "Delete a user"},
+	{Method: http.MethodPost, Path: "/users/{userID}/activate", Tag: "Users", Summary: "Activate a user"},
+	{Method: http.MethodPost, Path: "/users/{userID}/deactivate", Tag: "Users", Summary: "Deactivate a user"},
+	{Method: http.MethodPost, Path: "/users/{userID}/suspend", Tag: "Users", Summary: "Suspend a user"},
+	{Method: http.MethodPost, Path: "/users/{userID}/unsuspend", Tag: "Users", Summary: "Unsuspend a user"},
+	{Method: http.MethodPost, Path: "/users/{userID}/contain", Tag: "Sessions",
+		Summary: "Suspend a user, clear their sessions and revoke their tokens",
+		Request: body(models.ContainUserRequest{}), Response: models.Containment{}},
+	{Method: http.MethodDelete, Path: "/users/{userID}/sessions", Tag: "Sessions", Summary: "Clear all sessions of a user",
+		Params: []openapi.Param{
+			{Name: "revokeTokens", In: "query", Type: "boolean", Description: "Also revoke the user's OAuth tokens"},
+			{Name: "revokeGrants", In: "query", Type: "boolean", Description: "Also revoke the user's app grants"},
+		},
+		Response: models.ClearedSessions{}},
+	{Method: http.MethodGet, Path: "/users/{userID}/app-grants", Tag: "Sessions", Summary: "List a user's app grants",
+		Response: []*models.AppGrant{}},
+	{Method: http.MethodPut, Path: "/users/{userID}/password", Tag: "Users", Summary: "Set a user's password",
+		Request: body(models.SetPasswordRequest{})},
+	{Method: http.MethodPost, Path: "/users/{userID}/password/change", Tag: "Users", Summary: "Change a user's password",
+		Request: body(models.ChangePasswordRequest{})},
+	{Method: http.MethodPost, Path: "/users/{userID}/password/expire", Tag: "Users", Summary: "Expire a user's password"},
+	{Method: http.MethodPost, Path: "/users/{userID}/password/reset", Tag: "Users",
+		Summary: "Reset a user's password with a link or a temporary password",
+		Request: optional(models.ResetPasswordRequest{}), Response: models.PasswordReset{}},
+	{Method: http.MethodGet, Path: "/users/{userID}/factors", Tag: "Factors", Summary: "List a user's MFA factors",
+		Response: []*models.Factor{}},
+	{Method: http.MethodPost, Path: "/users/{userID}/factors", Tag: "Factors", Summary: "Enroll an MFA factor",
+		Request: body(models.EnrollFactorRequest{}), Status: http.StatusCreated, Response: models.Factor{}},
+	{Method: http.MethodDelete, Path: "/users/{userID}/factors", Tag: "Factors", Summary: "Reset all MFA factors of a user"},
+	{Method: http.MethodGet, Path: "/users/{userID}/factors/{factorID}", Tag: "Factors", Summary: "Get an MFA factor",
+		Response: models.Factor{}},
+	{Method: http.MethodDelete, Path: "/users/{userID}/factors/{factorID}", Tag: "Factors", Summary: "Reset an MFA factor"},
+	{Method: http.MethodPost, Path: "/users/{userID}/factors/{factorID}/activate", Tag: "Factors",
+		Summary: "Activate an enrolled MFA factor", Request: optional(models.ActivateFactorRequest{}), Response: models.Factor{}},
+	{Method: http.MethodPost, Path: "/users/{userID}/factors/{factorID}/verify", Tag: "Factors",
+		Summary: "Challenge an MFA factor", Request: optional(models.VerifyFactorRequest{}), Response: models.FactorVerification{}},
+	{Method: http.MethodGet, Path: "/users/{userID}/roles", Tag: "Users", Summary: "List the roles of a user", Response: []*models.Role{}},
+	{Method: http.MethodPut, Path: "/users/{userID}/roles/{roleID}", Tag: "Users", Summary: "Assign a role to a user",
+		Request: optional(models.AssignmentOptions{}), Response: models.Grant{}},
+	{Method: http.MethodDelete, Path: "/users/{userID}/roles/{roleID}", Tag: "Users", Summary: "Unassign a role from a user"},
+
+	// Groups.
+	{Method: http.MethodGet, Path: "/groups", Tag: "Groups", Summary: "List groups", Response: []*models.Group{}},
+	{Method: http.MethodPost, Path: "/groups", Tag: "Groups", Summary: "Create a group",
+		Request: body(models.CreateGroupRequest{}), Status: http.StatusCreated, Response: models.Group{}},
+	{Method: http.MethodGet, Path: "/groups/{groupID}", Tag: "Groups", Summary: "Get a group",
+		Params:   []openapi.Param{query(expand.QueryParam, "Related resources to include, separated by commas: members, roles")},
+		Response: models.Group{}},
+	{Method: http.MethodPut, Path: "/groups/{groupID}", Tag: "Groups", Summary: "Update a group",
+		Request: body(models.UpdateGroupRequest{}), Response: models.Group{}},
+	{Method: http.MethodPatch, Path: "/groups/{groupID}", Tag: "Groups", Summary: "Partially update a group",
+		Request: patchBody(models.GroupPatchDocument{}), Response: models.Group{}},
+	{Method: http.MethodDelete, Path: "/groups/{groupID}", Tag: "Groups", Summary: "Delete a group"},
+	{Method: http.MethodGet, Path: "/groups/{groupID}/members", Tag: "Groups", Summary: "List the members of a group", Response: []*models.User{}},
+	{Method: http.MethodPut, Path: "/groups/{groupID}/members/{userID}", Tag: "Groups", Summary: "Add a user to a group",
+		Request: optional(models.AssignmentOptions{}), Response: models.Grant{}},
+	{Method: http.MethodDelete, Path: "/groups/{groupID}/members/{userID}", Tag: "Groups", Summary: "Remove a user from a group"},
+	{Method: http.MethodGet, Path: "/groups/{groupID}/roles", Tag: "Groups", Summary: "List the roles of a group", Response: []*models.Role{}},
+	{Method: http.MethodPut, Path: "/groups/{groupID}/roles/{roleID}", Tag: "Groups", Summary: "Assign a role to a group"},
+	{Method: http.MethodDelete, Path: "/groups/{groupID}/roles/{roleID}", Tag: "Groups", Summary: "Unassign a role from a group"},
+
+	// Group rules.
+	{Method: http.MethodGet, Path: "/group-rules", Tag: "Group Rules", Summary: "List group rules",
+		Params: []openapi.Param{query("search", "Okta search over rule names")}, Response: []*models.GroupRule{}},
+	{Method: http.MethodPost, Path: "/group-rules", Tag: "Group Rules", Summary: "Create a group rule",
+		Request: body(models.CreateGroupRuleRequest{}), Status: http.StatusCreated, Response: models.GroupRule{}},
+	{Method: http.MethodPost, Path: "/group-rules/validate", Tag: "Group Rules", Summary: "Validate a rule expression",
+		Request: body(models.ValidateExpressionRequest{}), Response: models.ExpressionValidation{}},
+	{Method: http.MethodPost, Path: "/group-rules/preview", Tag: "Group Rules", Summary: "Evaluate an expression against a user",
+		Request: body(models.PreviewGroupRuleRequest{}), Response: models.GroupRulePreview{}},
+	{Method: http.MethodGet, Path: "/group-rules/{ruleID}", Tag: "Group Rules", Summary: "Get a group rule", Response: models.GroupRule{}},
+	{Method: http.MethodPut, Path: "/group-rules/{ruleID}", Tag: "Group Rules", Summary: "Update an inactive group rule",
+		Request: body(models.UpdateGroupRuleRequest{}), Response: models.GroupRule{}},
+	{Method: http.MethodDelete, Path: "/group-rules/{ruleID}", Tag: "Group Rules", Summary: "Delete a group rule",
+		Params: []openapi.Param{{Name: "removeUsers", In: "query", Type: "boolean", Description: "Also remove the users the rule added"}}},
+	{Method: http.MethodPost, Path: "/group-rules/{ruleID}/activate", Tag: "Group Rules", Summary: "Activate a group rule"},
+	{Method: http.MethodPost, Path: "/group-rules/{ruleID}/deactivate", Tag: "Group Rules", Summary: "Deactivate a group rule"},
+	{Method: http.MethodPost, Path: "/group-rules/{ruleID}/preview", Tag: "Group Rules", Summary: "Evaluate a rule against a user",
+		Request: body(models.PreviewGroupRuleRequest{}), Response: models.GroupRulePreview{}},
+
+	// Roles.
+	{Method: http.MethodGet, Path: "/roles", Tag: "Roles", Summary: "List roles", Response: []*models.Role{}},
+	{Method: http.MethodPost, Path: "/roles", Tag: "Roles", Summary: "Create a custom role",
+		Request: body(models.CreateRoleRequest{}), Status: http.StatusCreated, Response: models.Role{}},
+	{Method: http.MethodGet, Path: "/roles/{roleID}", Tag: "Roles", Summary: "Get a role", Response: models.Role{}},
+	{Method: http.MethodPut, Path: "/roles/{roleID}", Tag: "Roles", Summary: "Update a custom role",
+		Request: body(models.UpdateRoleRequest{}), Response: models.Role{}},
+	{Method: http.MethodDelete, Path: "/roles/{roleID}", Tag: "Roles", Summary: "Delete a custom role"},
+
+	// Grants.
+	{Method: http.MethodGet, Path: "/grants", Tag: "Grants", Summary: "List time-bound grants",
+		Params: []openapi.Param{
+			query("status", "Grant status, ACTIVE by default", models.GrantStatusActive, models.GrantStatusExpired),
+			query("userId", "Filter by user"),
+			query("resourceType", "Filter by resource type", models.ResourceTypeRole, models.ResourceTypeGroup),
+		},
+		Response: []*models.Grant{}},
+	{Method: http.MethodGet, Path: "/grants/{grantID}", Tag: "Grants", Summary: "Get a grant", Response: models.Grant{}},
+	{Method: http.MethodPost, Path: "/grants/{grantID}/extend", Tag: "Grants", Summary: "Extend an active grant",
+		Request: body(models.ExtendGrantRequest{}), Response: models.Grant{}},
+
+	// Access requests.
+	{Method: http.MethodGet, Path: "/access-requests", Tag: "Access Requests", Summary: "List access requests",
+		Params: []openapi.Param{
+			query("status", "Filter by status"),
+			query("userId", "Filter by requesting user"),
+			query("approver", "Filter by eligible approver"),
+		},
+		Response: []*models.AccessRequest{}},
+	{Method: http.MethodPost, Path: "/access-requests", Tag: "Access Requests", Summary: "Request access to a role or group",
+		Request: body(models.CreateAccessRequestRequest{}), Status: http.StatusCreated, Response: models.AccessRequest{}},
+	{Method: http.MethodGet, Path: "/access-requests/{requestID}", Tag: "Access Requests", Summary: "Get an access request", Response: models.AccessRequest{}},
+	{Method: http.MethodPost, Path: "/access-requests/{requestID}/approve", Tag: "Access Requests", Summary: "Approve and fulfil an access request",
+		Request: optional(models.AccessRequestDecision{}), Response: models.AccessRequest{}},
+	{Method: http.MethodPost, Path: "/access-requests/{requestID}/deny", Tag: "Access Requests", Summary: "Deny an access request",
+		Request: optional(models.AccessRequestDecision{}), Response: models.AccessRequest{}},
+
+	// Access reviews.
+	{Method: http.MethodGet, Path: "/access-reviews", Tag: "Access Reviews", Summary: "List campaigns", Response: []*models.Campaign{}},
+	{Method: http.MethodPost, Path: "/access-reviews", Tag: "Access Reviews", Summary: "Create a campaign",
+		Request: body(models.CreateCampaignRequest{}), Status: http.StatusAccepted, Response: models.Campaign{}},
+	{Method: http.MethodGet, Path: "/access-reviews/{campaignID}", Tag: "Access Reviews", Summary: "Get a campaign", Response: models.Campaign{}},
+	{Method: http.MethodGet, Path: "/access-reviews/{campaignID}/items", Tag: "Access Reviews", Summary: "List the items of a campaign",
+		Params: []openapi.Param{
+			query("reviewer", "Filter by reviewer"),
+			{Name: "pending", In: "query", Type: "boolean", Description: "Only items without a decision"},
+		},
+		Response: []models.ReviewItem{}},
+	{Method: http.MethodPost, Path: "/access-reviews/{campaignID}/items/{itemID}/decision", Tag: "Access Reviews", Summary: "Record a decision",
+		Request: body(models.ReviewDecisionRequest{}), Response: models.ReviewItem{}},
+	{Method: http.MethodPost, Path: "/access-reviews/{campaignID}/close", Tag: "Access Reviews", Summary: "Close a campaign and apply revocations",
+		Response: models.Campaign{}},
+	{Method: http.MethodPost, Path: "/access-reviews/{campaignID}/sign-off", Tag: "Access Reviews", Summary: "Sign off a closed campaign",
+		Request: optional(models.SignOffRequest{}), Response: models.CampaignReport{}},
+	{Method: http.MethodGet, Path: "/access-reviews/{campaignID}/report", Tag: "Access Reviews", Summary: "Get the campaign report",
+		Response: models.CampaignReport{}},
+
+	// Break-glass.
+	{Method: http.MethodGet, Path: "/break-glass", Tag: "Break-Glass", Summary: "List elevations",
+		Params: []openapi.Param{query("status", "Filter by status",
+			models.ElevationStatusPending, models.ElevationStatusActive, models.ElevationStatusRevoked, models.ElevationStatusFailed)},
+		Response: []*models.Elevation{}},
+	{Method: http.MethodPost, Path: "/break-glass", Tag: "Break-Glass", Summary: "Elevate to the emergency role",
+		Request: body(models.CreateElevationRequest{}), Status: http.StatusCreated, Response: models.Elevation{}},
+	{Method: http.MethodGet, Path: "/break-glass/{elevationID}", Tag: "Break-Glass", Summary: "Get an elevation", Response: models.Elevation{}},
+	{Method: http.MethodPost, Path: "/break-glass/{elevationID}/revoke", Tag: "Break-Glass", Summary: "Revoke an elevation early",
+		Response: models.Elevation{}},
+
+	// Separation of duties.
+	{Method: http.MethodGet, Path: "/sod/violations", Tag: "Separation of Duties", Summary: "List current violations", Response: []*models.SoDViolation{}},
+	{Method: http.MethodGet, Path: "/sod/rules", Tag: "Separation of Duties", Summary: "List rules", Response: []*models.SoDRule{}},
+	{Method: http.MethodPost, Path: "/sod/rules", Tag: "Separation of Duties", Summary: "Create a rule",
+		Request: body(models.CreateSoDRuleRequest{}), Status: http.StatusCreated, Response: models.SoDRule{}},
+	{Method: http.MethodGet, Path: "/sod/rules/{ruleID}", Tag: "Separation of Duties", Summary: "Get a rule", Response: models.SoDRule{}},
+	{Method: http.MethodDelete, Path: "/sod/rules/{ruleID}", Tag: "Separation of Duties", Summary: "Delete a rule"},
+	{Method: http.MethodGet, Path: "/sod/exceptions", Tag: "Separation of Duties", Summary: "List exceptions",
+		Params:   []openapi.Param{query("ruleId", "Filter by rule"), query("userId", "Filter by user")},
+		Response: []*models.SoDException{}},
+	{Method: http.MethodPost, Path: "/sod/exceptions", Tag: "Separation of Duties", Summary: "Grant an exception",
+		Request: body(models.CreateSoDExceptionRequest{}), Status: http.StatusCreated, Response: models.SoDException{}},
+	{Method: http.MethodDelete, Path: "/sod/exceptions/{exceptionID}", Tag: "Separation of Duties", Summary: "Delete an exception"},
+
+	// Desired state.
+	{Method: http.MethodPost, Path: "/desired-state/plan", Tag: "Desired State", Summary: "Plan the changes to reach a desired state",
+		Params:  []openapi.Param{{Name: "prune", In: "query", Type: "boolean", Description: "Also delete what the state does not list"}},
+		Request: desiredStateBody, Response: models.Plan{}},
+	{Method: http.MethodPost, Path: "/desired-state/apply", Tag: "Desired State", Summary: "Apply a desired state",
+		Params:       []openapi.Param{{Name: "prune", In: "query", Type: "boolean", Description: "Also delete what the state does not list"}},
+		Request:      desiredStateBody,
+		Response:     models.ApplyResult{},
+		Alternatives: []openapi.Content{{ContentType: openapi.ContentTypeNDJSON, Schema: models.ChangeResult{}}}},
+
+	// Drift.
+	{Method: http.MethodGet, Path: "/drift", Tag: "Drift", Summary: "List drift items",
+		Params: []openapi.Param{
+			query("status", "Drift status, OPEN by default", models.DriftStatusOpen, models.DriftStatusResolved),
+			query("object", "Filter by drifted object"),
+		},
+		Response: []*models.DriftItem{}},
+	{Method: http.MethodPost, Path: "/drift/check", Tag: "Drift", Summary: "Compare Okta against the baseline", Response: models.DriftCheck{}},
+	{Method: http.MethodGet, Path: "/drift/baseline", Tag: "Drift", Summary: "Get the approved baseline", Response: models.Baseline{}},
+	{Method: http.MethodPost, Path: "/drift/baseline", Tag: "Drift", Summary: "Approve the current state as the baseline",
+		Status: http.StatusCreated, Response: models.Baseline{}},
+	{Method: http.MethodGet, Path: "/drift/{itemID}", Tag: "Drift", Summary: "Get a drift item", Response: models.DriftItem{}},
+
+	// Snapshots.
+	{Method: http.MethodGet, Path: "/snapshots", Tag: "Snapshots", Summary: "List snapshots", Response: []*models.SnapshotSummary{}},
+	{Method: http.MethodPost, Path: "/snapshots", Tag: "Snapshots", Summary: "Take a snapshot",
+		Status: http.StatusCreated, Response: models.SnapshotSummary{}},
+	{Method: http.MethodPost, Path: "/snapshots/import", Tag: "Snapshots", Summary: "Import a snapshot archive",
+		Request: body(models.Snapshot{}), Status: http.StatusCreated, Response: models.SnapshotSummary{}},
+	{Method: http.MethodGet, Path: "/snapshots/diff", Tag: "Snapshots", Summary: "Compare two snapshots or a snapshot and live",
+		Params: []openapi.Param{
+			{Name: "from", In: "query", Required: true, Description: "Snapshot ID or live"},
+			{Name: "to", In: "query", Required: true, Description: "Snapshot ID or live"},
+			query("format", "Response format, json by default", "text", "json"),
+		},
+		Response:     models.SnapshotDiff{},
+		Alternatives: []openapi.Content{{ContentType: openapi.ContentTypeText}}},
+	{Method: http.MethodGet, Path: "/snapshots/{snapshotID}", Tag: "Snapshots", Summary: "Get a snapshot", Response: models.Snapshot{}},
+	{Method: http.MethodDelete, Path: "/snapshots/{snapshotID}", Tag: "Snapshots", Summary: "Delete a snapshot"},
+	{Method: http.MethodGet, Path: "/snapshots/{snapshotID}/archive", Tag: "Snapshots", Summary: "Download the snapshot archive",
+		Response: models.Snapshot{}, Raw: true},
+	{Method: http.MethodPost, Path: "/snapshots/{snapshotID}/restore", Tag: "Snapshots", Summary: "Restore a snapshot",
+		Request: optional(models.RestoreSnapshotRequest{}), Response: models.RestoreResult{}},
+
+	// Audit.
+	{Method: http.MethodGet, Path: "/audit", Tag: "Audit", Summary: "List audit entries",
+		Params: []openapi.Param{
+			{Name: "since", In: "query", Format: "date-time", Description: "Only entries at or after this time"},
+			query("actor", "Filter by actor"),
+			query("action", "Filter by action"),
+			query("targetId", "Filter by target"),
+		},
+		Response: []*models.AuditEntry{}},
+
+	// GraphQL.
+	{Method: http.MethodPost, Path: "/graphql", Tag: "GraphQL", Summary: "Query users, groups, roles and permissions",
+		Request: body(models.GraphQLRequest{}), Response: models.GraphQLResponse{}, Raw: true},
+}
+
+// patchBody accepts a merge patch of doc or a JSON Patch.
+func patchBody(doc any) *openapi.Body {
+	return &openapi.Body{
+		Schema:       doc,
+		ContentTypes: []string{patch.ContentTypeMergePatch, openapi.ContentTypeJSON},
+		Alternatives: []openapi.Content{{ContentType: patch.ContentTypeJSONPatch, Schema: []patch.Operation{}}},
+	}
+}
+
+var desiredStateBody = body(models.DesiredState{},
+	openapi.ContentTypeJSON, "application/yaml", "application/x-yaml", "text/yaml",
+)
+
+// NewSpec documents the routes registered on an org router by Setup, with
+// the org endpoints of NewRouter. Routes without an entry in routeDocs are
+// still described, without schemas, and logged.
+func NewSpec(log *zap.SugaredLogger, router chi.Routes) *openapi.Document {
+	generator := openapi.New(openapi.Info{
+		Title:   "Flexera IAM Platform API",
+		Version: "1.0.0",
+		Description: "Every " + APIVersion1URL + " operation is also served under " + APIVersion1URL +
+			"/orgs/{org} for the named Okta org.",
+	})
+
+	generator.Add(openapi.Route{
+		Method: http.MethodGet, Path: APIVersion1URL + "/orgs", Tag: "Orgs",
+		Summary: "List configured Okta orgs and their health", Response: []*models.Org{},
+	})
+
+	documented := make(map[string]bool, len(routeDocs))
+	for _, route := range routeDocs {
+		documented[route.Method+" "+route.Path] = true
+	}
+
+	registered := map[string]bool{}
+	var undocumented []openapi.Route
+	chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		registered[method+" "+route] = true
+		if !documented[method+" "+route] {
+			log.Infow("Route is missing from the API specification", "method", method, "route", route)
+			undocumented = append(undocumented, openapi.Route{Method: method, Path: route})
+		}
+		return nil
+	})
+
+	// Routes are added in the order of routeDocs, so the models are named
+	// before same-named types of other packages.
+	for _, route := range append(slices.Clone(routeDocs), undocumented...) {
+		if !registered[route.Method+" "+route.Path] {
+			continue
+		}
+		route.Path = APIVersion1URL + route.Path
+		route.Params = append([]openapi.Param{orgHeaderParam}, route.Params...)
+		if route.Method != http.MethodGet {
+			route.Params = append(route.Params, dryRunParam)
+		}
+		generator.Add(route)
+	}
+
+	return generator.Document()
+}
+
+// specPath maps a request under /api/v1/orgs/{org} to the operation path it
+// is documented under.
+func specPath(r *http.Request) string {
+	prefix := APIVersion1URL + "/orgs/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		return r.URL.Path
+	}
+
+	_, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if !ok {
+		return r.URL.Path
+	}
+	return APIVersion1URL + "/" + rest
+}
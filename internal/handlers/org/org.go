@@ -0,0 +1,37 @@
+package org_handlers
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log        *zap.SugaredLogger
+	clients    []*okta.Client
+	defaultOrg string
+}
+
+func New(log *zap.SugaredLogger, clients []*okta.Client, defaultOrg string) *Handler {
+	return &Handler{log: log, clients: clients, defaultOrg: defaultOrg}
+}
+
+func (h *Handler) GetOrgs(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get orgs request received")
+
+	orgs := make([]*models.Org, 0, len(h.clients))
+	for _, client := range h.clients {
+		orgs = append(orgs, &models.Org{
+			Name:    client.Name(),
+			Domain:  client.Domain(),
+			Default: client.Name() == h.defaultOrg,
+			Health:  client.Health(),
+		})
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", orgs)
+}
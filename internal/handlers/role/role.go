@@ -2,24 +2,31 @@ package role_handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/models"
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
 	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	"github.com/iamBelugaa/iam/pkg/actor"
 	"github.com/iamBelugaa/iam/pkg/response"
 )
 
 type Handler struct {
-	log      *zap.SugaredLogger
-	rolesSvc *role_service.Service
+	log       *zap.SugaredLogger
+	rolesSvc  *role_service.Service
+	grantsSvc *grant_service.Service
 }
 
-func New(log *zap.SugaredLogger, svc *role_service.Service) *Handler {
-	return &Handler{log: log, rolesSvc: svc}
+func New(log *zap.SugaredLogger, svc *role_service.Service, grantsSvc *grant_service.Service) *Handler {
+	return &Handler{log: log, rolesSvc: svc, grantsSvc: grantsSvc}
 }
 
 func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
@@ -71,6 +78,10 @@ func (h *Handler) GetRole(w http.ResponseWriter, r *http.Request) {
 	role, err := h.rolesSvc.GetRole(r.Context(), roleID)
 	if err != nil {
 		h.log.Infow("Failed to get role", zap.Error(err), "roleId", roleID)
+		if errors.Is(err, role_service.ErrNotFound) {
+			h.respondWithError(w, "Role not found", http.StatusNotFound)
+			return
+		}
 		h.respondWithError(w, "Failed to retrieve role", http.StatusInternalServerError)
 		return
 	}
@@ -136,14 +147,46 @@ func (h *Handler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 
 	h.log.Infow("Assign role to user request received", "roleId", roleID, "userId", userID)
 
-	if err := h.rolesSvc.AssignRoleToUser(r.Context(), userID, roleID); err != nil {
+	var opts models.AssignmentOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode assign role request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		h.respondWithError(w, "expiresAt must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	assignmentID, err := h.rolesSvc.AssignRoleToUser(r.Context(), userID, roleID)
+	if err != nil {
 		h.log.Infow("Failed to assign role to user", zap.Error(err), "roleId", roleID, "userId", userID)
-		h.respondWithError(w, "Failed to assign role to user", http.StatusInternalServerError)
+		h.respondWithAssignmentError(w, err, "Failed to assign role to user")
+		return
+	}
+
+	if opts.ExpiresAt == nil {
+		if err := h.grantsSvc.ForgetGrant(r.Context(), userID, models.ResourceTypeRole, roleID); err != nil {
+			h.log.Infow("Failed to clear time-bound grant", zap.Error(err), "roleId", roleID, "userId", userID)
+		}
+
+		h.log.Infow("Role assigned to user successfully", "roleId", roleID, "userId", userID)
+		response.RespondSuccess(w, http.StatusOK, "Role assigned to user successfully", nil)
+		return
+	}
+
+	grant, err := h.grantsSvc.RecordGrant(
+		r.Context(), actor.FromContext(r.Context()), userID, models.ResourceTypeRole, roleID, assignmentID, *opts.ExpiresAt,
+	)
+	if err != nil {
+		h.log.Infow("Failed to record time-bound grant", zap.Error(err), "roleId", roleID, "userId", userID)
+		h.respondWithError(w, "Role assigned but its expiry could not be recorded", http.StatusInternalServerError)
 		return
 	}
 
-	h.log.Infow("Role assigned to user successfully", "roleId", roleID, "userId", userID)
-	response.RespondSuccess(w, http.StatusOK, "Role assigned to user successfully", nil)
+	h.log.Infow("Role assigned to user successfully", "roleId", roleID, "userId", userID, "expiresAt", grant.ExpiresAt)
+	response.RespondSuccess(w, http.StatusOK, "Role assigned to user until "+grant.ExpiresAt.Format(time.RFC3339), grant)
 }
 
 func (h *Handler) UnassignRoleFromUser(w http.ResponseWriter, r *http.Request) {
@@ -159,10 +202,14 @@ func (h *Handler) UnassignRoleFromUser(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.rolesSvc.UnassignRoleFromUser(r.Context(), userID, roleID); err != nil {
 		h.log.Infow("Failed to unassign role from user", zap.Error(err), "roleId", roleID, "userId", userID)
-		h.respondWithError(w, "Failed to unassign role from user", http.StatusInternalServerError)
+		h.respondWithAssignmentError(w, err, "Failed to unassign role from user")
 		return
 	}
 
+	if err := h.grantsSvc.ForgetGrant(r.Context(), userID, models.ResourceTypeRole, roleID); err != nil {
+		h.log.Infow("Failed to clear time-bound grant", zap.Error(err), "roleId", roleID, "userId", userID)
+	}
+
 	h.log.Infow("Role unassigned from user successfully", "roleId", roleID, "userId", userID)
 	response.RespondSuccess(w, http.StatusOK, "Role unassigned from user successfully", nil)
 }
@@ -180,7 +227,7 @@ func (h *Handler) AssignRoleToGroup(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.rolesSvc.AssignRoleToGroup(r.Context(), groupID, roleID); err != nil {
 		h.log.Infow("Failed to assign role to group", zap.Error(err), "roleId", roleID, "groupId", groupID)
-		h.respondWithError(w, "Failed to assign role to group", http.StatusInternalServerError)
+		h.respondWithAssignmentError(w, err, "Failed to assign role to group")
 		return
 	}
 
@@ -201,7 +248,7 @@ func (h *Handler) UnassignRoleFromGroup(w http.ResponseWriter, r *http.Request)
 
 	if err := h.rolesSvc.UnassignRoleFromGroup(r.Context(), groupID, roleID); err != nil {
 		h.log.Infow("Failed to unassign role from group", zap.Error(err), "roleId", roleID, "groupId", groupID)
-		h.respondWithError(w, "Failed to unassign role from group", http.StatusInternalServerError)
+		h.respondWithAssignmentError(w, err, "Failed to unassign role from group")
 		return
 	}
 
@@ -249,6 +296,20 @@ func (h *Handler) GetGroupRoles(w http.ResponseWriter, r *http.Request) {
 	response.RespondSuccess(w, http.StatusOK, "Success", roles)
 }
 
+func (h *Handler) respondWithAssignmentError(w http.ResponseWriter, err error, fallback string) {
+	var violation *sod_service.ViolationError
+	switch {
+	case errors.As(err, &violation):
+		response.RespondError(w, http.StatusConflict, "SOD_VIOLATION", violation.Error(), violation.Rule)
+	case errors.Is(err, role_service.ErrAlreadyAssigned):
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, role_service.ErrNotAssigned):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
 	response.RespondError(w, statusCode, "API_ERROR", message, nil)
 }
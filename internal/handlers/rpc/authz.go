@@ -0,0 +1,117 @@
+package rpc_handlers
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	iamv1 "github.com/iamBelugaa/iam/pkg/pb/iam/v1"
+)
+
+type authzServer struct {
+	iamv1.UnimplementedAuthzServer
+	log *zap.SugaredLogger
+}
+
+// HasRole looks at the roles assigned to the user first, then at those of
+// each of the user's groups.
+func (s *authzServer) HasRole(ctx context.Context, req *iamv1.HasRoleRequest) (*iamv1.HasRoleResponse, error) {
+	if err := required("user_id", req.GetUserId(), "role", req.GetRole()); err != nil {
+		return nil, err
+	}
+
+	services := servicesFrom(ctx)
+	roles, err := services.Roles.GetUserRoles(ctx, req.GetUserId())
+	if err != nil {
+		s.log.Infow("Failed to get user roles", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to retrieve user roles")
+	}
+	if matchRole(roles, req.GetRole()) {
+		return &iamv1.HasRoleResponse{Granted: true}, nil
+	}
+
+	groups, err := services.Users.GetUserGroups(ctx, req.GetUserId())
+	if err != nil {
+		s.log.Infow("Failed to get user groups", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to retrieve user groups")
+	}
+	for _, group := range groups {
+		roles, err := services.Roles.GetGroupRoles(ctx, group.ID)
+		if err != nil {
+			s.log.Infow("Failed to get group roles", zap.Error(err), "groupId", group.ID)
+			return nil, toStatus(err, "Failed to retrieve group roles")
+		}
+		if matchRole(roles, req.GetRole()) {
+			return &iamv1.HasRoleResponse{Granted: true}, nil
+		}
+	}
+	return &iamv1.HasRoleResponse{Granted: false}, nil
+}
+
+func (s *authzServer) IsMember(ctx context.Context, req *iamv1.IsMemberRequest) (*iamv1.IsMemberResponse, error) {
+	if err := required("user_id", req.GetUserId(), "group_id", req.GetGroupId()); err != nil {
+		return nil, err
+	}
+
+	groups, err := servicesFrom(ctx).Users.GetUserGroups(ctx, req.GetUserId())
+	if err != nil {
+		s.log.Infow("Failed to get user groups", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to retrieve user groups")
+	}
+	for _, group := range groups {
+		if group.ID == req.GetGroupId() {
+			return &iamv1.IsMemberResponse{Member: true}, nil
+		}
+	}
+	return &iamv1.IsMemberResponse{Member: false}, nil
+}
+
+// CheckAssignment runs the separation-of-duties checks the role and group
+// services run before an assignment, without assigning anything.
+func (s *authzServer) CheckAssignment(ctx context.Context, req *iamv1.CheckAssignmentRequest) (*iamv1.CheckAssignmentResponse, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	sod := servicesFrom(ctx).SoD
+	var err error
+	switch resource := req.GetResource().(type) {
+	case *iamv1.CheckAssignmentRequest_RoleId:
+		err = sod.CheckUserRole(ctx, req.GetUserId(), resource.RoleId)
+	case *iamv1.CheckAssignmentRequest_GroupId:
+		err = sod.CheckUserGroup(ctx, req.GetUserId(), resource.GroupId)
+	default:
+		return nil, invalidArgument("role_id or group_id is required")
+	}
+
+	var violation *sod_service.ViolationError
+	switch {
+	case errors.As(err, &violation):
+		return &iamv1.CheckAssignmentResponse{
+			Allowed: false,
+			Violation: &iamv1.Violation{
+				RuleId:   violation.Rule.ID,
+				RuleName: violation.Rule.Name,
+				Message:  violation.Error(),
+			},
+		}, nil
+	case err != nil:
+		s.log.Infow("Failed to check assignment", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to check assignment")
+	}
+	return &iamv1.CheckAssignmentResponse{Allowed: true}, nil
+}
+
+// matchRole matches a role by ID, by type for built-in roles such as
+// SUPER_ADMIN, or by name.
+func matchRole(roles []*models.Role, role string) bool {
+	for _, candidate := range roles {
+		if candidate.ID == role || candidate.Type == role || candidate.Name == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package rpc_handlers
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	iamv1 "github.com/iamBelugaa/iam/pkg/pb/iam/v1"
+)
+
+func toUser(user *models.User) *iamv1.User {
+	return &iamv1.User{
+		Id:          user.ID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Login:       user.Login,
+		Status:      user.Status,
+		ManagerId:   user.ManagerID,
+		Created:     timestamp(user.Created),
+		Activated:   optionalTimestamp(user.Activated),
+		LastLogin:   optionalTimestamp(user.LastLogin),
+		LastUpdated: optionalTimestamp(user.LastUpdated),
+		Profile:     toStruct(user.Profile),
+	}
+}
+
+func toUsers(users []*models.User) []*iamv1.User {
+	result := make([]*iamv1.User, len(users))
+	for i, user := range users {
+		result[i] = toUser(user)
+	}
+	return result
+}
+
+func toGroup(group *models.Group) *iamv1.Group {
+	return &iamv1.Group{
+		Id:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Type:        group.Type,
+		Created:     timestamp(group.Created),
+		LastUpdated: timestamp(group.LastUpdated),
+		Profile:     toStruct(group.Profile),
+	}
+}
+
+func toGroups(groups []*models.Group) []*iamv1.Group {
+	result := make([]*iamv1.Group, len(groups))
+	for i, group := range groups {
+		result[i] = toGroup(group)
+	}
+	return result
+}
+
+func toRole(role *models.Role) *iamv1.Role {
+	return &iamv1.Role{
+		Id:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Type:        role.Type,
+		Created:     timestamp(role.Created),
+		LastUpdated: timestamp(role.LastUpdated),
+	}
+}
+
+func toRoles(roles []*models.Role) []*iamv1.Role {
+	result := make([]*iamv1.Role, len(roles))
+	for i, role := range roles {
+		result[i] = toRole(role)
+	}
+	return result
+}
+
+func toGrant(grant *models.Grant) *iamv1.Grant {
+	return &iamv1.Grant{
+		Id:           grant.ID,
+		UserId:       grant.UserID,
+		ResourceType: grant.ResourceType,
+		ResourceId:   grant.ResourceID,
+		Status:       grant.Status,
+		GrantedBy:    grant.GrantedBy,
+		Created:      timestamp(grant.Created),
+		ExpiresAt:    timestamp(grant.ExpiresAt),
+	}
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
+
+// toStruct converts profile attributes. Attributes decoded from Okta JSON
+// always convert, so an error only drops a malformed profile.
+func toStruct(attributes map[string]any) *structpb.Struct {
+	if len(attributes) == 0 {
+		return nil
+	}
+	result, err := structpb.NewStruct(attributes)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+func fromStruct(attributes *structpb.Struct) map[string]any {
+	if attributes == nil {
+		return nil
+	}
+	return attributes.AsMap()
+}
+
+// expiresAt returns the expiry of a time-bound assignment, or nil for a
+// permanent one. It must be in the future.
+func expiresAt(ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, invalidArgument("expires_at is not a valid timestamp")
+	}
+	t := ts.AsTime()
+	if !t.After(time.Now()) {
+		return nil, invalidArgument("expires_at must be in the future")
+	}
+	return &t, nil
+}
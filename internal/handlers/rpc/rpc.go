@@ -0,0 +1,287 @@
+// Package rpc_handlers serves the users, groups, roles and authz services of
+// the gRPC API on top of the same service layer as the REST handlers.
+package rpc_handlers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	oktasdk "github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	grant_service "github.com/iamBelugaa/iam/internal/services/grant"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/health"
+	"github.com/iamBelugaa/iam/pkg/metrics"
+	"github.com/iamBelugaa/iam/pkg/org"
+	iamv1 "github.com/iamBelugaa/iam/pkg/pb/iam/v1"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+// Metadata keys read from every call. gRPC metadata keys are lower case, so
+// these are the lower-case forms of the REST headers.
+var (
+	actorKey     = strings.ToLower(actor.Header)
+	orgKey       = strings.ToLower(org.Header)
+	requestIDKey = strings.ToLower(middleware.RequestIDHeader)
+)
+
+// healthInterval is how often the health service is synced with the checker.
+const healthInterval = 5 * time.Second
+
+// Services are the services of one org that the gRPC API calls.
+type Services struct {
+	Users  *user_service.Service
+	Groups *group_service.Service
+	Roles  *role_service.Service
+	Grants *grant_service.Service
+	SoD    *sod_service.Service
+}
+
+// Server is the gRPC API server. It serves the standard health service,
+// reporting SERVING while the checker reports the service ready, and the
+// reflection service when enabled.
+type Server struct {
+	*grpc.Server
+	log     *zap.SugaredLogger
+	checker *health.Checker
+	health  *grpchealth.Server
+}
+
+// New returns the gRPC API server. A call is served by the org named in its
+// x-okta-org metadata, else by the default org, and identifies its caller
+// with x-actor-id like the REST API.
+func New(
+	log *zap.SugaredLogger,
+	orgs map[string]*Services,
+	defaultOrg string,
+	enableReflection bool,
+	checker *health.Checker,
+) *Server {
+	server := &Server{
+		log:     log,
+		checker: checker,
+		health:  grpchealth.NewServer(),
+	}
+
+	server.Server = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		server.recoverer,
+		requestID,
+		tracing.UnaryServerInterceptor,
+		metrics.UnaryServerInterceptor,
+		server.logger,
+		identity(orgs, defaultOrg),
+	))
+
+	iamv1.RegisterUsersServer(server.Server, &usersServer{log: log})
+	iamv1.RegisterGroupsServer(server.Server, &groupsServer{log: log})
+	iamv1.RegisterRolesServer(server.Server, &rolesServer{log: log})
+	iamv1.RegisterAuthzServer(server.Server, &authzServer{log: log})
+	healthpb.RegisterHealthServer(server.Server, server.health)
+	if enableReflection {
+		reflection.Register(server.Server)
+	}
+
+	server.syncHealth()
+	return server
+}
+
+// WatchHealth keeps the health service in line with the checker until ctx
+// is done, then reports every service as not serving.
+func (s *Server) WatchHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.health.Shutdown()
+			return
+		case <-ticker.C:
+			s.syncHealth()
+		}
+	}
+}
+
+func (s *Server) syncHealth() {
+	serving := healthpb.HealthCheckResponse_NOT_SERVING
+	if s.checker.Report().Ready {
+		serving = healthpb.HealthCheckResponse_SERVING
+	}
+
+	// The empty name stands for the server as a whole.
+	s.health.SetServingStatus("", serving)
+	for name := range s.Server.GetServiceInfo() {
+		s.health.SetServingStatus(name, serving)
+	}
+}
+
+func (s *Server) recoverer(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while serving gRPC call", "method", info.FullMethod,
+				"panic", recovered, "stack", string(debug.Stack()),
+			)
+			err = status.Error(codes.Internal, "Internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (s *Server) logger(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.log.Infow("gRPC call served",
+		"method", info.FullMethod,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start),
+		"requestId", middleware.GetReqID(ctx),
+	)
+	return resp, err
+}
+
+// requestID continues the request ID of the caller, or starts a new one, and
+// sends it back in the response header.
+func requestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := first(ctx, requestIDKey)
+	if id == "" {
+		id = fmt.Sprintf("grpc-%06d", middleware.NextRequestID())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return handler(context.WithValue(ctx, middleware.RequestIDKey, id), req)
+}
+
+type servicesKey struct{}
+
+// identity stores the caller and the org of a call in its context.
+func identity(orgs map[string]*Services, defaultOrg string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if id := first(ctx, actorKey); id != "" {
+			ctx = actor.WithActor(ctx, id)
+		}
+
+		// The health and reflection services are not tied to an org.
+		if !strings.HasPrefix(info.FullMethod, "/iam.v1.") {
+			return handler(ctx, req)
+		}
+
+		name := cmp.Or(first(ctx, orgKey), defaultOrg)
+		services, ok := orgs[name]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "Unknown org '%s'", name)
+		}
+		ctx = org.WithName(ctx, name)
+		return handler(context.WithValue(ctx, servicesKey{}, services), req)
+	}
+}
+
+// servicesFrom returns the services of the org of the call.
+func servicesFrom(ctx context.Context) *Services {
+	return ctx.Value(servicesKey{}).(*Services)
+}
+
+func first(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// required checks that the fields, given as name and value pairs, are set.
+func required(fields ...string) error {
+	for i := 0; i < len(fields); i += 2 {
+		if strings.TrimSpace(fields[i+1]) == "" {
+			return invalidArgument(fields[i] + " is required")
+		}
+	}
+	return nil
+}
+
+func invalidArgument(message string) error {
+	return status.Error(codes.InvalidArgument, message)
+}
+
+// toStatus maps a service error to a gRPC status. Errors the caller cannot
+// act on are reported with the fallback message, as the REST API does.
+func toStatus(err error, fallback string) error {
+	var violation *sod_service.ViolationError
+	var oktaErr *oktasdk.GenericOpenAPIError
+
+	switch {
+	case errors.As(err, &violation):
+		st, detailErr := status.New(codes.FailedPrecondition, violation.Error()).WithDetails(&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "SOD_VIOLATION",
+				Subject:     violation.Rule.ID,
+				Description: violation.Rule.Name,
+			}},
+		})
+		if detailErr != nil {
+			return status.Error(codes.FailedPrecondition, violation.Error())
+		}
+		return st.Err()
+	case errors.Is(err, role_service.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, role_service.ErrAlreadyAssigned):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, role_service.ErrNotAssigned):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, user_service.ErrInvalidStatus):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.As(err, &oktaErr):
+		return status.Error(oktaCode(oktaErr), fallback)
+	}
+	return status.Error(codes.Internal, fallback)
+}
+
+// oktaCode maps the error code of a failed Okta call, such as E0000007, to a
+// gRPC code. The SDK does not keep the HTTP status on its errors, but the
+// error code in the response body names the failure more precisely anyway.
+func oktaCode(err *oktasdk.GenericOpenAPIError) codes.Code {
+	model, ok := err.Model().(oktasdk.Error)
+	if !ok {
+		return codes.Internal
+	}
+
+	switch model.GetErrorCode() {
+	case "E0000001", "E0000003", "E0000029":
+		return codes.InvalidArgument
+	case "E0000004", "E0000005", "E0000011":
+		return codes.Unauthenticated
+	case "E0000006":
+		return codes.PermissionDenied
+	case "E0000007":
+		return codes.NotFound
+	case "E0000015", "E0000016", "E0000038":
+		return codes.FailedPrecondition
+	case "E0000047":
+		return codes.ResourceExhausted
+	case "E0000010":
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
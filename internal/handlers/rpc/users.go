@@ -0,0 +1,148 @@
+package rpc_handlers
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	iamv1 "github.com/iamBelugaa/iam/pkg/pb/iam/v1"
+)
+
+type usersServer struct {
+	iamv1.UnimplementedUsersServer
+	log *zap.SugaredLogger
+}
+
+func (s *usersServer) ListUsers(ctx context.Context, req *iamv1.ListUsersRequest) (*iamv1.ListUsersResponse, error) {
+	users, err := servicesFrom(ctx).Users.GetUsers(ctx)
+	if err != nil {
+		s.log.Infow("Failed to get users", zap.Error(err))
+		return nil, toStatus(err, "Failed to retrieve users")
+	}
+	return &iamv1.ListUsersResponse{Users: toUsers(users)}, nil
+}
+
+func (s *usersServer) GetUser(ctx context.Context, req *iamv1.GetUserRequest) (*iamv1.User, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	user, err := servicesFrom(ctx).Users.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		s.log.Infow("Failed to get user", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to retrieve user")
+	}
+	return toUser(user), nil
+}
+
+func (s *usersServer) CreateUser(ctx context.Context, req *iamv1.CreateUserRequest) (*iamv1.User, error) {
+	err := required(
+		"email", req.GetEmail(),
+		"first_name", req.GetFirstName(),
+		"last_name", req.GetLastName(),
+		"login", req.GetLogin(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := servicesFrom(ctx).Users.CreateUser(ctx, &models.CreateUserRequest{
+		Email:     req.GetEmail(),
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Login:     req.GetLogin(),
+		Password:  req.GetPassword(),
+		Profile:   fromStruct(req.GetProfile()),
+		Activate:  req.GetActivate(),
+	})
+	if err != nil {
+		s.log.Infow("Failed to create user", zap.Error(err), "email", req.GetEmail())
+		return nil, toStatus(err, "Failed to create user")
+	}
+
+	s.log.Infow("User created successfully", "userId", user.ID)
+	return toUser(user), nil
+}
+
+func (s *usersServer) UpdateUser(ctx context.Context, req *iamv1.UpdateUserRequest) (*iamv1.User, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	user, err := servicesFrom(ctx).Users.UpdateUser(ctx, req.GetUserId(), &models.UpdateUserRequest{
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Profile:   fromStruct(req.GetProfile()),
+	})
+	if err != nil {
+		s.log.Infow("Failed to update user", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to update user")
+	}
+
+	s.log.Infow("User updated successfully", "userId", user.ID)
+	return toUser(user), nil
+}
+
+func (s *usersServer) DeleteUser(ctx context.Context, req *iamv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	if err := servicesFrom(ctx).Users.DeleteUser(ctx, req.GetUserId()); err != nil {
+		s.log.Infow("Failed to delete user", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to delete user")
+	}
+
+	s.log.Infow("User deleted successfully", "userId", req.GetUserId())
+	return &emptypb.Empty{}, nil
+}
+
+func (s *usersServer) ActivateUser(ctx context.Context, req *iamv1.UserLifecycleRequest) (*emptypb.Empty, error) {
+	return s.lifecycle(ctx, req, "activate", servicesFrom(ctx).Users.ActivateUser)
+}
+
+func (s *usersServer) DeactivateUser(ctx context.Context, req *iamv1.UserLifecycleRequest) (*emptypb.Empty, error) {
+	return s.lifecycle(ctx, req, "deactivate", servicesFrom(ctx).Users.DeactivateUser)
+}
+
+func (s *usersServer) SuspendUser(ctx context.Context, req *iamv1.UserLifecycleRequest) (*emptypb.Empty, error) {
+	return s.lifecycle(ctx, req, "suspend", servicesFrom(ctx).Users.SuspendUser)
+}
+
+func (s *usersServer) UnsuspendUser(ctx context.Context, req *iamv1.UserLifecycleRequest) (*emptypb.Empty, error) {
+	return s.lifecycle(ctx, req, "unsuspend", servicesFrom(ctx).Users.UnsuspendUser)
+}
+
+func (s *usersServer) lifecycle(
+	ctx context.Context,
+	req *iamv1.UserLifecycleRequest,
+	operation string,
+	change func(ctx context.Context, userID string) error,
+) (*emptypb.Empty, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	if err := change(ctx, req.GetUserId()); err != nil {
+		s.log.Infow("Failed to "+operation+" user", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to "+operation+" user")
+	}
+
+	s.log.Infow("User lifecycle changed successfully", "operation", operation, "userId", req.GetUserId())
+	return &emptypb.Empty{}, nil
+}
+
+func (s *usersServer) ListUserGroups(ctx context.Context, req *iamv1.ListUserGroupsRequest) (*iamv1.ListUserGroupsResponse, error) {
+	if err := required("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	groups, err := servicesFrom(ctx).Users.GetUserGroups(ctx, req.GetUserId())
+	if err != nil {
+		s.log.Infow("Failed to get user groups", zap.Error(err), "userId", req.GetUserId())
+		return nil, toStatus(err, "Failed to retrieve user groups")
+	}
+	return &iamv1.ListUserGroupsResponse{Groups: toGroups(groups)}, nil
+}
@@ -0,0 +1,143 @@
+package session_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	session_service "github.com/iamBelugaa/iam/internal/services/session"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log         *zap.SugaredLogger
+	sessionsSvc *session_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *session_service.Service) *Handler {
+	return &Handler{log: log, sessionsSvc: svc}
+}
+
+func (h *Handler) GetAppGrants(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get user app grants request received", "userId", userID)
+
+	grants, err := h.sessionsSvc.GetAppGrants(r.Context(), userID)
+	if err != nil {
+		h.log.Infow("Failed to get user app grants", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to retrieve user app grants")
+		return
+	}
+
+	h.log.Infow("User app grants retrieved successfully", "userId", userID, "count", len(grants))
+	response.RespondSuccess(w, http.StatusOK, "Success", grants)
+}
+
+func (h *Handler) ClearSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	revokeTokens, err := boolQuery(r, "revokeTokens")
+	if err != nil {
+		h.respondWithError(w, "revokeTokens must be true or false", http.StatusBadRequest)
+		return
+	}
+	revokeGrants, err := boolQuery(r, "revokeGrants")
+	if err != nil {
+		h.respondWithError(w, "revokeGrants must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Clear user sessions request received", "userId", userID,
+		"revokeTokens", revokeTokens,
+		"revokeGrants", revokeGrants,
+	)
+
+	cleared, err := h.sessionsSvc.ClearSessions(r.Context(), userID, revokeTokens, revokeGrants)
+	if err != nil {
+		h.log.Infow("Failed to clear user sessions", zap.Error(err), "userId", userID)
+		h.respondWithServiceError(w, err, "Failed to clear user sessions")
+		return
+	}
+
+	h.log.Infow("User sessions cleared successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "User sessions cleared successfully", cleared)
+}
+
+func (h *Handler) ContainUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Contain user request received", "userId", userID)
+
+	var req models.ContainUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode contain user request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		h.respondWithError(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+
+	containment, err := h.sessionsSvc.ContainUser(r.Context(), userID, &req)
+	if err != nil {
+		h.log.Infow("Failed to contain user", zap.Error(err), "userId", userID)
+		if containment != nil {
+			// Report the steps that did succeed, so the caller knows what is
+			// left to do by hand.
+			status := http.StatusInternalServerError
+			if errors.Is(err, session_service.ErrCannotSuspend) {
+				status = http.StatusConflict
+			}
+			response.RespondError(w, status, "API_ERROR", "Failed to fully contain user", containment)
+			return
+		}
+		h.respondWithServiceError(w, err, "Failed to contain user")
+		return
+	}
+
+	h.log.Infow("User contained successfully", "userId", userID,
+		"suspended", containment.Suspended,
+		"deactivated", containment.Deactivated,
+	)
+	response.RespondSuccess(w, http.StatusOK, "User contained successfully", containment)
+}
+
+func boolQuery(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	if errors.Is(err, session_service.ErrNotFound) {
+		h.respondWithError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	h.respondWithError(w, fallback, http.StatusInternalServerError)
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,227 @@
+package snapshot_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	snapshot_service "github.com/iamBelugaa/iam/internal/services/snapshot"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+// maxArchiveSize caps the body of a snapshot import.
+const maxArchiveSize = 32 << 20
+
+type Handler struct {
+	log         *zap.SugaredLogger
+	snapshotSvc *snapshot_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *snapshot_service.Service) *Handler {
+	return &Handler{log: log, snapshotSvc: svc}
+}
+
+func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create snapshot request received")
+
+	snapshot, err := h.snapshotSvc.CreateSnapshot(r.Context(), actor.FromContext(r.Context()))
+	if err != nil {
+		h.log.Infow("Failed to create snapshot", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to create snapshot")
+		return
+	}
+
+	h.log.Infow("Snapshot created successfully", "snapshotId", snapshot.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Snapshot created successfully", snapshot.Summary())
+}
+
+func (h *Handler) ImportSnapshot(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Import snapshot request received")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	if err != nil {
+		h.log.Infow("Failed to read import snapshot request", zap.Error(err))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondWithError(w, fmt.Sprintf("Snapshot archive exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := h.snapshotSvc.ImportSnapshot(r.Context(), body)
+	if err != nil {
+		h.log.Infow("Failed to import snapshot", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to import snapshot")
+		return
+	}
+
+	h.log.Infow("Snapshot imported successfully", "snapshotId", snapshot.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Snapshot imported successfully", snapshot.Summary())
+}
+
+func (h *Handler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get snapshots request received")
+
+	snapshots := h.snapshotSvc.GetSnapshots(r.Context())
+
+	h.log.Infow("Snapshots retrieved successfully", "count", len(snapshots))
+	response.RespondSuccess(w, http.StatusOK, "Success", snapshots)
+}
+
+func (h *Handler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := chi.URLParam(r, "snapshotID")
+	if snapshotID == "" {
+		h.respondWithError(w, "Snapshot ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get snapshot request received", "snapshotId", snapshotID)
+
+	snapshot, err := h.snapshotSvc.GetSnapshot(r.Context(), snapshotID)
+	if err != nil {
+		h.log.Infow("Failed to get snapshot", zap.Error(err), "snapshotId", snapshotID)
+		h.respondWithServiceError(w, err, "Failed to retrieve snapshot")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", snapshot)
+}
+
+// DownloadSnapshot returns the bare archive, ready to be imported elsewhere.
+func (h *Handler) DownloadSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := chi.URLParam(r, "snapshotID")
+	if snapshotID == "" {
+		h.respondWithError(w, "Snapshot ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Download snapshot request received", "snapshotId", snapshotID)
+
+	snapshot, err := h.snapshotSvc.GetSnapshot(r.Context(), snapshotID)
+	if err != nil {
+		h.log.Infow("Failed to get snapshot", zap.Error(err), "snapshotId", snapshotID)
+		h.respondWithServiceError(w, err, "Failed to retrieve snapshot")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="snapshot-%s.json"`, snapshot.ID))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		h.log.Infow("Failed to write snapshot archive", zap.Error(err), "snapshotId", snapshotID)
+	}
+}
+
+func (h *Handler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := chi.URLParam(r, "snapshotID")
+	if snapshotID == "" {
+		h.respondWithError(w, "Snapshot ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Delete snapshot request received", "snapshotId", snapshotID)
+
+	if err := h.snapshotSvc.DeleteSnapshot(r.Context(), snapshotID); err != nil {
+		h.log.Infow("Failed to delete snapshot", zap.Error(err), "snapshotId", snapshotID)
+		h.respondWithServiceError(w, err, "Failed to delete snapshot")
+		return
+	}
+
+	h.log.Infow("Snapshot deleted successfully", "snapshotId", snapshotID)
+	response.RespondSuccess(w, http.StatusOK, "Snapshot deleted successfully", nil)
+}
+
+func (h *Handler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := chi.URLParam(r, "snapshotID")
+	if snapshotID == "" {
+		h.respondWithError(w, "Snapshot ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Restore snapshot request received", "snapshotId", snapshotID)
+
+	var req models.RestoreSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Infow("Failed to decode restore snapshot request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.snapshotSvc.RestoreSnapshot(r.Context(), snapshotID, &req)
+	if err != nil {
+		h.log.Infow("Failed to restore snapshot", zap.Error(err), "snapshotId", snapshotID)
+		h.respondWithServiceError(w, err, "Failed to restore snapshot")
+		return
+	}
+
+	h.log.Infow("Snapshot restored", "snapshotId", snapshotID, "applied", result.Apply.Applied, "failed", result.Apply.Failed)
+	if result.Apply.Failed > 0 {
+		response.RespondSuccess(w, http.StatusOK, "Snapshot partially restored", result)
+		return
+	}
+	response.RespondSuccess(w, http.StatusOK, "Snapshot restored successfully", result)
+}
+
+// DiffSnapshots compares the from and to snapshots, either of which may be
+// "live". format=text, or Accept: text/plain, returns a human-readable report.
+func (h *Handler) DiffSnapshots(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to := query.Get("from"), query.Get("to")
+
+	h.log.Infow("Diff snapshots request received", "from", from, "to", to)
+
+	format := query.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/plain") {
+		format = "text"
+	}
+	if format != "" && format != "text" && format != "json" {
+		h.respondWithError(w, "format must be text or json", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.snapshotSvc.Diff(r.Context(), from, to)
+	if err != nil {
+		h.log.Infow("Failed to diff snapshots", zap.Error(err), "from", from, "to", to)
+		h.respondWithServiceError(w, err, "Failed to compare snapshots")
+		return
+	}
+
+	if format != "text" {
+		response.RespondSuccess(w, http.StatusOK, "Success", diff)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := snapshot_service.WriteDiff(w, diff); err != nil {
+		h.log.Infow("Failed to write snapshot diff", zap.Error(err))
+	}
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, snapshot_service.ErrNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, snapshot_service.ErrInvalidArchive), errors.Is(err, snapshot_service.ErrInvalidRequest):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,171 @@
+package sod_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	sod_service "github.com/iamBelugaa/iam/internal/services/sod"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+type Handler struct {
+	log    *zap.SugaredLogger
+	sodSvc *sod_service.Service
+}
+
+func New(log *zap.SugaredLogger, svc *sod_service.Service) *Handler {
+	return &Handler{log: log, sodSvc: svc}
+}
+
+func (h *Handler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create separation-of-duties rule request received")
+
+	var req models.CreateSoDRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode create rule request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.sodSvc.CreateRule(r.Context(), actor.FromContext(r.Context()), &req)
+	if err != nil {
+		h.log.Infow("Failed to create separation-of-duties rule", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to create rule")
+		return
+	}
+
+	h.log.Infow("Separation-of-duties rule created successfully", "ruleId", rule.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Rule created successfully", rule)
+}
+
+func (h *Handler) GetRules(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get separation-of-duties rules request received")
+
+	rules := h.sodSvc.GetRules(r.Context())
+
+	h.log.Infow("Separation-of-duties rules retrieved successfully", "count", len(rules))
+	response.RespondSuccess(w, http.StatusOK, "Success", rules)
+}
+
+func (h *Handler) GetRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Get separation-of-duties rule request received", "ruleId", ruleID)
+
+	rule, err := h.sodSvc.GetRule(r.Context(), ruleID)
+	if err != nil {
+		h.log.Infow("Failed to get separation-of-duties rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithServiceError(w, err, "Failed to retrieve rule")
+		return
+	}
+
+	response.RespondSuccess(w, http.StatusOK, "Success", rule)
+}
+
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+	if ruleID == "" {
+		h.respondWithError(w, "Rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Delete separation-of-duties rule request received", "ruleId", ruleID)
+
+	if err := h.sodSvc.DeleteRule(r.Context(), ruleID); err != nil {
+		h.log.Infow("Failed to delete separation-of-duties rule", zap.Error(err), "ruleId", ruleID)
+		h.respondWithServiceError(w, err, "Failed to delete rule")
+		return
+	}
+
+	h.log.Infow("Separation-of-duties rule deleted successfully", "ruleId", ruleID)
+	response.RespondSuccess(w, http.StatusOK, "Rule deleted successfully", nil)
+}
+
+func (h *Handler) CreateException(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Create separation-of-duties exception request received")
+
+	var req models.CreateSoDExceptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode create exception request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	exception, err := h.sodSvc.CreateException(r.Context(), actor.FromContext(r.Context()), &req)
+	if err != nil {
+		h.log.Infow("Failed to create separation-of-duties exception", zap.Error(err))
+		h.respondWithServiceError(w, err, "Failed to create exception")
+		return
+	}
+
+	h.log.Infow("Separation-of-duties exception created successfully", "exceptionId", exception.ID)
+	response.RespondSuccess(w, http.StatusCreated, "Exception created successfully", exception)
+}
+
+func (h *Handler) GetExceptions(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get separation-of-duties exceptions request received")
+
+	query := r.URL.Query()
+	exceptions := h.sodSvc.GetExceptions(r.Context(), query.Get("ruleId"), query.Get("userId"))
+
+	h.log.Infow("Separation-of-duties exceptions retrieved successfully", "count", len(exceptions))
+	response.RespondSuccess(w, http.StatusOK, "Success", exceptions)
+}
+
+func (h *Handler) DeleteException(w http.ResponseWriter, r *http.Request) {
+	exceptionID := chi.URLParam(r, "exceptionID")
+	if exceptionID == "" {
+		h.respondWithError(w, "Exception ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Delete separation-of-duties exception request received", "exceptionId", exceptionID)
+
+	if err := h.sodSvc.DeleteException(r.Context(), exceptionID); err != nil {
+		h.log.Infow("Failed to delete separation-of-duties exception", zap.Error(err), "exceptionId", exceptionID)
+		h.respondWithServiceError(w, err, "Failed to delete exception")
+		return
+	}
+
+	h.log.Infow("Separation-of-duties exception deleted successfully", "exceptionId", exceptionID)
+	response.RespondSuccess(w, http.StatusOK, "Exception deleted successfully", nil)
+}
+
+func (h *Handler) GetViolations(w http.ResponseWriter, r *http.Request) {
+	h.log.Infow("Get separation-of-duties violations request received")
+
+	violations, err := h.sodSvc.GetViolations(r.Context())
+	if err != nil {
+		h.log.Infow("Failed to scan separation-of-duties violations", zap.Error(err))
+		h.respondWithError(w, "Failed to retrieve violations", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Separation-of-duties violations retrieved successfully", "count", len(violations))
+	response.RespondSuccess(w, http.StatusOK, "Success", violations)
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, sod_service.ErrRuleNotFound), errors.Is(err, sod_service.ErrExceptionNotFound):
+		h.respondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, sod_service.ErrInvalidRequest):
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	response.RespondError(w, statusCode, "API_ERROR", message, nil)
+}
@@ -0,0 +1,171 @@
+package user_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/openapi"
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+func (h *Handler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Set password request received", "userId", userID)
+
+	var req models.SetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode set password request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		h.respondWithFieldErrors(w, "Invalid request body", fieldError("password", "is required"))
+		return
+	}
+
+	if err := h.usersSvc.SetUserPassword(r.Context(), userID, req.Password); err != nil {
+		h.log.Infow("Failed to set password", zap.Error(err), "userId", userID)
+		h.respondWithPasswordError(w, err, "Failed to set password")
+		return
+	}
+
+	h.log.Infow("Password set successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "Password set successfully", nil)
+}
+
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Change password request received", "userId", userID)
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Infow("Failed to decode change password request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var fieldErrors []openapi.FieldError
+	if req.OldPassword == "" {
+		fieldErrors = append(fieldErrors, fieldError("oldPassword", "is required"))
+	}
+	if req.NewPassword == "" {
+		fieldErrors = append(fieldErrors, fieldError("newPassword", "is required"))
+	}
+	if len(fieldErrors) > 0 {
+		h.respondWithFieldErrors(w, "Invalid request body", fieldErrors...)
+		return
+	}
+
+	if err := h.usersSvc.ChangeUserPassword(r.Context(), userID, req.OldPassword, req.NewPassword); err != nil {
+		h.log.Infow("Failed to change password", zap.Error(err), "userId", userID)
+		h.respondWithPasswordError(w, err, "Failed to change password")
+		return
+	}
+
+	h.log.Infow("Password changed successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "Password changed successfully", nil)
+}
+
+func (h *Handler) ExpirePassword(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Expire password request received", "userId", userID)
+
+	if err := h.usersSvc.ExpireUserPassword(r.Context(), userID); err != nil {
+		h.log.Infow("Failed to expire password", zap.Error(err), "userId", userID)
+		h.respondWithLifecycleError(w, err, "Failed to expire password")
+		return
+	}
+
+	h.log.Infow("Password expired successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "Password expired successfully", nil)
+}
+
+// ResetPassword resets a password with a one-time link by default. The body
+// is optional.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	h.log.Infow("Reset password request received", "userId", userID)
+
+	var req models.ResetPasswordRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Infow("Failed to decode reset password request", zap.Error(err))
+			h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch req.Method {
+	case "", models.PasswordResetLink, models.PasswordResetTemporary:
+	default:
+		h.respondWithFieldErrors(w, "Invalid request body", fieldError("method", "must be one of link, temporary_password"))
+		return
+	}
+
+	reset, err := h.usersSvc.ResetUserPassword(r.Context(), userID, &req)
+	if err != nil {
+		h.log.Infow("Failed to reset password", zap.Error(err), "userId", userID)
+		h.respondWithError(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Infow("Password reset successfully", "userId", userID, "method", reset.Method)
+	response.RespondSuccess(w, http.StatusOK, "Password reset successfully", reset)
+}
+
+// respondWithPasswordError reports every reason a new password was rejected,
+// by the local policy or by Okta, as field errors.
+func (h *Handler) respondWithPasswordError(w http.ResponseWriter, err error, fallback string) {
+	if errors.Is(err, user_service.ErrNotFound) {
+		h.respondWithError(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	var passwordErr *user_service.PasswordError
+	if !errors.As(err, &passwordErr) {
+		h.respondWithError(w, fallback, http.StatusInternalServerError)
+		return
+	}
+
+	fieldErrors := make([]openapi.FieldError, len(passwordErr.Violations))
+	for i, violation := range passwordErr.Violations {
+		fieldErrors[i] = fieldError(passwordErr.Field, violation)
+	}
+	h.respondWithFieldErrors(w, "Password was rejected", fieldErrors...)
+}
+
+// respondWithFieldErrors answers in the format of requests rejected by the
+// OpenAPI validator.
+func (h *Handler) respondWithFieldErrors(w http.ResponseWriter, message string, fieldErrors ...openapi.FieldError) {
+	response.RespondError(w, http.StatusBadRequest, "VALIDATION_ERROR", message, fieldErrors)
+}
+
+func fieldError(field, message string) openapi.FieldError {
+	return openapi.FieldError{In: "body", Field: field, Message: message}
+}
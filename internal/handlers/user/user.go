@@ -1,25 +1,37 @@
 package user_handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/models"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
 	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/expand"
+	"github.com/iamBelugaa/iam/pkg/patch"
 	"github.com/iamBelugaa/iam/pkg/response"
 )
 
 type Handler struct {
 	log      *zap.SugaredLogger
 	usersSvc *user_service.Service
+	rolesSvc *role_service.Service
+
+	// expandConcurrency bounds the lookups of ?expand made at a time.
+	expandConcurrency int
 }
 
-func New(log *zap.SugaredLogger, svc *user_service.Service) *Handler {
-	return &Handler{log: log, usersSvc: svc}
+func New(
+	log *zap.SugaredLogger, svc *user_service.Service, rolesSvc *role_service.Service, expandConcurrency int,
+) *Handler {
+	return &Handler{log: log, usersSvc: svc, rolesSvc: rolesSvc, expandConcurrency: expandConcurrency}
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +80,12 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	h.log.Infow("Get user request received", zap.String("userId", userID))
 
+	expansions, err := expand.Parse(r, "groups", "roles")
+	if err != nil {
+		h.respondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	user, err := h.usersSvc.GetUser(r.Context(), userID)
 	if err != nil {
 		h.log.Infow("Failed to get user", zap.Error(err), "userId", userID)
@@ -75,6 +93,12 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.expandUser(r.Context(), user, expansions); err != nil {
+		h.log.Infow("Failed to expand user", zap.Error(err), "userId", userID, "expand", expansions)
+		h.respondWithError(w, "Failed to retrieve related resources of user", http.StatusInternalServerError)
+		return
+	}
+
 	h.log.Infow("User retrieved successfully", "userId", userID)
 	response.RespondSuccess(w, http.StatusOK, "Success", user)
 }
@@ -106,6 +130,46 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	response.RespondSuccess(w, http.StatusOK, "User updated successfully", user)
 }
 
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		h.respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	h.log.Infow("Patch user request received", "userId", userID, "contentType", contentType)
+
+	if !patch.Supported(contentType) {
+		h.respondWithError(w, "Unsupported patch format - use application/merge-patch+json or application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Infow("Failed to read patch user request", zap.Error(err))
+		h.respondWithError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersSvc.PatchUser(r.Context(), userID, contentType, body)
+	if err != nil {
+		h.log.Infow("Failed to patch user", zap.Error(err), "userId", userID)
+		switch {
+		case errors.Is(err, patch.ErrTestFailed):
+			h.respondWithError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, patch.ErrInvalidPatch):
+			h.respondWithError(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.respondWithError(w, "Failed to patch user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.log.Infow("User patched successfully", "userId", userID)
+	response.RespondSuccess(w, http.StatusOK, "User updated successfully", user)
+}
+
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userID")
 	if userID == "" {
@@ -138,7 +202,7 @@ func (h *Handler) ActivateUser(w http.ResponseWriter, r *http.Request) {
 	err := h.usersSvc.ActivateUser(r.Context(), userID)
 	if err != nil {
 		h.log.Infow("Failed to activate user", zap.Error(err), "userId", userID)
-		h.respondWithError(w, "Failed to activate user", http.StatusInternalServerError)
+		h.respondWithLifecycleError(w, err, "Failed to activate user")
 		return
 	}
 
@@ -157,7 +221,7 @@ func (h *Handler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.usersSvc.DeactivateUser(r.Context(), userID); err != nil {
 		h.log.Infow("Failed to deactivate user", zap.Error(err), "userId", userID)
-		h.respondWithError(w, "Failed to deactivate user", http.StatusInternalServerError)
+		h.respondWithLifecycleError(w, err, "Failed to deactivate user")
 		return
 	}
 
@@ -176,7 +240,7 @@ func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.usersSvc.SuspendUser(r.Context(), userID); err != nil {
 		h.log.Infow("Failed to suspend user", zap.Error(err), "userId", userID)
-		h.respondWithError(w, "Failed to suspend user", http.StatusInternalServerError)
+		h.respondWithLifecycleError(w, err, "Failed to suspend user")
 		return
 	}
 
@@ -195,7 +259,7 @@ func (h *Handler) UnSuspendUser(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.usersSvc.UnsuspendUser(r.Context(), userID); err != nil {
 		h.log.Infow("Failed to unsuspend user", zap.Error(err), "userId", userID)
-		h.respondWithError(w, "Failed to unsuspend user", http.StatusInternalServerError)
+		h.respondWithLifecycleError(w, err, "Failed to unsuspend user")
 		return
 	}
 
@@ -203,6 +267,43 @@ func (h *Handler) UnSuspendUser(w http.ResponseWriter, r *http.Request) {
 	response.RespondSuccess(w, http.StatusOK, "User unsuspended successfully", nil)
 }
 
+func (h *Handler) respondWithLifecycleError(w http.ResponseWriter, err error, fallback string) {
+	if errors.Is(err, user_service.ErrInvalidStatus) {
+		h.respondWithError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	h.respondWithError(w, fallback, http.StatusInternalServerError)
+}
+
+// expandUser fills the groups and roles of user named in expansions. Each
+// relationship writes only its own field, so they are fetched concurrently.
+func (h *Handler) expandUser(ctx context.Context, user *models.User, expansions []string) error {
+	return expand.Load(ctx, h.expandConcurrency, expansions, map[string]func(context.Context) error{
+		"groups": func(ctx context.Context) error {
+			groups, err := h.usersSvc.GetUserGroups(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			user.Groups = make([]models.Group, 0, len(groups))
+			for _, group := range groups {
+				user.Groups = append(user.Groups, *group)
+			}
+			return nil
+		},
+		"roles": func(ctx context.Context) error {
+			roles, err := h.rolesSvc.GetUserRoles(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			user.Roles = make([]models.Role, 0, len(roles))
+			for _, role := range roles {
+				user.Roles = append(user.Roles, *role)
+			}
+			return nil
+		},
+	})
+}
+
 func (h *Handler) respondWithError(w http.ResponseWriter, message string, statusCode int) {
 	response.RespondError(w, statusCode, "API_ERROR", message, nil)
 }
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	AccessRequestStatusPending   string = "PENDING"
+	AccessRequestStatusApproved  string = "APPROVED"
+	AccessRequestStatusDenied    string = "DENIED"
+	AccessRequestStatusExpired   string = "EXPIRED"
+	AccessRequestStatusFulfilled string = "FULFILLED"
+)
+
+const (
+	ResourceTypeRole  string = "role"
+	ResourceTypeGroup string = "group"
+)
+
+// AccessRequest represents a user's request for a role or group membership
+// that has to be approved before it is granted.
+type AccessRequest struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"userId"`
+	RequestedBy      string     `json:"requestedBy"`
+	ResourceType     string     `json:"resourceType"`
+	ResourceID       string     `json:"resourceId"`
+	Justification    string     `json:"justification"`
+	Status           string     `json:"status"`
+	Approvers        []string   `json:"approvers"`
+	DecidedBy        string     `json:"decidedBy,omitempty"`
+	DecisionComment  string     `json:"decisionComment,omitempty"`
+	FulfillmentError string     `json:"fulfillmentError,omitempty"`
+	Created          time.Time  `json:"created"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	DecidedAt        *time.Time `json:"decidedAt,omitempty"`
+	FulfilledAt      *time.Time `json:"fulfilledAt,omitempty"`
+}
+
+// CreateAccessRequestRequest represents the data needed to request access.
+// UserID defaults to the caller when empty.
+type CreateAccessRequestRequest struct {
+	UserID        string `json:"userId"`
+	ResourceType  string `json:"resourceType" validate:"required,oneof=role group"`
+	ResourceID    string `json:"resourceId" validate:"required"`
+	Justification string `json:"justification" validate:"required"`
+}
+
+// AccessRequestDecision represents an approver's decision on an access request.
+type AccessRequestDecision struct {
+	Comment string `json:"comment"`
+}
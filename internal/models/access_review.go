@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+const (
+	CampaignStatusSnapshotting string = "SNAPSHOTTING"
+	CampaignStatusOpen         string = "OPEN"
+	CampaignStatusClosed       string = "CLOSED"
+	CampaignStatusSignedOff    string = "SIGNED_OFF"
+	CampaignStatusFailed       string = "FAILED"
+)
+
+const (
+	ReviewDecisionKeep   string = "KEEP"
+	ReviewDecisionRevoke string = "REVOKE"
+)
+
+const (
+	ReviewerStrategyManager string = "manager"
+	ReviewerStrategyOwner   string = "owner"
+	ReviewerStrategyFixed   string = "fixed"
+)
+
+const (
+	PrincipalTypeUser  string = "user"
+	PrincipalTypeGroup string = "group"
+)
+
+// Campaign represents an access review that snapshots who holds the selected
+// roles and group memberships and collects a keep or revoke decision for each.
+type Campaign struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	Description      string       `json:"description,omitempty"`
+	RoleIDs          []string     `json:"roleIds"`
+	GroupIDs         []string     `json:"groupIds"`
+	ReviewerStrategy string       `json:"reviewerStrategy"`
+	Reviewers        []string     `json:"reviewers,omitempty"`
+	Signer           string       `json:"signer,omitempty"`
+	Status           string       `json:"status"`
+	Error            string       `json:"error,omitempty"`
+	CreatedBy        string       `json:"createdBy"`
+	Created          time.Time    `json:"created"`
+	DueAt            *time.Time   `json:"dueAt,omitempty"`
+	ClosedBy         string       `json:"closedBy,omitempty"`
+	ClosedAt         *time.Time   `json:"closedAt,omitempty"`
+	SignedOffBy      string       `json:"signedOffBy,omitempty"`
+	SignedOffAt      *time.Time   `json:"signedOffAt,omitempty"`
+	SignOffComment   string       `json:"signOffComment,omitempty"`
+	SignOffDigest    string       `json:"signOffDigest,omitempty"`
+	Items            []ReviewItem `json:"items,omitempty"`
+}
+
+// ReviewItem is a single role assignment or group membership under review.
+type ReviewItem struct {
+	ID            string     `json:"id"`
+	PrincipalType string     `json:"principalType"`
+	PrincipalID   string     `json:"principalId"`
+	PrincipalName string     `json:"principalName"`
+	ResourceType  string     `json:"resourceType"`
+	ResourceID    string     `json:"resourceId"`
+	ResourceName  string     `json:"resourceName"`
+	Reviewers     []string   `json:"reviewers"`
+	Decision      string     `json:"decision,omitempty"`
+	Comment       string     `json:"comment,omitempty"`
+	DecidedBy     string     `json:"decidedBy,omitempty"`
+	DecidedAt     *time.Time `json:"decidedAt,omitempty"`
+	Revoked       bool       `json:"revoked,omitempty"`
+	RevokeError   string     `json:"revokeError,omitempty"`
+}
+
+// CreateCampaignRequest represents the data needed to start an access review.
+// Reviewers are used by the fixed strategy, and as the fallback when a user
+// has no manager or a group has no owner. Signer may close and sign off the
+// campaign besides its owner.
+type CreateCampaignRequest struct {
+	Name             string     `json:"name" validate:"required"`
+	Description      string     `json:"description"`
+	RoleIDs          []string   `json:"roleIds"`
+	GroupIDs         []string   `json:"groupIds"`
+	ReviewerStrategy string     `json:"reviewerStrategy" validate:"omitempty,oneof=manager owner fixed"`
+	Reviewers        []string   `json:"reviewers"`
+	Signer           string     `json:"signer"`
+	DueAt            *time.Time `json:"dueAt"`
+}
+
+// ReviewDecisionRequest represents a reviewer's decision on a review item.
+type ReviewDecisionRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=KEEP REVOKE"`
+	Comment  string `json:"comment"`
+}
+
+// SignOffRequest represents the final sign-off of a closed campaign.
+type SignOffRequest struct {
+	Comment string `json:"comment"`
+}
+
+// CampaignReport summarises the outcome of a campaign. Digest is the SHA-256
+// of the report without its sign-off fields, and matches the campaign's
+// SignOffDigest as long as nothing changed after sign-off.
+type CampaignReport struct {
+	CampaignID        string       `json:"campaignId"`
+	Name              string       `json:"name"`
+	Status            string       `json:"status"`
+	Created           time.Time    `json:"created"`
+	CreatedBy         string       `json:"createdBy"`
+	ClosedAt          *time.Time   `json:"closedAt,omitempty"`
+	ClosedBy          string       `json:"closedBy,omitempty"`
+	SignedOffAt       *time.Time   `json:"signedOffAt,omitempty"`
+	SignedOffBy       string       `json:"signedOffBy,omitempty"`
+	SignOffComment    string       `json:"signOffComment,omitempty"`
+	Total             int          `json:"total"`
+	Kept              int          `json:"kept"`
+	Revoked           int          `json:"revoked"`
+	RevokeFailed      int          `json:"revokeFailed"`
+	PendingRevocation int          `json:"pendingRevocation"`
+	Undecided         int          `json:"undecided"`
+	Items             []ReviewItem `json:"items"`
+	Digest            string       `json:"digest"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	AuditOutcomeSuccess string = "SUCCESS"
+	AuditOutcomeFailure string = "FAILURE"
+)
+
+// AuditEntry records a privileged change made through this service.
+type AuditEntry struct {
+	ID         string         `json:"id"`
+	Time       time.Time      `json:"time"`
+	Actor      string         `json:"actor"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"targetType"`
+	TargetID   string         `json:"targetId"`
+	Outcome    string         `json:"outcome"`
+	Reason     string         `json:"reason,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
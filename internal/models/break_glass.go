@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	ElevationStatusPending string = "PENDING"
+	ElevationStatusActive  string = "ACTIVE"
+	ElevationStatusRevoked string = "REVOKED"
+	ElevationStatusFailed  string = "FAILED"
+)
+
+// Elevation represents a break-glass assignment of the emergency role to an
+// on-call engineer for a short window.
+type Elevation struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	RoleID string `json:"roleId"`
+
+	// AssignmentID identifies the role assignment in Okta, which is what is
+	// unassigned when the elevation ends.
+	AssignmentID string `json:"assignmentId,omitempty"`
+
+	// HeldBefore records that the user already held the role when the
+	// elevation was requested. Such an elevation assigns nothing, so nothing
+	// is unassigned when it ends.
+	HeldBefore bool `json:"heldBefore,omitempty"`
+
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	ActivatedAt *time.Time `json:"activatedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy   string     `json:"revokedBy,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// CreateElevationRequest represents the data needed to break glass.
+// DurationMinutes defaults to the configured window when zero.
+type CreateElevationRequest struct {
+	Reason          string `json:"reason" validate:"required"`
+	DurationMinutes int    `json:"durationMinutes"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+const (
+	ChangeActionCreate   string = "create"
+	ChangeActionUpdate   string = "update"
+	ChangeActionDelete   string = "delete"
+	ChangeActionAdd      string = "add"
+	ChangeActionRemove   string = "remove"
+	ChangeActionAssign   string = "assign"
+	ChangeActionUnassign string = "unassign"
+
+	ChangeObjectRole           string = "role"
+	ChangeObjectRolePermission string = "role_permission"
+	ChangeObjectGroup          string = "group"
+	ChangeObjectMembership     string = "membership"
+	ChangeObjectGroupRole      string = "group_role"
+	ChangeObjectUserRole       string = "user_role"
+
+	ChangeStatusApplied string = "applied"
+	ChangeStatusFailed  string = "failed"
+	ChangeStatusSkipped string = "skipped"
+)
+
+// DesiredState declares the custom roles, groups, memberships and role
+// assignments that should exist in Okta. It is kept as YAML in git.
+type DesiredState struct {
+	Roles  []DesiredRole  `yaml:"roles,omitempty" json:"roles,omitempty"`
+	Groups []DesiredGroup `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Users  []DesiredUser  `yaml:"users,omitempty" json:"users,omitempty"`
+}
+
+// DesiredRole is a custom role, matched to Okta by name. Permissions are
+// authoritative when set and left alone when omitted, like group members.
+type DesiredRole struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Permissions []string `yaml:"permissions,omitempty" json:"permissions,omitempty"`
+}
+
+// DesiredGroup is an Okta group, matched by name. Members (user logins or
+// IDs) and Roles (role types) are authoritative when set and left alone
+// when omitted.
+type DesiredGroup struct {
+	Name        string         `yaml:"name" json:"name"`
+	Description string         `yaml:"description,omitempty" json:"description,omitempty"`
+	Profile     map[string]any `yaml:"profile,omitempty" json:"profile,omitempty"`
+	Members     []string       `yaml:"members,omitempty" json:"members,omitempty"`
+	Roles       []string       `yaml:"roles,omitempty" json:"roles,omitempty"`
+}
+
+// DesiredUser lists the roles assigned directly to a user, given by login or ID.
+type DesiredUser struct {
+	User  string   `yaml:"user" json:"user"`
+	Roles []string `yaml:"roles" json:"roles"`
+}
+
+// PlannedChange is one step needed to bring Okta to the desired state.
+type PlannedChange struct {
+	Action      string `json:"action"`
+	Object      string `json:"object"`
+	Description string `json:"description"`
+	GroupID     string `json:"groupId,omitempty"`
+	GroupName   string `json:"groupName,omitempty"`
+	RoleID      string `json:"roleId,omitempty"`
+	RoleName    string `json:"roleName,omitempty"`
+	UserID      string `json:"userId,omitempty"`
+	UserLogin   string `json:"userLogin,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+	Before      any    `json:"before,omitempty"`
+	After       any    `json:"after,omitempty"`
+}
+
+// Plan is the ordered list of changes between the desired and live state.
+type Plan struct {
+	Prune       bool            `json:"prune"`
+	Changes     []PlannedChange `json:"changes"`
+	Summary     map[string]int  `json:"summary"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// ChangeResult reports the outcome of one planned change during apply.
+type ChangeResult struct {
+	Index  int           `json:"index"`
+	Total  int           `json:"total"`
+	Change PlannedChange `json:"change"`
+	Status string        `json:"status"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// ApplyResult reports the outcome of applying a plan. Apply stops at the
+// first failure and skips the remaining changes.
+type ApplyResult struct {
+	Applied int            `json:"applied"`
+	Failed  int            `json:"failed"`
+	Skipped int            `json:"skipped"`
+	Results []ChangeResult `json:"results"`
+}
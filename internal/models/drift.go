@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+const (
+	DriftChangeAdded    string = "added"
+	DriftChangeRemoved  string = "removed"
+	DriftChangeModified string = "modified"
+)
+
+const (
+	DriftStatusOpen     string = "OPEN"
+	DriftStatusResolved string = "RESOLVED"
+)
+
+// IAMState is the access configuration of an org: Okta groups with their
+// members and admin roles, custom roles and the admin roles of users.
+type IAMState struct {
+	Groups    []IAMGroup     `json:"groups"`
+	Roles     []IAMRole      `json:"roles"`
+	UserRoles []IAMUserRoles `json:"userRoles"`
+}
+
+// IAMGroup is a group with the IDs of its members and its role types.
+type IAMGroup struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`
+	Roles       []string `json:"roles"`
+}
+
+// IAMRole is a custom role.
+type IAMRole struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// IAMUserRoles lists the role types directly assigned to a user.
+type IAMUserRoles struct {
+	UserID string   `json:"userId"`
+	Roles  []string `json:"roles"`
+}
+
+// Baseline is an approved IAMState that live Okta is checked against.
+type Baseline struct {
+	ID         string    `json:"id"`
+	ApprovedBy string    `json:"approvedBy,omitempty"`
+	ApprovedAt time.Time `json:"approvedAt"`
+	State      IAMState  `json:"state"`
+}
+
+// DriftItem is one difference between the baseline and live Okta. Its ID is
+// derived from what drifted, so repeated checks update the same item.
+type DriftItem struct {
+	ID          string     `json:"id"`
+	BaselineID  string     `json:"baselineId"`
+	Change      string     `json:"change"`
+	Object      string     `json:"object"`
+	ResourceID  string     `json:"resourceId"`
+	Description string     `json:"description"`
+	Expected    any        `json:"expected,omitempty"`
+	Actual      any        `json:"actual,omitempty"`
+	Status      string     `json:"status"`
+	DetectedAt  time.Time  `json:"detectedAt"`
+	LastSeenAt  time.Time  `json:"lastSeenAt"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// DriftCheck summarizes one comparison of live Okta against the baseline.
+type DriftCheck struct {
+	BaselineID string       `json:"baselineId"`
+	CheckedAt  time.Time    `json:"checkedAt"`
+	Detected   int          `json:"detected"`
+	Resolved   int          `json:"resolved"`
+	Open       []*DriftItem `json:"open"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+)
+
+const (
+	FactorStatusActive            string = "ACTIVE"
+	FactorStatusPendingActivation string = "PENDING_ACTIVATION"
+
+	FactorResultSuccess  string = "SUCCESS"
+	FactorResultRejected string = "REJECTED"
+	FactorResultWaiting  string = "WAITING"
+)
+
+// Factor is a multifactor authentication method enrolled for a user, such as
+// an SMS number or an authenticator app.
+type Factor struct {
+	ID          string         `json:"id"`
+	FactorType  string         `json:"factorType"`
+	Provider    string         `json:"provider"`
+	VendorName  string         `json:"vendorName,omitempty"`
+	Status      string         `json:"status"`
+	Created     *time.Time     `json:"created,omitempty"`
+	LastUpdated *time.Time     `json:"lastUpdated,omitempty"`
+	Profile     map[string]any `json:"profile,omitempty"`
+
+	// Activation holds what the user needs to finish enrolling, e.g. the QR
+	// code of an authenticator app. It is only returned by enrollment.
+	Activation map[string]any `json:"activation,omitempty"`
+}
+
+// EnrollFactorRequest enrolls a factor for a user. Profile depends on the
+// factor type, e.g. {"phoneNumber": "+1-555-415-1337"} for sms.
+type EnrollFactorRequest struct {
+	FactorType string         `json:"factorType" validate:"required"`
+	Provider   string         `json:"provider" validate:"required"`
+	Profile    map[string]any `json:"profile,omitempty"`
+
+	// Activate skips activation for factors that allow it, e.g. sms and
+	// email.
+	Activate bool `json:"activate,omitempty"`
+}
+
+// ActivateFactorRequest finishes enrolling a factor with the passcode the
+// user received or generated.
+type ActivateFactorRequest struct {
+	PassCode string `json:"passCode,omitempty"`
+}
+
+// VerifyFactorRequest challenges a factor, e.g. when helpdesk staff confirm
+// a caller's identity. Without a passcode or answer, sms, call, email and
+// push factors send a challenge to the user.
+type VerifyFactorRequest struct {
+	PassCode string `json:"passCode,omitempty"`
+	Answer   string `json:"answer,omitempty"`
+}
+
+// FactorVerification is the result of a factor challenge.
+type FactorVerification struct {
+	FactorResult string     `json:"factorResult"`
+	Message      string     `json:"message,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ConvertOktaFactorToModel reads the fields common to every factor type from
+// the type-specific factor the SDK decoded.
+func ConvertOktaFactorToModel(oktaFactor *okta.ListFactors200ResponseInner) (*Factor, error) {
+	data, err := json.Marshal(oktaFactor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode factor: %w", err)
+	}
+
+	var decoded struct {
+		Factor
+		Embedded map[string]any `json:"_embedded"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode factor: %w", err)
+	}
+
+	factor := decoded.Factor
+	if activation, ok := decoded.Embedded["activation"].(map[string]any); ok {
+		factor.Activation = activation
+	}
+	return &factor, nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	GrantStatusActive  string = "ACTIVE"
+	GrantStatusExpired string = "EXPIRED"
+	GrantStatusFailed  string = "FAILED"
+)
+
+// Grant represents a time-bound role assignment or group membership that is
+// revoked automatically once ExpiresAt passes. A grant that cannot be revoked
+// after repeated attempts is marked FAILED and needs manual cleanup.
+type Grant struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceId"`
+
+	// AssignmentID identifies a role assignment in Okta, which is what is
+	// unassigned on expiry. It is empty for group memberships.
+	AssignmentID string `json:"assignmentId,omitempty"`
+
+	Status         string     `json:"status"`
+	GrantedBy      string     `json:"grantedBy,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	RevokeAttempts int        `json:"revokeAttempts,omitempty"`
+	Created        time.Time  `json:"created"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+}
+
+// AssignmentOptions represents the optional body of role assignment and group
+// membership requests. A nil ExpiresAt makes the assignment permanent.
+type AssignmentOptions struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ExtendGrantRequest represents the new expiry of a time-bound grant.
+type ExtendGrantRequest struct {
+	ExpiresAt time.Time `json:"expiresAt" validate:"required"`
+}
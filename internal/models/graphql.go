@@ -0,0 +1,21 @@
+package models
+
+// GraphQLRequest is a GraphQL query with its variables.
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQLResponse is the result of a GraphQL query. Data holds what could be
+// resolved, even when some fields failed.
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
@@ -28,7 +28,7 @@ type Group struct {
 
 // CreateGroupRequest represents the data needed to create a new group.
 type CreateGroupRequest struct {
-	Name        string         `json:"name"`
+	Name        string         `json:"name" validate:"required"`
 	Description string         `json:"description"`
 	Profile     map[string]any `json:"profile,omitempty"`
 }
@@ -40,6 +40,14 @@ type UpdateGroupRequest struct {
 	Profile     map[string]any `json:"profile,omitempty"`
 }
 
+// GroupPatchDocument is the editable view of a group that PATCH requests are
+// applied to. Profile holds every profile attribute other than name and description.
+type GroupPatchDocument struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Profile     map[string]any `json:"profile"`
+}
+
 // GroupRoleAssignment represents assigning a role to a group
 type GroupRoleAssignment struct {
 	RoleID  string `json:"roleId"`
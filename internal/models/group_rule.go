@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+
+	"github.com/iamBelugaa/iam/pkg/expression"
+)
+
+const (
+	GroupRuleStatusActive   string = "ACTIVE"
+	GroupRuleStatusInactive string = "INACTIVE"
+	GroupRuleStatusInvalid  string = "INVALID"
+
+	GroupRuleType           string = "group_rule"
+	GroupRuleExpressionType string = "urn:okta:expression:1.0"
+)
+
+// GroupRule assigns every user matching Expression to the groups in GroupIDs.
+// For example: user.department == "Engineering" assigns to "Engineering".
+type GroupRule struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	Expression      string    `json:"expression"`
+	GroupIDs        []string  `json:"groupIds"`
+	ExcludedUserIDs []string  `json:"excludedUserIds,omitempty"`
+	Created         time.Time `json:"created"`
+	LastUpdated     time.Time `json:"lastUpdated"`
+}
+
+// CreateGroupRuleRequest represents the data needed to create a new group rule.
+type CreateGroupRuleRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	Expression      string   `json:"expression" validate:"required"`
+	GroupIDs        []string `json:"groupIds" validate:"required"`
+	ExcludedUserIDs []string `json:"excludedUserIds,omitempty"`
+}
+
+// UpdateGroupRuleRequest replaces the definition of an inactive group rule.
+type UpdateGroupRuleRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	Expression      string   `json:"expression" validate:"required"`
+	GroupIDs        []string `json:"groupIds" validate:"required"`
+	ExcludedUserIDs []string `json:"excludedUserIds,omitempty"`
+}
+
+// ValidateExpressionRequest carries a group rule expression to validate.
+type ValidateExpressionRequest struct {
+	Expression string `json:"expression" validate:"required"`
+}
+
+// ExpressionValidation is the result of validating a group rule expression.
+type ExpressionValidation struct {
+	Valid      bool     `json:"valid"`
+	Error      string   `json:"error,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// PreviewGroupRuleRequest is a sample user to evaluate a rule against.
+// Expression and GroupIDs are ignored when previewing an existing rule.
+type PreviewGroupRuleRequest struct {
+	Expression string             `json:"expression,omitempty"`
+	GroupIDs   []string           `json:"groupIds,omitempty"`
+	UserID     string             `json:"userId,omitempty"`
+	Profile    map[string]any     `json:"profile"`
+	Groups     []expression.Group `json:"groups,omitempty"`
+}
+
+// GroupRulePreview reports whether the sample user matches a rule and which
+// groups they would be assigned to.
+type GroupRulePreview struct {
+	Matched           bool     `json:"matched"`
+	Excluded          bool     `json:"excluded,omitempty"`
+	GroupIDs          []string `json:"groupIds"`
+	Attributes        []string `json:"attributes"`
+	MissingAttributes []string `json:"missingAttributes,omitempty"`
+}
+
+func ConvertOktaGroupRuleToModel(oktaRule *okta.GroupRule) *GroupRule {
+	rule := &GroupRule{
+		ID:       oktaRule.GetId(),
+		Name:     oktaRule.GetName(),
+		Status:   oktaRule.GetStatus(),
+		GroupIDs: []string{},
+	}
+
+	if oktaRule.Conditions != nil {
+		if oktaRule.Conditions.Expression != nil {
+			rule.Expression = oktaRule.Conditions.Expression.GetValue()
+		}
+		if oktaRule.Conditions.People != nil && oktaRule.Conditions.People.Users != nil {
+			rule.ExcludedUserIDs = oktaRule.Conditions.People.Users.Exclude
+		}
+	}
+
+	if oktaRule.Actions != nil && oktaRule.Actions.AssignUserToGroups != nil {
+		rule.GroupIDs = oktaRule.Actions.AssignUserToGroups.GroupIds
+	}
+
+	if oktaRule.Created != nil {
+		rule.Created = oktaRule.GetCreated()
+	}
+
+	if oktaRule.LastUpdated != nil {
+		rule.LastUpdated = oktaRule.GetLastUpdated()
+	}
+
+	return rule
+}
@@ -0,0 +1,11 @@
+package models
+
+import oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+
+// Org describes a configured Okta org and the health of the calls made to it.
+type Org struct {
+	Name    string            `json:"name"`
+	Domain  string            `json:"domain"`
+	Default bool              `json:"default"`
+	Health  oktaclient.Health `json:"health"`
+}
@@ -0,0 +1,41 @@
+package models
+
+const (
+	// PasswordResetLink creates a one-time link the user sets a new
+	// password with.
+	PasswordResetLink string = "link"
+
+	// PasswordResetTemporary expires the password and returns a one-time
+	// temporary password the user must change at the next sign-in.
+	PasswordResetTemporary string = "temporary_password"
+)
+
+// SetPasswordRequest sets a new password for a user without the old one.
+type SetPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangePasswordRequest changes a password, proving the old one.
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+// ResetPasswordRequest resets a password with a link or a temporary
+// password. SendEmail has Okta email the link to the user instead of
+// returning it.
+type ResetPasswordRequest struct {
+	Method         string `json:"method,omitempty" validate:"omitempty,oneof=link temporary_password"`
+	SendEmail      bool   `json:"sendEmail,omitempty"`
+	RevokeSessions bool   `json:"revokeSessions,omitempty"`
+}
+
+// PasswordReset is the result of a password reset. ResetURL is empty when
+// the link was emailed to the user.
+type PasswordReset struct {
+	UserID            string `json:"userId"`
+	Method            string `json:"method"`
+	ResetURL          string `json:"resetUrl,omitempty"`
+	TemporaryPassword string `json:"temporaryPassword,omitempty"`
+	Emailed           bool   `json:"emailed,omitempty"`
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+)
 
 const (
 	ActionRead   string = "read"
@@ -36,3 +41,23 @@ type UpdatePermissionRequest struct {
 	Resource    string `json:"resource,omitempty"`
 	Action      string `json:"action,omitempty"`
 }
+
+// ConvertOktaPermissionToModel converts a custom role permission. Okta names
+// permissions like okta.users.manage or okta.groups.members.manage, which
+// gives the resource (users) and the action (manage, members.manage).
+func ConvertOktaPermissionToModel(oktaPermission *okta.Permission) *Permission {
+	label := oktaPermission.GetLabel()
+	permission := &Permission{
+		ID:          label,
+		Name:        label,
+		Created:     oktaPermission.GetCreated(),
+		LastUpdated: oktaPermission.GetLastUpdated(),
+	}
+
+	parts := strings.Split(strings.TrimPrefix(label, "okta."), ".")
+	if len(parts) > 1 {
+		permission.Resource = parts[0]
+		permission.Action = strings.Join(parts[1:], ".")
+	}
+	return permission
+}
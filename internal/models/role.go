@@ -1,6 +1,7 @@
 package models
 
 import (
+	"cmp"
 	"time"
 
 	"github.com/okta/okta-sdk-golang/v5/okta"
@@ -11,6 +12,22 @@ const (
 	RoleTypeCustom string = "CUSTOM"
 )
 
+// StandardRoleLabels maps the standard Okta admin role types to the labels
+// Okta shows for them, so a role named either way can be recognised before it
+// is assigned.
+var StandardRoleLabels = map[string]string{
+	"SUPER_ADMIN":                 "Super Administrator",
+	"ORG_ADMIN":                   "Organizational Administrator",
+	"APP_ADMIN":                   "Application Administrator",
+	"USER_ADMIN":                  "Group Administrator",
+	"GROUP_MEMBERSHIP_ADMIN":      "Group Membership Administrator",
+	"HELP_DESK_ADMIN":             "Help Desk Administrator",
+	"READ_ONLY_ADMIN":             "Read-only Administrator",
+	"MOBILE_ADMIN":                "Mobile Administrator",
+	"API_ACCESS_MANAGEMENT_ADMIN": "API Access Management Administrator",
+	"REPORT_ADMIN":                "Report Administrator",
+}
+
 // Role represents a set of permissions that can be assigned to users or groups.
 // Examples: "Admin", "ReadOnly", "UserManager", "BillingViewer".
 type Role struct {
@@ -23,9 +40,12 @@ type Role struct {
 }
 
 // CreateRoleRequest represents the data needed to create a new role.
+// Permissions are the permission types the role grants, e.g.
+// okta.users.read.
 type CreateRoleRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 // UpdateRoleRequest represents the data that can be updated for a role.
@@ -52,10 +72,12 @@ func ConvertOktaIamRoleToModel(oktaRole *okta.IamRole) *Role {
 	return role
 }
 
+// ConvertOktaRoleToModel converts a role assignment. Type is the assigned
+// role type, such as USER_ADMIN, or CUSTOM for custom roles.
 func ConvertOktaRoleToModel(assignment *okta.Role) *Role {
 	role := &Role{
 		ID:          assignment.GetId(),
-		Type:        RoleTypeCustom,
+		Type:        cmp.Or(assignment.GetType(), RoleTypeCustom),
 		Name:        assignment.GetLabel(),
 		Description: assignment.GetDescription(),
 		Created:     assignment.GetCreated(),
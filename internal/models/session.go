@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+)
+
+// AppGrant is a user's consent to an OAuth scope for an app, which lets the
+// app get tokens for the user without asking again.
+type AppGrant struct {
+	ID          string     `json:"id"`
+	ClientID    string     `json:"clientId"`
+	ScopeID     string     `json:"scopeId"`
+	Issuer      string     `json:"issuer"`
+	Status      string     `json:"status"`
+	Source      string     `json:"source,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+}
+
+// ClearedSessions is the outcome of signing a user out everywhere.
+type ClearedSessions struct {
+	UserID        string `json:"userId"`
+	TokensRevoked bool   `json:"tokensRevoked"`
+	GrantsRevoked bool   `json:"grantsRevoked"`
+}
+
+// ContainUserRequest contains a user suspected of being compromised or who
+// is leaving in a hurry.
+type ContainUserRequest struct {
+	Reason string `json:"reason" validate:"required"`
+
+	// RevokeGrants also revokes the user's app grants, so apps must ask for
+	// consent again once the user is unsuspended.
+	RevokeGrants bool `json:"revokeGrants,omitempty"`
+
+	// Deactivate deactivates a user Okta cannot suspend, such as one locked
+	// out or in recovery. Deactivation removes the user's app assignments
+	// and cannot be undone, so it is never done unless asked for.
+	Deactivate bool `json:"deactivate,omitempty"`
+}
+
+// Containment is the outcome of containing a user. PreviousStatus is the
+// status the user had before. An active user is suspended, and a user Okta
+// cannot suspend is deactivated only when the request asks for it. Contained
+// is true only once the user can no longer sign in and every step succeeded.
+type Containment struct {
+	UserID          string `json:"userId"`
+	PreviousStatus  string `json:"previousStatus"`
+	Contained       bool   `json:"contained"`
+	Suspended       bool   `json:"suspended"`
+	Deactivated     bool   `json:"deactivated"`
+	SessionsCleared bool   `json:"sessionsCleared"`
+	TokensRevoked   bool   `json:"tokensRevoked"`
+	GrantsRevoked   bool   `json:"grantsRevoked"`
+}
+
+func ConvertOktaGrantToModel(oktaGrant *okta.OAuth2ScopeConsentGrant) *AppGrant {
+	return &AppGrant{
+		ID:          oktaGrant.GetId(),
+		ClientID:    oktaGrant.GetClientId(),
+		ScopeID:     oktaGrant.GetScopeId(),
+		Issuer:      oktaGrant.GetIssuer(),
+		Status:      oktaGrant.GetStatus(),
+		Source:      oktaGrant.GetSource(),
+		Created:     oktaGrant.Created,
+		LastUpdated: oktaGrant.LastUpdated,
+	}
+}
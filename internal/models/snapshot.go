@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+const (
+	// SnapshotFormat identifies snapshot archives.
+	SnapshotFormat string = "iam-snapshot"
+	// SnapshotVersion is the archive version written by this build. Archives
+	// of this or an older version can be restored.
+	SnapshotVersion int = 1
+)
+
+// Snapshot is a portable archive of an org's access configuration. Users are
+// referenced by login, groups and roles by name, so a snapshot can be restored
+// into another org.
+type Snapshot struct {
+	Format    string              `json:"format"`
+	Version   int                 `json:"version"`
+	ID        string              `json:"id"`
+	Source    string              `json:"source,omitempty"`
+	CreatedBy string              `json:"createdBy,omitempty"`
+	Created   time.Time           `json:"created"`
+	Users     []SnapshotUser      `json:"users"`
+	Groups    []SnapshotGroup     `json:"groups"`
+	Roles     []SnapshotRole      `json:"roles"`
+	UserRoles []SnapshotUserRoles `json:"userRoles"`
+}
+
+// SnapshotUser is a user. Profile holds the attributes other than the
+// top-level ones.
+type SnapshotUser struct {
+	Login     string         `json:"login"`
+	Email     string         `json:"email"`
+	FirstName string         `json:"firstName"`
+	LastName  string         `json:"lastName"`
+	Status    string         `json:"status"`
+	Profile   map[string]any `json:"profile,omitempty"`
+}
+
+// SnapshotGroup is a group with the logins of its members.
+type SnapshotGroup struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Profile     map[string]any           `json:"profile,omitempty"`
+	Members     []string                 `json:"members"`
+	Roles       []SnapshotRoleAssignment `json:"roles"`
+}
+
+// SnapshotRole is a custom role with the permission types it grants.
+type SnapshotRole struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type SnapshotUserRoles struct {
+	Login string                   `json:"login"`
+	Roles []SnapshotRoleAssignment `json:"roles"`
+}
+
+// SnapshotRoleAssignment is an assigned role type. Label names the custom
+// role of CUSTOM assignments.
+type SnapshotRoleAssignment struct {
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+}
+
+// SnapshotSummary describes a stored snapshot without its contents.
+type SnapshotSummary struct {
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	Source    string    `json:"source,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	Created   time.Time `json:"created"`
+	Users     int       `json:"users"`
+	Groups    int       `json:"groups"`
+	Roles     int       `json:"roles"`
+}
+
+func (s *Snapshot) Summary() *SnapshotSummary {
+	return &SnapshotSummary{
+		ID:        s.ID,
+		Version:   s.Version,
+		Source:    s.Source,
+		CreatedBy: s.CreatedBy,
+		Created:   s.Created,
+		Users:     len(s.Users),
+		Groups:    len(s.Groups),
+		Roles:     len(s.Roles),
+	}
+}
+
+// RestoreSnapshotRequest limits a restore to the named groups, and optionally
+// creates users that do not exist in the target org.
+type RestoreSnapshotRequest struct {
+	Groups      []string `json:"groups,omitempty"`
+	CreateUsers bool     `json:"createUsers"`
+}
+
+// RestoreResult reports what a restore did. Restores only add: nothing in the
+// target org is updated or removed.
+type RestoreResult struct {
+	SnapshotID   string       `json:"snapshotId"`
+	CreatedUsers []string     `json:"createdUsers"`
+	MissingUsers []string     `json:"missingUsers"`
+	Warnings     []string     `json:"warnings"`
+	Plan         *Plan        `json:"plan"`
+	Apply        *ApplyResult `json:"apply"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+const (
+	DiffChangeAdded   string = "added"
+	DiffChangeRemoved string = "removed"
+)
+
+// SnapshotRef identifies one side of a diff. Live is set when the side was
+// read from Okta rather than from a stored snapshot.
+type SnapshotRef struct {
+	ID      string    `json:"id,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	Created time.Time `json:"created"`
+	Live    bool      `json:"live,omitempty"`
+}
+
+// SnapshotDiff lists what changed from one snapshot to another.
+type SnapshotDiff struct {
+	From              SnapshotRef            `json:"from"`
+	To                SnapshotRef            `json:"to"`
+	UsersAdded        []SnapshotUser         `json:"usersAdded"`
+	UsersRemoved      []SnapshotUser         `json:"usersRemoved"`
+	StatusChanges     []UserStatusChange     `json:"statusChanges"`
+	ProfileChanges    []ProfileFieldChange   `json:"profileChanges"`
+	GroupsAdded       []string               `json:"groupsAdded"`
+	GroupsRemoved     []string               `json:"groupsRemoved"`
+	RolesAdded        []string               `json:"rolesAdded"`
+	RolesRemoved      []string               `json:"rolesRemoved"`
+	MembershipChanges []MembershipChange     `json:"membershipChanges"`
+	RoleChanges       []RoleAssignmentChange `json:"roleChanges"`
+}
+
+type UserStatusChange struct {
+	Login string `json:"login"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ProfileFieldChange is a changed user attribute. From or To is nil when the
+// attribute was added or removed.
+type ProfileFieldChange struct {
+	Login string `json:"login"`
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type MembershipChange struct {
+	Change string `json:"change"`
+	Group  string `json:"group"`
+	Login  string `json:"login"`
+}
+
+// RoleAssignmentChange is a role assigned to or unassigned from a user or
+// group. Role is the role type, or the label of a custom role.
+type RoleAssignmentChange struct {
+	Change        string `json:"change"`
+	PrincipalType string `json:"principalType"`
+	Principal     string `json:"principal"`
+	Role          string `json:"role"`
+}
+
+// Empty reports whether nothing changed.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.UsersAdded) == 0 && len(d.UsersRemoved) == 0 && len(d.StatusChanges) == 0 &&
+		len(d.ProfileChanges) == 0 && len(d.GroupsAdded) == 0 && len(d.GroupsRemoved) == 0 &&
+		len(d.RolesAdded) == 0 && len(d.RolesRemoved) == 0 && len(d.MembershipChanges) == 0 &&
+		len(d.RoleChanges) == 0
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// SoDSide identifies one side of a separation-of-duties rule. ID may be a role
+// ID, role type or label, or a group ID or name.
+type SoDSide struct {
+	Type string `json:"type" validate:"required,oneof=role group"`
+	ID   string `json:"id" validate:"required"`
+}
+
+// SoDRule declares two assignments that one user must not hold together.
+type SoDRule struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Left        SoDSide   `json:"left"`
+	Right       SoDSide   `json:"right"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+// CreateSoDRuleRequest represents the data needed to create a SoD rule.
+type CreateSoDRuleRequest struct {
+	Name        string  `json:"name" validate:"required"`
+	Description string  `json:"description"`
+	Left        SoDSide `json:"left" validate:"required"`
+	Right       SoDSide `json:"right" validate:"required"`
+}
+
+// SoDException allows a user to hold both sides of a rule.
+type SoDException struct {
+	ID         string     `json:"id"`
+	RuleID     string     `json:"ruleId"`
+	UserID     string     `json:"userId"`
+	Reason     string     `json:"reason"`
+	ApprovedBy string     `json:"approvedBy"`
+	Created    time.Time  `json:"created"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// CreateSoDExceptionRequest represents the data needed to record an exception.
+type CreateSoDExceptionRequest struct {
+	RuleID    string     `json:"ruleId"`
+	UserID    string     `json:"userId" validate:"required"`
+	Reason    string     `json:"reason" validate:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// SoDViolation is a user that holds both sides of a rule.
+type SoDViolation struct {
+	Rule        SoDRule `json:"rule"`
+	UserID      string  `json:"userId"`
+	Login       string  `json:"login,omitempty"`
+	LeftVia     string  `json:"leftVia"`
+	RightVia    string  `json:"rightVia"`
+	ExceptionID string  `json:"exceptionId,omitempty"`
+}
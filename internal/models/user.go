@@ -24,6 +24,7 @@ type User struct {
 	LastName    string         `json:"lastName"`
 	Login       string         `json:"login"`
 	Status      string         `json:"status"`
+	ManagerID   string         `json:"managerId,omitempty"`
 	Created     time.Time      `json:"created"`
 	Activated   *time.Time     `json:"activated,omitempty"`
 	LastLogin   *time.Time     `json:"lastLogin,omitempty"`
@@ -35,10 +36,10 @@ type User struct {
 
 // CreateUserRequest represents the data needed to create a new user.
 type CreateUserRequest struct {
-	Email     string         `json:"email"`
-	FirstName string         `json:"firstName"`
-	LastName  string         `json:"lastName"`
-	Login     string         `json:"login"`
+	Email     string         `json:"email" validate:"required"`
+	FirstName string         `json:"firstName" validate:"required"`
+	LastName  string         `json:"lastName" validate:"required"`
+	Login     string         `json:"login" validate:"required"`
 	Password  string         `json:"password"`
 	Profile   map[string]any `json:"profile"`
 	Activate  bool           `json:"activate"`
@@ -51,6 +52,16 @@ type UpdateUserRequest struct {
 	Profile   map[string]any `json:"profile"`
 }
 
+// UserPatchDocument is the editable view of a user that PATCH requests are
+// applied to. Profile holds every profile attribute other than the top-level ones.
+type UserPatchDocument struct {
+	Email     string         `json:"email"`
+	FirstName string         `json:"firstName"`
+	LastName  string         `json:"lastName"`
+	Login     string         `json:"login"`
+	Profile   map[string]any `json:"profile"`
+}
+
 // UserGroupAssignment represents assigning a user to a group.
 type UserGroupAssignment struct {
 	UserID  string `json:"userId" validate:"required"`
@@ -68,6 +79,7 @@ func ConvertOktaUserToModel(oktaUser *okta.User) *User {
 		user.FirstName = oktaUser.Profile.GetFirstName()
 		user.LastName = oktaUser.Profile.GetLastName()
 		user.Login = oktaUser.Profile.GetLogin()
+		user.ManagerID = oktaUser.Profile.GetManagerId()
 		user.Profile = oktaUser.Profile.AdditionalProperties
 	}
 
@@ -0,0 +1,361 @@
+package access_request_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotFound       = errors.New("access request not found")
+	ErrInvalidRequest = errors.New("invalid access request")
+	ErrNotApprover    = errors.New("caller is not an approver for this access request")
+	ErrNotPending     = errors.New("access request is not pending")
+)
+
+// Filter narrows the access requests returned by List. Empty fields match everything.
+type Filter struct {
+	Status   string
+	UserID   string
+	Approver string
+}
+
+type Service struct {
+	cfg       *config.AccessRequestConfig
+	log       *zap.SugaredLogger
+	store     *store.Store[models.AccessRequest]
+	notifier  notify.Notifier
+	rolesSvc  *role_service.Service
+	groupsSvc *group_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	cfg *config.AccessRequestConfig,
+	store *store.Store[models.AccessRequest],
+	notifier notify.Notifier,
+	rolesSvc *role_service.Service,
+	groupsSvc *group_service.Service,
+) *Service {
+	return &Service{
+		cfg:       cfg,
+		log:       log,
+		store:     store,
+		notifier:  notifier,
+		rolesSvc:  rolesSvc,
+		groupsSvc: groupsSvc,
+	}
+}
+
+func (s *Service) CreateAccessRequest(ctx context.Context, requesterID string, req *models.CreateAccessRequestRequest) (*models.AccessRequest, error) {
+	ctx, span := tracing.Start(ctx, "access_request_service.CreateAccessRequest")
+	defer span.End()
+
+	userID := req.UserID
+	if userID == "" {
+		userID = requesterID
+	}
+
+	s.log.Infow("Creating access request", "userId", userID,
+		"resourceType", req.ResourceType, "resourceId", req.ResourceID,
+	)
+
+	switch {
+	case userID == "":
+		return nil, fmt.Errorf("%w: userId is required", ErrInvalidRequest)
+	case req.ResourceType != models.ResourceTypeRole && req.ResourceType != models.ResourceTypeGroup:
+		return nil, fmt.Errorf("%w: resourceType must be %q or %q", ErrInvalidRequest, models.ResourceTypeRole, models.ResourceTypeGroup)
+	case req.ResourceID == "":
+		return nil, fmt.Errorf("%w: resourceId is required", ErrInvalidRequest)
+	case strings.TrimSpace(req.Justification) == "":
+		return nil, fmt.Errorf("%w: justification is required", ErrInvalidRequest)
+	}
+
+	approvers, err := s.approvers(ctx, req.ResourceType, req.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvers) == 0 {
+		return nil, fmt.Errorf("%w: no approvers are configured for %s %s", ErrInvalidRequest, req.ResourceType, req.ResourceID)
+	}
+
+	now := time.Now().UTC()
+	accessRequest := models.AccessRequest{
+		ID:            store.NewID(),
+		UserID:        userID,
+		RequestedBy:   requesterID,
+		ResourceType:  req.ResourceType,
+		ResourceID:    req.ResourceID,
+		Justification: req.Justification,
+		Status:        models.AccessRequestStatusPending,
+		Approvers:     approvers,
+		Created:       now,
+		ExpiresAt:     now.Add(s.cfg.TTL),
+	}
+
+	if err := s.store.Put(ctx, accessRequest.ID, accessRequest); err != nil {
+		s.log.Infow("Failed to store access request", zap.Error(err), "userId", userID)
+		return nil, fmt.Errorf("failed to store access request: %w", err)
+	}
+
+	s.notify(ctx, "access_request.created", &accessRequest, approvers,
+		fmt.Sprintf("User %s requests %s %s: %s", userID, req.ResourceType, req.ResourceID, req.Justification),
+	)
+
+	s.log.Infow("Access request created successfully", "accessRequestId", accessRequest.ID, "userId", userID)
+	return &accessRequest, nil
+}
+
+func (s *Service) GetAccessRequest(ctx context.Context, requestID string) (*models.AccessRequest, error) {
+	_, span := tracing.Start(ctx, "access_request_service.GetAccessRequest", tracing.AccessRequestID.String(requestID))
+	defer span.End()
+
+	accessRequest, ok := s.store.Get(requestID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &accessRequest, nil
+}
+
+func (s *Service) GetAccessRequests(ctx context.Context, filter Filter) []*models.AccessRequest {
+	_, span := tracing.Start(ctx, "access_request_service.GetAccessRequests")
+	defer span.End()
+
+	result := []*models.AccessRequest{}
+	for _, accessRequest := range s.store.List() {
+		if filter.Status != "" && !strings.EqualFold(accessRequest.Status, filter.Status) {
+			continue
+		}
+		if filter.UserID != "" && accessRequest.UserID != filter.UserID {
+			continue
+		}
+		if filter.Approver != "" && !slices.Contains(accessRequest.Approvers, filter.Approver) {
+			continue
+		}
+		result = append(result, &accessRequest)
+	}
+	return result
+}
+
+// ApproveAccessRequest approves a pending request and grants the access. When
+// granting fails the request stays approved and approving it again retries.
+func (s *Service) ApproveAccessRequest(ctx context.Context, requestID, approverID, comment string) (*models.AccessRequest, error) {
+	ctx, span := tracing.Start(ctx, "access_request_service.ApproveAccessRequest", tracing.AccessRequestID.String(requestID))
+	defer span.End()
+
+	s.log.Infow("Approving access request", "accessRequestId", requestID, "approverId", approverID)
+
+	accessRequest, err := s.decide(ctx, requestID, approverID, comment, models.AccessRequestStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.grant(ctx, accessRequest); err != nil {
+		s.log.Infow("Failed to fulfill access request", zap.Error(err), "accessRequestId", requestID)
+		updated, _ := s.store.Update(ctx, requestID, func(record *models.AccessRequest) error {
+			record.FulfillmentError = err.Error()
+			return nil
+		})
+		return &updated, fmt.Errorf("failed to fulfill access request: %w", err)
+	}
+
+	updated, err := s.store.Update(ctx, requestID, func(record *models.AccessRequest) error {
+		now := time.Now().UTC()
+		record.Status = models.AccessRequestStatusFulfilled
+		record.FulfillmentError = ""
+		record.FulfilledAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store access request: %w", err)
+	}
+
+	s.notify(ctx, "access_request.fulfilled", &updated, []string{updated.UserID, updated.RequestedBy},
+		fmt.Sprintf("Access to %s %s was approved by %s and granted", updated.ResourceType, updated.ResourceID, approverID),
+	)
+
+	s.log.Infow("Access request fulfilled successfully", "accessRequestId", requestID)
+	return &updated, nil
+}
+
+func (s *Service) DenyAccessRequest(ctx context.Context, requestID, approverID, comment string) (*models.AccessRequest, error) {
+	ctx, span := tracing.Start(ctx, "access_request_service.DenyAccessRequest", tracing.AccessRequestID.String(requestID))
+	defer span.End()
+
+	s.log.Infow("Denying access request", "accessRequestId", requestID, "approverId", approverID)
+
+	accessRequest, err := s.decide(ctx, requestID, approverID, comment, models.AccessRequestStatusDenied)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, "access_request.denied", accessRequest, []string{accessRequest.UserID, accessRequest.RequestedBy},
+		fmt.Sprintf("Access to %s %s was denied by %s", accessRequest.ResourceType, accessRequest.ResourceID, approverID),
+	)
+
+	s.log.Infow("Access request denied successfully", "accessRequestId", requestID)
+	return accessRequest, nil
+}
+
+// ExpirePendingRequests moves pending requests past their expiry to EXPIRED
+// and returns how many were expired.
+func (s *Service) ExpirePendingRequests(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "access_request_service.ExpirePendingRequests")
+	defer span.End()
+
+	now := time.Now().UTC()
+	expired := 0
+
+	for _, accessRequest := range s.store.List() {
+		if accessRequest.Status != models.AccessRequestStatusPending || now.Before(accessRequest.ExpiresAt) {
+			continue
+		}
+
+		updated, err := s.store.Update(ctx, accessRequest.ID, func(record *models.AccessRequest) error {
+			if record.Status != models.AccessRequestStatusPending {
+				return ErrNotPending
+			}
+			record.Status = models.AccessRequestStatusExpired
+			return nil
+		})
+		if err != nil {
+			continue
+		}
+
+		expired++
+		s.notify(ctx, "access_request.expired", &updated, []string{updated.UserID, updated.RequestedBy},
+			fmt.Sprintf("Request for %s %s expired without a decision", updated.ResourceType, updated.ResourceID),
+		)
+	}
+
+	if expired > 0 {
+		s.log.Infow("Expired pending access requests", "count", expired)
+	}
+	return expired
+}
+
+// Run expires pending requests every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+// tick expires pending requests once. A panic is logged instead of stopping
+// the loop, so the next tick tries again.
+func (s *Service) tick(ctx context.Context) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while expiring pending access requests", "panic", recovered,
+				"stack", string(debug.Stack()),
+			)
+		}
+	}()
+	s.ExpirePendingRequests(ctx)
+}
+
+func (s *Service) decide(ctx context.Context, requestID, approverID, comment, status string) (*models.AccessRequest, error) {
+	if approverID == "" {
+		return nil, ErrNotApprover
+	}
+
+	accessRequest, err := s.store.Update(ctx, requestID, func(record *models.AccessRequest) error {
+		retry := status == models.AccessRequestStatusApproved &&
+			record.Status == models.AccessRequestStatusApproved && record.FulfillmentError != ""
+
+		if record.Status != models.AccessRequestStatusPending && !retry {
+			return ErrNotPending
+		}
+		if record.Status == models.AccessRequestStatusPending && !time.Now().Before(record.ExpiresAt) {
+			return ErrNotPending
+		}
+		// Neither the user nor whoever filed the request on their behalf
+		// may decide it, even when they are approvers of the resource.
+		if approverID == record.UserID || approverID == record.RequestedBy ||
+			!slices.Contains(record.Approvers, approverID) {
+			return ErrNotApprover
+		}
+
+		now := time.Now().UTC()
+		record.Status = status
+		record.DecidedBy = approverID
+		record.DecisionComment = comment
+		record.DecidedAt = &now
+		return nil
+	})
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &accessRequest, nil
+}
+
+func (s *Service) grant(ctx context.Context, accessRequest *models.AccessRequest) error {
+	if accessRequest.ResourceType == models.ResourceTypeRole {
+		_, err := s.rolesSvc.AssignRoleToUser(ctx, accessRequest.UserID, accessRequest.ResourceID)
+		return err
+	}
+	return s.groupsSvc.AddUserToGroup(ctx, accessRequest.ResourceID, accessRequest.UserID)
+}
+
+func (s *Service) approvers(ctx context.Context, resourceType, resourceID string) ([]string, error) {
+	var approvers []string
+
+	if resourceType == models.ResourceTypeRole {
+		approvers = append(approvers, s.cfg.RoleApprovers[resourceID]...)
+	} else {
+		owners, err := s.groupsSvc.GetGroupOwners(ctx, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		approvers = append(approvers, owners...)
+		approvers = append(approvers, s.cfg.GroupApprovers[resourceID]...)
+	}
+
+	approvers = append(approvers, s.cfg.Admins...)
+	slices.Sort(approvers)
+	return slices.Compact(approvers), nil
+}
+
+func (s *Service) notify(ctx context.Context, eventType string, accessRequest *models.AccessRequest, recipients []string, message string) {
+	slices.Sort(recipients)
+	event := notify.Event{
+		Type:       eventType,
+		Subject:    accessRequest.ID,
+		Message:    message,
+		Recipients: slices.Compact(recipients),
+		Data:       accessRequest,
+		Time:       time.Now().UTC(),
+	}
+
+	if err := s.notifier.Notify(ctx, event); err != nil {
+		s.log.Infow("Failed to send access request notification", zap.Error(err),
+			"accessRequestId", accessRequest.ID, "type", eventType,
+		)
+	}
+}
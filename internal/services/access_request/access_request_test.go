@@ -0,0 +1,309 @@
+package access_request_service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+func TestDenyAccessRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  models.AccessRequest
+		approver string
+		err      error
+	}{
+		{
+			name:     "approver",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol"}},
+			approver: "carol",
+		},
+		{
+			name:     "user for themselves",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "alice", Approvers: []string{"alice", "carol"}},
+			approver: "alice",
+			err:      ErrNotApprover,
+		},
+		{
+			name:     "requester on behalf of someone else",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "bob", Approvers: []string{"bob", "carol"}},
+			approver: "bob",
+			err:      ErrNotApprover,
+		},
+		{
+			name:     "user who did not file the request",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "bob", Approvers: []string{"alice", "carol"}},
+			approver: "alice",
+			err:      ErrNotApprover,
+		},
+		{
+			name:     "not an approver",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol"}},
+			approver: "dave",
+			err:      ErrNotApprover,
+		},
+		{
+			name:     "no caller",
+			request:  models.AccessRequest{UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol"}},
+			approver: "",
+			err:      ErrNotApprover,
+		},
+		{
+			name: "expired",
+			request: models.AccessRequest{
+				UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol"},
+				ExpiresAt: time.Now().Add(-time.Minute),
+			},
+			approver: "carol",
+			err:      ErrNotPending,
+		},
+		{
+			name: "already decided",
+			request: models.AccessRequest{
+				UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol"},
+				Status: models.AccessRequestStatusDenied,
+			},
+			approver: "carol",
+			err:      ErrNotPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			requests, err := store.New[models.AccessRequest]("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := zap.NewNop().Sugar()
+			svc := New(log, nil, requests, notify.NewLogNotifier(log), nil, nil)
+
+			record := tt.request
+			record.ID = "req1"
+			if record.Status == "" {
+				record.Status = models.AccessRequestStatusPending
+			}
+			if record.ExpiresAt.IsZero() {
+				record.ExpiresAt = time.Now().Add(time.Hour)
+			}
+			if err := requests.Put(ctx, record.ID, record); err != nil {
+				t.Fatal(err)
+			}
+
+			denied, err := svc.DenyAccessRequest(ctx, record.ID, tt.approver, "")
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				if stored, _ := requests.Get(record.ID); stored.Status != record.Status {
+					t.Fatalf("status = %s, want it unchanged at %s", stored.Status, record.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if denied.Status != models.AccessRequestStatusDenied || denied.DecidedBy != tt.approver {
+				t.Fatalf("got status %s decided by %s", denied.Status, denied.DecidedBy)
+			}
+		})
+	}
+}
+
+func TestApproveAccessRequestRejectsRequester(t *testing.T) {
+	ctx := context.Background()
+	requests, err := store.New[models.AccessRequest]("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.NewNop().Sugar()
+	// No role or group service is wired in, so a grant would panic.
+	svc := New(log, nil, requests, notify.NewLogNotifier(log), nil, nil)
+
+	record := models.AccessRequest{
+		ID: "req1", UserID: "alice", RequestedBy: "bob", Approvers: []string{"bob"},
+		ResourceType: models.ResourceTypeGroup, ResourceID: "00g1",
+		Status: models.AccessRequestStatusPending, ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := requests.Put(ctx, record.ID, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ApproveAccessRequest(ctx, record.ID, "bob", ""); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("error = %v, want %v", err, ErrNotApprover)
+	}
+}
+
+func TestCreateAccessRequest(t *testing.T) {
+	cfg := &config.AccessRequestConfig{
+		TTL:           time.Hour,
+		Admins:        []string{"erin", "carol"},
+		RoleApprovers: map[string][]string{"SUPER_ADMIN": {"carol", "dave"}, "ORG_ADMIN": {}},
+	}
+
+	tests := []struct {
+		name        string
+		requesterID string
+		req         models.CreateAccessRequestRequest
+		want        *models.AccessRequest
+		err         error
+	}{
+		{
+			name:        "for the caller",
+			requesterID: "alice",
+			req:         models.CreateAccessRequestRequest{ResourceType: models.ResourceTypeRole, ResourceID: "SUPER_ADMIN", Justification: "On call"},
+			want: &models.AccessRequest{
+				UserID: "alice", RequestedBy: "alice", Approvers: []string{"carol", "dave", "erin"},
+			},
+		},
+		{
+			name:        "on behalf of someone else",
+			requesterID: "bob",
+			req:         models.CreateAccessRequestRequest{UserID: "alice", ResourceType: models.ResourceTypeRole, ResourceID: "ORG_ADMIN", Justification: "On call"},
+			want: &models.AccessRequest{
+				UserID: "alice", RequestedBy: "bob", Approvers: []string{"carol", "erin"},
+			},
+		},
+		{
+			name: "no user",
+			req:  models.CreateAccessRequestRequest{ResourceType: models.ResourceTypeRole, ResourceID: "SUPER_ADMIN", Justification: "On call"},
+			err:  ErrInvalidRequest,
+		},
+		{
+			name:        "unknown resource type",
+			requesterID: "alice",
+			req:         models.CreateAccessRequestRequest{ResourceType: "app", ResourceID: "0oa1", Justification: "On call"},
+			err:         ErrInvalidRequest,
+		},
+		{
+			name:        "no resource",
+			requesterID: "alice",
+			req:         models.CreateAccessRequestRequest{ResourceType: models.ResourceTypeRole, Justification: "On call"},
+			err:         ErrInvalidRequest,
+		},
+		{
+			name:        "blank justification",
+			requesterID: "alice",
+			req:         models.CreateAccessRequestRequest{ResourceType: models.ResourceTypeRole, ResourceID: "SUPER_ADMIN", Justification: " "},
+			err:         ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := store.New[models.AccessRequest]("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := zap.NewNop().Sugar()
+			svc := New(log, cfg, requests, notify.NewLogNotifier(log), nil, nil)
+
+			got, err := svc.CreateAccessRequest(context.Background(), tt.requesterID, &tt.req)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.UserID != tt.want.UserID || got.RequestedBy != tt.want.RequestedBy ||
+				!reflect.DeepEqual(got.Approvers, tt.want.Approvers) {
+				t.Fatalf("request = %+v, want %+v", got, tt.want)
+			}
+			if got.Status != models.AccessRequestStatusPending || !got.ExpiresAt.Equal(got.Created.Add(cfg.TTL)) {
+				t.Fatalf("request = %+v, want it pending for %s", got, cfg.TTL)
+			}
+		})
+	}
+}
+
+func TestGetAccessRequests(t *testing.T) {
+	ctx := context.Background()
+	requests, err := store.New[models.AccessRequest]("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.NewNop().Sugar()
+	svc := New(log, nil, requests, notify.NewLogNotifier(log), nil, nil)
+
+	for _, record := range []models.AccessRequest{
+		{ID: "req1", UserID: "alice", Approvers: []string{"carol"}, Status: models.AccessRequestStatusPending},
+		{ID: "req2", UserID: "alice", Approvers: []string{"dave"}, Status: models.AccessRequestStatusDenied},
+		{ID: "req3", UserID: "bob", Approvers: []string{"carol", "dave"}, Status: models.AccessRequestStatusPending},
+	} {
+		if err := requests.Put(ctx, record.ID, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "everything", want: []string{"req1", "req2", "req3"}},
+		{name: "status ignores case", filter: Filter{Status: "pending"}, want: []string{"req1", "req3"}},
+		{name: "by user", filter: Filter{UserID: "alice"}, want: []string{"req1", "req2"}},
+		{name: "by approver", filter: Filter{Approver: "dave"}, want: []string{"req2", "req3"}},
+		{name: "no match", filter: Filter{UserID: "bob", Approver: "erin"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, accessRequest := range svc.GetAccessRequests(ctx, tt.filter) {
+				got = append(got, accessRequest.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("GetAccessRequests(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpirePendingRequests(t *testing.T) {
+	ctx := context.Background()
+	requests, err := store.New[models.AccessRequest]("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.NewNop().Sugar()
+	svc := New(log, nil, requests, notify.NewLogNotifier(log), nil, nil)
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	for _, record := range []models.AccessRequest{
+		{ID: "req1", Status: models.AccessRequestStatusPending, ExpiresAt: past},
+		{ID: "req2", Status: models.AccessRequestStatusPending, ExpiresAt: future},
+		{ID: "req3", Status: models.AccessRequestStatusDenied, ExpiresAt: past},
+	} {
+		if err := requests.Put(ctx, record.ID, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := svc.ExpirePendingRequests(ctx); got != 1 {
+		t.Fatalf("ExpirePendingRequests() = %d, want 1", got)
+	}
+
+	want := map[string]string{
+		"req1": models.AccessRequestStatusExpired,
+		"req2": models.AccessRequestStatusPending,
+		"req3": models.AccessRequestStatusDenied,
+	}
+	for id, status := range want {
+		if stored, _ := requests.Get(id); stored.Status != status {
+			t.Fatalf("%s status = %s, want %s", id, stored.Status, status)
+		}
+	}
+}
@@ -0,0 +1,701 @@
+package access_review_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid access review request")
+	ErrNotFound       = errors.New("campaign not found")
+	ErrItemNotFound   = errors.New("review item not found")
+	ErrNotReviewer    = errors.New("caller is not a reviewer for this item")
+	ErrNotAllowed     = errors.New("caller is not the owner or signer of this campaign")
+	ErrSelfCertified  = errors.New("caller decided items of this campaign and cannot sign it off")
+	ErrWrongStatus    = errors.New("campaign is not in the required status")
+)
+
+type Service struct {
+	log       *zap.SugaredLogger
+	store     *store.Store[models.Campaign]
+	notifier  notify.Notifier
+	auditSvc  *audit_service.Service
+	usersSvc  *user_service.Service
+	rolesSvc  *role_service.Service
+	groupsSvc *group_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	store *store.Store[models.Campaign],
+	notifier notify.Notifier,
+	auditSvc *audit_service.Service,
+	usersSvc *user_service.Service,
+	rolesSvc *role_service.Service,
+	groupsSvc *group_service.Service,
+) *Service {
+	return &Service{
+		log:       log,
+		store:     store,
+		notifier:  notifier,
+		auditSvc:  auditSvc,
+		usersSvc:  usersSvc,
+		rolesSvc:  rolesSvc,
+		groupsSvc: groupsSvc,
+	}
+}
+
+// CreateCampaign stores a new campaign and snapshots the assignments under
+// review in the background. The campaign opens once the snapshot completes.
+func (s *Service) CreateCampaign(ctx context.Context, createdBy string, req *models.CreateCampaignRequest) (*models.Campaign, error) {
+	ctx, span := tracing.Start(ctx, "access_review_service.CreateCampaign")
+	defer span.End()
+
+	s.log.Infow("Creating access review campaign", "name", req.Name)
+
+	strategy := req.ReviewerStrategy
+	if strategy == "" {
+		strategy = models.ReviewerStrategyOwner
+	}
+
+	switch {
+	case createdBy == "":
+		return nil, fmt.Errorf("%w: caller identity is required to own a campaign", ErrInvalidRequest)
+	case strings.TrimSpace(req.Name) == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	case len(req.RoleIDs) == 0 && len(req.GroupIDs) == 0:
+		return nil, fmt.Errorf("%w: at least one role or group is required", ErrInvalidRequest)
+	case strategy != models.ReviewerStrategyManager && strategy != models.ReviewerStrategyOwner &&
+		strategy != models.ReviewerStrategyFixed:
+		return nil, fmt.Errorf("%w: reviewerStrategy must be manager, owner or fixed", ErrInvalidRequest)
+	case strategy == models.ReviewerStrategyFixed && len(req.Reviewers) == 0:
+		return nil, fmt.Errorf("%w: reviewers are required for the fixed strategy", ErrInvalidRequest)
+	}
+
+	campaign := models.Campaign{
+		ID:               store.NewID(),
+		Name:             req.Name,
+		Description:      req.Description,
+		RoleIDs:          req.RoleIDs,
+		GroupIDs:         req.GroupIDs,
+		ReviewerStrategy: strategy,
+		Reviewers:        req.Reviewers,
+		Signer:           req.Signer,
+		Status:           models.CampaignStatusSnapshotting,
+		CreatedBy:        createdBy,
+		Created:          time.Now().UTC(),
+		DueAt:            req.DueAt,
+	}
+
+	if err := s.store.Put(ctx, campaign.ID, campaign); err != nil {
+		return nil, fmt.Errorf("failed to store campaign: %w", err)
+	}
+
+	// The snapshot walks every member and role holder and can outlive the request.
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Call{System: "access_review", Operation: "snapshot", Resource: campaign.ID})
+	} else {
+		go s.snapshot(context.WithoutCancel(ctx), campaign)
+	}
+
+	s.log.Infow("Access review campaign created", "campaignId", campaign.ID)
+	return &campaign, nil
+}
+
+func (s *Service) GetCampaign(ctx context.Context, campaignID string) (*models.Campaign, error) {
+	_, span := tracing.Start(ctx, "access_review_service.GetCampaign", tracing.CampaignID.String(campaignID))
+	defer span.End()
+
+	campaign, ok := s.store.Get(campaignID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &campaign, nil
+}
+
+// GetCampaigns returns all campaigns without their items, newest first.
+func (s *Service) GetCampaigns(ctx context.Context) []*models.Campaign {
+	_, span := tracing.Start(ctx, "access_review_service.GetCampaigns")
+	defer span.End()
+
+	result := []*models.Campaign{}
+	for _, campaign := range s.store.List() {
+		campaign.Items = nil
+		result = append(result, &campaign)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Created.After(result[j].Created) })
+	return result
+}
+
+// GetItems returns the items of a campaign, optionally only those assigned to
+// reviewer and only those still awaiting a decision.
+func (s *Service) GetItems(ctx context.Context, campaignID, reviewer string, pendingOnly bool) ([]models.ReviewItem, error) {
+	_, span := tracing.Start(ctx, "access_review_service.GetItems", tracing.CampaignID.String(campaignID))
+	defer span.End()
+
+	campaign, ok := s.store.Get(campaignID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	result := []models.ReviewItem{}
+	for _, item := range campaign.Items {
+		if reviewer != "" && !slices.Contains(item.Reviewers, reviewer) {
+			continue
+		}
+		if pendingOnly && item.Decision != "" {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (s *Service) DecideItem(ctx context.Context, campaignID, itemID, reviewerID string, req *models.ReviewDecisionRequest) (*models.ReviewItem, error) {
+	ctx, span := tracing.Start(ctx, "access_review_service.DecideItem", tracing.CampaignID.String(campaignID), tracing.ItemID.String(itemID))
+	defer span.End()
+
+	s.log.Infow("Recording review decision", "campaignId", campaignID, "itemId", itemID,
+		"reviewerId", reviewerID, "decision", req.Decision,
+	)
+
+	decision := strings.ToUpper(req.Decision)
+	if decision != models.ReviewDecisionKeep && decision != models.ReviewDecisionRevoke {
+		return nil, fmt.Errorf("%w: decision must be KEEP or REVOKE", ErrInvalidRequest)
+	}
+
+	var decided models.ReviewItem
+	_, err := s.store.Update(ctx, campaignID, func(campaign *models.Campaign) error {
+		if campaign.Status != models.CampaignStatusOpen {
+			return ErrWrongStatus
+		}
+
+		index := slices.IndexFunc(campaign.Items, func(item models.ReviewItem) bool { return item.ID == itemID })
+		if index < 0 {
+			return ErrItemNotFound
+		}
+
+		items := slices.Clone(campaign.Items)
+		item := &items[index]
+		if reviewerID == "" || !slices.Contains(item.Reviewers, reviewerID) {
+			return ErrNotReviewer
+		}
+
+		now := time.Now().UTC()
+		item.Decision = decision
+		item.Comment = req.Comment
+		item.DecidedBy = reviewerID
+		item.DecidedAt = &now
+
+		campaign.Items = items
+		decided = *item
+		return nil
+	})
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Review decision recorded", "campaignId", campaignID, "itemId", itemID)
+	return &decided, nil
+}
+
+// CloseCampaign stops accepting decisions and applies every REVOKE decision
+// through the role and group services. Undecided items are kept and reported.
+func (s *Service) CloseCampaign(ctx context.Context, campaignID, closedBy string) (*models.Campaign, error) {
+	ctx, span := tracing.Start(ctx, "access_review_service.CloseCampaign", tracing.CampaignID.String(campaignID))
+	defer span.End()
+
+	s.log.Infow("Closing access review campaign", "campaignId", campaignID, "closedBy", closedBy)
+
+	campaign, err := s.store.Update(ctx, campaignID, func(campaign *models.Campaign) error {
+		if !canFinish(campaign, closedBy) {
+			return ErrNotAllowed
+		}
+		if campaign.Status != models.CampaignStatusOpen {
+			return ErrWrongStatus
+		}
+
+		now := time.Now().UTC()
+		campaign.Status = models.CampaignStatusClosed
+		campaign.ClosedBy = closedBy
+		campaign.ClosedAt = &now
+		return nil
+	})
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := slices.Clone(campaign.Items)
+	for i := range items {
+		if items[i].Decision != models.ReviewDecisionRevoke {
+			continue
+		}
+
+		outcome, errMsg := models.AuditOutcomeSuccess, ""
+		if err := s.revoke(ctx, &items[i]); err != nil {
+			s.log.Infow("Failed to apply review revocation", zap.Error(err),
+				"campaignId", campaignID, "itemId", items[i].ID,
+			)
+			items[i].RevokeError = err.Error()
+			outcome, errMsg = models.AuditOutcomeFailure, err.Error()
+		} else {
+			items[i].Revoked = true
+		}
+
+		s.auditSvc.Record(ctx, models.AuditEntry{
+			Actor:      items[i].DecidedBy,
+			Action:     "access_review.revoke",
+			TargetType: items[i].PrincipalType,
+			TargetID:   items[i].PrincipalID,
+			Outcome:    outcome,
+			Reason:     items[i].Comment,
+			Error:      errMsg,
+			Details: map[string]any{
+				"campaignId":   campaignID,
+				"resourceType": items[i].ResourceType,
+				"resourceId":   items[i].ResourceID,
+			},
+		})
+	}
+
+	campaign, err = s.store.Update(ctx, campaignID, func(record *models.Campaign) error {
+		record.Items = items
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store campaign: %w", err)
+	}
+
+	s.log.Infow("Access review campaign closed", "campaignId", campaignID)
+	return &campaign, nil
+}
+
+// SignOff records the final approval of a closed campaign together with the
+// digest of its report, so later changes to the results can be detected.
+func (s *Service) SignOff(ctx context.Context, campaignID, signedOffBy, comment string) (*models.CampaignReport, error) {
+	ctx, span := tracing.Start(ctx, "access_review_service.SignOff", tracing.CampaignID.String(campaignID))
+	defer span.End()
+
+	s.log.Infow("Signing off access review campaign", "campaignId", campaignID, "signedOffBy", signedOffBy)
+
+	campaign, err := s.store.Update(ctx, campaignID, func(campaign *models.Campaign) error {
+		if !canFinish(campaign, signedOffBy) {
+			return ErrNotAllowed
+		}
+		if campaign.Status != models.CampaignStatusClosed {
+			return ErrWrongStatus
+		}
+		if slices.ContainsFunc(campaign.Items, func(item models.ReviewItem) bool {
+			return item.DecidedBy == signedOffBy
+		}) {
+			return ErrSelfCertified
+		}
+
+		digest, err := reportDigest(buildReport(campaign))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		campaign.Status = models.CampaignStatusSignedOff
+		campaign.SignedOffBy = signedOffBy
+		campaign.SignedOffAt = &now
+		campaign.SignOffComment = comment
+		campaign.SignOffDigest = digest
+		return nil
+	})
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.report(&campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.Record(ctx, models.AuditEntry{
+		Actor:      signedOffBy,
+		Action:     "access_review.sign_off",
+		TargetType: "campaign",
+		TargetID:   campaignID,
+		Outcome:    models.AuditOutcomeSuccess,
+		Reason:     comment,
+		Details:    map[string]any{"digest": report.Digest},
+	})
+
+	s.log.Infow("Access review campaign signed off", "campaignId", campaignID)
+	return report, nil
+}
+
+// canFinish reports whether userID may close or sign off the campaign: its
+// owner or its named signer. Reviewers may not, so they cannot certify their
+// own decisions.
+func canFinish(campaign *models.Campaign, userID string) bool {
+	return userID != "" && (userID == campaign.CreatedBy || userID == campaign.Signer)
+}
+
+func (s *Service) GetReport(ctx context.Context, campaignID string) (*models.CampaignReport, error) {
+	_, span := tracing.Start(ctx, "access_review_service.GetReport", tracing.CampaignID.String(campaignID))
+	defer span.End()
+
+	campaign, ok := s.store.Get(campaignID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.report(&campaign)
+}
+
+func (s *Service) report(campaign *models.Campaign) (*models.CampaignReport, error) {
+	report := buildReport(campaign)
+
+	digest, err := reportDigest(report)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Digest = digest
+	report.SignedOffAt = campaign.SignedOffAt
+	report.SignedOffBy = campaign.SignedOffBy
+	report.SignOffComment = campaign.SignOffComment
+	return report, nil
+}
+
+func buildReport(campaign *models.Campaign) *models.CampaignReport {
+	report := &models.CampaignReport{
+		CampaignID: campaign.ID,
+		Name:       campaign.Name,
+		Status:     campaign.Status,
+		Created:    campaign.Created,
+		CreatedBy:  campaign.CreatedBy,
+		ClosedAt:   campaign.ClosedAt,
+		ClosedBy:   campaign.ClosedBy,
+		Total:      len(campaign.Items),
+		Items:      campaign.Items,
+	}
+
+	for _, item := range campaign.Items {
+		switch {
+		case item.Decision == "":
+			report.Undecided++
+		case item.Decision == models.ReviewDecisionKeep:
+			report.Kept++
+		case item.Revoked:
+			report.Revoked++
+		case item.RevokeError != "":
+			report.RevokeFailed++
+		default:
+			report.PendingRevocation++
+		}
+	}
+	return report
+}
+
+// reportDigest hashes the review content of the report. Status and sign-off
+// fields are left out so the digest stays stable across sign-off.
+func reportDigest(report *models.CampaignReport) (string, error) {
+	content := *report
+	content.Status = ""
+	content.Digest = ""
+	content.SignedOffAt = nil
+	content.SignedOffBy = ""
+	content.SignOffComment = ""
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *Service) revoke(ctx context.Context, item *models.ReviewItem) error {
+	switch {
+	case item.ResourceType == models.ResourceTypeGroup:
+		return s.groupsSvc.RemoveUserFromGroup(ctx, item.ResourceID, item.PrincipalID)
+	case item.PrincipalType == models.PrincipalTypeGroup:
+		return s.rolesSvc.UnassignRoleFromGroup(ctx, item.PrincipalID, item.ResourceID)
+	default:
+		return s.rolesSvc.UnassignRoleFromUser(ctx, item.PrincipalID, item.ResourceID)
+	}
+}
+
+// Recover fails the campaigns whose snapshot was interrupted by a restart, as
+// they would otherwise stay SNAPSHOTTING for good. It must run before any
+// campaign is created.
+func (s *Service) Recover(ctx context.Context) {
+	for _, campaign := range s.store.List() {
+		if campaign.Status == models.CampaignStatusSnapshotting {
+			s.fail(ctx, campaign.ID, errors.New("snapshot was interrupted by a restart"))
+		}
+	}
+}
+
+func (s *Service) snapshot(ctx context.Context, campaign models.Campaign) {
+	s.log.Infow("Snapshotting assignments for access review", "campaignId", campaign.ID)
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while snapshotting access review", "campaignId", campaign.ID,
+				"panic", recovered, "stack", string(debug.Stack()),
+			)
+			s.fail(ctx, campaign.ID, fmt.Errorf("snapshot failed: %v", recovered))
+		}
+	}()
+
+	items, err := s.collectItems(ctx, &campaign)
+	if err != nil {
+		s.fail(ctx, campaign.ID, err)
+		return
+	}
+
+	updated, err := s.store.Update(ctx, campaign.ID, func(record *models.Campaign) error {
+		record.Items = items
+		record.Status = models.CampaignStatusOpen
+		return nil
+	})
+	if err != nil {
+		s.fail(ctx, campaign.ID, fmt.Errorf("failed to store snapshot: %w", err))
+		return
+	}
+
+	s.notifyReviewers(ctx, &updated)
+	s.log.Infow("Access review campaign opened", "campaignId", campaign.ID, "itemCount", len(items))
+}
+
+// fail moves a campaign whose snapshot could not be taken to FAILED.
+func (s *Service) fail(ctx context.Context, campaignID string, err error) {
+	s.log.Infow("Failed to snapshot access review", zap.Error(err), "campaignId", campaignID)
+
+	_, updateErr := s.store.Update(ctx, campaignID, func(record *models.Campaign) error {
+		record.Status = models.CampaignStatusFailed
+		record.Error = err.Error()
+		return nil
+	})
+	if updateErr != nil {
+		s.log.Infow("Failed to mark access review as failed", zap.Error(updateErr), "campaignId", campaignID)
+	}
+}
+
+func (s *Service) collectItems(ctx context.Context, campaign *models.Campaign) ([]models.ReviewItem, error) {
+	var items []models.ReviewItem
+	users := map[string]*models.User{}
+
+	lookupUser := func(userID string) *models.User {
+		if user, ok := users[userID]; ok {
+			return user
+		}
+		user, err := s.usersSvc.GetUser(ctx, userID)
+		if err != nil {
+			user = &models.User{ID: userID}
+		}
+		users[userID] = user
+		return user
+	}
+
+	for _, groupID := range campaign.GroupIDs {
+		group, err := s.groupsSvc.GetGroup(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+
+		members, err := s.groupsSvc.GetGroupMembers(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+
+		var owners []string
+		if campaign.ReviewerStrategy == models.ReviewerStrategyOwner {
+			if owners, err = s.groupsSvc.GetGroupOwners(ctx, groupID); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, member := range members {
+			users[member.ID] = member
+			items = append(items, models.ReviewItem{
+				ID:            store.NewID(),
+				PrincipalType: models.PrincipalTypeUser,
+				PrincipalID:   member.ID,
+				PrincipalName: member.Login,
+				ResourceType:  models.ResourceTypeGroup,
+				ResourceID:    groupID,
+				ResourceName:  group.Name,
+				Reviewers:     s.reviewers(campaign, member, owners),
+			})
+		}
+	}
+
+	if len(campaign.RoleIDs) == 0 {
+		return items, nil
+	}
+
+	// Standard roles are assigned by type, e.g. USER_ADMIN, so a campaign may
+	// name them by type as well as by assignment ID or label.
+	matches := func(role *models.Role) bool {
+		return slices.Contains(campaign.RoleIDs, role.ID) || slices.Contains(campaign.RoleIDs, role.Type) ||
+			slices.Contains(campaign.RoleIDs, role.Name)
+	}
+
+	userIDs, err := s.rolesSvc.GetUsersWithRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range userIDs {
+		roles, err := s.rolesSvc.GetUserRoles(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range roles {
+			if !matches(role) {
+				continue
+			}
+
+			user := lookupUser(userID)
+			items = append(items, models.ReviewItem{
+				ID:            store.NewID(),
+				PrincipalType: models.PrincipalTypeUser,
+				PrincipalID:   userID,
+				PrincipalName: user.Login,
+				ResourceType:  models.ResourceTypeRole,
+				ResourceID:    role.ID,
+				ResourceName:  role.Name,
+				Reviewers:     s.reviewers(campaign, user, nil),
+			})
+		}
+	}
+
+	groups, err := s.groupsSvc.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		roles, err := s.rolesSvc.GetGroupRoles(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range roles {
+			if !matches(role) {
+				continue
+			}
+
+			var owners []string
+			if campaign.ReviewerStrategy == models.ReviewerStrategyOwner {
+				if owners, err = s.groupsSvc.GetGroupOwners(ctx, group.ID); err != nil {
+					return nil, err
+				}
+			}
+
+			items = append(items, models.ReviewItem{
+				ID:            store.NewID(),
+				PrincipalType: models.PrincipalTypeGroup,
+				PrincipalID:   group.ID,
+				PrincipalName: group.Name,
+				ResourceType:  models.ResourceTypeRole,
+				ResourceID:    role.ID,
+				ResourceName:  role.Name,
+				Reviewers:     s.reviewers(campaign, nil, owners),
+			})
+		}
+	}
+
+	// An item nobody can decide would only be kept by default at close.
+	for _, item := range items {
+		if len(item.Reviewers) == 0 {
+			return nil, fmt.Errorf("no reviewer other than the principal for %s %s of %s %s",
+				item.ResourceType, item.ResourceID, item.PrincipalType, item.PrincipalID,
+			)
+		}
+	}
+	return items, nil
+}
+
+// reviewers picks the reviewers of an item according to the campaign strategy,
+// falling back to the campaign reviewers and then to the campaign owner when
+// the strategy yields nobody.
+func (s *Service) reviewers(campaign *models.Campaign, user *models.User, owners []string) []string {
+	var reviewers []string
+
+	switch campaign.ReviewerStrategy {
+	case models.ReviewerStrategyManager:
+		if user != nil && user.ManagerID != "" {
+			reviewers = []string{user.ManagerID}
+		}
+	case models.ReviewerStrategyOwner:
+		reviewers = slices.Clone(owners)
+	}
+
+	// Nobody reviews their own access.
+	notSelf := func(reviewers []string) []string {
+		if user == nil {
+			return reviewers
+		}
+		return slices.DeleteFunc(reviewers, func(id string) bool { return id == user.ID })
+	}
+
+	if reviewers = notSelf(reviewers); len(reviewers) == 0 {
+		reviewers = notSelf(slices.Clone(campaign.Reviewers))
+	}
+	if len(reviewers) == 0 && campaign.CreatedBy != "" {
+		reviewers = notSelf([]string{campaign.CreatedBy})
+	}
+	return reviewers
+}
+
+func (s *Service) notifyReviewers(ctx context.Context, campaign *models.Campaign) {
+	var reviewers []string
+	for _, item := range campaign.Items {
+		reviewers = append(reviewers, item.Reviewers...)
+	}
+	slices.Sort(reviewers)
+
+	event := notify.Event{
+		Type:       "access_review.opened",
+		Subject:    campaign.ID,
+		Message:    fmt.Sprintf("Access review %q is open with %d items to review", campaign.Name, len(campaign.Items)),
+		Recipients: slices.Compact(reviewers),
+		Time:       time.Now().UTC(),
+	}
+
+	if err := s.notifier.Notify(ctx, event); err != nil {
+		s.log.Infow("Failed to notify reviewers", zap.Error(err), "campaignId", campaign.ID)
+	}
+}
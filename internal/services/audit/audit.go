@@ -0,0 +1,83 @@
+package audit_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+// Filter narrows the entries returned by GetAuditEntries. Empty fields match everything.
+type Filter struct {
+	Actor    string
+	Action   string
+	TargetID string
+	Since    time.Time
+}
+
+type Service struct {
+	log   *zap.SugaredLogger
+	store *store.Store[models.AuditEntry]
+}
+
+func New(log *zap.SugaredLogger, store *store.Store[models.AuditEntry]) *Service {
+	return &Service{log: log, store: store}
+}
+
+// Record persists an audit entry. ID and Time are filled in, and Actor
+// defaults to the caller in ctx.
+func (s *Service) Record(ctx context.Context, entry models.AuditEntry) (*models.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "audit_service.Record")
+	defer span.End()
+
+	entry.ID = store.NewID()
+	entry.Time = time.Now().UTC()
+	if entry.Actor == "" {
+		entry.Actor = actor.FromContext(ctx)
+	}
+
+	if err := s.store.Put(ctx, entry.ID, entry); err != nil {
+		s.log.Infow("Failed to record audit entry", zap.Error(err),
+			"action", entry.Action, "targetId", entry.TargetID,
+		)
+		return nil, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	s.log.Infow("Audit entry recorded", "auditId", entry.ID, "action", entry.Action,
+		"actor", entry.Actor, "targetId", entry.TargetID, "outcome", entry.Outcome,
+	)
+	return &entry, nil
+}
+
+// GetAuditEntries returns matching entries, oldest first.
+func (s *Service) GetAuditEntries(ctx context.Context, filter Filter) []*models.AuditEntry {
+	_, span := tracing.Start(ctx, "audit_service.GetAuditEntries")
+	defer span.End()
+
+	result := []*models.AuditEntry{}
+	for _, entry := range s.store.List() {
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if filter.TargetID != "" && entry.TargetID != filter.TargetID {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			continue
+		}
+		result = append(result, &entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result
+}
@@ -0,0 +1,446 @@
+package break_glass_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotConfigured   = errors.New("break-glass is not configured")
+	ErrNotEligible     = errors.New("caller is not eligible for break-glass elevation")
+	ErrInvalidRequest  = errors.New("invalid break-glass request")
+	ErrAlreadyElevated = errors.New("caller already holds an active break-glass elevation")
+	ErrNotFound        = errors.New("elevation not found")
+	ErrNotActive       = errors.New("elevation is not active")
+)
+
+// Service runs the break-glass flow. Every step is persisted before the next
+// Okta call is made, and notifications go through a durable outbox, so a
+// restart mid-window neither loses the audit trail nor leaves the role assigned.
+type Service struct {
+	cfg      *config.BreakGlassConfig
+	log      *zap.SugaredLogger
+	store    *store.Store[models.Elevation]
+	notifier notify.Notifier
+	auditSvc *audit_service.Service
+	rolesSvc *role_service.Service
+
+	// mu makes the check for an existing elevation and the write of a new
+	// one atomic, so concurrent requests cannot both elevate.
+	mu sync.Mutex
+}
+
+func New(
+	log *zap.SugaredLogger,
+	cfg *config.BreakGlassConfig,
+	store *store.Store[models.Elevation],
+	notifier notify.Notifier,
+	auditSvc *audit_service.Service,
+	rolesSvc *role_service.Service,
+) *Service {
+	return &Service{
+		cfg:      cfg,
+		log:      log,
+		store:    store,
+		notifier: notifier,
+		auditSvc: auditSvc,
+		rolesSvc: rolesSvc,
+	}
+}
+
+func (s *Service) Elevate(ctx context.Context, userID string, req *models.CreateElevationRequest) (*models.Elevation, error) {
+	ctx, span := tracing.Start(ctx, "break_glass_service.Elevate", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Break-glass elevation requested", "userId", userID, "roleId", s.cfg.RoleID)
+
+	duration := s.cfg.DefaultDuration
+	if req.DurationMinutes != 0 {
+		duration = time.Duration(req.DurationMinutes) * time.Minute
+	}
+
+	switch {
+	case s.cfg.RoleID == "":
+		return nil, ErrNotConfigured
+	case userID == "":
+		return nil, ErrNotEligible
+	case !slices.Contains(s.cfg.EligibleUsers, userID):
+		return nil, ErrNotEligible
+	case strings.TrimSpace(req.Reason) == "":
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidRequest)
+	case duration <= 0 || duration > s.cfg.MaxDuration:
+		return nil, fmt.Errorf("%w: duration must be between 1 minute and %s", ErrInvalidRequest, s.cfg.MaxDuration)
+	}
+
+	// Whether the user already holds the role decides what the elevation may
+	// take away when it ends, so it is looked up and stored before anything
+	// is assigned.
+	heldBefore := false
+	if _, err := s.rolesSvc.FindUserAssignment(ctx, userID, s.cfg.RoleID); err == nil {
+		heldBefore = true
+	} else if !errors.Is(err, role_service.ErrNotAssigned) {
+		return nil, fmt.Errorf("failed to look up existing role assignment: %w", err)
+	}
+
+	now := time.Now().UTC()
+	elevation := models.Elevation{
+		ID:          store.NewID(),
+		UserID:      userID,
+		RoleID:      s.cfg.RoleID,
+		HeldBefore:  heldBefore,
+		Reason:      req.Reason,
+		Status:      models.ElevationStatusPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(duration),
+	}
+
+	// Persist the intent, the audit entry and the security alert before the
+	// role is assigned. If any of them cannot be written, nothing is granted.
+	if err := s.create(ctx, &elevation); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, userID, "break_glass.requested", &elevation, models.AuditOutcomeSuccess, ""); err != nil {
+		s.fail(ctx, userID, &elevation, err)
+		return nil, err
+	}
+
+	if err := s.notify(ctx, "break_glass.requested", &elevation,
+		fmt.Sprintf("BREAK-GLASS: %s is elevating to %s until %s. Reason: %s",
+			userID, elevation.RoleID, elevation.ExpiresAt.Format(time.RFC3339), req.Reason),
+	); err != nil {
+		s.fail(ctx, userID, &elevation, err)
+		return nil, fmt.Errorf("failed to queue security notification: %w", err)
+	}
+
+	var assignmentID string
+	if !heldBefore {
+		var err error
+		assignmentID, err = s.rolesSvc.AssignRoleToUser(ctx, userID, elevation.RoleID)
+		if err != nil {
+			s.fail(ctx, userID, &elevation, err)
+			return nil, fmt.Errorf("failed to assign emergency role: %w", err)
+		}
+	}
+
+	updated, err := s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+		activatedAt := time.Now().UTC()
+		record.Status = models.ElevationStatusActive
+		record.AssignmentID = assignmentID
+		record.ActivatedAt = &activatedAt
+		return nil
+	})
+	if err != nil {
+		// The role is assigned but the record still says PENDING. Recovery
+		// finds the assignment in Okta, and a PENDING elevation is revoked
+		// when its window ends either way.
+		return nil, fmt.Errorf("failed to store elevation: %w", err)
+	}
+
+	s.audit(ctx, userID, "break_glass.activated", &updated, models.AuditOutcomeSuccess, "")
+	s.log.Infow("Break-glass elevation active", "elevationId", updated.ID,
+		"userId", userID, "expiresAt", updated.ExpiresAt,
+	)
+	return &updated, nil
+}
+
+func (s *Service) GetElevation(ctx context.Context, elevationID string) (*models.Elevation, error) {
+	_, span := tracing.Start(ctx, "break_glass_service.GetElevation", tracing.ElevationID.String(elevationID))
+	defer span.End()
+
+	elevation, ok := s.store.Get(elevationID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &elevation, nil
+}
+
+// GetElevations returns elevations, newest first, optionally filtered by status.
+func (s *Service) GetElevations(ctx context.Context, status string) []*models.Elevation {
+	_, span := tracing.Start(ctx, "break_glass_service.GetElevations")
+	defer span.End()
+
+	result := []*models.Elevation{}
+	for _, elevation := range s.store.List() {
+		if status == "" || elevation.Status == status {
+			result = append(result, &elevation)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].RequestedAt.After(result[j].RequestedAt) })
+	return result
+}
+
+// RevokeElevation ends an active elevation before its window closes.
+func (s *Service) RevokeElevation(ctx context.Context, elevationID, revokedBy string) (*models.Elevation, error) {
+	ctx, span := tracing.Start(ctx, "break_glass_service.RevokeElevation", tracing.ElevationID.String(elevationID))
+	defer span.End()
+
+	elevation, ok := s.store.Get(elevationID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if elevation.Status != models.ElevationStatusActive && elevation.Status != models.ElevationStatusPending {
+		return nil, ErrNotActive
+	}
+
+	return s.revoke(ctx, &elevation, revokedBy)
+}
+
+// RevokeExpiredElevations revokes every elevation whose window has ended,
+// including PENDING ones whose assignment could not be looked up on recovery.
+// Failures are recorded on the elevation and retried on the next run.
+func (s *Service) RevokeExpiredElevations(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "break_glass_service.RevokeExpiredElevations")
+	defer span.End()
+
+	now := time.Now()
+	revoked := 0
+
+	for _, elevation := range s.store.List() {
+		if (elevation.Status != models.ElevationStatusActive && elevation.Status != models.ElevationStatusPending) ||
+			elevation.ExpiresAt.After(now) {
+			continue
+		}
+		if _, err := s.revoke(ctx, &elevation, "system"); err == nil {
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// Recover resumes elevations interrupted by a restart. Okta is asked whether
+// the role of a PENDING elevation was assigned: if so the elevation becomes
+// active and is revoked when its original window ends, otherwise it failed.
+// An elevation whose assignment cannot be looked up stays PENDING and is
+// revoked when its window ends. An elevation of a user who held the role
+// before assigned nothing and becomes active without a lookup.
+func (s *Service) Recover(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "break_glass_service.Recover")
+	defer span.End()
+
+	for _, elevation := range s.store.List() {
+		if elevation.Status != models.ElevationStatusPending {
+			continue
+		}
+
+		var assignmentID string
+		var err error
+		if !elevation.HeldBefore {
+			assignmentID, err = s.rolesSvc.FindUserAssignment(ctx, elevation.UserID, elevation.RoleID)
+		}
+		if errors.Is(err, role_service.ErrNotAssigned) {
+			s.log.Infow("Interrupted break-glass elevation was never assigned", "elevationId", elevation.ID)
+			s.fail(ctx, "system", &elevation, errors.New("interrupted by restart before the role was assigned"))
+			continue
+		}
+		if err != nil {
+			s.log.Infow("Failed to look up interrupted elevation in Okta", zap.Error(err), "elevationId", elevation.ID)
+			s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+				record.LastError = "interrupted by restart; assignment outcome unknown: " + err.Error()
+				return nil
+			})
+			continue
+		}
+
+		updated, err := s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+			activatedAt := time.Now().UTC()
+			record.Status = models.ElevationStatusActive
+			record.AssignmentID = assignmentID
+			record.ActivatedAt = &activatedAt
+			record.LastError = ""
+			return nil
+		})
+		if err != nil {
+			s.log.Infow("Failed to recover elevation", zap.Error(err), "elevationId", elevation.ID)
+			continue
+		}
+
+		s.log.Infow("Recovered interrupted break-glass elevation", "elevationId", updated.ID)
+		s.audit(ctx, "system", "break_glass.recovered", &updated, models.AuditOutcomeSuccess, "")
+	}
+}
+
+// Run recovers interrupted elevations and then revokes expired ones every
+// interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	s.tick(ctx, true)
+
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx, false)
+		}
+	}
+}
+
+// tick revokes expired elevations once, after reconciling the ones a restart
+// left pending when reconcile is set. A panic is logged instead of stopping
+// the loop, so the next tick tries again.
+func (s *Service) tick(ctx context.Context, reconcile bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while revoking expired break-glass elevations", "panic", recovered,
+				"stack", string(debug.Stack()),
+			)
+		}
+	}()
+
+	if reconcile {
+		s.Recover(ctx)
+	}
+	s.RevokeExpiredElevations(ctx)
+}
+
+func (s *Service) revoke(ctx context.Context, elevation *models.Elevation, revokedBy string) (*models.Elevation, error) {
+	s.log.Infow("Revoking break-glass elevation", "elevationId", elevation.ID, "userId", elevation.UserID)
+
+	if err := s.unassign(ctx, elevation); err != nil {
+		s.log.Infow("Failed to revoke break-glass elevation", zap.Error(err), "elevationId", elevation.ID)
+		s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+			record.LastError = err.Error()
+			return nil
+		})
+		s.audit(ctx, revokedBy, "break_glass.revoke_failed", elevation, models.AuditOutcomeFailure, err.Error())
+		return nil, fmt.Errorf("failed to revoke emergency role: %w", err)
+	}
+
+	updated, err := s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+		revokedAt := time.Now().UTC()
+		record.Status = models.ElevationStatusRevoked
+		record.RevokedAt = &revokedAt
+		record.RevokedBy = revokedBy
+		record.LastError = ""
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store elevation: %w", err)
+	}
+
+	s.audit(ctx, revokedBy, "break_glass.revoked", &updated, models.AuditOutcomeSuccess, "")
+	s.notify(ctx, "break_glass.revoked", &updated,
+		fmt.Sprintf("BREAK-GLASS: %s no longer holds %s (revoked by %s)", updated.UserID, updated.RoleID, revokedBy),
+	)
+
+	s.log.Infow("Break-glass elevation revoked", "elevationId", updated.ID)
+	return &updated, nil
+}
+
+// create stores a new PENDING elevation unless the user already holds one.
+func (s *Service) create(ctx context.Context, elevation *models.Elevation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.store.List() {
+		if existing.UserID == elevation.UserID &&
+			(existing.Status == models.ElevationStatusPending || existing.Status == models.ElevationStatusActive) {
+			return ErrAlreadyElevated
+		}
+	}
+
+	if err := s.store.Put(ctx, elevation.ID, *elevation); err != nil {
+		return fmt.Errorf("failed to store elevation: %w", err)
+	}
+	return nil
+}
+
+// unassign removes the emergency role by its assignment ID, looking the
+// assignment up when the ID was never stored. A role that is no longer
+// assigned counts as removed, and a role the user held before the elevation
+// is left in place.
+func (s *Service) unassign(ctx context.Context, elevation *models.Elevation) error {
+	if elevation.HeldBefore {
+		return nil
+	}
+
+	assignmentID := elevation.AssignmentID
+	if assignmentID == "" {
+		found, err := s.rolesSvc.FindUserAssignment(ctx, elevation.UserID, elevation.RoleID)
+		if errors.Is(err, role_service.ErrNotAssigned) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		assignmentID = found
+	}
+
+	err := s.rolesSvc.UnassignRoleFromUser(ctx, elevation.UserID, assignmentID)
+	if errors.Is(err, role_service.ErrNotAssigned) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) fail(ctx context.Context, actor string, elevation *models.Elevation, cause error) {
+	updated, err := s.store.Update(ctx, elevation.ID, func(record *models.Elevation) error {
+		record.Status = models.ElevationStatusFailed
+		record.LastError = cause.Error()
+		return nil
+	})
+	if err != nil {
+		s.log.Infow("Failed to store failed elevation", zap.Error(err), "elevationId", elevation.ID)
+		return
+	}
+
+	s.audit(ctx, actor, "break_glass.failed", &updated, models.AuditOutcomeFailure, cause.Error())
+	s.notify(ctx, "break_glass.failed", &updated,
+		fmt.Sprintf("BREAK-GLASS: elevation of %s to %s failed: %v", updated.UserID, updated.RoleID, cause),
+	)
+}
+
+func (s *Service) audit(ctx context.Context, actor, action string, elevation *models.Elevation, outcome, errMsg string) error {
+	_, err := s.auditSvc.Record(ctx, models.AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   elevation.UserID,
+		Outcome:    outcome,
+		Reason:     elevation.Reason,
+		Error:      errMsg,
+		Details: map[string]any{
+			"elevationId": elevation.ID,
+			"roleId":      elevation.RoleID,
+			"expiresAt":   elevation.ExpiresAt,
+			"heldBefore":  elevation.HeldBefore,
+		},
+	})
+	return err
+}
+
+func (s *Service) notify(ctx context.Context, eventType string, elevation *models.Elevation, message string) error {
+	err := s.notifier.Notify(ctx, notify.Event{
+		Type:       eventType,
+		Subject:    elevation.ID,
+		Message:    message,
+		Recipients: s.cfg.SecurityRecipients,
+		Data:       elevation,
+		Time:       time.Now().UTC(),
+	})
+	if err != nil {
+		s.log.Infow("Failed to queue break-glass notification", zap.Error(err), "elevationId", elevation.ID)
+	}
+	return err
+}
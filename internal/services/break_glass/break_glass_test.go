@@ -0,0 +1,82 @@
+package break_glass_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+)
+
+func TestElevateValidation(t *testing.T) {
+	cfg := &config.BreakGlassConfig{
+		RoleID:          "SUPER_ADMIN",
+		EligibleUsers:   []string{"00u1"},
+		DefaultDuration: time.Hour,
+		MaxDuration:     4 * time.Hour,
+	}
+
+	tests := []struct {
+		name   string
+		cfg    *config.BreakGlassConfig
+		userID string
+		req    models.CreateElevationRequest
+		err    error
+	}{
+		{
+			name:   "no role configured",
+			cfg:    &config.BreakGlassConfig{EligibleUsers: []string{"00u1"}, MaxDuration: time.Hour},
+			userID: "00u1",
+			req:    models.CreateElevationRequest{Reason: "Outage"},
+			err:    ErrNotConfigured,
+		},
+		{
+			name: "anonymous caller",
+			req:  models.CreateElevationRequest{Reason: "Outage"},
+			err:  ErrNotEligible,
+		},
+		{
+			name:   "caller not eligible",
+			userID: "00u2",
+			req:    models.CreateElevationRequest{Reason: "Outage"},
+			err:    ErrNotEligible,
+		},
+		{
+			name:   "blank reason",
+			userID: "00u1",
+			req:    models.CreateElevationRequest{Reason: "  "},
+			err:    ErrInvalidRequest,
+		},
+		{
+			name:   "negative duration",
+			userID: "00u1",
+			req:    models.CreateElevationRequest{Reason: "Outage", DurationMinutes: -5},
+			err:    ErrInvalidRequest,
+		},
+		{
+			name:   "duration over the maximum",
+			userID: "00u1",
+			req:    models.CreateElevationRequest{Reason: "Outage", DurationMinutes: 241},
+			err:    ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcCfg := cfg
+			if tt.cfg != nil {
+				svcCfg = tt.cfg
+			}
+			svc := New(zap.NewNop().Sugar(), svcCfg, nil, nil, nil, nil)
+
+			_, err := svc.Elevate(context.Background(), tt.userID, &tt.req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
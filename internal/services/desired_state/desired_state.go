@@ -0,0 +1,646 @@
+package desired_state_service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var ErrInvalidState = errors.New("invalid desired state")
+
+type Service struct {
+	log       *zap.SugaredLogger
+	usersSvc  *user_service.Service
+	groupsSvc *group_service.Service
+	rolesSvc  *role_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	usersSvc *user_service.Service,
+	groupsSvc *group_service.Service,
+	rolesSvc *role_service.Service,
+) *Service {
+	return &Service{log: log, usersSvc: usersSvc, groupsSvc: groupsSvc, rolesSvc: rolesSvc}
+}
+
+// Parse decodes a desired state document. JSON is accepted as well as YAML,
+// and unknown fields are rejected so typos do not silently drop settings.
+func Parse(data []byte) (*models.DesiredState, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var state models.DesiredState
+	if err := decoder.Decode(&state); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	return &state, nil
+}
+
+// live is the part of the Okta org a desired state is compared against.
+type live struct {
+	roles  map[string]*models.Role
+	groups map[string]*models.Group
+	users  map[string]*models.User
+}
+
+// Plan diffs state against live Okta and returns the changes in the order
+// they must be applied. With prune, groups and custom roles missing from the
+// state are deleted.
+func (s *Service) Plan(ctx context.Context, state *models.DesiredState, prune bool) (*models.Plan, error) {
+	ctx, span := tracing.Start(ctx, "desired_state_service.Plan")
+	defer span.End()
+
+	s.log.Infow("Planning desired state", "roles", len(state.Roles), "groups", len(state.Groups),
+		"users", len(state.Users), "prune", prune,
+	)
+
+	if err := validate(state); err != nil {
+		return nil, err
+	}
+
+	current, err := s.load(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.PlannedChange
+
+	for _, desired := range state.Roles {
+		role, ok := current.roles[desired.Name]
+		switch {
+		case !ok:
+			changes = append(changes, models.PlannedChange{
+				Action:      models.ChangeActionCreate,
+				Object:      models.ChangeObjectRole,
+				Description: fmt.Sprintf("create role %s", desired.Name),
+				RoleName:    desired.Name,
+				After:       desired,
+			})
+		case desired.Description != "" && desired.Description != role.Description:
+			changes = append(changes, models.PlannedChange{
+				Action:      models.ChangeActionUpdate,
+				Object:      models.ChangeObjectRole,
+				Description: fmt.Sprintf("update role %s", desired.Name),
+				RoleID:      role.ID,
+				RoleName:    desired.Name,
+				Before:      models.DesiredRole{Name: role.Name, Description: role.Description},
+				After:       desired,
+			})
+		}
+
+		if ok && desired.Permissions != nil {
+			permissions, err := s.rolesSvc.GetRolePermissions(ctx, role.ID)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, planPermissions(role, desired.Permissions, permissions)...)
+		}
+	}
+
+	for _, desired := range state.Groups {
+		group, ok := current.groups[desired.Name]
+		switch {
+		case !ok:
+			changes = append(changes, models.PlannedChange{
+				Action:      models.ChangeActionCreate,
+				Object:      models.ChangeObjectGroup,
+				Description: fmt.Sprintf("create group %s", desired.Name),
+				GroupName:   desired.Name,
+				After:       models.DesiredGroup{Name: desired.Name, Description: desired.Description, Profile: desired.Profile},
+			})
+		case groupChanged(group, desired):
+			changes = append(changes, models.PlannedChange{
+				Action:      models.ChangeActionUpdate,
+				Object:      models.ChangeObjectGroup,
+				Description: fmt.Sprintf("update group %s", desired.Name),
+				GroupID:     group.ID,
+				GroupName:   desired.Name,
+				Before:      models.DesiredGroup{Name: group.Name, Description: group.Description, Profile: group.Profile},
+				After:       models.DesiredGroup{Name: desired.Name, Description: desired.Description, Profile: desired.Profile},
+			})
+		}
+	}
+
+	for _, desired := range state.Groups {
+		if desired.Members == nil {
+			continue
+		}
+		memberChanges, err := s.planMembers(ctx, current, desired)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, memberChanges...)
+	}
+
+	for _, desired := range state.Groups {
+		if desired.Roles == nil {
+			continue
+		}
+		group := current.groups[desired.Name]
+
+		var assigned []*models.Role
+		if group != nil {
+			if assigned, err = s.rolesSvc.GetGroupRoles(ctx, group.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		changes = append(changes, planAssignments(
+			models.ChangeObjectGroupRole, "group "+desired.Name, desired.Roles, assigned,
+			func(change *models.PlannedChange) {
+				change.GroupName = desired.Name
+				if group != nil {
+					change.GroupID = group.ID
+				}
+			},
+		)...)
+	}
+
+	for _, desired := range state.Users {
+		user := current.users[desired.User]
+		assigned, err := s.rolesSvc.GetUserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, planAssignments(
+			models.ChangeObjectUserRole, "user "+user.Login, desired.Roles, assigned,
+			func(change *models.PlannedChange) {
+				change.UserID = user.ID
+				change.UserLogin = user.Login
+			},
+		)...)
+	}
+
+	if prune {
+		changes = append(changes, planPrune(state, current)...)
+	}
+
+	plan := &models.Plan{
+		Prune:       prune,
+		Changes:     changes,
+		Summary:     map[string]int{},
+		GeneratedAt: time.Now().UTC(),
+	}
+	if plan.Changes == nil {
+		plan.Changes = []models.PlannedChange{}
+	}
+	for _, change := range plan.Changes {
+		plan.Summary[change.Action]++
+	}
+
+	s.log.Infow("Desired state planned", "changes", len(plan.Changes))
+	return plan, nil
+}
+
+// Apply plans state and executes the changes in order. progress, when set, is
+// called after every change. Apply stops at the first failure and reports the
+// remaining changes as skipped.
+func (s *Service) Apply(
+	ctx context.Context, state *models.DesiredState, prune bool, progress func(models.ChangeResult),
+) (*models.ApplyResult, error) {
+	ctx, span := tracing.Start(ctx, "desired_state_service.Apply")
+	defer span.End()
+
+	plan, err := s.Plan(ctx, state, prune)
+	if err != nil {
+		return nil, err
+	}
+	return s.Execute(ctx, plan, progress), nil
+}
+
+// Execute makes the changes of a plan in order, like Apply, without planning
+// again. Callers may drop changes from a plan before executing it.
+func (s *Service) Execute(ctx context.Context, plan *models.Plan, progress func(models.ChangeResult)) *models.ApplyResult {
+	ctx, span := tracing.Start(ctx, "desired_state_service.Execute")
+	defer span.End()
+
+	s.log.Infow("Applying desired state", "changes", len(plan.Changes))
+
+	// Groups created during apply get their IDs here, so later membership and
+	// role changes can find them.
+	groupIDs := map[string]string{}
+
+	result := &models.ApplyResult{Results: []models.ChangeResult{}}
+	failed := false
+
+	for i, change := range plan.Changes {
+		changeResult := models.ChangeResult{Index: i + 1, Total: len(plan.Changes), Change: change}
+
+		switch {
+		case failed:
+			changeResult.Status = models.ChangeStatusSkipped
+			result.Skipped++
+		default:
+			s.log.Infow("Applying change", "index", i+1, "total", len(plan.Changes), "change", change.Description)
+
+			if err := s.apply(ctx, change, groupIDs); err != nil {
+				s.log.Infow("Failed to apply change", zap.Error(err), "change", change.Description)
+				changeResult.Status = models.ChangeStatusFailed
+				changeResult.Error = err.Error()
+				result.Failed++
+				failed = true
+			} else {
+				changeResult.Status = models.ChangeStatusApplied
+				result.Applied++
+			}
+		}
+
+		result.Results = append(result.Results, changeResult)
+		if progress != nil {
+			progress(changeResult)
+		}
+	}
+
+	s.log.Infow("Desired state applied", "applied", result.Applied, "failed", result.Failed, "skipped", result.Skipped)
+	return result
+}
+
+func (s *Service) apply(ctx context.Context, change models.PlannedChange, groupIDs map[string]string) error {
+	switch change.Object {
+	case models.ChangeObjectRole:
+		desired, _ := change.After.(models.DesiredRole)
+		switch change.Action {
+		case models.ChangeActionCreate:
+			_, err := s.rolesSvc.CreateRole(ctx, &models.CreateRoleRequest{
+				Name:        desired.Name,
+				Description: desired.Description,
+				Permissions: desired.Permissions,
+			})
+			return err
+		case models.ChangeActionUpdate:
+			_, err := s.rolesSvc.UpdateRole(ctx, change.RoleID, &models.UpdateRoleRequest{Name: desired.Name, Description: desired.Description})
+			return err
+		case models.ChangeActionDelete:
+			return s.rolesSvc.DeleteRole(ctx, change.RoleID)
+		}
+
+	case models.ChangeObjectRolePermission:
+		switch change.Action {
+		case models.ChangeActionAdd:
+			return s.rolesSvc.AddRolePermission(ctx, change.RoleID, change.Permission)
+		case models.ChangeActionRemove:
+			return s.rolesSvc.RemoveRolePermission(ctx, change.RoleID, change.Permission)
+		}
+
+	case models.ChangeObjectGroup:
+		desired, _ := change.After.(models.DesiredGroup)
+		switch change.Action {
+		case models.ChangeActionCreate:
+			group, err := s.groupsSvc.CreateGroup(ctx, &models.CreateGroupRequest{
+				Name:        desired.Name,
+				Description: desired.Description,
+				Profile:     desired.Profile,
+			})
+			if err != nil {
+				return err
+			}
+			groupIDs[desired.Name] = group.ID
+			return nil
+		case models.ChangeActionUpdate:
+			_, err := s.groupsSvc.UpdateGroup(ctx, change.GroupID, &models.UpdateGroupRequest{
+				Name:        desired.Name,
+				Description: desired.Description,
+				Profile:     desired.Profile,
+			})
+			return err
+		case models.ChangeActionDelete:
+			return s.groupsSvc.DeleteGroup(ctx, change.GroupID)
+		}
+
+	case models.ChangeObjectMembership, models.ChangeObjectGroupRole:
+		groupID := change.GroupID
+		if groupID == "" {
+			groupID = groupIDs[change.GroupName]
+		}
+		if groupID == "" {
+			// In a dry run the group is never created, so the changes that
+			// depend on it can only be described.
+			if dryrun.Enabled(ctx) {
+				dryrun.Record(ctx, dryrun.Call{
+					System:    dryrun.SystemOkta,
+					Operation: change.Action + " " + change.Object,
+					Resource:  "groups/" + change.GroupName,
+				})
+				return nil
+			}
+			return fmt.Errorf("group %s was not created", change.GroupName)
+		}
+
+		switch change.Action {
+		case models.ChangeActionAdd:
+			return s.groupsSvc.AddUserToGroup(ctx, groupID, change.UserID)
+		case models.ChangeActionRemove:
+			return s.groupsSvc.RemoveUserFromGroup(ctx, groupID, change.UserID)
+		case models.ChangeActionAssign:
+			return s.rolesSvc.AssignRoleToGroup(ctx, groupID, change.RoleName)
+		case models.ChangeActionUnassign:
+			return s.rolesSvc.UnassignRoleFromGroup(ctx, groupID, change.RoleID)
+		}
+
+	case models.ChangeObjectUserRole:
+		switch change.Action {
+		case models.ChangeActionAssign:
+			_, err := s.rolesSvc.AssignRoleToUser(ctx, change.UserID, change.RoleName)
+			return err
+		case models.ChangeActionUnassign:
+			return s.rolesSvc.UnassignRoleFromUser(ctx, change.UserID, change.RoleID)
+		}
+	}
+
+	return fmt.Errorf("unsupported change %s %s", change.Action, change.Object)
+}
+
+// load reads the live roles, groups and, when the state names any, users.
+// Each listing is read to its last page, and one that cannot be finished
+// fails the plan, so prune never diffs against a partial inventory and
+// deletes what it did not see.
+func (s *Service) load(ctx context.Context, state *models.DesiredState) (*live, error) {
+	current := &live{
+		roles:  map[string]*models.Role{},
+		groups: map[string]*models.Group{},
+		users:  map[string]*models.User{},
+	}
+
+	roles, err := s.rolesSvc.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		current.roles[role.Name] = role
+	}
+
+	groups, err := s.groupsSvc.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		// Built-in and app groups are not managed through the desired state.
+		if group.Type == models.GroupTypeOkta {
+			current.groups[group.Name] = group
+		}
+	}
+
+	needsUsers := len(state.Users) > 0
+	for _, group := range state.Groups {
+		needsUsers = needsUsers || len(group.Members) > 0
+	}
+	if !needsUsers {
+		return current, nil
+	}
+
+	users, err := s.usersSvc.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		current.users[user.ID] = user
+		current.users[user.Login] = user
+	}
+
+	var unknown []string
+	for _, group := range state.Groups {
+		for _, member := range group.Members {
+			if current.users[member] == nil {
+				unknown = append(unknown, member)
+			}
+		}
+	}
+	for _, user := range state.Users {
+		if current.users[user.User] == nil {
+			unknown = append(unknown, user.User)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return nil, fmt.Errorf("%w: unknown users %v", ErrInvalidState, slices.Compact(unknown))
+	}
+
+	return current, nil
+}
+
+func (s *Service) planMembers(ctx context.Context, current *live, desired models.DesiredGroup) ([]models.PlannedChange, error) {
+	group := current.groups[desired.Name]
+
+	members := map[string]*models.User{}
+	if group != nil {
+		users, err := s.groupsSvc.GetGroupMembers(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			members[user.ID] = user
+		}
+	}
+
+	wanted := map[string]*models.User{}
+	for _, ref := range desired.Members {
+		user := current.users[ref]
+		wanted[user.ID] = user
+	}
+
+	change := func(action, description string, user *models.User) models.PlannedChange {
+		planned := models.PlannedChange{
+			Action:      action,
+			Object:      models.ChangeObjectMembership,
+			Description: description,
+			GroupName:   desired.Name,
+			UserID:      user.ID,
+			UserLogin:   user.Login,
+		}
+		if group != nil {
+			planned.GroupID = group.ID
+		}
+		return planned
+	}
+
+	var changes []models.PlannedChange
+	for _, id := range slices.Sorted(maps.Keys(wanted)) {
+		if members[id] == nil {
+			user := wanted[id]
+			changes = append(changes, change(models.ChangeActionAdd,
+				fmt.Sprintf("add %s to group %s", user.Login, desired.Name), user))
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(members)) {
+		if wanted[id] == nil {
+			user := members[id]
+			changes = append(changes, change(models.ChangeActionRemove,
+				fmt.Sprintf("remove %s from group %s", user.Login, desired.Name), user))
+		}
+	}
+	return changes, nil
+}
+
+// planPermissions compares the desired permissions of an existing custom role
+// with the ones it has in Okta.
+func planPermissions(role *models.Role, desired []string, current []*models.Permission) []models.PlannedChange {
+	change := func(action, description, permission string) models.PlannedChange {
+		return models.PlannedChange{
+			Action:      action,
+			Object:      models.ChangeObjectRolePermission,
+			Description: description,
+			RoleID:      role.ID,
+			RoleName:    role.Name,
+			Permission:  permission,
+		}
+	}
+
+	var changes []models.PlannedChange
+	for _, permission := range desired {
+		if slices.ContainsFunc(current, func(p *models.Permission) bool { return p.Name == permission }) {
+			continue
+		}
+		changes = append(changes, change(models.ChangeActionAdd,
+			fmt.Sprintf("add permission %s to role %s", permission, role.Name), permission))
+	}
+	for _, permission := range current {
+		if slices.Contains(desired, permission.Name) {
+			continue
+		}
+		changes = append(changes, change(models.ChangeActionRemove,
+			fmt.Sprintf("remove permission %s from role %s", permission.Name, role.Name), permission.Name))
+	}
+	return changes
+}
+
+// planAssignments compares desired role types with the current assignments of
+// one principal. Unassigning needs the assignment ID, assigning the role type.
+func planAssignments(
+	object, principal string, desired []string, assigned []*models.Role, fill func(*models.PlannedChange),
+) []models.PlannedChange {
+	var changes []models.PlannedChange
+
+	for _, roleType := range desired {
+		if slices.ContainsFunc(assigned, func(role *models.Role) bool { return role.Type == roleType }) {
+			continue
+		}
+		change := models.PlannedChange{
+			Action:      models.ChangeActionAssign,
+			Object:      object,
+			Description: fmt.Sprintf("assign %s to %s", roleType, principal),
+			RoleName:    roleType,
+		}
+		fill(&change)
+		changes = append(changes, change)
+	}
+
+	for _, role := range assigned {
+		if slices.Contains(desired, role.Type) {
+			continue
+		}
+		change := models.PlannedChange{
+			Action:      models.ChangeActionUnassign,
+			Object:      object,
+			Description: fmt.Sprintf("unassign %s from %s", role.Type, principal),
+			RoleID:      role.ID,
+			RoleName:    role.Type,
+		}
+		fill(&change)
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// planPrune deletes unmanaged groups before unmanaged roles, after every
+// other change has been made.
+func planPrune(state *models.DesiredState, current *live) []models.PlannedChange {
+	var changes []models.PlannedChange
+
+	for _, name := range slices.Sorted(maps.Keys(current.groups)) {
+		if slices.ContainsFunc(state.Groups, func(g models.DesiredGroup) bool { return g.Name == name }) {
+			continue
+		}
+		group := current.groups[name]
+		changes = append(changes, models.PlannedChange{
+			Action:      models.ChangeActionDelete,
+			Object:      models.ChangeObjectGroup,
+			Description: fmt.Sprintf("delete group %s", name),
+			GroupID:     group.ID,
+			GroupName:   name,
+			Before:      models.DesiredGroup{Name: group.Name, Description: group.Description, Profile: group.Profile},
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(current.roles)) {
+		if slices.ContainsFunc(state.Roles, func(r models.DesiredRole) bool { return r.Name == name }) {
+			continue
+		}
+		role := current.roles[name]
+		changes = append(changes, models.PlannedChange{
+			Action:      models.ChangeActionDelete,
+			Object:      models.ChangeObjectRole,
+			Description: fmt.Sprintf("delete role %s", name),
+			RoleID:      role.ID,
+			RoleName:    name,
+			Before:      models.DesiredRole{Name: role.Name, Description: role.Description},
+		})
+	}
+
+	return changes
+}
+
+func groupChanged(group *models.Group, desired models.DesiredGroup) bool {
+	if desired.Description != "" && desired.Description != group.Description {
+		return true
+	}
+	for key, value := range desired.Profile {
+		if !reflect.DeepEqual(group.Profile[key], value) {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(state *models.DesiredState) error {
+	seen := map[string]bool{}
+	for _, role := range state.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("%w: every role needs a name", ErrInvalidState)
+		}
+		if seen["role:"+role.Name] {
+			return fmt.Errorf("%w: role %s is declared twice", ErrInvalidState, role.Name)
+		}
+		seen["role:"+role.Name] = true
+	}
+
+	for _, group := range state.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("%w: every group needs a name", ErrInvalidState)
+		}
+		if seen["group:"+group.Name] {
+			return fmt.Errorf("%w: group %s is declared twice", ErrInvalidState, group.Name)
+		}
+		seen["group:"+group.Name] = true
+	}
+
+	for _, user := range state.Users {
+		if user.User == "" {
+			return fmt.Errorf("%w: every user entry needs a user", ErrInvalidState)
+		}
+		if seen["user:"+user.User] {
+			return fmt.Errorf("%w: user %s is declared twice", ErrInvalidState, user.User)
+		}
+		seen["user:"+user.User] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package desired_state_service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *models.DesiredState
+		err  error
+	}{
+		{
+			name: "yaml",
+			data: "roles:\n  - name: Auditor\n    permissions: [okta.users.read]\n" +
+				"groups:\n  - name: Engineering\n    members: [ada@example.com]\n" +
+				"users:\n  - user: ada@example.com\n    roles: [READ_ONLY_ADMIN]\n",
+			want: &models.DesiredState{
+				Roles:  []models.DesiredRole{{Name: "Auditor", Permissions: []string{"okta.users.read"}}},
+				Groups: []models.DesiredGroup{{Name: "Engineering", Members: []string{"ada@example.com"}}},
+				Users:  []models.DesiredUser{{User: "ada@example.com", Roles: []string{"READ_ONLY_ADMIN"}}},
+			},
+		},
+		{
+			name: "json",
+			data: `{"groups":[{"name":"Engineering","roles":["USER_ADMIN"]}]}`,
+			want: &models.DesiredState{Groups: []models.DesiredGroup{{Name: "Engineering", Roles: []string{"USER_ADMIN"}}}},
+		},
+		{
+			name: "empty members are kept apart from omitted ones",
+			data: "groups:\n  - name: Engineering\n    members: []\n",
+			want: &models.DesiredState{Groups: []models.DesiredGroup{{Name: "Engineering", Members: []string{}}}},
+		},
+		{
+			name: "empty document",
+			data: "",
+			want: &models.DesiredState{},
+		},
+		{
+			name: "unknown field",
+			data: "groups:\n  - name: Engineering\n    member: [ada@example.com]\n",
+			err:  ErrInvalidState,
+		},
+		{
+			name: "malformed",
+			data: "groups: [",
+			err:  ErrInvalidState,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		state models.DesiredState
+		err   string
+	}{
+		{
+			name: "valid",
+			state: models.DesiredState{
+				Roles:  []models.DesiredRole{{Name: "Engineering"}},
+				Groups: []models.DesiredGroup{{Name: "Engineering"}},
+				Users:  []models.DesiredUser{{User: "ada@example.com"}},
+			},
+		},
+		{
+			name:  "role without a name",
+			state: models.DesiredState{Roles: []models.DesiredRole{{Description: "x"}}},
+			err:   "every role needs a name",
+		},
+		{
+			name:  "duplicate role",
+			state: models.DesiredState{Roles: []models.DesiredRole{{Name: "Auditor"}, {Name: "Auditor"}}},
+			err:   "role Auditor is declared twice",
+		},
+		{
+			name:  "group without a name",
+			state: models.DesiredState{Groups: []models.DesiredGroup{{}}},
+			err:   "every group needs a name",
+		},
+		{
+			name:  "duplicate group",
+			state: models.DesiredState{Groups: []models.DesiredGroup{{Name: "Ops"}, {Name: "Ops"}}},
+			err:   "group Ops is declared twice",
+		},
+		{
+			name:  "user entry without a user",
+			state: models.DesiredState{Users: []models.DesiredUser{{Roles: []string{"USER_ADMIN"}}}},
+			err:   "every user entry needs a user",
+		},
+		{
+			name:  "duplicate user",
+			state: models.DesiredState{Users: []models.DesiredUser{{User: "ada"}, {User: "ada"}}},
+			err:   "user ada is declared twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(&tt.state)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidState) || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPlanAssignments(t *testing.T) {
+	userAdmin := &models.Role{ID: "ra1", Type: "USER_ADMIN"}
+	readOnly := &models.Role{ID: "ra2", Type: "READ_ONLY_ADMIN"}
+
+	tests := []struct {
+		name     string
+		desired  []string
+		assigned []*models.Role
+		want     []models.PlannedChange
+	}{
+		{
+			name:     "in sync",
+			desired:  []string{"USER_ADMIN"},
+			assigned: []*models.Role{userAdmin},
+		},
+		{
+			name:    "assign by type",
+			desired: []string{"USER_ADMIN", "READ_ONLY_ADMIN"},
+			want: []models.PlannedChange{
+				{Action: models.ChangeActionAssign, Object: models.ChangeObjectUserRole,
+					Description: "assign USER_ADMIN to user ada", RoleName: "USER_ADMIN", UserID: "00u1"},
+				{Action: models.ChangeActionAssign, Object: models.ChangeObjectUserRole,
+					Description: "assign READ_ONLY_ADMIN to user ada", RoleName: "READ_ONLY_ADMIN", UserID: "00u1"},
+			},
+		},
+		{
+			name:     "unassign by assignment ID after assigning",
+			desired:  []string{"READ_ONLY_ADMIN"},
+			assigned: []*models.Role{userAdmin},
+			want: []models.PlannedChange{
+				{Action: models.ChangeActionAssign, Object: models.ChangeObjectUserRole,
+					Description: "assign READ_ONLY_ADMIN to user ada", RoleName: "READ_ONLY_ADMIN", UserID: "00u1"},
+				{Action: models.ChangeActionUnassign, Object: models.ChangeObjectUserRole,
+					Description: "unassign USER_ADMIN from user ada", RoleID: "ra1", RoleName: "USER_ADMIN", UserID: "00u1"},
+			},
+		},
+		{
+			name:     "empty list removes every assignment",
+			desired:  []string{},
+			assigned: []*models.Role{readOnly},
+			want: []models.PlannedChange{
+				{Action: models.ChangeActionUnassign, Object: models.ChangeObjectUserRole,
+					Description: "unassign READ_ONLY_ADMIN from user ada", RoleID: "ra2", RoleName: "READ_ONLY_ADMIN", UserID: "00u1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planAssignments(models.ChangeObjectUserRole, "user ada", tt.desired, tt.assigned,
+				func(change *models.PlannedChange) { change.UserID = "00u1" },
+			)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("planAssignments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPermissions(t *testing.T) {
+	role := &models.Role{ID: "cr1", Name: "Auditor"}
+	permission := func(name string) *models.Permission { return &models.Permission{ID: name, Name: name} }
+
+	tests := []struct {
+		name    string
+		desired []string
+		current []*models.Permission
+		want    []models.PlannedChange
+	}{
+		{
+			name:    "in sync",
+			desired: []string{"okta.users.read"},
+			current: []*models.Permission{permission("okta.users.read")},
+		},
+		{
+			name:    "add before remove",
+			desired: []string{"okta.groups.read"},
+			current: []*models.Permission{permission("okta.users.read")},
+			want: []models.PlannedChange{
+				{Action: models.ChangeActionAdd, Object: models.ChangeObjectRolePermission,
+					Description: "add permission okta.groups.read to role Auditor",
+					RoleID:      "cr1", RoleName: "Auditor", Permission: "okta.groups.read"},
+				{Action: models.ChangeActionRemove, Object: models.ChangeObjectRolePermission,
+					Description: "remove permission okta.users.read from role Auditor",
+					RoleID:      "cr1", RoleName: "Auditor", Permission: "okta.users.read"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planPermissions(role, tt.desired, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("planPermissions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	current := &live{
+		roles: map[string]*models.Role{
+			"Zeta":    {ID: "cr2", Name: "Zeta"},
+			"Auditor": {ID: "cr1", Name: "Auditor", Description: "Reads users"},
+			"Kept":    {ID: "cr3", Name: "Kept"},
+		},
+		groups: map[string]*models.Group{
+			"Ops":         {ID: "00g2", Name: "Ops"},
+			"Engineering": {ID: "00g1", Name: "Engineering"},
+			"Contractors": {ID: "00g3", Name: "Contractors", Description: "External"},
+		},
+	}
+	state := &models.DesiredState{
+		Roles:  []models.DesiredRole{{Name: "Kept"}},
+		Groups: []models.DesiredGroup{{Name: "Engineering"}},
+	}
+
+	want := []models.PlannedChange{
+		{Action: models.ChangeActionDelete, Object: models.ChangeObjectGroup, Description: "delete group Contractors",
+			GroupID: "00g3", GroupName: "Contractors",
+			Before: models.DesiredGroup{Name: "Contractors", Description: "External"}},
+		{Action: models.ChangeActionDelete, Object: models.ChangeObjectGroup, Description: "delete group Ops",
+			GroupID: "00g2", GroupName: "Ops", Before: models.DesiredGroup{Name: "Ops"}},
+		{Action: models.ChangeActionDelete, Object: models.ChangeObjectRole, Description: "delete role Auditor",
+			RoleID: "cr1", RoleName: "Auditor", Before: models.DesiredRole{Name: "Auditor", Description: "Reads users"}},
+		{Action: models.ChangeActionDelete, Object: models.ChangeObjectRole, Description: "delete role Zeta",
+			RoleID: "cr2", RoleName: "Zeta", Before: models.DesiredRole{Name: "Zeta"}},
+	}
+
+	if got := planPrune(state, current); !reflect.DeepEqual(got, want) {
+		t.Fatalf("planPrune() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanRejectsUnknownUsers(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/iam/roles":
+			w.Write([]byte(`{"roles":[]}`))
+		case "/api/v1/groups":
+			w.Write([]byte(`[]`))
+		case "/api/v1/users":
+			w.Write([]byte(`[{"id":"00u1","status":"ACTIVE","profile":{"login":"ada@example.com"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorCode":"E0000007","errorSummary":"Not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg, err := okta.NewConfiguration(
+		okta.WithOrgUrl(server.URL),
+		okta.WithToken("test-token"),
+		okta.WithCache(false),
+		okta.WithHttpClientPtr(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("failed to configure Okta client: %v", err)
+	}
+	// The SDK keeps only the host name of the org URL, which drops the port.
+	cfg.Host = server.Listener.Addr().String()
+	client := okta.NewAPIClient(cfg)
+
+	log := zap.NewNop().Sugar()
+	svc := New(log, user_service.New(log, client), group_service.New(log, client), role_service.New(log, client))
+
+	state := &models.DesiredState{
+		Groups: []models.DesiredGroup{{Name: "Engineering", Members: []string{"ada@example.com", "bob@example.com"}}},
+		Users: []models.DesiredUser{
+			{User: "carol@example.com", Roles: []string{"USER_ADMIN"}},
+			{User: "00u1", Roles: []string{}},
+			{User: "bob@example.com", Roles: []string{}},
+		},
+	}
+
+	_, err = svc.Plan(context.Background(), state, false)
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("error = %v, want %v", err, ErrInvalidState)
+	}
+	if want := "unknown users [bob@example.com carol@example.com]"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("error = %v, want it to name %q", err, want)
+	}
+}
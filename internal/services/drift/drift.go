@@ -0,0 +1,530 @@
+package drift_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNoBaseline   = errors.New("no baseline has been approved")
+	ErrItemNotFound = errors.New("drift item not found")
+)
+
+// Filter narrows the drift items returned by GetDriftItems. Empty fields match
+// everything.
+type Filter struct {
+	Status string
+	Object string
+}
+
+type Service struct {
+	log       *zap.SugaredLogger
+	interval  time.Duration
+	baselines *store.Store[models.Baseline]
+	items     *store.Store[models.DriftItem]
+	notifier  notify.Notifier
+	auditSvc  *audit_service.Service
+	groupsSvc *group_service.Service
+	rolesSvc  *role_service.Service
+
+	// mu keeps the background checker and on-demand checks from updating the
+	// same items concurrently.
+	mu sync.Mutex
+
+	// checkedAt is the time of the latest check. Every open item was seen by
+	// it, so it is reported as their LastSeenAt instead of being written to
+	// each of them on every check.
+	checkedAt atomic.Pointer[time.Time]
+}
+
+func New(
+	log *zap.SugaredLogger,
+	interval time.Duration,
+	baselines *store.Store[models.Baseline],
+	items *store.Store[models.DriftItem],
+	notifier notify.Notifier,
+	auditSvc *audit_service.Service,
+	groupsSvc *group_service.Service,
+	rolesSvc *role_service.Service,
+) *Service {
+	return &Service{
+		log:       log,
+		interval:  interval,
+		baselines: baselines,
+		items:     items,
+		notifier:  notifier,
+		auditSvc:  auditSvc,
+		groupsSvc: groupsSvc,
+		rolesSvc:  rolesSvc,
+	}
+}
+
+// Capture reads the current IAMState of the org. Only groups of type
+// OKTA_GROUP are included, since app groups are owned by their source.
+func (s *Service) Capture(ctx context.Context) (*models.IAMState, error) {
+	ctx, span := tracing.Start(ctx, "drift_service.Capture")
+	defer span.End()
+
+	s.log.Infow("Capturing IAM state")
+
+	state := &models.IAMState{
+		Groups:    []models.IAMGroup{},
+		Roles:     []models.IAMRole{},
+		UserRoles: []models.IAMUserRoles{},
+	}
+
+	groups, err := s.groupsSvc.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Type != models.GroupTypeOkta {
+			continue
+		}
+
+		members, err := s.groupsSvc.GetGroupMembers(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := s.rolesSvc.GetGroupRoles(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		memberIDs := make([]string, 0, len(members))
+		for _, member := range members {
+			memberIDs = append(memberIDs, member.ID)
+		}
+		slices.Sort(memberIDs)
+
+		state.Groups = append(state.Groups, models.IAMGroup{
+			ID:          group.ID,
+			Name:        group.Name,
+			Description: group.Description,
+			Members:     memberIDs,
+			Roles:       roleKeys(roles),
+		})
+	}
+
+	roles, err := s.rolesSvc.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		state.Roles = append(state.Roles, models.IAMRole{ID: role.ID, Name: role.Name, Description: role.Description})
+	}
+
+	userIDs, err := s.rolesSvc.GetUsersWithRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		roles, err := s.rolesSvc.GetUserRoles(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		state.UserRoles = append(state.UserRoles, models.IAMUserRoles{UserID: userID, Roles: roleKeys(roles)})
+	}
+
+	slices.SortFunc(state.Groups, func(a, b models.IAMGroup) int { return strings.Compare(a.ID, b.ID) })
+	slices.SortFunc(state.Roles, func(a, b models.IAMRole) int { return strings.Compare(a.ID, b.ID) })
+	slices.SortFunc(state.UserRoles, func(a, b models.IAMUserRoles) int { return strings.Compare(a.UserID, b.UserID) })
+
+	s.log.Infow("IAM state captured", "groups", len(state.Groups), "roles", len(state.Roles),
+		"usersWithRoles", len(state.UserRoles),
+	)
+	return state, nil
+}
+
+// ApproveBaseline captures live Okta as the new baseline. Drift against the
+// previous baseline is resolved by the check that follows.
+func (s *Service) ApproveBaseline(ctx context.Context, approvedBy string) (*models.Baseline, error) {
+	ctx, span := tracing.Start(ctx, "drift_service.ApproveBaseline")
+	defer span.End()
+
+	s.log.Infow("Approving drift baseline", "approvedBy", approvedBy)
+
+	state, err := s.Capture(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := models.Baseline{
+		ID:         store.NewID(),
+		ApprovedBy: approvedBy,
+		ApprovedAt: time.Now().UTC(),
+		State:      *state,
+	}
+	if err := s.baselines.Put(ctx, baseline.ID, baseline); err != nil {
+		return nil, fmt.Errorf("failed to store baseline: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, models.AuditEntry{
+		Actor:      approvedBy,
+		Action:     "drift.baseline_approved",
+		TargetType: "baseline",
+		TargetID:   baseline.ID,
+		Outcome:    models.AuditOutcomeSuccess,
+		Details: map[string]any{
+			"groups": len(state.Groups), "roles": len(state.Roles), "usersWithRoles": len(state.UserRoles),
+		},
+	})
+
+	if _, err := s.check(ctx, &baseline, state); err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Drift baseline approved", "baselineId", baseline.ID)
+	return &baseline, nil
+}
+
+// GetBaseline returns the most recently approved baseline.
+func (s *Service) GetBaseline(ctx context.Context) (*models.Baseline, error) {
+	_, span := tracing.Start(ctx, "drift_service.GetBaseline")
+	defer span.End()
+
+	var latest *models.Baseline
+	for _, baseline := range s.baselines.List() {
+		if latest == nil || baseline.ApprovedAt.After(latest.ApprovedAt) {
+			latest = &baseline
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoBaseline
+	}
+	return latest, nil
+}
+
+func (s *Service) GetDriftItems(ctx context.Context, filter Filter) []*models.DriftItem {
+	_, span := tracing.Start(ctx, "drift_service.GetDriftItems")
+	defer span.End()
+
+	result := []*models.DriftItem{}
+	for _, item := range s.items.List() {
+		if filter.Status != "" && item.Status != filter.Status {
+			continue
+		}
+		if filter.Object != "" && item.Object != filter.Object {
+			continue
+		}
+		result = append(result, s.seen(item))
+	}
+
+	slices.SortStableFunc(result, func(a, b *models.DriftItem) int { return b.DetectedAt.Compare(a.DetectedAt) })
+	return result
+}
+
+func (s *Service) GetDriftItem(ctx context.Context, itemID string) (*models.DriftItem, error) {
+	_, span := tracing.Start(ctx, "drift_service.GetDriftItem", tracing.ItemID.String(itemID))
+	defer span.End()
+
+	item, ok := s.items.Get(itemID)
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	return s.seen(item), nil
+}
+
+// Check compares live Okta against the baseline, records new drift, resolves
+// drift that has gone away and emits a drift.detected event for new items.
+func (s *Service) Check(ctx context.Context) (*models.DriftCheck, error) {
+	ctx, span := tracing.Start(ctx, "drift_service.Check")
+	defer span.End()
+
+	baseline, err := s.GetBaseline(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Checking for drift", "baselineId", baseline.ID)
+
+	state, err := s.Capture(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.check(ctx, baseline, state)
+}
+
+// Run checks for drift every interval until ctx is cancelled. Nothing is
+// checked until a baseline has been approved.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+// tick checks for drift once. A panic is logged instead of stopping the loop,
+// so the next tick tries again.
+func (s *Service) tick(ctx context.Context) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while checking for drift", "panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+
+	if _, err := s.Check(ctx); err != nil && !errors.Is(err, ErrNoBaseline) {
+		s.log.Infow("Failed to check for drift", zap.Error(err))
+	}
+}
+
+func (s *Service) check(ctx context.Context, baseline *models.Baseline, state *models.IAMState) (*models.DriftCheck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	result := &models.DriftCheck{BaselineID: baseline.ID, CheckedAt: now, Open: []*models.DriftItem{}}
+
+	// Only new, changed and resolved items are written, in one batch, so a
+	// check that finds the same drift as the last one writes nothing.
+	found := map[string]bool{}
+	changed := map[string]models.DriftItem{}
+	var detected []*models.DriftItem
+
+	for _, item := range diff(&baseline.State, state) {
+		found[item.ID] = true
+		item.BaselineID = baseline.ID
+		item.Status = models.DriftStatusOpen
+		item.LastSeenAt = now
+
+		existing, ok := s.items.Get(item.ID)
+		switch {
+		case !ok || existing.Status != models.DriftStatusOpen:
+			item.DetectedAt = now
+			result.Detected++
+			detected = append(detected, &item)
+			changed[item.ID] = item
+		case !unchanged(existing, item):
+			item.DetectedAt = existing.DetectedAt
+			changed[item.ID] = item
+		default:
+			item.DetectedAt = existing.DetectedAt
+		}
+		result.Open = append(result.Open, &item)
+	}
+
+	for _, item := range s.items.List() {
+		if item.Status != models.DriftStatusOpen || found[item.ID] {
+			continue
+		}
+		item.Status = models.DriftStatusResolved
+		item.ResolvedAt = &now
+		changed[item.ID] = item
+		result.Resolved++
+	}
+
+	if err := s.items.PutAll(ctx, changed); err != nil {
+		return nil, fmt.Errorf("failed to store drift items: %w", err)
+	}
+	s.checkedAt.Store(&now)
+
+	if len(detected) > 0 {
+		event := notify.Event{
+			Type:    "drift.detected",
+			Subject: baseline.ID,
+			Message: fmt.Sprintf("%d new changes in Okta differ from the approved baseline", len(detected)),
+			Data:    detected,
+			Time:    now,
+		}
+		if err := s.notifier.Notify(ctx, event); err != nil {
+			s.log.Infow("Failed to send drift notification", zap.Error(err), "baselineId", baseline.ID)
+		}
+	}
+
+	s.log.Infow("Drift check complete", "baselineId", baseline.ID, "open", len(result.Open),
+		"detected", result.Detected, "resolved", result.Resolved,
+	)
+	return result, nil
+}
+
+// seen returns item with the time of the latest check as its LastSeenAt
+// when it is still open.
+func (s *Service) seen(item models.DriftItem) *models.DriftItem {
+	if checkedAt := s.checkedAt.Load(); checkedAt != nil && item.Status == models.DriftStatusOpen &&
+		checkedAt.After(item.LastSeenAt) {
+		item.LastSeenAt = *checkedAt
+	}
+	return &item
+}
+
+// unchanged reports whether a stored open item still describes the drift
+// found now. Items are compared as JSON because the Expected and Actual
+// values of a stored item are read back as plain maps and slices.
+func unchanged(stored, found models.DriftItem) bool {
+	stored.DetectedAt, stored.LastSeenAt = found.DetectedAt, found.LastSeenAt
+	encoded := canonical(stored)
+	return encoded != "" && encoded == canonical(found)
+}
+
+// canonical encodes v as JSON with the keys of every object sorted, or
+// returns an empty string when v cannot be encoded.
+func canonical(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return ""
+	}
+	data, _ = json.Marshal(generic)
+	return string(data)
+}
+
+// diff lists the differences of live from baseline. Groups and roles are
+// matched by ID, so a rename shows up as a modification.
+func diff(baseline, live *models.IAMState) []models.DriftItem {
+	var items []models.DriftItem
+
+	baseGroups := index(baseline.Groups, func(g models.IAMGroup) string { return g.ID })
+	liveGroups := index(live.Groups, func(g models.IAMGroup) string { return g.ID })
+
+	for _, group := range baseline.Groups {
+		current, ok := liveGroups[group.ID]
+		if !ok {
+			items = append(items, item(models.DriftChangeRemoved, models.ChangeObjectGroup, group.ID,
+				fmt.Sprintf("group %s was deleted", group.Name), group, nil))
+			continue
+		}
+		if current.Name != group.Name || current.Description != group.Description {
+			items = append(items, item(models.DriftChangeModified, models.ChangeObjectGroup, group.ID,
+				fmt.Sprintf("group %s was changed", group.Name),
+				models.IAMGroup{ID: group.ID, Name: group.Name, Description: group.Description},
+				models.IAMGroup{ID: current.ID, Name: current.Name, Description: current.Description}))
+		}
+
+		items = append(items, diffSet(models.ChangeObjectMembership, group.ID, group.Members, current.Members,
+			func(userID string) string { return fmt.Sprintf("member %s of group %s", userID, group.Name) })...)
+		items = append(items, diffSet(models.ChangeObjectGroupRole, group.ID, group.Roles, current.Roles,
+			func(role string) string { return fmt.Sprintf("role %s of group %s", role, group.Name) })...)
+	}
+	for _, group := range live.Groups {
+		if _, ok := baseGroups[group.ID]; !ok {
+			items = append(items, item(models.DriftChangeAdded, models.ChangeObjectGroup, group.ID,
+				fmt.Sprintf("group %s was created", group.Name), nil, group))
+		}
+	}
+
+	baseRoles := index(baseline.Roles, func(r models.IAMRole) string { return r.ID })
+	liveRoles := index(live.Roles, func(r models.IAMRole) string { return r.ID })
+
+	for _, role := range baseline.Roles {
+		current, ok := liveRoles[role.ID]
+		switch {
+		case !ok:
+			items = append(items, item(models.DriftChangeRemoved, models.ChangeObjectRole, role.ID,
+				fmt.Sprintf("role %s was deleted", role.Name), role, nil))
+		case current != role:
+			items = append(items, item(models.DriftChangeModified, models.ChangeObjectRole, role.ID,
+				fmt.Sprintf("role %s was changed", role.Name), role, current))
+		}
+	}
+	for _, role := range live.Roles {
+		if _, ok := baseRoles[role.ID]; !ok {
+			items = append(items, item(models.DriftChangeAdded, models.ChangeObjectRole, role.ID,
+				fmt.Sprintf("role %s was created", role.Name), nil, role))
+		}
+	}
+
+	baseUsers := index(baseline.UserRoles, func(u models.IAMUserRoles) string { return u.UserID })
+	liveUsers := index(live.UserRoles, func(u models.IAMUserRoles) string { return u.UserID })
+
+	for _, userID := range unionKeys(baseUsers, liveUsers) {
+		items = append(items, diffSet(models.ChangeObjectUserRole, userID, baseUsers[userID].Roles, liveUsers[userID].Roles,
+			func(role string) string { return fmt.Sprintf("role %s of user %s", role, userID) })...)
+	}
+
+	return items
+}
+
+// diffSet reports the entries added to or removed from a set.
+func diffSet(object, ownerID string, baseline, live []string, describe func(string) string) []models.DriftItem {
+	var items []models.DriftItem
+	for _, entry := range baseline {
+		if !slices.Contains(live, entry) {
+			items = append(items, item(models.DriftChangeRemoved, object, ownerID+"/"+entry,
+				describe(entry)+" was removed", entry, nil))
+		}
+	}
+	for _, entry := range live {
+		if !slices.Contains(baseline, entry) {
+			items = append(items, item(models.DriftChangeAdded, object, ownerID+"/"+entry,
+				describe(entry)+" was added", nil, entry))
+		}
+	}
+	return items
+}
+
+func item(change, object, resourceID, description string, expected, actual any) models.DriftItem {
+	return models.DriftItem{
+		ID:          object + ":" + change + ":" + resourceID,
+		Change:      change,
+		Object:      object,
+		ResourceID:  resourceID,
+		Description: description,
+		Expected:    expected,
+		Actual:      actual,
+	}
+}
+
+func index[T any](values []T, key func(T) string) map[string]T {
+	result := make(map[string]T, len(values))
+	for _, value := range values {
+		result[key(value)] = value
+	}
+	return result
+}
+
+func unionKeys[T any](a, b map[string]T) []string {
+	var keys []string
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// roleKeys identifies assignments by role type, or by label for custom roles,
+// which all share the CUSTOM type.
+func roleKeys(roles []*models.Role) []string {
+	keys := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.Type == models.RoleTypeCustom && role.Name != "" {
+			keys = append(keys, role.Name)
+			continue
+		}
+		keys = append(keys, role.Type)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
@@ -0,0 +1,207 @@
+package drift_service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iamBelugaa/iam/internal/models"
+)
+
+func TestDiff(t *testing.T) {
+	engineering := models.IAMGroup{
+		ID: "00g1", Name: "Engineering", Description: "Builds things",
+		Members: []string{"00u1", "00u2"}, Roles: []string{"READ_ONLY_ADMIN"},
+	}
+	helpDesk := models.IAMRole{ID: "cr1", Name: "Help Desk", Description: "Resets passwords"}
+
+	tests := []struct {
+		name           string
+		baseline, live models.IAMState
+		want           []models.DriftItem
+	}{
+		{
+			name:     "no drift",
+			baseline: models.IAMState{Groups: []models.IAMGroup{engineering}, Roles: []models.IAMRole{helpDesk}},
+			live:     models.IAMState{Groups: []models.IAMGroup{engineering}, Roles: []models.IAMRole{helpDesk}},
+		},
+		{
+			name:     "group deleted",
+			baseline: models.IAMState{Groups: []models.IAMGroup{engineering}},
+			want: []models.DriftItem{
+				item(models.DriftChangeRemoved, models.ChangeObjectGroup, "00g1", "group Engineering was deleted", engineering, nil),
+			},
+		},
+		{
+			name: "group created",
+			live: models.IAMState{Groups: []models.IAMGroup{engineering}},
+			want: []models.DriftItem{
+				item(models.DriftChangeAdded, models.ChangeObjectGroup, "00g1", "group Engineering was created", nil, engineering),
+			},
+		},
+		{
+			name:     "group renamed is matched by ID",
+			baseline: models.IAMState{Groups: []models.IAMGroup{engineering}},
+			live: models.IAMState{Groups: []models.IAMGroup{{
+				ID: "00g1", Name: "Platform", Description: "Builds things",
+				Members: engineering.Members, Roles: engineering.Roles,
+			}}},
+			want: []models.DriftItem{
+				item(models.DriftChangeModified, models.ChangeObjectGroup, "00g1", "group Engineering was changed",
+					models.IAMGroup{ID: "00g1", Name: "Engineering", Description: "Builds things"},
+					models.IAMGroup{ID: "00g1", Name: "Platform", Description: "Builds things"}),
+			},
+		},
+		{
+			name:     "members and roles of a group",
+			baseline: models.IAMState{Groups: []models.IAMGroup{engineering}},
+			live: models.IAMState{Groups: []models.IAMGroup{{
+				ID: "00g1", Name: "Engineering", Description: "Builds things",
+				Members: []string{"00u2", "00u3"}, Roles: []string{"READ_ONLY_ADMIN", "Help Desk"},
+			}}},
+			want: []models.DriftItem{
+				item(models.DriftChangeRemoved, models.ChangeObjectMembership, "00g1/00u1",
+					"member 00u1 of group Engineering was removed", "00u1", nil),
+				item(models.DriftChangeAdded, models.ChangeObjectMembership, "00g1/00u3",
+					"member 00u3 of group Engineering was added", nil, "00u3"),
+				item(models.DriftChangeAdded, models.ChangeObjectGroupRole, "00g1/Help Desk",
+					"role Help Desk of group Engineering was added", nil, "Help Desk"),
+			},
+		},
+		{
+			name:     "roles created, changed and deleted",
+			baseline: models.IAMState{Roles: []models.IAMRole{helpDesk, {ID: "cr2", Name: "Auditor"}}},
+			live: models.IAMState{Roles: []models.IAMRole{
+				{ID: "cr1", Name: "Help Desk", Description: "Unlocks accounts"},
+				{ID: "cr3", Name: "Operator"},
+			}},
+			want: []models.DriftItem{
+				item(models.DriftChangeModified, models.ChangeObjectRole, "cr1", "role Help Desk was changed",
+					helpDesk, models.IAMRole{ID: "cr1", Name: "Help Desk", Description: "Unlocks accounts"}),
+				item(models.DriftChangeRemoved, models.ChangeObjectRole, "cr2", "role Auditor was deleted",
+					models.IAMRole{ID: "cr2", Name: "Auditor"}, nil),
+				item(models.DriftChangeAdded, models.ChangeObjectRole, "cr3", "role Operator was created",
+					nil, models.IAMRole{ID: "cr3", Name: "Operator"}),
+			},
+		},
+		{
+			name: "user roles of users on either side",
+			baseline: models.IAMState{UserRoles: []models.IAMUserRoles{
+				{UserID: "00u2", Roles: []string{"SUPER_ADMIN"}},
+				{UserID: "00u1", Roles: []string{"READ_ONLY_ADMIN"}},
+			}},
+			live: models.IAMState{UserRoles: []models.IAMUserRoles{
+				{UserID: "00u1", Roles: []string{"READ_ONLY_ADMIN", "Help Desk"}},
+			}},
+			want: []models.DriftItem{
+				item(models.DriftChangeAdded, models.ChangeObjectUserRole, "00u1/Help Desk",
+					"role Help Desk of user 00u1 was added", nil, "Help Desk"),
+				item(models.DriftChangeRemoved, models.ChangeObjectUserRole, "00u2/SUPER_ADMIN",
+					"role SUPER_ADMIN of user 00u2 was removed", "SUPER_ADMIN", nil),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff(&tt.baseline, &tt.live)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnchanged(t *testing.T) {
+	detected := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	found := item(models.DriftChangeModified, models.ChangeObjectRole, "cr1", "role Help Desk was changed",
+		models.IAMRole{ID: "cr1", Name: "Help Desk"}, models.IAMRole{ID: "cr1", Name: "Help Desk", Description: "Unlocks accounts"})
+	found.Status = models.DriftStatusOpen
+	found.DetectedAt, found.LastSeenAt = detected.Add(time.Hour), detected.Add(time.Hour)
+
+	// A stored item is read back from JSON, so its values are plain maps.
+	var stored models.DriftItem
+	data, err := json.Marshal(found)
+	if err != nil {
+		t.Fatalf("failed to encode item: %v", err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("failed to decode item: %v", err)
+	}
+	stored.DetectedAt, stored.LastSeenAt = detected, detected
+
+	tests := []struct {
+		name   string
+		stored func(models.DriftItem) models.DriftItem
+		want   bool
+	}{
+		{
+			name: "same drift read back as maps",
+			want: true,
+		},
+		{
+			name: "actual value changed",
+			stored: func(item models.DriftItem) models.DriftItem {
+				item.Actual = map[string]any{"id": "cr1", "name": "Help Desk", "description": "Resets passwords"}
+				return item
+			},
+		},
+		{
+			name: "description changed",
+			stored: func(item models.DriftItem) models.DriftItem {
+				item.Description = "role Support was changed"
+				return item
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := stored
+			if tt.stored != nil {
+				candidate = tt.stored(candidate)
+			}
+			if got := unchanged(candidate, found); got != tt.want {
+				t.Fatalf("unchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []*models.Role
+		want  []string
+	}{
+		{name: "no roles", want: []string{}},
+		{
+			name: "custom roles by name, others by type",
+			roles: []*models.Role{
+				{Type: "SUPER_ADMIN"},
+				{Type: models.RoleTypeCustom, Name: "Help Desk"},
+				{Type: "READ_ONLY_ADMIN"},
+			},
+			want: []string{"Help Desk", "READ_ONLY_ADMIN", "SUPER_ADMIN"},
+		},
+		{
+			name:  "custom role without a name",
+			roles: []*models.Role{{Type: models.RoleTypeCustom}},
+			want:  []string{models.RoleTypeCustom},
+		},
+		{
+			name:  "duplicates collapse",
+			roles: []*models.Role{{Type: "READ_ONLY_ADMIN"}, {Type: "READ_ONLY_ADMIN"}},
+			want:  []string{"READ_ONLY_ADMIN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roleKeys(tt.roles); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("roleKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
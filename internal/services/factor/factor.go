@@ -0,0 +1,352 @@
+package factor_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotFound        = errors.New("factor not found")
+	ErrInvalidFactor   = errors.New("invalid factor")
+	ErrInvalidPassCode = errors.New("invalid passcode or answer")
+)
+
+// Okta error codes the service turns into its own errors.
+const (
+	oktaNotFound        = "E0000007"
+	oktaInvalidPassCode = "E0000068"
+)
+
+// Service manages the multifactor authentication factors of users. Every
+// change, and every challenge sent to a user, is audited.
+type Service struct {
+	client   *okta.APIClient
+	log      *zap.SugaredLogger
+	auditSvc *audit_service.Service
+}
+
+func New(log *zap.SugaredLogger, client *okta.APIClient, auditSvc *audit_service.Service) *Service {
+	return &Service{log: log, client: client, auditSvc: auditSvc}
+}
+
+func (s *Service) GetFactors(ctx context.Context, userID string) ([]*models.Factor, error) {
+	ctx, span := tracing.Start(ctx, "factor_service.GetFactors", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Getting user factors from Okta", "userId", userID)
+
+	factors, response, err := s.client.UserFactorAPI.ListFactors(ctx, userID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get user factors from Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get user factors from Okta: %w", err)
+	}
+
+	result := make([]*models.Factor, len(factors))
+	for i := range factors {
+		if result[i], err = models.ConvertOktaFactorToModel(&factors[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	s.log.Infow("User factors retrieved successfully from Okta", "userId", userID, "factorCount", len(result))
+	return result, nil
+}
+
+func (s *Service) GetFactor(ctx context.Context, userID, factorID string) (*models.Factor, error) {
+	ctx, span := tracing.Start(ctx, "factor_service.GetFactor",
+		tracing.UserID.String(userID), tracing.FactorID.String(factorID),
+	)
+	defer span.End()
+
+	s.log.Infow("Getting user factor from Okta", "userId", userID, "factorId", factorID)
+
+	factor, response, err := s.client.UserFactorAPI.GetFactor(ctx, userID, factorID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get user factor from Okta", zap.Error(err),
+			"userId", userID,
+			"factorId", factorID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, wrap(err, "failed to get user factor from Okta")
+	}
+
+	return models.ConvertOktaFactorToModel(factor)
+}
+
+// EnrollFactor enrolls a factor for a user. Unless activated right away,
+// the factor stays pending until ActivateFactor is called with a passcode.
+func (s *Service) EnrollFactor(ctx context.Context, userID string, req *models.EnrollFactorRequest) (*models.Factor, error) {
+	ctx, span := tracing.Start(ctx, "factor_service.EnrollFactor", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Enrolling user factor in Okta", "userId", userID,
+		"factorType", req.FactorType, "provider", req.Provider,
+	)
+
+	// The SDK picks the type of the factor by its factorType.
+	data, err := json.Marshal(map[string]any{
+		"factorType": req.FactorType,
+		"provider":   req.Provider,
+		"profile":    req.Profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFactor, err)
+	}
+	var body okta.ListFactors200ResponseInner
+	if err := body.UnmarshalJSON(data); err != nil || body.GetActualInstance() == nil {
+		return nil, fmt.Errorf("%w: unsupported factor type '%s'", ErrInvalidFactor, req.FactorType)
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserFactorAPI.EnrollFactor", "users/"+userID+"/factors", req))
+		status := models.FactorStatusPendingActivation
+		if req.Activate {
+			status = models.FactorStatusActive
+		}
+		return &models.Factor{
+			FactorType: req.FactorType, Provider: req.Provider, Status: status, Profile: req.Profile,
+		}, nil
+	}
+
+	enrolled, response, err := s.client.UserFactorAPI.EnrollFactor(ctx, userID).
+		Body(body).Activate(req.Activate).Execute()
+	details := map[string]any{"factorType": req.FactorType, "provider": req.Provider}
+	if err != nil {
+		s.log.Infow("Failed to enroll user factor in Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		s.audit(ctx, "factor.enrolled", userID, details, err)
+		return nil, wrap(err, "failed to enroll user factor in Okta")
+	}
+
+	factor, err := models.ConvertOktaFactorToModel(enrolled)
+	if err != nil {
+		return nil, err
+	}
+	details["factorId"] = factor.ID
+	s.audit(ctx, "factor.enrolled", userID, details, nil)
+
+	s.log.Infow("User factor enrolled successfully in Okta", "userId", userID, "factorId", factor.ID)
+	return factor, nil
+}
+
+// ActivateFactor finishes enrolling a pending factor.
+func (s *Service) ActivateFactor(
+	ctx context.Context, userID, factorID string, req *models.ActivateFactorRequest,
+) (*models.Factor, error) {
+	ctx, span := tracing.Start(ctx, "factor_service.ActivateFactor",
+		tracing.UserID.String(userID), tracing.FactorID.String(factorID),
+	)
+	defer span.End()
+
+	s.log.Infow("Activating user factor in Okta", "userId", userID, "factorId", factorID)
+
+	if dryrun.Enabled(ctx) {
+		factor, err := s.GetFactor(ctx, userID, factorID)
+		if err != nil {
+			return nil, err
+		}
+		dryrun.Record(ctx, dryrun.Okta("UserFactorAPI.ActivateFactor", "users/"+userID+"/factors/"+factorID, nil))
+		factor.Status = models.FactorStatusActive
+		return factor, nil
+	}
+
+	body := map[string]any{}
+	if req.PassCode != "" {
+		body["passCode"] = req.PassCode
+	}
+
+	activated, response, err := s.client.UserFactorAPI.ActivateFactor(ctx, userID, factorID).Body(body).Execute()
+	details := map[string]any{"factorId": factorID}
+	if err != nil {
+		s.log.Infow("Failed to activate user factor in Okta", zap.Error(err),
+			"userId", userID,
+			"factorId", factorID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		s.audit(ctx, "factor.activated", userID, details, err)
+		return nil, wrap(err, "failed to activate user factor in Okta")
+	}
+
+	factor, err := models.ConvertOktaFactorToModel(activated)
+	if err != nil {
+		return nil, err
+	}
+	details["factorType"] = factor.FactorType
+	s.audit(ctx, "factor.activated", userID, details, nil)
+
+	s.log.Infow("User factor activated successfully in Okta", "userId", userID, "factorId", factorID)
+	return factor, nil
+}
+
+// VerifyFactor challenges a factor. A wrong passcode or answer is reported
+// as a REJECTED result rather than an error.
+func (s *Service) VerifyFactor(
+	ctx context.Context, userID, factorID string, req *models.VerifyFactorRequest,
+) (*models.FactorVerification, error) {
+	ctx, span := tracing.Start(ctx, "factor_service.VerifyFactor",
+		tracing.UserID.String(userID), tracing.FactorID.String(factorID),
+	)
+	defer span.End()
+
+	s.log.Infow("Verifying user factor in Okta", "userId", userID, "factorId", factorID)
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserFactorAPI.VerifyFactor", "users/"+userID+"/factors/"+factorID+"/verify", nil))
+		return &models.FactorVerification{FactorResult: models.FactorResultWaiting}, nil
+	}
+
+	body := map[string]any{}
+	if req.PassCode != "" {
+		body["passCode"] = req.PassCode
+	}
+	if req.Answer != "" {
+		body["answer"] = req.Answer
+	}
+
+	result, response, err := s.client.UserFactorAPI.VerifyFactor(ctx, userID, factorID).Body(body).Execute()
+	details := map[string]any{"factorId": factorID}
+	if err != nil {
+		if oktaErrorCode(err) == oktaInvalidPassCode {
+			details["factorResult"] = models.FactorResultRejected
+			s.audit(ctx, "factor.verified", userID, details, nil)
+			return &models.FactorVerification{FactorResult: models.FactorResultRejected}, nil
+		}
+
+		s.log.Infow("Failed to verify user factor in Okta", zap.Error(err),
+			"userId", userID,
+			"factorId", factorID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		s.audit(ctx, "factor.verified", userID, details, err)
+		return nil, wrap(err, "failed to verify user factor in Okta")
+	}
+
+	verification := &models.FactorVerification{
+		FactorResult: result.GetFactorResult(),
+		Message:      result.GetFactorMessage(),
+		ExpiresAt:    result.ExpiresAt,
+	}
+	details["factorResult"] = verification.FactorResult
+	s.audit(ctx, "factor.verified", userID, details, nil)
+
+	s.log.Infow("User factor verified in Okta", "userId", userID, "factorId", factorID,
+		"factorResult", verification.FactorResult,
+	)
+	return verification, nil
+}
+
+// ResetFactor unenrolls one factor, e.g. of a lost phone. The user enrolls
+// it again at the next sign-in if the sign-on policy requires it.
+func (s *Service) ResetFactor(ctx context.Context, userID, factorID string) error {
+	ctx, span := tracing.Start(ctx, "factor_service.ResetFactor",
+		tracing.UserID.String(userID), tracing.FactorID.String(factorID),
+	)
+	defer span.End()
+
+	s.log.Infow("Resetting user factor in Okta", "userId", userID, "factorId", factorID)
+
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetFactor(ctx, userID, factorID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("UserFactorAPI.UnenrollFactor", "users/"+userID+"/factors/"+factorID, nil))
+		return nil
+	}
+
+	response, err := s.client.UserFactorAPI.UnenrollFactor(ctx, userID, factorID).Execute()
+	details := map[string]any{"factorId": factorID}
+	if err != nil {
+		s.log.Infow("Failed to reset user factor in Okta", zap.Error(err),
+			"userId", userID,
+			"factorId", factorID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		s.audit(ctx, "factor.reset", userID, details, err)
+		return wrap(err, "failed to reset user factor in Okta")
+	}
+	s.audit(ctx, "factor.reset", userID, details, nil)
+
+	s.log.Infow("User factor reset successfully in Okta", "userId", userID, "factorId", factorID)
+	return nil
+}
+
+// ResetFactors unenrolls every factor of a user.
+func (s *Service) ResetFactors(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "factor_service.ResetFactors", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Resetting all user factors in Okta", "userId", userID)
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.ResetFactors", "users/"+userID, nil))
+		return nil
+	}
+
+	response, err := s.client.UserAPI.ResetFactors(ctx, userID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to reset all user factors in Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		s.audit(ctx, "factor.reset_all", userID, nil, err)
+		return fmt.Errorf("failed to reset all user factors in Okta: %w", err)
+	}
+	s.audit(ctx, "factor.reset_all", userID, nil, nil)
+
+	s.log.Infow("All user factors reset successfully in Okta", "userId", userID)
+	return nil
+}
+
+func (s *Service) audit(ctx context.Context, action, userID string, details map[string]any, err error) {
+	outcome, errMsg := models.AuditOutcomeSuccess, ""
+	if err != nil {
+		outcome, errMsg = models.AuditOutcomeFailure, err.Error()
+	}
+	s.auditSvc.Record(ctx, models.AuditEntry{
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Outcome:    outcome,
+		Error:      errMsg,
+		Details:    details,
+	})
+}
+
+// wrap turns the Okta errors for an unknown factor and a wrong passcode into
+// the service's own errors.
+func wrap(err error, message string) error {
+	switch oktaErrorCode(err) {
+	case oktaNotFound:
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case oktaInvalidPassCode:
+		return fmt.Errorf("%w: %v", ErrInvalidPassCode, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func oktaErrorCode(err error) string {
+	var oktaErr *okta.GenericOpenAPIError
+	if !errors.As(err, &oktaErr) {
+		return ""
+	}
+	if model, ok := oktaErr.Model().(okta.Error); ok {
+		return model.GetErrorCode()
+	}
+	return ""
+}
@@ -0,0 +1,317 @@
+package grant_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	"github.com/iamBelugaa/iam/pkg/notify"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotFound      = errors.New("grant not found")
+	ErrInvalidExpiry = errors.New("expiresAt must be in the future")
+	ErrNotActive     = errors.New("grant is not active")
+	ErrNoAssignment  = errors.New("grant has no role assignment ID to revoke")
+)
+
+// Filter narrows the grants returned by GetGrants. Empty fields match everything.
+type Filter struct {
+	Status       string
+	UserID       string
+	ResourceType string
+}
+
+type Service struct {
+	log       *zap.SugaredLogger
+	cfg       *config.GrantConfig
+	store     *store.Store[models.Grant]
+	notifier  notify.Notifier
+	rolesSvc  *role_service.Service
+	groupsSvc *group_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	cfg *config.GrantConfig,
+	store *store.Store[models.Grant],
+	notifier notify.Notifier,
+	rolesSvc *role_service.Service,
+	groupsSvc *group_service.Service,
+) *Service {
+	return &Service{
+		log:       log,
+		cfg:       cfg,
+		store:     store,
+		notifier:  notifier,
+		rolesSvc:  rolesSvc,
+		groupsSvc: groupsSvc,
+	}
+}
+
+// GrantID returns the ID of the grant of resource to user. There is at most one
+// grant per user and resource, so granting again replaces the previous one.
+func GrantID(userID, resourceType, resourceID string) string {
+	return fmt.Sprintf("%s:%s:%s", resourceType, resourceID, userID)
+}
+
+// RecordGrant persists a time-bound grant for an assignment that was just made.
+// assignmentID is the ID of the Okta role assignment, and empty for groups.
+func (s *Service) RecordGrant(
+	ctx context.Context, grantedBy, userID, resourceType, resourceID, assignmentID string, expiresAt time.Time,
+) (*models.Grant, error) {
+	ctx, span := tracing.Start(ctx, "grant_service.RecordGrant", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Recording time-bound grant", "userId", userID,
+		"resourceType", resourceType, "resourceId", resourceID, "expiresAt", expiresAt,
+	)
+
+	if !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	grant := models.Grant{
+		ID:           GrantID(userID, resourceType, resourceID),
+		UserID:       userID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		AssignmentID: assignmentID,
+		Status:       models.GrantStatusActive,
+		GrantedBy:    grantedBy,
+		Created:      time.Now().UTC(),
+		ExpiresAt:    expiresAt.UTC(),
+	}
+
+	if err := s.store.Put(ctx, grant.ID, grant); err != nil {
+		s.log.Infow("Failed to store grant", zap.Error(err), "grantId", grant.ID)
+		return nil, fmt.Errorf("failed to store grant: %w", err)
+	}
+
+	s.log.Infow("Time-bound grant recorded successfully", "grantId", grant.ID)
+	return &grant, nil
+}
+
+// ForgetGrant drops the time-bound grant of resource to user, if any. It is
+// called when the assignment is removed or made permanent.
+func (s *Service) ForgetGrant(ctx context.Context, userID, resourceType, resourceID string) error {
+	ctx, span := tracing.Start(ctx, "grant_service.ForgetGrant", tracing.UserID.String(userID))
+	defer span.End()
+
+	err := s.store.Delete(ctx, GrantID(userID, resourceType, resourceID))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to delete grant: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetGrant(ctx context.Context, grantID string) (*models.Grant, error) {
+	_, span := tracing.Start(ctx, "grant_service.GetGrant", tracing.GrantID.String(grantID))
+	defer span.End()
+
+	grant, ok := s.store.Get(grantID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &grant, nil
+}
+
+func (s *Service) GetGrants(ctx context.Context, filter Filter) []*models.Grant {
+	_, span := tracing.Start(ctx, "grant_service.GetGrants")
+	defer span.End()
+
+	result := []*models.Grant{}
+	for _, grant := range s.store.List() {
+		if filter.Status != "" && grant.Status != filter.Status {
+			continue
+		}
+		if filter.UserID != "" && grant.UserID != filter.UserID {
+			continue
+		}
+		if filter.ResourceType != "" && grant.ResourceType != filter.ResourceType {
+			continue
+		}
+		result = append(result, &grant)
+	}
+	return result
+}
+
+func (s *Service) ExtendGrant(ctx context.Context, grantID string, expiresAt time.Time) (*models.Grant, error) {
+	ctx, span := tracing.Start(ctx, "grant_service.ExtendGrant", tracing.GrantID.String(grantID))
+	defer span.End()
+
+	s.log.Infow("Extending time-bound grant", "grantId", grantID, "expiresAt", expiresAt)
+
+	if !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	grant, err := s.store.Update(ctx, grantID, func(record *models.Grant) error {
+		if record.Status != models.GrantStatusActive {
+			return ErrNotActive
+		}
+		record.ExpiresAt = expiresAt.UTC()
+		return nil
+	})
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Time-bound grant extended successfully", "grantId", grantID)
+	return &grant, nil
+}
+
+// RevokeExpiredGrants removes every active grant whose expiry has passed.
+// Grants that fail to revoke stay active and are retried on the next run,
+// until they have failed MaxRevokeAttempts times and are marked FAILED.
+func (s *Service) RevokeExpiredGrants(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "grant_service.RevokeExpiredGrants")
+	defer span.End()
+
+	now := time.Now()
+	revoked := 0
+
+	for _, grant := range s.store.List() {
+		if grant.Status != models.GrantStatusActive || grant.ExpiresAt.After(now) {
+			continue
+		}
+
+		if err := s.revoke(ctx, &grant); err != nil {
+			s.log.Infow("Failed to revoke expired grant", zap.Error(err), "grantId", grant.ID)
+			s.recordFailure(ctx, &grant, err)
+			continue
+		}
+
+		updated, err := s.store.Update(ctx, grant.ID, func(record *models.Grant) error {
+			revokedAt := time.Now().UTC()
+			record.Status = models.GrantStatusExpired
+			record.LastError = ""
+			record.RevokedAt = &revokedAt
+			return nil
+		})
+		if err != nil {
+			s.log.Infow("Failed to store revoked grant", zap.Error(err), "grantId", grant.ID)
+			continue
+		}
+
+		revoked++
+		s.notify(ctx, "grant.expired", &updated, []string{updated.UserID},
+			fmt.Sprintf("Time-bound access to %s %s expired and was revoked", updated.ResourceType, updated.ResourceID),
+		)
+	}
+
+	if revoked > 0 {
+		s.log.Infow("Revoked expired grants", "count", revoked)
+	}
+	return revoked
+}
+
+// Run revokes expired grants every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	s.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+// tick revokes expired grants once. A panic is logged instead of stopping the
+// loop, so the next tick tries again.
+func (s *Service) tick(ctx context.Context) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while revoking expired grants", "panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+	s.RevokeExpiredGrants(ctx)
+}
+
+// revoke removes the access a grant gave. A role assignment or group
+// membership that is already gone counts as revoked.
+func (s *Service) revoke(ctx context.Context, grant *models.Grant) error {
+	if grant.ResourceType != models.ResourceTypeRole {
+		err := s.groupsSvc.RemoveUserFromGroup(ctx, grant.ResourceID, grant.UserID)
+		if errors.Is(err, group_service.ErrNotMember) {
+			return nil
+		}
+		return err
+	}
+
+	if grant.AssignmentID == "" {
+		return ErrNoAssignment
+	}
+
+	err := s.rolesSvc.UnassignRoleFromUser(ctx, grant.UserID, grant.AssignmentID)
+	if errors.Is(err, role_service.ErrNotAssigned) {
+		return nil
+	}
+	return err
+}
+
+// recordFailure counts a failed revocation. Once MaxRevokeAttempts is reached
+// the grant is marked FAILED, so it is no longer retried, and an alert is sent
+// to whoever granted it.
+func (s *Service) recordFailure(ctx context.Context, grant *models.Grant, cause error) {
+	updated, err := s.store.Update(ctx, grant.ID, func(record *models.Grant) error {
+		record.LastError = cause.Error()
+		record.RevokeAttempts++
+		if record.RevokeAttempts >= s.cfg.MaxRevokeAttempts {
+			record.Status = models.GrantStatusFailed
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Infow("Failed to store grant revocation failure", zap.Error(err), "grantId", grant.ID)
+		return
+	}
+	if updated.Status != models.GrantStatusFailed {
+		return
+	}
+
+	s.log.Infow("Giving up on revoking expired grant", "grantId", updated.ID, "attempts", updated.RevokeAttempts)
+
+	recipients := []string{}
+	if updated.GrantedBy != "" {
+		recipients = append(recipients, updated.GrantedBy)
+	}
+	s.notify(ctx, "grant.revoke_failed", &updated, recipients,
+		fmt.Sprintf("Time-bound access of %s to %s %s expired but could not be revoked after %d attempts: %s",
+			updated.UserID, updated.ResourceType, updated.ResourceID, updated.RevokeAttempts, updated.LastError),
+	)
+}
+
+func (s *Service) notify(ctx context.Context, eventType string, grant *models.Grant, recipients []string, message string) {
+	event := notify.Event{
+		Type:       eventType,
+		Subject:    grant.ID,
+		Message:    message,
+		Recipients: recipients,
+		Data:       grant,
+		Time:       time.Now().UTC(),
+	}
+
+	if err := s.notifier.Notify(ctx, event); err != nil {
+		s.log.Infow("Failed to send grant notification", zap.Error(err), "grantId", grant.ID)
+	}
+}
@@ -0,0 +1,182 @@
+package grant_service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+func TestGrantID(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		resourceType string
+		resourceID   string
+		want         string
+	}{
+		{name: "role", userID: "00u1", resourceType: models.ResourceTypeRole, resourceID: "SUPER_ADMIN", want: "role:SUPER_ADMIN:00u1"},
+		{name: "group", userID: "00u1", resourceType: models.ResourceTypeGroup, resourceID: "00g1", want: "group:00g1:00u1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GrantID(tt.userID, tt.resourceType, tt.resourceID); got != tt.want {
+				t.Fatalf("GrantID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordGrant(t *testing.T) {
+	ctx := context.Background()
+	svc := testService(t)
+
+	past := time.Now().Add(-time.Minute)
+	if _, err := svc.RecordGrant(ctx, "00u9", "00u1", models.ResourceTypeGroup, "00g1", "", past); !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("error = %v, want %v", err, ErrInvalidExpiry)
+	}
+
+	first := time.Now().Add(time.Hour)
+	if _, err := svc.RecordGrant(ctx, "00u9", "00u1", models.ResourceTypeRole, "SUPER_ADMIN", "ra1", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := time.Now().Add(2 * time.Hour)
+	if _, err := svc.RecordGrant(ctx, "00u8", "00u1", models.ResourceTypeRole, "SUPER_ADMIN", "ra2", second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	grants := svc.GetGrants(ctx, Filter{})
+	if len(grants) != 1 {
+		t.Fatalf("got %d grants, want the second grant to replace the first", len(grants))
+	}
+	if got := grants[0]; got.AssignmentID != "ra2" || got.GrantedBy != "00u8" || !got.ExpiresAt.Equal(second) {
+		t.Fatalf("grant = %+v, want the second grant", got)
+	}
+}
+
+func TestGetGrants(t *testing.T) {
+	ctx := context.Background()
+	svc := testService(t)
+
+	grants := []models.Grant{
+		{UserID: "00u1", ResourceType: models.ResourceTypeRole, ResourceID: "SUPER_ADMIN", Status: models.GrantStatusActive},
+		{UserID: "00u1", ResourceType: models.ResourceTypeGroup, ResourceID: "00g1", Status: models.GrantStatusExpired},
+		{UserID: "00u2", ResourceType: models.ResourceTypeGroup, ResourceID: "00g1", Status: models.GrantStatusActive},
+	}
+	for _, grant := range grants {
+		grant.ID = GrantID(grant.UserID, grant.ResourceType, grant.ResourceID)
+		if err := svc.store.Put(ctx, grant.ID, grant); err != nil {
+			t.Fatalf("failed to store grant: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name:   "everything",
+			filter: Filter{},
+			want:   []string{"group:00g1:00u1", "group:00g1:00u2", "role:SUPER_ADMIN:00u1"},
+		},
+		{
+			name:   "by status",
+			filter: Filter{Status: models.GrantStatusActive},
+			want:   []string{"group:00g1:00u2", "role:SUPER_ADMIN:00u1"},
+		},
+		{
+			name:   "by user and resource type",
+			filter: Filter{UserID: "00u1", ResourceType: models.ResourceTypeGroup},
+			want:   []string{"group:00g1:00u1"},
+		},
+		{
+			name:   "no match",
+			filter: Filter{UserID: "00u3"},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, grant := range svc.GetGrants(ctx, tt.filter) {
+				got = append(got, grant.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("GetGrants(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtendGrant(t *testing.T) {
+	ctx := context.Background()
+	later := time.Now().Add(3 * time.Hour)
+
+	tests := []struct {
+		name      string
+		status    string
+		grantID   string
+		expiresAt time.Time
+		err       error
+	}{
+		{name: "active", status: models.GrantStatusActive, grantID: "role:SUPER_ADMIN:00u1", expiresAt: later},
+		{name: "expiry in the past", status: models.GrantStatusActive, grantID: "role:SUPER_ADMIN:00u1", expiresAt: time.Now().Add(-time.Minute), err: ErrInvalidExpiry},
+		{name: "expired", status: models.GrantStatusExpired, grantID: "role:SUPER_ADMIN:00u1", expiresAt: later, err: ErrNotActive},
+		{name: "unknown", status: models.GrantStatusActive, grantID: "role:SUPER_ADMIN:00u2", expiresAt: later, err: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := testService(t)
+			grant := models.Grant{
+				ID: "role:SUPER_ADMIN:00u1", UserID: "00u1", ResourceType: models.ResourceTypeRole,
+				ResourceID: "SUPER_ADMIN", Status: tt.status, ExpiresAt: time.Now().Add(time.Hour).UTC(),
+			}
+			if err := svc.store.Put(ctx, grant.ID, grant); err != nil {
+				t.Fatalf("failed to store grant: %v", err)
+			}
+
+			got, err := svc.ExtendGrant(ctx, tt.grantID, tt.expiresAt)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.ExpiresAt.Equal(tt.expiresAt) {
+				t.Fatalf("ExpiresAt = %v, want %v", got.ExpiresAt, tt.expiresAt)
+			}
+		})
+	}
+}
+
+func TestRevokeWithoutAssignment(t *testing.T) {
+	svc := testService(t)
+	grant := &models.Grant{UserID: "00u1", ResourceType: models.ResourceTypeRole, ResourceID: "SUPER_ADMIN"}
+
+	if err := svc.revoke(context.Background(), grant); !errors.Is(err, ErrNoAssignment) {
+		t.Fatalf("error = %v, want %v", err, ErrNoAssignment)
+	}
+}
+
+func testService(t *testing.T) *Service {
+	t.Helper()
+
+	grants, err := store.New[models.Grant]("")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return New(zap.NewNop().Sugar(), nil, grants, nil, nil, nil)
+}
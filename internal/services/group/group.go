@@ -2,23 +2,47 @@ package group_service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/patch"
+	"github.com/iamBelugaa/iam/pkg/tracing"
 	"github.com/okta/okta-sdk-golang/v5/okta"
 	"go.uber.org/zap"
 )
 
+// ErrNotMember is returned when a membership to remove does not exist
+// because the group or the user is gone.
+var ErrNotMember = errors.New("user is not a member of the group")
+
+// Guard vets group memberships before they are made in Okta.
+type Guard interface {
+	CheckUserGroup(ctx context.Context, userID, groupID string) error
+}
+
 type Service struct {
 	client *okta.APIClient
 	log    *zap.SugaredLogger
+	guard  Guard
 }
 
 func New(log *zap.SugaredLogger, client *okta.APIClient) *Service {
 	return &Service{log: log, client: client}
 }
 
+// UseGuard makes every membership change that adds a user pass guard first.
+func (s *Service) UseGuard(guard Guard) {
+	s.guard = guard
+}
+
 func (s *Service) CreateGroup(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "group_service.CreateGroup")
+	defer span.End()
+
 	s.log.Infow("Creating group in Okta", "name", req.Name)
 
 	profile := okta.GroupProfile{
@@ -30,13 +54,19 @@ func (s *Service) CreateGroup(ctx context.Context, req *models.CreateGroupReques
 		profile.AdditionalProperties = req.Profile
 	}
 
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.CreateGroup", "groups", profile))
+		groupType := models.GroupTypeOkta
+		return models.ConvertOktaGroupToModel(&okta.Group{Id: new(string), Type: &groupType, Profile: &profile}), nil
+	}
+
 	group, response, err := s.client.GroupAPI.
 		CreateGroup(ctx).Group(okta.Group{Profile: &profile}).Execute()
 
 	if err != nil {
 		s.log.Infow("Failed to create group in Okta", zap.Error(err),
 			"name", req.Name,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to create group in Okta: %w", err)
 	}
@@ -46,13 +76,16 @@ func (s *Service) CreateGroup(ctx context.Context, req *models.CreateGroupReques
 }
 
 func (s *Service) GetGroup(ctx context.Context, groupID string) (*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "group_service.GetGroup", tracing.GroupID.String(groupID))
+	defer span.End()
+
 	s.log.Infow("Getting group from Okta", "groupId", groupID)
 
 	group, response, err := s.client.GroupAPI.GetGroup(ctx, groupID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to get group from Okta", zap.Error(err),
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to get group from Okta: %w", err)
 	}
@@ -61,12 +94,29 @@ func (s *Service) GetGroup(ctx context.Context, groupID string) (*models.Group,
 }
 
 func (s *Service) GetGroups(ctx context.Context) ([]*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "group_service.GetGroups")
+	defer span.End()
+
 	s.log.Infow("Getting groups from Okta")
 
-	groups, _, err := s.client.GroupAPI.ListGroups(ctx).Execute()
-	if err != nil {
-		s.log.Infow("Failed to get groups from Okta", zap.Error(err))
-		return nil, fmt.Errorf("failed to get groups from Okta: %w", err)
+	var groups []okta.Group
+	request := s.client.GroupAPI.ListGroups(ctx)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get groups from Okta", zap.Error(err), "statusCode", oktaclient.StatusCode(response))
+			return nil, fmt.Errorf("failed to get groups from Okta: %w", err)
+		}
+
+		groups = append(groups, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
 	}
 
 	result := make([]*models.Group, len(groups))
@@ -79,52 +129,147 @@ func (s *Service) GetGroups(ctx context.Context) ([]*models.Group, error) {
 }
 
 func (s *Service) UpdateGroup(ctx context.Context, groupID string, req *models.UpdateGroupRequest) (*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "group_service.UpdateGroup", tracing.GroupID.String(groupID))
+	defer span.End()
+
 	s.log.Infow("Updating group in Okta", zap.String("groupId", groupID))
 
-	var updateNeeded bool
-	var profile okta.GroupProfile
+	if req.Name == "" && req.Description == "" && len(req.Profile) == 0 {
+		return s.GetGroup(ctx, groupID)
+	}
+
+	// ReplaceGroup overwrites the whole profile, so start from the current one
+	// to keep the fields that are not part of this update.
+	current, response, err := s.client.GroupAPI.GetGroup(ctx, groupID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get group from Okta", zap.Error(err),
+			"groupId", groupID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get group from Okta: %w", err)
+	}
+
+	profile := okta.GroupProfile{AdditionalProperties: map[string]any{}}
+	if current.Profile != nil {
+		profile.Name = current.Profile.Name
+		profile.Description = current.Profile.Description
+		for key, value := range current.Profile.AdditionalProperties {
+			profile.AdditionalProperties[key] = value
+		}
+	}
 
 	if req.Name != "" {
-		updateNeeded = true
 		profile.SetName(req.Name)
 	}
 
 	if req.Description != "" {
-		updateNeeded = true
 		profile.SetDescription(req.Description)
 	}
 
-	if len(req.Profile) > 0 {
-		updateNeeded = true
-		profile.AdditionalProperties = req.Profile
+	for key, value := range req.Profile {
+		profile.AdditionalProperties[key] = value
 	}
 
-	if !updateNeeded {
-		return s.GetGroup(ctx, groupID)
+	return s.replaceGroup(ctx, current, &profile)
+}
+
+// PatchGroup applies a JSON Merge Patch or JSON Patch to the group's current
+// profile and replaces the whole profile in Okta, so removed attributes are cleared.
+func (s *Service) PatchGroup(ctx context.Context, groupID, contentType string, body []byte) (*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "group_service.PatchGroup", tracing.GroupID.String(groupID))
+	defer span.End()
+
+	s.log.Infow("Patching group in Okta", "groupId", groupID, "contentType", contentType)
+
+	current, response, err := s.client.GroupAPI.GetGroup(ctx, groupID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get group from Okta", zap.Error(err),
+			"groupId", groupID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get group from Okta: %w", err)
+	}
+
+	document := models.GroupPatchDocument{Profile: map[string]any{}}
+	if current.Profile != nil {
+		document.Name = current.Profile.GetName()
+		document.Description = current.Profile.GetDescription()
+		for key, value := range current.Profile.AdditionalProperties {
+			document.Profile[key] = value
+		}
+	}
+
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode group profile: %w", err)
+	}
+
+	patched, err := patch.Apply(contentType, raw, body)
+	if err != nil {
+		s.log.Infow("Failed to apply patch to group", zap.Error(err), "groupId", groupID)
+		return nil, fmt.Errorf("failed to apply patch to group: %w", err)
+	}
+
+	var result models.GroupPatchDocument
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return nil, fmt.Errorf("failed to apply patch to group: %w: %v", patch.ErrInvalidPatch, err)
+	}
+
+	profile := okta.GroupProfile{AdditionalProperties: result.Profile}
+	profile.SetName(result.Name)
+	if result.Description != "" {
+		profile.SetDescription(result.Description)
+	}
+
+	return s.replaceGroup(ctx, current, &profile)
+}
+
+func (s *Service) replaceGroup(ctx context.Context, current *okta.Group, profile *okta.GroupProfile) (*models.Group, error) {
+	groupID := current.GetId()
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.ReplaceGroup", "groups/"+groupID, profile))
+		return models.ConvertOktaGroupToModel(&okta.Group{
+			Id:      current.Id,
+			Type:    current.Type,
+			Created: current.Created,
+			Profile: profile,
+		}), nil
 	}
 
 	updatedGroup, response, err := s.client.GroupAPI.
-		ReplaceGroup(ctx, groupID).Group(okta.Group{Profile: &profile}).Execute()
+		ReplaceGroup(ctx, groupID).Group(okta.Group{Profile: profile}).Execute()
 	if err != nil {
 		s.log.Infow("Failed to update group in Okta", zap.Error(err),
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to update group in Okta: %w", err)
 	}
 
-	s.log.Info("Group updated successfully in Okta", "groupId", groupID)
+	s.log.Infow("Group updated successfully in Okta", "groupId", groupID)
 	return models.ConvertOktaGroupToModel(updatedGroup), nil
 }
 
 func (s *Service) DeleteGroup(ctx context.Context, groupID string) error {
+	ctx, span := tracing.Start(ctx, "group_service.DeleteGroup", tracing.GroupID.String(groupID))
+	defer span.End()
+
 	s.log.Infow("Deleting group from Okta", "groupId", groupID)
 
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetGroup(ctx, groupID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.DeleteGroup", "groups/"+groupID, nil))
+		return nil
+	}
+
 	response, err := s.client.GroupAPI.DeleteGroup(ctx, groupID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to delete group from Okta", zap.Error(err),
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to delete group from Okta: %w", err)
 	}
@@ -134,14 +279,39 @@ func (s *Service) DeleteGroup(ctx context.Context, groupID string) error {
 }
 
 func (s *Service) AddUserToGroup(ctx context.Context, groupID, userID string) error {
+	ctx, span := tracing.Start(ctx, "group_service.AddUserToGroup", tracing.GroupID.String(groupID), tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Adding user to group in Okta", "groupId", groupID, "userId", userID)
 
+	if s.guard != nil {
+		if err := s.guard.CheckUserGroup(ctx, userID, groupID); err != nil {
+			s.log.Infow("Group membership rejected", zap.Error(err), "groupId", groupID, "userId", userID)
+			return err
+		}
+	}
+
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetGroup(ctx, groupID); err != nil {
+			return err
+		}
+		if _, response, err := s.client.UserAPI.GetUser(ctx, userID).Execute(); err != nil {
+			s.log.Infow("Failed to get user from Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return fmt.Errorf("failed to get user from Okta: %w", err)
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.AssignUserToGroup", "groups/"+groupID+"/users/"+userID, nil))
+		return nil
+	}
+
 	response, err := s.client.GroupAPI.AssignUserToGroup(ctx, groupID, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to add user to group in Okta", zap.Error(err),
 			"groupId", groupID,
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to add user to group in Okta: %w", err)
 	}
@@ -151,15 +321,29 @@ func (s *Service) AddUserToGroup(ctx context.Context, groupID, userID string) er
 }
 
 func (s *Service) RemoveUserFromGroup(ctx context.Context, groupID, userID string) error {
+	ctx, span := tracing.Start(ctx, "group_service.RemoveUserFromGroup", tracing.GroupID.String(groupID), tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Removing user from group in Okta", "groupId", groupID, "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetGroup(ctx, groupID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.UnassignUserFromGroup", "groups/"+groupID+"/users/"+userID, nil))
+		return nil
+	}
+
 	response, err := s.client.GroupAPI.UnassignUserFromGroup(ctx, groupID, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to remove user from group in Okta", zap.Error(err),
 			"groupId", groupID,
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %v", ErrNotMember, err)
+		}
 		return fmt.Errorf("failed to remove user from group in Okta: %w", err)
 	}
 
@@ -168,15 +352,32 @@ func (s *Service) RemoveUserFromGroup(ctx context.Context, groupID, userID strin
 }
 
 func (s *Service) GetGroupMembers(ctx context.Context, groupID string) ([]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "group_service.GetGroupMembers", tracing.GroupID.String(groupID))
+	defer span.End()
+
 	s.log.Infow("Getting group members from Okta", "groupId", groupID)
 
-	users, response, err := s.client.GroupAPI.ListGroupUsers(ctx, groupID).Execute()
-	if err != nil {
-		s.log.Infow("Failed to get group members from Okta", zap.Error(err),
-			"groupId", groupID,
-			"statusCode", response.StatusCode,
-		)
-		return nil, fmt.Errorf("failed to get group members from Okta: %w", err)
+	var users []okta.GroupMember
+	request := s.client.GroupAPI.ListGroupUsers(ctx, groupID)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get group members from Okta", zap.Error(err),
+				"groupId", groupID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to get group members from Okta: %w", err)
+		}
+
+		users = append(users, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
 	}
 
 	result := make([]*models.User, len(users))
@@ -203,3 +404,54 @@ func (s *Service) GetGroupMembers(ctx context.Context, groupID string) ([]*model
 	s.log.Infow("Group members retrieved successfully from Okta", "groupId", groupID, "memberCount", len(result))
 	return result, nil
 }
+
+// GetGroupOwners returns the IDs of the users that own the group.
+func (s *Service) GetGroupOwners(ctx context.Context, groupID string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "group_service.GetGroupOwners", tracing.GroupID.String(groupID))
+	defer span.End()
+
+	s.log.Infow("Getting group owners from Okta", "groupId", groupID)
+
+	var owners []okta.GroupOwner
+	request := s.client.GroupOwnerAPI.ListGroupOwners(ctx, groupID)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get group owners from Okta", zap.Error(err),
+				"groupId", groupID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to get group owners from Okta: %w", err)
+		}
+
+		owners = append(owners, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
+	}
+
+	var result []string
+	for _, owner := range owners {
+		if owner.GetType() == "USER" && owner.GetId() != "" {
+			result = append(result, owner.GetId())
+		}
+	}
+
+	s.log.Infow("Group owners retrieved successfully from Okta", "groupId", groupID, "ownerCount", len(result))
+	return result, nil
+}
+
+// isNotFound reports whether err is Okta's "resource not found" error.
+func isNotFound(err error) bool {
+	var oktaErr *okta.GenericOpenAPIError
+	if !errors.As(err, &oktaErr) {
+		return false
+	}
+	model, ok := oktaErr.Model().(okta.Error)
+	return ok && model.GetErrorCode() == "E0000007"
+}
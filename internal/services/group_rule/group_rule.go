@@ -0,0 +1,348 @@
+package group_rule_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/expression"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid group rule")
+	ErrRuleActive  = errors.New("group rule must be deactivated before it can be changed")
+)
+
+type Service struct {
+	client *okta.APIClient
+	log    *zap.SugaredLogger
+}
+
+func New(log *zap.SugaredLogger, client *okta.APIClient) *Service {
+	return &Service{log: log, client: client}
+}
+
+func (s *Service) CreateGroupRule(ctx context.Context, req *models.CreateGroupRuleRequest) (*models.GroupRule, error) {
+	ctx, span := tracing.Start(ctx, "group_rule_service.CreateGroupRule")
+	defer span.End()
+
+	s.log.Infow("Creating group rule in Okta", "name", req.Name)
+
+	if err := validateRule(req.Name, req.Expression, req.GroupIDs); err != nil {
+		return nil, err
+	}
+
+	groupRule := oktaGroupRule(req.Name, req.Expression, req.GroupIDs, req.ExcludedUserIDs)
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.CreateGroupRule", "groups/rules", groupRule))
+		groupRule.Status = okta.PtrString(models.GroupRuleStatusInactive)
+		return models.ConvertOktaGroupRuleToModel(&groupRule), nil
+	}
+
+	rule, response, err := s.client.GroupAPI.CreateGroupRule(ctx).GroupRule(groupRule).Execute()
+
+	if err != nil {
+		s.log.Infow("Failed to create group rule in Okta", zap.Error(err),
+			"name", req.Name,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to create group rule in Okta: %w", err)
+	}
+
+	s.log.Infow("Group rule created successfully in Okta", "ruleId", rule.GetId(), "name", req.Name)
+	return models.ConvertOktaGroupRuleToModel(rule), nil
+}
+
+func (s *Service) GetGroupRule(ctx context.Context, ruleID string) (*models.GroupRule, error) {
+	ctx, span := tracing.Start(ctx, "group_rule_service.GetGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Getting group rule from Okta", "ruleId", ruleID)
+
+	rule, response, err := s.client.GroupAPI.GetGroupRule(ctx, ruleID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get group rule from Okta", zap.Error(err),
+			"ruleId", ruleID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get group rule from Okta: %w", err)
+	}
+
+	return models.ConvertOktaGroupRuleToModel(rule), nil
+}
+
+func (s *Service) GetGroupRules(ctx context.Context, search string) ([]*models.GroupRule, error) {
+	ctx, span := tracing.Start(ctx, "group_rule_service.GetGroupRules")
+	defer span.End()
+
+	s.log.Infow("Getting group rules from Okta", "search", search)
+
+	request := s.client.GroupAPI.ListGroupRules(ctx)
+	if search != "" {
+		request = request.Search(search)
+	}
+
+	var rules []okta.GroupRule
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get group rules from Okta", zap.Error(err), "statusCode", oktaclient.StatusCode(response))
+			return nil, fmt.Errorf("failed to get group rules from Okta: %w", err)
+		}
+
+		rules = append(rules, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
+	}
+
+	result := make([]*models.GroupRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, models.ConvertOktaGroupRuleToModel(&rule))
+	}
+
+	s.log.Infow("Group rules retrieved successfully from Okta", "count", len(result))
+	return result, nil
+}
+
+// UpdateGroupRule replaces a rule's definition. Okta only accepts changes to
+// inactive rules, so active rules are rejected before the call is made.
+func (s *Service) UpdateGroupRule(ctx context.Context, ruleID string, req *models.UpdateGroupRuleRequest) (*models.GroupRule, error) {
+	ctx, span := tracing.Start(ctx, "group_rule_service.UpdateGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Updating group rule in Okta", "ruleId", ruleID)
+
+	if err := validateRule(req.Name, req.Expression, req.GroupIDs); err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetGroupRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == models.GroupRuleStatusActive {
+		return nil, ErrRuleActive
+	}
+
+	groupRule := oktaGroupRule(req.Name, req.Expression, req.GroupIDs, req.ExcludedUserIDs)
+	groupRule.Id = &ruleID
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.ReplaceGroupRule", "groups/rules/"+ruleID, groupRule))
+		groupRule.Status = &current.Status
+		return models.ConvertOktaGroupRuleToModel(&groupRule), nil
+	}
+
+	rule, response, err := s.client.GroupAPI.ReplaceGroupRule(ctx, ruleID).GroupRule(groupRule).Execute()
+	if err != nil {
+		s.log.Infow("Failed to update group rule in Okta", zap.Error(err),
+			"ruleId", ruleID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to update group rule in Okta: %w", err)
+	}
+
+	s.log.Infow("Group rule updated successfully in Okta", "ruleId", ruleID)
+	return models.ConvertOktaGroupRuleToModel(rule), nil
+}
+
+func (s *Service) ActivateGroupRule(ctx context.Context, ruleID string) error {
+	ctx, span := tracing.Start(ctx, "group_rule_service.ActivateGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Activating group rule in Okta", "ruleId", ruleID)
+
+	if dryrun.Enabled(ctx) {
+		rule, err := s.GetGroupRule(ctx, ruleID)
+		if err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.ActivateGroupRule", "groups/rules/"+ruleID+"/lifecycle", nil))
+
+		rule.Status = models.GroupRuleStatusActive
+		dryrun.SetResult(ctx, rule)
+		return nil
+	}
+
+	response, err := s.client.GroupAPI.ActivateGroupRule(ctx, ruleID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to activate group rule in Okta", zap.Error(err),
+			"ruleId", ruleID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return fmt.Errorf("failed to activate group rule in Okta: %w", err)
+	}
+
+	s.log.Infow("Group rule activated successfully in Okta", "ruleId", ruleID)
+	return nil
+}
+
+func (s *Service) DeactivateGroupRule(ctx context.Context, ruleID string) error {
+	ctx, span := tracing.Start(ctx, "group_rule_service.DeactivateGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Deactivating group rule in Okta", "ruleId", ruleID)
+
+	if dryrun.Enabled(ctx) {
+		rule, err := s.GetGroupRule(ctx, ruleID)
+		if err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.DeactivateGroupRule", "groups/rules/"+ruleID+"/lifecycle", nil))
+
+		rule.Status = models.GroupRuleStatusInactive
+		dryrun.SetResult(ctx, rule)
+		return nil
+	}
+
+	response, err := s.client.GroupAPI.DeactivateGroupRule(ctx, ruleID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to deactivate group rule in Okta", zap.Error(err),
+			"ruleId", ruleID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return fmt.Errorf("failed to deactivate group rule in Okta: %w", err)
+	}
+
+	s.log.Infow("Group rule deactivated successfully in Okta", "ruleId", ruleID)
+	return nil
+}
+
+// DeleteGroupRule deletes a rule. When removeUsers is set, Okta also removes
+// the users the rule assigned from its groups.
+func (s *Service) DeleteGroupRule(ctx context.Context, ruleID string, removeUsers bool) error {
+	ctx, span := tracing.Start(ctx, "group_rule_service.DeleteGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Deleting group rule in Okta", "ruleId", ruleID, "removeUsers", removeUsers)
+
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetGroupRule(ctx, ruleID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("GroupAPI.DeleteGroupRule", "groups/rules/"+ruleID, map[string]bool{"removeUsers": removeUsers}))
+		return nil
+	}
+
+	response, err := s.client.GroupAPI.DeleteGroupRule(ctx, ruleID).RemoveUsers(removeUsers).Execute()
+	if err != nil {
+		s.log.Infow("Failed to delete group rule in Okta", zap.Error(err),
+			"ruleId", ruleID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return fmt.Errorf("failed to delete group rule in Okta: %w", err)
+	}
+
+	s.log.Infow("Group rule deleted successfully in Okta", "ruleId", ruleID)
+	return nil
+}
+
+// ValidateExpression checks an expression locally without calling Okta.
+func (s *Service) ValidateExpression(ctx context.Context, src string) *models.ExpressionValidation {
+	_, span := tracing.Start(ctx, "group_rule_service.ValidateExpression")
+	defer span.End()
+
+	expr, err := expression.Parse(src)
+	if err != nil {
+		return &models.ExpressionValidation{Valid: false, Error: err.Error()}
+	}
+	return &models.ExpressionValidation{Valid: true, Attributes: expr.Attributes()}
+}
+
+// PreviewGroupRule evaluates a rule against a sample user. When ruleID is set
+// the stored rule is used, otherwise the expression in req.
+func (s *Service) PreviewGroupRule(ctx context.Context, ruleID string, req *models.PreviewGroupRuleRequest) (*models.GroupRulePreview, error) {
+	ctx, span := tracing.Start(ctx, "group_rule_service.PreviewGroupRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Previewing group rule", "ruleId", ruleID)
+
+	src, groupIDs, excluded := req.Expression, req.GroupIDs, []string(nil)
+	if ruleID != "" {
+		rule, err := s.GetGroupRule(ctx, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		src, groupIDs, excluded = rule.Expression, rule.GroupIDs, rule.ExcludedUserIDs
+	}
+
+	expr, err := expression.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	matched, err := expr.Match(expression.Env{User: req.Profile, Groups: req.Groups})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	preview := &models.GroupRulePreview{
+		Matched:    matched,
+		Excluded:   req.UserID != "" && slices.Contains(excluded, req.UserID),
+		GroupIDs:   []string{},
+		Attributes: expr.Attributes(),
+	}
+	for _, attr := range preview.Attributes {
+		if _, ok := req.Profile[attr]; !ok {
+			preview.MissingAttributes = append(preview.MissingAttributes, attr)
+		}
+	}
+	if preview.Matched && !preview.Excluded {
+		preview.GroupIDs = groupIDs
+	}
+
+	s.log.Infow("Group rule preview completed", "ruleId", ruleID, "matched", preview.Matched)
+	return preview, nil
+}
+
+func validateRule(name, src string, groupIDs []string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if len(groupIDs) == 0 {
+		return fmt.Errorf("%w: at least one group ID is required", ErrInvalidRule)
+	}
+	if _, err := expression.Parse(src); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+func oktaGroupRule(name, src string, groupIDs, excludedUserIDs []string) okta.GroupRule {
+	ruleType := models.GroupRuleType
+	expressionType := models.GroupRuleExpressionType
+
+	conditions := okta.GroupRuleConditions{
+		Expression: &okta.GroupRuleExpression{Type: &expressionType, Value: &src},
+	}
+	if len(excludedUserIDs) > 0 {
+		conditions.People = &okta.GroupRulePeopleCondition{
+			Users: &okta.GroupRuleUserCondition{Exclude: excludedUserIDs},
+		}
+	}
+
+	return okta.GroupRule{
+		Name:       &name,
+		Type:       &ruleType,
+		Conditions: &conditions,
+		Actions: &okta.GroupRuleAction{
+			AssignUserToGroups: &okta.GroupRuleGroupAssignment{GroupIds: groupIDs},
+		},
+	}
+}
@@ -2,34 +2,67 @@ package role_service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/okta/okta-sdk-golang/v5/okta"
 	"go.uber.org/zap"
 
 	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/tracing"
 )
 
+// Errors returned by dry runs for assignment changes Okta would reject.
+var (
+	ErrNotFound        = errors.New("role not found")
+	ErrAlreadyAssigned = errors.New("role is already assigned")
+	ErrNotAssigned     = errors.New("role is not assigned")
+)
+
+// Guard vets role assignments before they are made in Okta.
+type Guard interface {
+	CheckUserRole(ctx context.Context, userID, roleID string) error
+	CheckGroupRole(ctx context.Context, groupID, roleID string) error
+}
+
 type Service struct {
 	client *okta.APIClient
 	log    *zap.SugaredLogger
+	guard  Guard
 }
 
 func New(log *zap.SugaredLogger, client *okta.APIClient) *Service {
 	return &Service{log: log, client: client}
 }
 
+// UseGuard makes every role assignment pass guard first.
+func (s *Service) UseGuard(guard Guard) {
+	s.guard = guard
+}
+
 func (s *Service) CreateRole(ctx context.Context, req *models.CreateRoleRequest) (*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.CreateRole")
+	defer span.End()
+
 	s.log.Infow("Creating role in Okta", "name", req.Name)
 
 	createRoleRequest := okta.CreateIamRoleRequest{
 		Label:       req.Name,
 		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("RoleAPI.CreateRole", "iam/roles", createRoleRequest))
+		return &models.Role{Name: req.Name, Description: req.Description}, nil
 	}
 
 	role, response, err := s.client.RoleAPI.CreateRole(ctx).Instance(createRoleRequest).Execute()
 	if err != nil {
-		s.log.Infow("Failed to create role in Okta", zap.Error(err), "name", req.Name, "statusCode", response.StatusCode)
+		s.log.Infow("Failed to create role in Okta", zap.Error(err), "name", req.Name, "statusCode", oktaclient.StatusCode(response))
 		return nil, fmt.Errorf("failed to create role in Okta: %w", err)
 	}
 
@@ -38,11 +71,17 @@ func (s *Service) CreateRole(ctx context.Context, req *models.CreateRoleRequest)
 }
 
 func (s *Service) GetRole(ctx context.Context, roleID string) (*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetRole", tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Getting role from Okta", "roleId", roleID)
 
 	role, response, err := s.client.RoleAPI.GetRole(ctx, roleID).Execute()
 	if err != nil {
-		s.log.Infow("Failed to get role from Okta", zap.Error(err), "roleId", roleID, "statusCode", response.StatusCode)
+		s.log.Infow("Failed to get role from Okta", zap.Error(err), "roleId", roleID, "statusCode", oktaclient.StatusCode(response))
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
 		return nil, fmt.Errorf("failed to get role from Okta: %w", err)
 	}
 
@@ -51,17 +90,34 @@ func (s *Service) GetRole(ctx context.Context, roleID string) (*models.Role, err
 }
 
 func (s *Service) GetRoles(ctx context.Context) ([]*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetRoles")
+	defer span.End()
+
 	s.log.Infow("Getting roles from Okta")
 
-	roles, _, err := s.client.RoleAPI.ListRoles(ctx).Execute()
-	if err != nil {
-		s.log.Infow("Failed to get roles from Okta", zap.Error(err))
-		return nil, fmt.Errorf("failed to get roles from Okta: %w", err)
+	var roles []okta.IamRole
+	request := s.client.RoleAPI.ListRoles(ctx)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.LinkedCursor(page.Links)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get roles from Okta", zap.Error(err), "statusCode", oktaclient.StatusCode(response))
+			return nil, fmt.Errorf("failed to get roles from Okta: %w", err)
+		}
+
+		roles = append(roles, page.Roles...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
 	}
 
-	result := make([]*models.Role, len(roles.Roles))
-	for i := range roles.Roles {
-		result[i] = models.ConvertOktaIamRoleToModel(&roles.Roles[i])
+	result := make([]*models.Role, len(roles))
+	for i := range roles {
+		result[i] = models.ConvertOktaIamRoleToModel(&roles[i])
 	}
 
 	s.log.Infow("Roles retrieved successfully from Okta", "count", len(result))
@@ -69,6 +125,9 @@ func (s *Service) GetRoles(ctx context.Context) ([]*models.Role, error) {
 }
 
 func (s *Service) UpdateRole(ctx context.Context, roleID string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.UpdateRole", tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Updating role in Okta", "roleId", roleID)
 
 	updateRoleRequest := okta.UpdateIamRoleRequest{}
@@ -88,9 +147,25 @@ func (s *Service) UpdateRole(ctx context.Context, roleID string, req *models.Upd
 		return s.GetRole(ctx, roleID)
 	}
 
+	if dryrun.Enabled(ctx) {
+		role, err := s.GetRole(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAPI.ReplaceRole", "iam/roles/"+roleID, updateRoleRequest))
+
+		if req.Name != "" {
+			role.Name = req.Name
+		}
+		if req.Description != "" {
+			role.Description = req.Description
+		}
+		return role, nil
+	}
+
 	role, response, err := s.client.RoleAPI.ReplaceRole(ctx, roleID).Instance(updateRoleRequest).Execute()
 	if err != nil {
-		s.log.Infow("Failed to update role in Okta", zap.Error(err), "roleId", roleID, "statusCode", response.StatusCode)
+		s.log.Infow("Failed to update role in Okta", zap.Error(err), "roleId", roleID, "statusCode", oktaclient.StatusCode(response))
 		return nil, fmt.Errorf("failed to update role in Okta: %w", err)
 	}
 
@@ -99,13 +174,24 @@ func (s *Service) UpdateRole(ctx context.Context, roleID string, req *models.Upd
 }
 
 func (s *Service) DeleteRole(ctx context.Context, roleID string) error {
+	ctx, span := tracing.Start(ctx, "role_service.DeleteRole", tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Deleting role from Okta", "roleId", roleID)
 
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetRole(ctx, roleID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAPI.DeleteRole", "iam/roles/"+roleID, nil))
+		return nil
+	}
+
 	response, err := s.client.RoleAPI.DeleteRole(ctx, roleID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to delete role from Okta", zap.Error(err),
 			"roleId", roleID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to delete role from Okta: %w", err)
 	}
@@ -114,38 +200,83 @@ func (s *Service) DeleteRole(ctx context.Context, roleID string) error {
 	return nil
 }
 
-func (s *Service) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
+// AssignRoleToUser assigns a role to a user and returns the ID of the
+// assignment, which UnassignRoleFromUser needs to remove it again. Dry runs
+// return an empty ID.
+func (s *Service) AssignRoleToUser(ctx context.Context, userID, roleID string) (string, error) {
+	ctx, span := tracing.Start(ctx, "role_service.AssignRoleToUser", tracing.UserID.String(userID), tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Assigning role to user in Okta", "roleId", roleID, "userId", userID)
 
+	if s.guard != nil {
+		if err := s.guard.CheckUserRole(ctx, userID, roleID); err != nil {
+			s.log.Infow("Role assignment to user rejected", zap.Error(err), "roleId", roleID, "userId", userID)
+			return "", err
+		}
+	}
+
 	assignRoleRequest := okta.AssignRoleRequest{
 		Type: &roleID,
 	}
 
-	_, response, err := s.client.RoleAssignmentAPI.
+	if dryrun.Enabled(ctx) {
+		roles, err := s.GetUserRoles(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		if hasRole(roles, roleID) {
+			return "", ErrAlreadyAssigned
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAssignmentAPI.AssignRoleToUser", "users/"+userID+"/roles", assignRoleRequest))
+		return "", nil
+	}
+
+	assignment, response, err := s.client.RoleAssignmentAPI.
 		AssignRoleToUser(ctx, userID).AssignRoleRequest(assignRoleRequest).Execute()
 	if err != nil {
 		s.log.Infow("Failed to assign role to user in Okta", zap.Error(err),
 			"roleId", roleID,
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
-		return fmt.Errorf("failed to assign role to user in Okta: %w", err)
+		return "", fmt.Errorf("failed to assign role to user in Okta: %w", err)
 	}
 
-	s.log.Infow("Role assigned to user successfully in Okta", "roleId", roleID, "userId", userID)
-	return nil
+	s.log.Infow("Role assigned to user successfully in Okta", "roleId", roleID, "userId", userID,
+		"assignmentId", assignment.GetId(),
+	)
+	return assignment.GetId(), nil
 }
 
 func (s *Service) UnassignRoleFromUser(ctx context.Context, userID, roleID string) error {
+	ctx, span := tracing.Start(ctx, "role_service.UnassignRoleFromUser", tracing.UserID.String(userID), tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Unassigning role from user in Okta", "roleId", roleID, "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		roles, err := s.GetUserRoles(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !hasRole(roles, roleID) {
+			return ErrNotAssigned
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAssignmentAPI.UnassignRoleFromUser", "users/"+userID+"/roles/"+roleID, nil))
+		return nil
+	}
+
 	response, err := s.client.RoleAssignmentAPI.UnassignRoleFromUser(ctx, userID, roleID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to unassign role from user in Okta", zap.Error(err),
 			"roleId", roleID,
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %v", ErrNotAssigned, err)
+		}
 		return fmt.Errorf("failed to unassign role from user in Okta: %w", err)
 	}
 
@@ -154,19 +285,41 @@ func (s *Service) UnassignRoleFromUser(ctx context.Context, userID, roleID strin
 }
 
 func (s *Service) AssignRoleToGroup(ctx context.Context, groupID, roleID string) error {
+	ctx, span := tracing.Start(ctx, "role_service.AssignRoleToGroup", tracing.GroupID.String(groupID), tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Assigning role to group in Okta", "roleId", roleID, "groupId", groupID)
 
+	if s.guard != nil {
+		if err := s.guard.CheckGroupRole(ctx, groupID, roleID); err != nil {
+			s.log.Infow("Role assignment to group rejected", zap.Error(err), "roleId", roleID, "groupId", groupID)
+			return err
+		}
+	}
+
 	assignRoleRequest := okta.AssignRoleRequest{
 		Type: &roleID,
 	}
 
+	if dryrun.Enabled(ctx) {
+		roles, err := s.GetGroupRoles(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if hasRole(roles, roleID) {
+			return ErrAlreadyAssigned
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAssignmentAPI.AssignRoleToGroup", "groups/"+groupID+"/roles", assignRoleRequest))
+		return nil
+	}
+
 	_, response, err := s.client.RoleAssignmentAPI.
 		AssignRoleToGroup(ctx, groupID).AssignRoleRequest(assignRoleRequest).Execute()
 	if err != nil {
 		s.log.Infow("Failed to assign role to group in Okta", zap.Error(err),
 			"roleId", roleID,
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to assign role to group in Okta: %w", err)
 	}
@@ -176,15 +329,33 @@ func (s *Service) AssignRoleToGroup(ctx context.Context, groupID, roleID string)
 }
 
 func (s *Service) UnassignRoleFromGroup(ctx context.Context, groupID, roleID string) error {
+	ctx, span := tracing.Start(ctx, "role_service.UnassignRoleFromGroup", tracing.GroupID.String(groupID), tracing.RoleID.String(roleID))
+	defer span.End()
+
 	s.log.Infow("Unassigning role from group in Okta", "roleId", roleID, "groupId", groupID)
 
+	if dryrun.Enabled(ctx) {
+		roles, err := s.GetGroupRoles(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if !hasRole(roles, roleID) {
+			return ErrNotAssigned
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAssignmentAPI.UnassignRoleFromGroup", "groups/"+groupID+"/roles/"+roleID, nil))
+		return nil
+	}
+
 	response, err := s.client.RoleAssignmentAPI.UnassignRoleFromGroup(ctx, groupID, roleID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to unassign role from group in Okta", zap.Error(err),
 			"roleId", roleID,
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %v", ErrNotAssigned, err)
+		}
 		return fmt.Errorf("failed to unassign role from group in Okta: %w", err)
 	}
 
@@ -193,13 +364,16 @@ func (s *Service) UnassignRoleFromGroup(ctx context.Context, groupID, roleID str
 }
 
 func (s *Service) GetUserRoles(ctx context.Context, userID string) ([]*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetUserRoles", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Getting user roles from Okta", "userId", userID)
 
 	roles, response, err := s.client.RoleAssignmentAPI.ListAssignedRolesForUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to get user roles from Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to get user roles from Okta: %w", err)
 	}
@@ -214,13 +388,16 @@ func (s *Service) GetUserRoles(ctx context.Context, userID string) ([]*models.Ro
 }
 
 func (s *Service) GetGroupRoles(ctx context.Context, groupID string) ([]*models.Role, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetGroupRoles", tracing.GroupID.String(groupID))
+	defer span.End()
+
 	s.log.Infow("Getting group roles from Okta", "groupId", groupID)
 
 	roles, response, err := s.client.RoleAssignmentAPI.ListGroupAssignedRoles(ctx, groupID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to get group roles from Okta", zap.Error(err),
 			"groupId", groupID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to get group roles from Okta: %w", err)
 	}
@@ -233,3 +410,164 @@ func (s *Service) GetGroupRoles(ctx context.Context, groupID string) ([]*models.
 	s.log.Infow("Group roles retrieved successfully from Okta", "groupId", groupID, "roleCount", len(result))
 	return result, nil
 }
+
+// FindUserAssignment returns the ID of the user's direct assignment of
+// roleID, which may be an assignment ID or a role type. It returns
+// ErrNotAssigned when the user does not hold the role.
+func (s *Service) FindUserAssignment(ctx context.Context, userID, roleID string) (string, error) {
+	roles, err := s.GetUserRoles(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, role := range roles {
+		if role.ID == roleID || role.Type == roleID {
+			return role.ID, nil
+		}
+	}
+	return "", ErrNotAssigned
+}
+
+// GetRolePermissions returns the permissions of a custom role, identified by
+// its ID or label. Standard roles such as USER_ADMIN have no permission list.
+func (s *Service) GetRolePermissions(ctx context.Context, roleIDOrLabel string) ([]*models.Permission, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetRolePermissions", tracing.RoleID.String(roleIDOrLabel))
+	defer span.End()
+
+	s.log.Infow("Getting role permissions from Okta", "role", roleIDOrLabel)
+
+	permissions, response, err := s.client.RoleAPI.ListRolePermissions(ctx, roleIDOrLabel).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get role permissions from Okta", zap.Error(err),
+			"role", roleIDOrLabel,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get role permissions from Okta: %w", err)
+	}
+
+	result := make([]*models.Permission, len(permissions.Permissions))
+	for i := range permissions.Permissions {
+		result[i] = models.ConvertOktaPermissionToModel(&permissions.Permissions[i])
+	}
+
+	s.log.Infow("Role permissions retrieved successfully from Okta", "role", roleIDOrLabel, "count", len(result))
+	return result, nil
+}
+
+// AddRolePermission grants permission, such as okta.users.read, to a custom
+// role identified by its ID or label.
+func (s *Service) AddRolePermission(ctx context.Context, roleIDOrLabel, permission string) error {
+	ctx, span := tracing.Start(ctx, "role_service.AddRolePermission", tracing.RoleID.String(roleIDOrLabel))
+	defer span.End()
+
+	s.log.Infow("Adding role permission in Okta", "role", roleIDOrLabel, "permission", permission)
+
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetRole(ctx, roleIDOrLabel); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAPI.CreateRolePermission", "iam/roles/"+roleIDOrLabel+"/permissions/"+permission, nil))
+		return nil
+	}
+
+	response, err := s.client.RoleAPI.CreateRolePermission(ctx, roleIDOrLabel, permission).Execute()
+	if err != nil {
+		s.log.Infow("Failed to add role permission in Okta", zap.Error(err),
+			"role", roleIDOrLabel,
+			"permission", permission,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return fmt.Errorf("failed to add role permission in Okta: %w", err)
+	}
+
+	s.log.Infow("Role permission added successfully in Okta", "role", roleIDOrLabel, "permission", permission)
+	return nil
+}
+
+// RemoveRolePermission takes permission away from a custom role identified
+// by its ID or label.
+func (s *Service) RemoveRolePermission(ctx context.Context, roleIDOrLabel, permission string) error {
+	ctx, span := tracing.Start(ctx, "role_service.RemoveRolePermission", tracing.RoleID.String(roleIDOrLabel))
+	defer span.End()
+
+	s.log.Infow("Removing role permission in Okta", "role", roleIDOrLabel, "permission", permission)
+
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetRole(ctx, roleIDOrLabel); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("RoleAPI.DeleteRolePermission", "iam/roles/"+roleIDOrLabel+"/permissions/"+permission, nil))
+		return nil
+	}
+
+	response, err := s.client.RoleAPI.DeleteRolePermission(ctx, roleIDOrLabel, permission).Execute()
+	if err != nil {
+		s.log.Infow("Failed to remove role permission in Okta", zap.Error(err),
+			"role", roleIDOrLabel,
+			"permission", permission,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return fmt.Errorf("failed to remove role permission in Okta: %w", err)
+	}
+
+	s.log.Infow("Role permission removed successfully in Okta", "role", roleIDOrLabel, "permission", permission)
+	return nil
+}
+
+// GetUsersWithRoles returns the IDs of all users that hold at least one
+// directly assigned admin role.
+func (s *Service) GetUsersWithRoles(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "role_service.GetUsersWithRoles")
+	defer span.End()
+
+	s.log.Infow("Getting users with role assignments from Okta")
+
+	var users []okta.RoleAssignedUser
+	request := s.client.RoleAssignmentAPI.ListUsersWithRoleAssignments(ctx)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.LinkedCursor(page.Links)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get users with role assignments from Okta", zap.Error(err),
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to get users with role assignments from Okta: %w", err)
+		}
+
+		users = append(users, page.Value...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
+	}
+
+	result := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.GetId() != "" {
+			result = append(result, user.GetId())
+		}
+	}
+
+	s.log.Infow("Users with role assignments retrieved successfully from Okta", "count", len(result))
+	return result, nil
+}
+
+// isNotFound reports whether Okta answered that the resource does not exist.
+func isNotFound(err error) bool {
+	var oktaErr *okta.GenericOpenAPIError
+	if !errors.As(err, &oktaErr) {
+		return false
+	}
+	model, ok := oktaErr.Model().(okta.Error)
+	return ok && model.GetErrorCode() == "E0000007"
+}
+
+// hasRole reports whether roles contains the assignment roleID, which may be
+// an assignment ID or a role type.
+func hasRole(roles []*models.Role, roleID string) bool {
+	return slices.ContainsFunc(roles, func(role *models.Role) bool {
+		return role.ID == roleID || role.Type == roleID
+	})
+}
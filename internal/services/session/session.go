@@ -0,0 +1,237 @@
+package session_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotFound      = errors.New("user not found")
+	ErrCannotSuspend = errors.New("user cannot be suspended in their current status")
+)
+
+// oktaNotFound is the Okta error code for an unknown user.
+const oktaNotFound = "E0000007"
+
+// Service signs users out of Okta and the apps they use, for offboarding and
+// incident response. Every change is audited.
+type Service struct {
+	client   *okta.APIClient
+	log      *zap.SugaredLogger
+	auditSvc *audit_service.Service
+	usersSvc *user_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	client *okta.APIClient,
+	auditSvc *audit_service.Service,
+	usersSvc *user_service.Service,
+) *Service {
+	return &Service{log: log, client: client, auditSvc: auditSvc, usersSvc: usersSvc}
+}
+
+// GetAppGrants lists the OAuth scopes the user has consented to, per app.
+func (s *Service) GetAppGrants(ctx context.Context, userID string) ([]*models.AppGrant, error) {
+	ctx, span := tracing.Start(ctx, "session_service.GetAppGrants", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Getting user app grants from Okta", "userId", userID)
+
+	var grants []okta.OAuth2ScopeConsentGrant
+	request := s.client.UserAPI.ListUserGrants(ctx, userID)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get user app grants from Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, wrap(err, "failed to get user app grants from Okta")
+		}
+
+		grants = append(grants, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
+	}
+
+	result := make([]*models.AppGrant, len(grants))
+	for i := range grants {
+		result[i] = models.ConvertOktaGrantToModel(&grants[i])
+	}
+
+	s.log.Infow("User app grants retrieved successfully from Okta", "userId", userID, "count", len(result))
+	return result, nil
+}
+
+// ClearSessions ends every Okta session of a user. Apps that rely on their
+// own sessions or on OAuth tokens keep the user signed in unless revokeTokens
+// is set; revokeGrants also withdraws the user's consent to app scopes.
+func (s *Service) ClearSessions(
+	ctx context.Context, userID string, revokeTokens, revokeGrants bool,
+) (*models.ClearedSessions, error) {
+	ctx, span := tracing.Start(ctx, "session_service.ClearSessions", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Clearing user sessions in Okta", "userId", userID,
+		"revokeTokens", revokeTokens,
+		"revokeGrants", revokeGrants,
+	)
+
+	details := map[string]any{"revokeTokens": revokeTokens, "revokeGrants": revokeGrants}
+	if err := s.clear(ctx, userID, revokeTokens, revokeGrants); err != nil {
+		s.audit(ctx, "session.cleared", userID, "", details, err)
+		return nil, err
+	}
+	s.audit(ctx, "session.cleared", userID, "", details, nil)
+
+	s.log.Infow("User sessions cleared successfully in Okta", "userId", userID)
+	return &models.ClearedSessions{UserID: userID, TokensRevoked: revokeTokens, GrantsRevoked: revokeGrants}, nil
+}
+
+// ContainUser stops a user from signing in and ends their sessions and OAuth
+// tokens. Okta only suspends active users. Users it cannot suspend but who
+// may still sign in, e.g. when locked out or in recovery, are deactivated
+// when req.Deactivate is set, and otherwise fail with ErrCannotSuspend.
+// Sessions are cleared either way, and the returned containment reports what
+// was done.
+func (s *Service) ContainUser(
+	ctx context.Context, userID string, req *models.ContainUserRequest,
+) (*models.Containment, error) {
+	ctx, span := tracing.Start(ctx, "session_service.ContainUser", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Containing user", "userId", userID, "revokeGrants", req.RevokeGrants, "deactivate", req.Deactivate)
+
+	user, err := s.usersSvc.GetUser(ctx, userID)
+	if err != nil {
+		err = wrap(err, "failed to contain user")
+		s.audit(ctx, "user.contained", userID, req.Reason, nil, err)
+		return nil, err
+	}
+
+	containment := &models.Containment{UserID: userID, PreviousStatus: user.Status}
+
+	var blockErr error
+	switch user.Status {
+	case models.UserStatusActive:
+		if blockErr = s.usersSvc.SuspendUser(ctx, userID); blockErr == nil {
+			containment.Suspended = true
+		}
+	case models.UserStatusSuspended, models.UserStatusDeprovisioned:
+		// Already unable to sign in, but may still have sessions and tokens
+		// from before.
+	default:
+		if !req.Deactivate {
+			blockErr = fmt.Errorf("%w: %s", ErrCannotSuspend, user.Status)
+		} else if blockErr = s.usersSvc.DeactivateUser(ctx, userID); blockErr == nil {
+			containment.Deactivated = true
+		}
+	}
+
+	clearErr := s.clear(ctx, userID, true, req.RevokeGrants)
+	if clearErr == nil {
+		containment.SessionsCleared = true
+		containment.TokensRevoked = true
+		containment.GrantsRevoked = req.RevokeGrants
+	}
+
+	err = errors.Join(blockErr, clearErr)
+	containment.Contained = err == nil
+	s.audit(ctx, "user.contained", userID, req.Reason, map[string]any{
+		"previousStatus":  containment.PreviousStatus,
+		"contained":       containment.Contained,
+		"suspended":       containment.Suspended,
+		"deactivated":     containment.Deactivated,
+		"sessionsCleared": containment.SessionsCleared,
+		"tokensRevoked":   containment.TokensRevoked,
+		"grantsRevoked":   containment.GrantsRevoked,
+	}, err)
+	if err != nil {
+		s.log.Infow("Failed to fully contain user", zap.Error(err), "userId", userID)
+		return containment, err
+	}
+
+	s.log.Infow("User contained successfully", "userId", userID,
+		"suspended", containment.Suspended,
+		"deactivated", containment.Deactivated,
+	)
+	return containment, nil
+}
+
+func (s *Service) clear(ctx context.Context, userID string, revokeTokens, revokeGrants bool) error {
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.RevokeUserSessions", "users/"+userID+"/sessions",
+			map[string]bool{"oauthTokens": revokeTokens},
+		))
+		if revokeGrants {
+			dryrun.Record(ctx, dryrun.Okta("UserAPI.RevokeUserGrants", "users/"+userID+"/grants", nil))
+		}
+		return nil
+	}
+
+	response, err := s.client.UserAPI.RevokeUserSessions(ctx, userID).OauthTokens(revokeTokens).Execute()
+	if err != nil {
+		s.log.Infow("Failed to clear user sessions in Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return wrap(err, "failed to clear user sessions in Okta")
+	}
+
+	if revokeGrants {
+		response, err := s.client.UserAPI.RevokeUserGrants(ctx, userID).Execute()
+		if err != nil {
+			s.log.Infow("Failed to revoke user app grants in Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return wrap(err, "failed to revoke user app grants in Okta")
+		}
+	}
+	return nil
+}
+
+func (s *Service) audit(ctx context.Context, action, userID, reason string, details map[string]any, err error) {
+	outcome, errMsg := models.AuditOutcomeSuccess, ""
+	if err != nil {
+		outcome, errMsg = models.AuditOutcomeFailure, err.Error()
+	}
+	s.auditSvc.Record(ctx, models.AuditEntry{
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Outcome:    outcome,
+		Reason:     reason,
+		Error:      errMsg,
+		Details:    details,
+	})
+}
+
+// wrap turns the Okta error for an unknown user into ErrNotFound.
+func wrap(err error, message string) error {
+	var oktaErr *okta.GenericOpenAPIError
+	if errors.As(err, &oktaErr) {
+		if model, ok := oktaErr.Model().(okta.Error); ok && model.GetErrorCode() == oktaNotFound {
+			return fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
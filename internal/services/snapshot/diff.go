@@ -0,0 +1,346 @@
+package snapshot_service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+// Live stands for the current state of the org wherever a snapshot ID is
+// expected in a diff.
+const Live = "live"
+
+// Diff compares two stored snapshots, or a snapshot and live Okta when either
+// ID is Live.
+func (s *Service) Diff(ctx context.Context, fromID, toID string) (*models.SnapshotDiff, error) {
+	ctx, span := tracing.Start(ctx, "snapshot_service.Diff",
+		tracing.DiffFrom.String(fromID), tracing.DiffTo.String(toID),
+	)
+	defer span.End()
+
+	if fromID == "" || toID == "" {
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidRequest)
+	}
+
+	s.log.Infow("Comparing snapshots", "from", fromID, "to", toID)
+
+	from, err := s.Resolve(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.Resolve(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffSnapshots(from, to), nil
+}
+
+// Resolve returns the stored snapshot, or captures the org when snapshotID is
+// Live. A live capture is not stored and has Live as its ID.
+func (s *Service) Resolve(ctx context.Context, snapshotID string) (*models.Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "snapshot_service.Resolve", tracing.SnapshotID.String(snapshotID))
+	defer span.End()
+
+	if snapshotID != Live {
+		return s.GetSnapshot(ctx, snapshotID)
+	}
+
+	snapshot, err := s.capture(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ID = Live
+	return snapshot, nil
+}
+
+// DiffSnapshots lists what changed from one snapshot to the other. Users are
+// matched by login and groups by name, ignoring case.
+func DiffSnapshots(from, to *models.Snapshot) *models.SnapshotDiff {
+	diff := &models.SnapshotDiff{
+		From:              ref(from),
+		To:                ref(to),
+		UsersAdded:        []models.SnapshotUser{},
+		UsersRemoved:      []models.SnapshotUser{},
+		StatusChanges:     []models.UserStatusChange{},
+		ProfileChanges:    []models.ProfileFieldChange{},
+		GroupsAdded:       []string{},
+		GroupsRemoved:     []string{},
+		RolesAdded:        []string{},
+		RolesRemoved:      []string{},
+		MembershipChanges: []models.MembershipChange{},
+		RoleChanges:       []models.RoleAssignmentChange{},
+	}
+
+	fromUsers := byKey(from.Users, func(u models.SnapshotUser) string { return u.Login })
+	toUsers := byKey(to.Users, func(u models.SnapshotUser) string { return u.Login })
+
+	for _, key := range sortedKeys(fromUsers, toUsers) {
+		before, inFrom := fromUsers[key]
+		after, inTo := toUsers[key]
+		switch {
+		case !inTo:
+			diff.UsersRemoved = append(diff.UsersRemoved, before)
+		case !inFrom:
+			diff.UsersAdded = append(diff.UsersAdded, after)
+		default:
+			if before.Status != after.Status {
+				diff.StatusChanges = append(diff.StatusChanges, models.UserStatusChange{
+					Login: after.Login, From: before.Status, To: after.Status,
+				})
+			}
+			diff.ProfileChanges = append(diff.ProfileChanges, profileChanges(before, after)...)
+		}
+	}
+
+	fromGroups := byKey(from.Groups, func(g models.SnapshotGroup) string { return g.Name })
+	toGroups := byKey(to.Groups, func(g models.SnapshotGroup) string { return g.Name })
+
+	for _, key := range sortedKeys(fromGroups, toGroups) {
+		before, inFrom := fromGroups[key]
+		after, inTo := toGroups[key]
+		name := after.Name
+		switch {
+		case !inTo:
+			name = before.Name
+			diff.GroupsRemoved = append(diff.GroupsRemoved, name)
+		case !inFrom:
+			diff.GroupsAdded = append(diff.GroupsAdded, name)
+		}
+
+		// Members and roles of added or removed groups count as changes too,
+		// so the deltas add up to the access that was gained or lost.
+		for _, login := range added(before.Members, after.Members) {
+			diff.MembershipChanges = append(diff.MembershipChanges,
+				models.MembershipChange{Change: models.DiffChangeAdded, Group: name, Login: login})
+		}
+		for _, login := range added(after.Members, before.Members) {
+			diff.MembershipChanges = append(diff.MembershipChanges,
+				models.MembershipChange{Change: models.DiffChangeRemoved, Group: name, Login: login})
+		}
+		diff.RoleChanges = append(diff.RoleChanges,
+			roleChanges(models.PrincipalTypeGroup, name, before.Roles, after.Roles)...)
+	}
+
+	fromRoles := byKey(from.Roles, func(r models.SnapshotRole) string { return r.Name })
+	toRoles := byKey(to.Roles, func(r models.SnapshotRole) string { return r.Name })
+
+	for _, key := range sortedKeys(fromRoles, toRoles) {
+		before, inFrom := fromRoles[key]
+		after, inTo := toRoles[key]
+		switch {
+		case !inTo:
+			diff.RolesRemoved = append(diff.RolesRemoved, before.Name)
+		case !inFrom:
+			diff.RolesAdded = append(diff.RolesAdded, after.Name)
+		}
+	}
+
+	fromUserRoles := byKey(from.UserRoles, func(u models.SnapshotUserRoles) string { return u.Login })
+	toUserRoles := byKey(to.UserRoles, func(u models.SnapshotUserRoles) string { return u.Login })
+
+	for _, key := range sortedKeys(fromUserRoles, toUserRoles) {
+		before, after := fromUserRoles[key], toUserRoles[key]
+		diff.RoleChanges = append(diff.RoleChanges,
+			roleChanges(models.PrincipalTypeUser, cmp.Or(after.Login, before.Login), before.Roles, after.Roles)...)
+	}
+
+	return diff
+}
+
+// WriteDiff writes diff as a human-readable report.
+func WriteDiff(w io.Writer, diff *models.SnapshotDiff) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\n", describeRef(diff.From))
+	fmt.Fprintf(&b, "To:   %s\n", describeRef(diff.To))
+
+	if diff.Empty() {
+		b.WriteString("\nNo changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	section(&b, "Users added", diff.UsersAdded, func(u models.SnapshotUser) string {
+		return fmt.Sprintf("+ %s (%s)", u.Login, u.Status)
+	})
+	section(&b, "Users removed", diff.UsersRemoved, func(u models.SnapshotUser) string {
+		return fmt.Sprintf("- %s (%s)", u.Login, u.Status)
+	})
+	section(&b, "Status changes", diff.StatusChanges, func(c models.UserStatusChange) string {
+		return fmt.Sprintf("~ %s: %s -> %s", c.Login, c.From, c.To)
+	})
+	section(&b, "Profile changes", diff.ProfileChanges, func(c models.ProfileFieldChange) string {
+		return fmt.Sprintf("~ %s %s: %s -> %s", c.Login, c.Field, describeValue(c.From), describeValue(c.To))
+	})
+	section(&b, "Groups added", diff.GroupsAdded, func(name string) string { return "+ " + name })
+	section(&b, "Groups removed", diff.GroupsRemoved, func(name string) string { return "- " + name })
+	section(&b, "Custom roles added", diff.RolesAdded, func(name string) string { return "+ " + name })
+	section(&b, "Custom roles removed", diff.RolesRemoved, func(name string) string { return "- " + name })
+	section(&b, "Membership changes", diff.MembershipChanges, func(c models.MembershipChange) string {
+		if c.Change == models.DiffChangeAdded {
+			return fmt.Sprintf("+ %s joined %s", c.Login, c.Group)
+		}
+		return fmt.Sprintf("- %s left %s", c.Login, c.Group)
+	})
+	section(&b, "Role assignment changes", diff.RoleChanges, func(c models.RoleAssignmentChange) string {
+		if c.Change == models.DiffChangeAdded {
+			return fmt.Sprintf("+ %s assigned to %s %s", c.Role, c.PrincipalType, c.Principal)
+		}
+		return fmt.Sprintf("- %s unassigned from %s %s", c.Role, c.PrincipalType, c.Principal)
+	})
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func section[T any](b *strings.Builder, title string, entries []T, line func(T) string) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s (%d):\n", title, len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(b, "  %s\n", line(entry))
+	}
+}
+
+func ref(snapshot *models.Snapshot) models.SnapshotRef {
+	if snapshot.ID == Live {
+		return models.SnapshotRef{Source: snapshot.Source, Created: snapshot.Created, Live: true}
+	}
+	return models.SnapshotRef{ID: snapshot.ID, Source: snapshot.Source, Created: snapshot.Created}
+}
+
+func describeRef(ref models.SnapshotRef) string {
+	name := ref.ID
+	if ref.Live {
+		name = "live"
+	}
+
+	var details []string
+	if ref.Source != "" {
+		details = append(details, ref.Source)
+	}
+	if !ref.Created.IsZero() {
+		details = append(details, ref.Created.Format(time.RFC3339))
+	}
+	if len(details) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(details, ", "))
+}
+
+func describeValue(value any) string {
+	if value == nil {
+		return "(none)"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func profileChanges(before, after models.SnapshotUser) []models.ProfileFieldChange {
+	var changes []models.ProfileFieldChange
+
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"email", before.Email, after.Email},
+		{"firstName", before.FirstName, after.FirstName},
+		{"lastName", before.LastName, after.LastName},
+	}
+	for _, field := range fields {
+		if field.before != field.after {
+			changes = append(changes, models.ProfileFieldChange{
+				Login: after.Login, Field: field.name, From: field.before, To: field.after,
+			})
+		}
+	}
+
+	keys := slices.Collect(maps.Keys(before.Profile))
+	for key := range after.Profile {
+		if _, ok := before.Profile[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if !reflect.DeepEqual(before.Profile[key], after.Profile[key]) {
+			changes = append(changes, models.ProfileFieldChange{
+				Login: after.Login, Field: key, From: before.Profile[key], To: after.Profile[key],
+			})
+		}
+	}
+	return changes
+}
+
+func roleChanges(principalType, principal string, before, after []models.SnapshotRoleAssignment) []models.RoleAssignmentChange {
+	beforeNames, afterNames := roleNames(before), roleNames(after)
+
+	var changes []models.RoleAssignmentChange
+	for _, role := range added(beforeNames, afterNames) {
+		changes = append(changes, models.RoleAssignmentChange{
+			Change: models.DiffChangeAdded, PrincipalType: principalType, Principal: principal, Role: role,
+		})
+	}
+	for _, role := range added(afterNames, beforeNames) {
+		changes = append(changes, models.RoleAssignmentChange{
+			Change: models.DiffChangeRemoved, PrincipalType: principalType, Principal: principal, Role: role,
+		})
+	}
+	return changes
+}
+
+// roleNames names assignments by role type, or by label for custom roles.
+func roleNames(assignments []models.SnapshotRoleAssignment) []string {
+	names := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		names = append(names, cmp.Or(assignment.Label, assignment.Type))
+	}
+	return names
+}
+
+// added returns the entries of to that are not in from, ignoring case.
+func added(from, to []string) []string {
+	var result []string
+	for _, entry := range to {
+		if !slices.ContainsFunc(from, func(other string) bool { return strings.EqualFold(entry, other) }) {
+			result = append(result, entry)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+func byKey[T any](values []T, key func(T) string) map[string]T {
+	result := make(map[string]T, len(values))
+	for _, value := range values {
+		result[strings.ToLower(key(value))] = value
+	}
+	return result
+}
+
+func sortedKeys[T any](a, b map[string]T) []string {
+	keys := slices.Collect(maps.Keys(a))
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
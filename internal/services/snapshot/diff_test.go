@@ -0,0 +1,258 @@
+package snapshot_service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamBelugaa/iam/internal/models"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	ada := models.SnapshotUser{
+		Login: "ada@example.com", Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace",
+		Status: "ACTIVE", Profile: map[string]any{"department": "Engineering"},
+	}
+	alan := models.SnapshotUser{
+		Login: "alan@example.com", Email: "alan@example.com", FirstName: "Alan", LastName: "Turing", Status: "ACTIVE",
+	}
+	custom := models.SnapshotRoleAssignment{Type: "CUSTOM", Label: "Help Desk"}
+	readOnly := models.SnapshotRoleAssignment{Type: "READ_ONLY_ADMIN"}
+
+	tests := []struct {
+		name     string
+		from, to models.Snapshot
+		want     func(*models.SnapshotDiff)
+	}{
+		{
+			name: "identical snapshots",
+			from: models.Snapshot{
+				Users:  []models.SnapshotUser{ada},
+				Groups: []models.SnapshotGroup{{Name: "Engineering", Members: []string{ada.Login}}},
+			},
+			to: models.Snapshot{
+				Users:  []models.SnapshotUser{ada},
+				Groups: []models.SnapshotGroup{{Name: "Engineering", Members: []string{ada.Login}}},
+			},
+		},
+		{
+			name: "users added and removed",
+			from: models.Snapshot{Users: []models.SnapshotUser{ada}},
+			to:   models.Snapshot{Users: []models.SnapshotUser{alan}},
+			want: func(d *models.SnapshotDiff) {
+				d.UsersAdded = []models.SnapshotUser{alan}
+				d.UsersRemoved = []models.SnapshotUser{ada}
+			},
+		},
+		{
+			name: "users and groups match ignoring case",
+			from: models.Snapshot{
+				Users:  []models.SnapshotUser{ada},
+				Groups: []models.SnapshotGroup{{Name: "Engineering", Members: []string{ada.Login}}},
+			},
+			to: models.Snapshot{
+				Users:  []models.SnapshotUser{{Login: "ADA@example.com", Email: ada.Email, FirstName: "Ada", LastName: "Lovelace", Status: "ACTIVE", Profile: ada.Profile}},
+				Groups: []models.SnapshotGroup{{Name: "ENGINEERING", Members: []string{"ADA@example.com"}}},
+			},
+		},
+		{
+			name: "status and profile changes",
+			from: models.Snapshot{Users: []models.SnapshotUser{ada}},
+			to: models.Snapshot{Users: []models.SnapshotUser{{
+				Login: ada.Login, Email: "countess@example.com", FirstName: "Ada", LastName: "Lovelace",
+				Status: "SUSPENDED", Profile: map[string]any{"title": "Analyst"},
+			}}},
+			want: func(d *models.SnapshotDiff) {
+				d.StatusChanges = []models.UserStatusChange{{Login: ada.Login, From: "ACTIVE", To: "SUSPENDED"}}
+				d.ProfileChanges = []models.ProfileFieldChange{
+					{Login: ada.Login, Field: "email", From: "ada@example.com", To: "countess@example.com"},
+					{Login: ada.Login, Field: "department", From: "Engineering", To: nil},
+					{Login: ada.Login, Field: "title", From: nil, To: "Analyst"},
+				}
+			},
+		},
+		{
+			name: "members and roles of added and removed groups count",
+			from: models.Snapshot{Groups: []models.SnapshotGroup{
+				{Name: "Support", Members: []string{alan.Login}, Roles: []models.SnapshotRoleAssignment{custom}},
+			}},
+			to: models.Snapshot{Groups: []models.SnapshotGroup{
+				{Name: "Auditors", Members: []string{ada.Login}, Roles: []models.SnapshotRoleAssignment{readOnly}},
+			}},
+			want: func(d *models.SnapshotDiff) {
+				d.GroupsAdded = []string{"Auditors"}
+				d.GroupsRemoved = []string{"Support"}
+				d.MembershipChanges = []models.MembershipChange{
+					{Change: models.DiffChangeAdded, Group: "Auditors", Login: ada.Login},
+					{Change: models.DiffChangeRemoved, Group: "Support", Login: alan.Login},
+				}
+				d.RoleChanges = []models.RoleAssignmentChange{
+					{Change: models.DiffChangeAdded, PrincipalType: models.PrincipalTypeGroup, Principal: "Auditors", Role: "READ_ONLY_ADMIN"},
+					{Change: models.DiffChangeRemoved, PrincipalType: models.PrincipalTypeGroup, Principal: "Support", Role: "Help Desk"},
+				}
+			},
+		},
+		{
+			name: "membership changes of a kept group",
+			from: models.Snapshot{Groups: []models.SnapshotGroup{{Name: "Engineering", Members: []string{ada.Login}}}},
+			to:   models.Snapshot{Groups: []models.SnapshotGroup{{Name: "Engineering", Members: []string{alan.Login}}}},
+			want: func(d *models.SnapshotDiff) {
+				d.MembershipChanges = []models.MembershipChange{
+					{Change: models.DiffChangeAdded, Group: "Engineering", Login: alan.Login},
+					{Change: models.DiffChangeRemoved, Group: "Engineering", Login: ada.Login},
+				}
+			},
+		},
+		{
+			name: "custom roles added and removed",
+			from: models.Snapshot{Roles: []models.SnapshotRole{{Name: "Help Desk"}}},
+			to:   models.Snapshot{Roles: []models.SnapshotRole{{Name: "Auditor"}}},
+			want: func(d *models.SnapshotDiff) {
+				d.RolesAdded = []string{"Auditor"}
+				d.RolesRemoved = []string{"Help Desk"}
+			},
+		},
+		{
+			name: "user roles are named by label for custom roles",
+			from: models.Snapshot{UserRoles: []models.SnapshotUserRoles{
+				{Login: ada.Login, Roles: []models.SnapshotRoleAssignment{readOnly}},
+			}},
+			to: models.Snapshot{UserRoles: []models.SnapshotUserRoles{
+				{Login: ada.Login, Roles: []models.SnapshotRoleAssignment{custom}},
+				{Login: alan.Login, Roles: []models.SnapshotRoleAssignment{readOnly}},
+			}},
+			want: func(d *models.SnapshotDiff) {
+				d.RoleChanges = []models.RoleAssignmentChange{
+					{Change: models.DiffChangeAdded, PrincipalType: models.PrincipalTypeUser, Principal: ada.Login, Role: "Help Desk"},
+					{Change: models.DiffChangeRemoved, PrincipalType: models.PrincipalTypeUser, Principal: ada.Login, Role: "READ_ONLY_ADMIN"},
+					{Change: models.DiffChangeAdded, PrincipalType: models.PrincipalTypeUser, Principal: alan.Login, Role: "READ_ONLY_ADMIN"},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := emptyDiff()
+			if tt.want != nil {
+				tt.want(want)
+			}
+
+			got := DiffSnapshots(&tt.from, &tt.to)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("DiffSnapshots() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsRefs(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	from := &models.Snapshot{ID: "snap-1", Source: "scheduled", Created: created}
+	to := &models.Snapshot{ID: Live, Created: created}
+
+	got := DiffSnapshots(from, to)
+	if want := (models.SnapshotRef{ID: "snap-1", Source: "scheduled", Created: created}); got.From != want {
+		t.Fatalf("From = %+v, want %+v", got.From, want)
+	}
+	if want := (models.SnapshotRef{Created: created, Live: true}); got.To != want {
+		t.Fatalf("To = %+v, want %+v", got.To, want)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		diff func(*models.SnapshotDiff)
+		want string
+	}{
+		{
+			name: "no changes",
+			want: "From: snap-1 (scheduled, 2026-03-01T09:00:00Z)\n" +
+				"To:   live\n" +
+				"\nNo changes.\n",
+		},
+		{
+			name: "every kind of change",
+			diff: func(d *models.SnapshotDiff) {
+				d.UsersAdded = []models.SnapshotUser{{Login: "alan@example.com", Status: "STAGED"}}
+				d.ProfileChanges = []models.ProfileFieldChange{
+					{Login: "ada@example.com", Field: "title", From: nil, To: "Analyst"},
+				}
+				d.GroupsRemoved = []string{"Support"}
+				d.MembershipChanges = []models.MembershipChange{
+					{Change: models.DiffChangeAdded, Group: "Engineering", Login: "alan@example.com"},
+					{Change: models.DiffChangeRemoved, Group: "Support", Login: "ada@example.com"},
+				}
+				d.RoleChanges = []models.RoleAssignmentChange{
+					{Change: models.DiffChangeRemoved, PrincipalType: models.PrincipalTypeGroup, Principal: "Support", Role: "Help Desk"},
+				}
+			},
+			want: "From: snap-1 (scheduled, 2026-03-01T09:00:00Z)\n" +
+				"To:   live\n" +
+				"\nUsers added (1):\n  + alan@example.com (STAGED)\n" +
+				"\nProfile changes (1):\n  ~ ada@example.com title: (none) -> \"Analyst\"\n" +
+				"\nGroups removed (1):\n  - Support\n" +
+				"\nMembership changes (2):\n  + alan@example.com joined Engineering\n  - ada@example.com left Support\n" +
+				"\nRole assignment changes (1):\n  - Help Desk unassigned from group Support\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := emptyDiff()
+			diff.From = models.SnapshotRef{ID: "snap-1", Source: "scheduled", Created: created}
+			diff.To = models.SnapshotRef{Live: true}
+			if tt.diff != nil {
+				tt.diff(diff)
+			}
+
+			var b strings.Builder
+			if err := WriteDiff(&b, diff); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Fatalf("WriteDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdded(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []string
+		want     []string
+	}{
+		{name: "nothing new", from: []string{"a", "b"}, to: []string{"b"}},
+		{name: "ignores case", from: []string{"Ada@Example.com"}, to: []string{"ada@example.com"}},
+		{name: "sorted", from: []string{"b"}, to: []string{"d", "b", "a"}, want: []string{"a", "d"}},
+		{name: "from empty", to: []string{"a"}, want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := added(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("added(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func emptyDiff() *models.SnapshotDiff {
+	return &models.SnapshotDiff{
+		UsersAdded:        []models.SnapshotUser{},
+		UsersRemoved:      []models.SnapshotUser{},
+		StatusChanges:     []models.UserStatusChange{},
+		ProfileChanges:    []models.ProfileFieldChange{},
+		GroupsAdded:       []string{},
+		GroupsRemoved:     []string{},
+		RolesAdded:        []string{},
+		RolesRemoved:      []string{},
+		MembershipChanges: []models.MembershipChange{},
+		RoleChanges:       []models.RoleAssignmentChange{},
+	}
+}
@@ -0,0 +1,576 @@
+package snapshot_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	desired_state_service "github.com/iamBelugaa/iam/internal/services/desired_state"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrNotFound       = errors.New("snapshot not found")
+	ErrInvalidArchive = errors.New("invalid snapshot archive")
+	ErrInvalidRequest = errors.New("invalid restore request")
+)
+
+type Service struct {
+	log             *zap.SugaredLogger
+	cfg             *config.SnapshotConfig
+	source          string
+	store           *store.Store[models.SnapshotSummary]
+	archives        *store.Files[models.Snapshot]
+	usersSvc        *user_service.Service
+	groupsSvc       *group_service.Service
+	rolesSvc        *role_service.Service
+	desiredStateSvc *desired_state_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	cfg *config.SnapshotConfig,
+	source string,
+	store *store.Store[models.SnapshotSummary],
+	archives *store.Files[models.Snapshot],
+	usersSvc *user_service.Service,
+	groupsSvc *group_service.Service,
+	rolesSvc *role_service.Service,
+	desiredStateSvc *desired_state_service.Service,
+) *Service {
+	return &Service{
+		log:             log,
+		cfg:             cfg,
+		source:          source,
+		store:           store,
+		archives:        archives,
+		usersSvc:        usersSvc,
+		groupsSvc:       groupsSvc,
+		rolesSvc:        rolesSvc,
+		desiredStateSvc: desiredStateSvc,
+	}
+}
+
+// CreateSnapshot captures the users, Okta groups with their members and
+// roles, custom roles and user role assignments of the org.
+func (s *Service) CreateSnapshot(ctx context.Context, createdBy string) (*models.Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "snapshot_service.CreateSnapshot")
+	defer span.End()
+
+	s.log.Infow("Creating snapshot", "createdBy", createdBy)
+
+	snapshot, err := s.capture(ctx, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Snapshot created", "snapshotId", snapshot.ID, "users", len(snapshot.Users),
+		"groups", len(snapshot.Groups), "roles", len(snapshot.Roles),
+	)
+	return snapshot, nil
+}
+
+func (s *Service) capture(ctx context.Context, createdBy string) (*models.Snapshot, error) {
+	snapshot := models.Snapshot{
+		Format:    models.SnapshotFormat,
+		Version:   models.SnapshotVersion,
+		ID:        store.NewID(),
+		Source:    s.source,
+		CreatedBy: createdBy,
+		Created:   time.Now().UTC(),
+		Users:     []models.SnapshotUser{},
+		Groups:    []models.SnapshotGroup{},
+		Roles:     []models.SnapshotRole{},
+		UserRoles: []models.SnapshotUserRoles{},
+	}
+
+	users, err := s.usersSvc.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logins := make(map[string]string, len(users))
+	for _, user := range users {
+		logins[user.ID] = user.Login
+		snapshot.Users = append(snapshot.Users, models.SnapshotUser{
+			Login:     user.Login,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Status:    user.Status,
+			Profile:   user.Profile,
+		})
+	}
+
+	groups, err := s.groupsSvc.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		// App and built-in groups are recreated by their source, not by a restore.
+		if group.Type != models.GroupTypeOkta {
+			continue
+		}
+
+		members, err := s.groupsSvc.GetGroupMembers(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := s.rolesSvc.GetGroupRoles(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		memberLogins := make([]string, 0, len(members))
+		for _, member := range members {
+			if member.Login == "" {
+				return nil, fmt.Errorf("member %s of group %s has no login", member.ID, group.Name)
+			}
+			memberLogins = append(memberLogins, member.Login)
+		}
+		slices.Sort(memberLogins)
+
+		snapshot.Groups = append(snapshot.Groups, models.SnapshotGroup{
+			Name:        group.Name,
+			Description: group.Description,
+			Profile:     group.Profile,
+			Members:     memberLogins,
+			Roles:       assignments(roles),
+		})
+	}
+
+	roles, err := s.rolesSvc.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		permissions, err := s.rolesSvc.GetRolePermissions(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshotRole := models.SnapshotRole{Name: role.Name, Description: role.Description, Permissions: []string{}}
+		for _, permission := range permissions {
+			snapshotRole.Permissions = append(snapshotRole.Permissions, permission.ID)
+		}
+		slices.Sort(snapshotRole.Permissions)
+		snapshot.Roles = append(snapshot.Roles, snapshotRole)
+	}
+
+	userIDs, err := s.rolesSvc.GetUsersWithRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		login, err := s.login(ctx, logins, userID)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := s.rolesSvc.GetUserRoles(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.UserRoles = append(snapshot.UserRoles, models.SnapshotUserRoles{
+			Login: login,
+			Roles: assignments(roles),
+		})
+	}
+
+	slices.SortFunc(snapshot.Users, func(a, b models.SnapshotUser) int { return strings.Compare(a.Login, b.Login) })
+	slices.SortFunc(snapshot.Groups, func(a, b models.SnapshotGroup) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(snapshot.Roles, func(a, b models.SnapshotRole) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(snapshot.UserRoles, func(a, b models.SnapshotUserRoles) int { return strings.Compare(a.Login, b.Login) })
+
+	return &snapshot, nil
+}
+
+// login returns the login of a user holding roles. Users missing from the
+// listing, such as deactivated ones, are looked up, and a snapshot that
+// cannot name every role holder fails rather than dropping them.
+func (s *Service) login(ctx context.Context, logins map[string]string, userID string) (string, error) {
+	if login := logins[userID]; login != "" {
+		return login, nil
+	}
+
+	user, err := s.usersSvc.GetUser(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the login of role holder %s: %w", userID, err)
+	}
+	if user.Login == "" {
+		return "", fmt.Errorf("role holder %s has no login", userID)
+	}
+	logins[userID] = user.Login
+	return user.Login, nil
+}
+
+// ParseArchive decodes and checks a snapshot archive.
+func ParseArchive(data []byte) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	switch {
+	case snapshot.Format != models.SnapshotFormat:
+		return nil, fmt.Errorf("%w: format must be %q", ErrInvalidArchive, models.SnapshotFormat)
+	case snapshot.Version < 1 || snapshot.Version > models.SnapshotVersion:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, snapshot.Version)
+	case snapshot.ID == "":
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidArchive)
+	}
+	return &snapshot, nil
+}
+
+// ImportSnapshot stores an archive downloaded from this or another org. The
+// snapshot keeps its original ID, source and creation time.
+func (s *Service) ImportSnapshot(ctx context.Context, data []byte) (*models.Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "snapshot_service.ImportSnapshot")
+	defer span.End()
+
+	snapshot, err := ParseArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Importing snapshot", "snapshotId", snapshot.ID, "source", snapshot.Source)
+
+	if err := s.save(ctx, snapshot); err != nil {
+		if errors.Is(err, store.ErrInvalidID) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetSnapshots lists the stored snapshots, newest first.
+func (s *Service) GetSnapshots(ctx context.Context) []*models.SnapshotSummary {
+	_, span := tracing.Start(ctx, "snapshot_service.GetSnapshots")
+	defer span.End()
+
+	result := []*models.SnapshotSummary{}
+	for _, summary := range s.store.List() {
+		result = append(result, &summary)
+	}
+
+	slices.SortStableFunc(result, func(a, b *models.SnapshotSummary) int { return b.Created.Compare(a.Created) })
+	return result
+}
+
+func (s *Service) GetSnapshot(ctx context.Context, snapshotID string) (*models.Snapshot, error) {
+	_, span := tracing.Start(ctx, "snapshot_service.GetSnapshot", tracing.SnapshotID.String(snapshotID))
+	defer span.End()
+
+	if _, ok := s.store.Get(snapshotID); !ok {
+		return nil, ErrNotFound
+	}
+
+	snapshot, err := s.archives.Get(snapshotID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *Service) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	ctx, span := tracing.Start(ctx, "snapshot_service.DeleteSnapshot", tracing.SnapshotID.String(snapshotID))
+	defer span.End()
+
+	s.log.Infow("Deleting snapshot", "snapshotId", snapshotID)
+
+	if err := s.store.Delete(ctx, snapshotID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	// Once the summary is gone the snapshot is no longer listed or read, so
+	// an archive left behind here only takes up space.
+	if err := s.archives.Delete(ctx, snapshotID); err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.log.Infow("Failed to delete snapshot archive", zap.Error(err), "snapshotId", snapshotID)
+	}
+	return nil
+}
+
+// save writes the archive of a snapshot to its own file and then its summary
+// to the index, so every listed snapshot can be read.
+func (s *Service) save(ctx context.Context, snapshot *models.Snapshot) error {
+	if err := s.archives.Put(ctx, snapshot.ID, *snapshot); err != nil {
+		return fmt.Errorf("failed to store snapshot archive: %w", err)
+	}
+	if err := s.store.Put(ctx, snapshot.ID, *snapshot.Summary()); err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreSnapshot recreates missing groups and custom roles and re-adds the
+// memberships and role assignments of a snapshot. Nothing that exists in the
+// org is changed or removed. With req.Groups only those groups are restored.
+func (s *Service) RestoreSnapshot(
+	ctx context.Context, snapshotID string, req *models.RestoreSnapshotRequest,
+) (*models.RestoreResult, error) {
+	ctx, span := tracing.Start(ctx, "snapshot_service.RestoreSnapshot", tracing.SnapshotID.String(snapshotID))
+	defer span.End()
+
+	snapshot, err := s.GetSnapshot(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Restoring snapshot", "snapshotId", snapshotID, "groups", req.Groups, "createUsers", req.CreateUsers)
+
+	groups := snapshot.Groups
+	if len(req.Groups) > 0 {
+		groups = nil
+		for _, name := range req.Groups {
+			index := slices.IndexFunc(snapshot.Groups, func(g models.SnapshotGroup) bool { return g.Name == name })
+			if index < 0 {
+				return nil, fmt.Errorf("%w: group %s is not in the snapshot", ErrInvalidRequest, name)
+			}
+			groups = append(groups, snapshot.Groups[index])
+		}
+	}
+
+	result := &models.RestoreResult{
+		SnapshotID:   snapshotID,
+		CreatedUsers: []string{},
+		MissingUsers: []string{},
+		Warnings:     []string{},
+	}
+
+	existing, err := s.ensureUsers(ctx, snapshot, groups, len(req.Groups) == 0, req.CreateUsers, result)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.DesiredState{}
+	if len(req.Groups) == 0 {
+		for _, role := range snapshot.Roles {
+			state.Roles = append(state.Roles, models.DesiredRole{
+				Name:        role.Name,
+				Description: role.Description,
+				Permissions: role.Permissions,
+			})
+		}
+		for _, user := range snapshot.UserRoles {
+			if !existing[strings.ToLower(user.Login)] {
+				continue
+			}
+			roles := roleTypes(user.Roles, "user "+user.Login, result)
+			if len(roles) > 0 {
+				state.Users = append(state.Users, models.DesiredUser{User: user.Login, Roles: roles})
+			}
+		}
+	}
+
+	for _, group := range groups {
+		members := []string{}
+		for _, login := range group.Members {
+			if existing[strings.ToLower(login)] {
+				members = append(members, login)
+			}
+		}
+		state.Groups = append(state.Groups, models.DesiredGroup{
+			Name:        group.Name,
+			Description: group.Description,
+			Profile:     group.Profile,
+			Members:     members,
+			Roles:       roleTypes(group.Roles, "group "+group.Name, result),
+		})
+	}
+
+	plan, err := s.desiredStateSvc.Plan(ctx, state, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// A restore only adds what is missing.
+	plan.Changes = slices.DeleteFunc(plan.Changes, func(change models.PlannedChange) bool {
+		switch change.Action {
+		case models.ChangeActionCreate, models.ChangeActionAdd, models.ChangeActionAssign:
+			return false
+		}
+		return true
+	})
+	clear(plan.Summary)
+	for _, change := range plan.Changes {
+		plan.Summary[change.Action]++
+	}
+
+	result.Plan = plan
+	result.Apply = s.desiredStateSvc.Execute(ctx, plan, nil)
+
+	s.log.Infow("Snapshot restored", "snapshotId", snapshotID, "applied", result.Apply.Applied,
+		"failed", result.Apply.Failed, "missingUsers", len(result.MissingUsers),
+	)
+	return result, nil
+}
+
+// scheduler is recorded as the creator of scheduled snapshots.
+const scheduler = "scheduler"
+
+// Run takes a snapshot every interval and keeps the configured number of
+// scheduled snapshots. A zero interval disables scheduled snapshots.
+func (s *Service) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+// tick takes a scheduled snapshot and prunes old ones. A panic is logged
+// instead of stopping the loop, so the next tick tries again.
+func (s *Service) tick(ctx context.Context) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.log.Infow("Panic while creating scheduled snapshot", "panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+
+	if _, err := s.CreateSnapshot(ctx, scheduler); err != nil {
+		s.log.Infow("Failed to create scheduled snapshot", zap.Error(err))
+		return
+	}
+	s.prune(ctx)
+}
+
+func (s *Service) prune(ctx context.Context) {
+	if s.cfg.Retention <= 0 {
+		return
+	}
+
+	// Snapshots taken on demand or imported are kept until deleted.
+	snapshots := slices.DeleteFunc(s.GetSnapshots(ctx), func(snapshot *models.SnapshotSummary) bool {
+		return snapshot.CreatedBy != scheduler
+	})
+	for _, snapshot := range snapshots[min(s.cfg.Retention, len(snapshots)):] {
+		if err := s.DeleteSnapshot(ctx, snapshot.ID); err != nil {
+			s.log.Infow("Failed to delete old snapshot", zap.Error(err), "snapshotId", snapshot.ID)
+		}
+	}
+}
+
+// ensureUsers returns the lower-cased logins of the users the restore can
+// refer to, creating the missing ones when createUsers is set.
+func (s *Service) ensureUsers(
+	ctx context.Context,
+	snapshot *models.Snapshot,
+	groups []models.SnapshotGroup,
+	withUserRoles, createUsers bool,
+	result *models.RestoreResult,
+) (map[string]bool, error) {
+	var needed []string
+	for _, group := range groups {
+		needed = append(needed, group.Members...)
+	}
+	if withUserRoles {
+		for _, user := range snapshot.UserRoles {
+			needed = append(needed, user.Login)
+		}
+	}
+	if len(needed) == 0 {
+		return map[string]bool{}, nil
+	}
+
+	users, err := s.usersSvc.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(users))
+	for _, user := range users {
+		existing[strings.ToLower(user.Login)] = true
+	}
+
+	slices.Sort(needed)
+	for _, login := range slices.Compact(needed) {
+		if login == "" || existing[strings.ToLower(login)] {
+			continue
+		}
+
+		index := slices.IndexFunc(snapshot.Users, func(u models.SnapshotUser) bool { return strings.EqualFold(u.Login, login) })
+		if !createUsers || index < 0 {
+			result.MissingUsers = append(result.MissingUsers, login)
+			continue
+		}
+
+		user := snapshot.Users[index]
+		if _, err := s.usersSvc.CreateUser(ctx, &models.CreateUserRequest{
+			Login:     user.Login,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Profile:   user.Profile,
+		}); err != nil {
+			return nil, err
+		}
+		result.CreatedUsers = append(result.CreatedUsers, login)
+
+		// A dry run does not create the user, so there is nothing to add to
+		// groups or assign roles to yet.
+		if !dryrun.Enabled(ctx) {
+			existing[strings.ToLower(login)] = true
+		}
+	}
+
+	return existing, nil
+}
+
+func assignments(roles []*models.Role) []models.SnapshotRoleAssignment {
+	result := make([]models.SnapshotRoleAssignment, 0, len(roles))
+	for _, role := range roles {
+		assignment := models.SnapshotRoleAssignment{Type: role.Type}
+		if role.Type == models.RoleTypeCustom {
+			assignment.Label = role.Name
+		}
+		result = append(result, assignment)
+	}
+	return result
+}
+
+// roleTypes returns the role types a restore can assign. Custom role
+// assignments also need a resource set, so they are reported instead.
+func roleTypes(assignments []models.SnapshotRoleAssignment, principal string, result *models.RestoreResult) []string {
+	types := []string{}
+	for _, assignment := range assignments {
+		if assignment.Type == models.RoleTypeCustom {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("custom role %s of %s must be reassigned manually", assignment.Label, principal))
+			continue
+		}
+		types = append(types, assignment.Type)
+	}
+	return types
+}
@@ -0,0 +1,518 @@
+package sod_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	audit_service "github.com/iamBelugaa/iam/internal/services/audit"
+	group_service "github.com/iamBelugaa/iam/internal/services/group"
+	role_service "github.com/iamBelugaa/iam/internal/services/role"
+	user_service "github.com/iamBelugaa/iam/internal/services/user"
+	"github.com/iamBelugaa/iam/pkg/store"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	ErrInvalidRequest    = errors.New("invalid separation-of-duties request")
+	ErrRuleNotFound      = errors.New("separation-of-duties rule not found")
+	ErrExceptionNotFound = errors.New("separation-of-duties exception not found")
+	ErrViolation         = errors.New("separation-of-duties violation")
+)
+
+// ViolationError is returned when an assignment would give a user both sides
+// of a rule without a recorded exception.
+type ViolationError struct {
+	Rule   models.SoDRule
+	UserID string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("assignment would violate separation-of-duties rule %q (%s %s / %s %s) for user %s",
+		e.Rule.Name, e.Rule.Left.Type, e.Rule.Left.ID, e.Rule.Right.Type, e.Rule.Right.ID, e.UserID,
+	)
+}
+
+func (e *ViolationError) Is(target error) bool {
+	return target == ErrViolation
+}
+
+// holding is a role or group a user holds, directly or through a group.
+type holding struct {
+	resourceType string
+	identifiers  []string
+	via          string
+}
+
+type Service struct {
+	log        *zap.SugaredLogger
+	rules      *store.Store[models.SoDRule]
+	exceptions *store.Store[models.SoDException]
+	auditSvc   *audit_service.Service
+	usersSvc   *user_service.Service
+	rolesSvc   *role_service.Service
+	groupsSvc  *group_service.Service
+}
+
+func New(
+	log *zap.SugaredLogger,
+	rules *store.Store[models.SoDRule],
+	exceptions *store.Store[models.SoDException],
+	auditSvc *audit_service.Service,
+	usersSvc *user_service.Service,
+	rolesSvc *role_service.Service,
+	groupsSvc *group_service.Service,
+) *Service {
+	return &Service{
+		log:        log,
+		rules:      rules,
+		exceptions: exceptions,
+		auditSvc:   auditSvc,
+		usersSvc:   usersSvc,
+		rolesSvc:   rolesSvc,
+		groupsSvc:  groupsSvc,
+	}
+}
+
+func (s *Service) CreateRule(ctx context.Context, createdBy string, req *models.CreateSoDRuleRequest) (*models.SoDRule, error) {
+	ctx, span := tracing.Start(ctx, "sod_service.CreateRule")
+	defer span.End()
+
+	s.log.Infow("Creating separation-of-duties rule", "name", req.Name)
+
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	for _, side := range []models.SoDSide{req.Left, req.Right} {
+		if side.Type != models.ResourceTypeRole && side.Type != models.ResourceTypeGroup {
+			return nil, fmt.Errorf("%w: side type must be %q or %q", ErrInvalidRequest, models.ResourceTypeRole, models.ResourceTypeGroup)
+		}
+		if side.ID == "" {
+			return nil, fmt.Errorf("%w: side id is required", ErrInvalidRequest)
+		}
+	}
+	if req.Left == req.Right {
+		return nil, fmt.Errorf("%w: both sides of a rule are the same", ErrInvalidRequest)
+	}
+
+	rule := models.SoDRule{
+		ID:          store.NewID(),
+		Name:        req.Name,
+		Description: req.Description,
+		Left:        req.Left,
+		Right:       req.Right,
+		CreatedBy:   createdBy,
+		Created:     time.Now().UTC(),
+	}
+
+	if err := s.rules.Put(ctx, rule.ID, rule); err != nil {
+		return nil, fmt.Errorf("failed to store rule: %w", err)
+	}
+
+	s.log.Infow("Separation-of-duties rule created", "ruleId", rule.ID)
+	return &rule, nil
+}
+
+func (s *Service) GetRule(ctx context.Context, ruleID string) (*models.SoDRule, error) {
+	_, span := tracing.Start(ctx, "sod_service.GetRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	rule, ok := s.rules.Get(ruleID)
+	if !ok {
+		return nil, ErrRuleNotFound
+	}
+	return &rule, nil
+}
+
+func (s *Service) GetRules(ctx context.Context) []*models.SoDRule {
+	_, span := tracing.Start(ctx, "sod_service.GetRules")
+	defer span.End()
+
+	result := []*models.SoDRule{}
+	for _, rule := range s.rules.List() {
+		result = append(result, &rule)
+	}
+	return result
+}
+
+func (s *Service) DeleteRule(ctx context.Context, ruleID string) error {
+	ctx, span := tracing.Start(ctx, "sod_service.DeleteRule", tracing.RuleID.String(ruleID))
+	defer span.End()
+
+	s.log.Infow("Deleting separation-of-duties rule", "ruleId", ruleID)
+
+	if err := s.rules.Delete(ctx, ruleID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrRuleNotFound
+		}
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) CreateException(ctx context.Context, approvedBy string, req *models.CreateSoDExceptionRequest) (*models.SoDException, error) {
+	ctx, span := tracing.Start(ctx, "sod_service.CreateException")
+	defer span.End()
+
+	s.log.Infow("Recording separation-of-duties exception", "ruleId", req.RuleID, "userId", req.UserID)
+
+	switch {
+	case req.UserID == "":
+		return nil, fmt.Errorf("%w: userId is required", ErrInvalidRequest)
+	case strings.TrimSpace(req.Reason) == "":
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidRequest)
+	case approvedBy == "":
+		return nil, fmt.Errorf("%w: approver identity is required", ErrInvalidRequest)
+	case approvedBy == req.UserID:
+		return nil, fmt.Errorf("%w: users cannot approve an exception for themselves", ErrInvalidRequest)
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidRequest)
+	}
+
+	if _, ok := s.rules.Get(req.RuleID); !ok {
+		return nil, ErrRuleNotFound
+	}
+
+	exception := models.SoDException{
+		ID:         store.NewID(),
+		RuleID:     req.RuleID,
+		UserID:     req.UserID,
+		Reason:     req.Reason,
+		ApprovedBy: approvedBy,
+		Created:    time.Now().UTC(),
+		ExpiresAt:  req.ExpiresAt,
+	}
+
+	if err := s.exceptions.Put(ctx, exception.ID, exception); err != nil {
+		return nil, fmt.Errorf("failed to store exception: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, models.AuditEntry{
+		Actor:      approvedBy,
+		Action:     "sod.exception_created",
+		TargetType: "user",
+		TargetID:   req.UserID,
+		Outcome:    models.AuditOutcomeSuccess,
+		Reason:     req.Reason,
+		Details:    map[string]any{"ruleId": req.RuleID, "exceptionId": exception.ID},
+	})
+
+	s.log.Infow("Separation-of-duties exception recorded", "exceptionId", exception.ID)
+	return &exception, nil
+}
+
+func (s *Service) GetExceptions(ctx context.Context, ruleID, userID string) []*models.SoDException {
+	_, span := tracing.Start(ctx, "sod_service.GetExceptions", tracing.RuleID.String(ruleID), tracing.UserID.String(userID))
+	defer span.End()
+
+	result := []*models.SoDException{}
+	for _, exception := range s.exceptions.List() {
+		if ruleID != "" && exception.RuleID != ruleID {
+			continue
+		}
+		if userID != "" && exception.UserID != userID {
+			continue
+		}
+		result = append(result, &exception)
+	}
+	return result
+}
+
+func (s *Service) DeleteException(ctx context.Context, exceptionID string) error {
+	ctx, span := tracing.Start(ctx, "sod_service.DeleteException", tracing.ExceptionID.String(exceptionID))
+	defer span.End()
+
+	s.log.Infow("Deleting separation-of-duties exception", "exceptionId", exceptionID)
+
+	if err := s.exceptions.Delete(ctx, exceptionID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrExceptionNotFound
+		}
+		return fmt.Errorf("failed to delete exception: %w", err)
+	}
+	return nil
+}
+
+// CheckUserRole implements role_service.Guard.
+func (s *Service) CheckUserRole(ctx context.Context, userID, roleID string) error {
+	ctx, span := tracing.Start(ctx, "sod_service.CheckUserRole", tracing.UserID.String(userID), tracing.RoleID.String(roleID))
+	defer span.End()
+
+	if len(s.rules.List()) == 0 {
+		return nil
+	}
+
+	identifiers, err := s.roleIdentifiers(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	return s.check(ctx, userID, []holding{{
+		resourceType: models.ResourceTypeRole,
+		identifiers:  identifiers,
+		via:          "direct",
+	}})
+}
+
+// CheckGroupRole implements role_service.Guard by checking every member of
+// the group as if it held the role.
+func (s *Service) CheckGroupRole(ctx context.Context, groupID, roleID string) error {
+	ctx, span := tracing.Start(ctx, "sod_service.CheckGroupRole", tracing.GroupID.String(groupID), tracing.RoleID.String(roleID))
+	defer span.End()
+
+	if len(s.rules.List()) == 0 {
+		return nil
+	}
+
+	identifiers, err := s.roleIdentifiers(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	members, err := s.groupsSvc.GetGroupMembers(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		err := s.check(ctx, member.ID, []holding{{
+			resourceType: models.ResourceTypeRole,
+			identifiers:  identifiers,
+			via:          "group:" + groupID,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckUserGroup implements group_service.Guard. Joining a group brings the
+// group itself and every role assigned to it.
+func (s *Service) CheckUserGroup(ctx context.Context, userID, groupID string) error {
+	ctx, span := tracing.Start(ctx, "sod_service.CheckUserGroup", tracing.UserID.String(userID), tracing.GroupID.String(groupID))
+	defer span.End()
+
+	if len(s.rules.List()) == 0 {
+		return nil
+	}
+
+	group, err := s.groupsSvc.GetGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := s.rolesSvc.GetGroupRoles(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	candidates := []holding{{
+		resourceType: models.ResourceTypeGroup,
+		identifiers:  []string{group.ID, group.Name},
+		via:          "direct",
+	}}
+	for _, role := range roles {
+		candidates = append(candidates, holding{
+			resourceType: models.ResourceTypeRole,
+			identifiers:  []string{role.ID, role.Type, role.Name},
+			via:          "group:" + groupID,
+		})
+	}
+
+	return s.check(ctx, userID, candidates)
+}
+
+// GetViolations scans every user and returns those holding both sides of a
+// rule, including the ones covered by an exception.
+func (s *Service) GetViolations(ctx context.Context) ([]*models.SoDViolation, error) {
+	ctx, span := tracing.Start(ctx, "sod_service.GetViolations")
+	defer span.End()
+
+	s.log.Infow("Scanning for separation-of-duties violations")
+
+	rules := s.rules.List()
+	result := []*models.SoDViolation{}
+	if len(rules) == 0 {
+		return result, nil
+	}
+
+	users, err := s.usersSvc.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Many users share the same groups, so each group's roles are read from
+	// Okta once per scan.
+	groupRoles := map[string][]*models.Role{}
+	for _, user := range users {
+		holdings, err := s.holdings(ctx, user.ID, groupRoles)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range rules {
+			left := matchSide(rule.Left, holdings)
+			right := matchSide(rule.Right, holdings)
+			if left == nil || right == nil {
+				continue
+			}
+
+			violation := &models.SoDViolation{
+				Rule:     rule,
+				UserID:   user.ID,
+				Login:    user.Login,
+				LeftVia:  left.via,
+				RightVia: right.via,
+			}
+			if exception := s.activeException(rule.ID, user.ID); exception != nil {
+				violation.ExceptionID = exception.ID
+			}
+			result = append(result, violation)
+		}
+	}
+
+	s.log.Infow("Separation-of-duties scan completed", "violationCount", len(result))
+	return result, nil
+}
+
+// check fails when adding candidates to the user's current holdings completes
+// a rule that has no active exception for the user.
+func (s *Service) check(ctx context.Context, userID string, candidates []holding) error {
+	rules := s.rules.List()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	current, err := s.holdings(ctx, userID, nil)
+	if err != nil {
+		return err
+	}
+	all := append(current, candidates...)
+
+	for _, rule := range rules {
+		newLeft := matchSide(rule.Left, candidates) != nil
+		newRight := matchSide(rule.Right, candidates) != nil
+		if !newLeft && !newRight {
+			continue
+		}
+		if matchSide(rule.Left, all) == nil || matchSide(rule.Right, all) == nil {
+			continue
+		}
+
+		exception := s.activeException(rule.ID, userID)
+		if exception == nil {
+			return &ViolationError{Rule: rule, UserID: userID}
+		}
+
+		s.auditSvc.Record(ctx, models.AuditEntry{
+			Action:     "sod.exception_used",
+			TargetType: "user",
+			TargetID:   userID,
+			Outcome:    models.AuditOutcomeSuccess,
+			Reason:     exception.Reason,
+			Details:    map[string]any{"ruleId": rule.ID, "exceptionId": exception.ID},
+		})
+	}
+	return nil
+}
+
+// roleIdentifiers returns every name a rule may use for the role about to be
+// assigned, as holdings do for assigned roles: its ID, type and label. Roles
+// are assigned by type, so roleID is usually a standard type; a custom role
+// is resolved by its ID or label.
+func (s *Service) roleIdentifiers(ctx context.Context, roleID string) ([]string, error) {
+	if label, ok := models.StandardRoleLabels[roleID]; ok {
+		return []string{roleID, label}, nil
+	}
+
+	role, err := s.rolesSvc.GetRole(ctx, roleID)
+	if errors.Is(err, role_service.ErrNotFound) {
+		// A standard type this service has no label for.
+		return []string{roleID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve role %s: %w", roleID, err)
+	}
+	return []string{roleID, role.ID, role.Type, role.Name}, nil
+}
+
+// holdings returns the user's direct roles, groups, and roles inherited from
+// groups. When groupRoles is set, the roles of each group are looked up there
+// first and stored there after they are read from Okta.
+func (s *Service) holdings(ctx context.Context, userID string, groupRoles map[string][]*models.Role) ([]holding, error) {
+	var result []holding
+
+	roles, err := s.rolesSvc.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		result = append(result, holding{
+			resourceType: models.ResourceTypeRole,
+			identifiers:  []string{role.ID, role.Type, role.Name},
+			via:          "direct",
+		})
+	}
+
+	groups, err := s.usersSvc.GetUserGroups(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		result = append(result, holding{
+			resourceType: models.ResourceTypeGroup,
+			identifiers:  []string{group.ID, group.Name},
+			via:          "direct",
+		})
+
+		inherited, ok := groupRoles[group.ID]
+		if !ok {
+			if inherited, err = s.rolesSvc.GetGroupRoles(ctx, group.ID); err != nil {
+				return nil, err
+			}
+			if groupRoles != nil {
+				groupRoles[group.ID] = inherited
+			}
+		}
+		for _, role := range inherited {
+			result = append(result, holding{
+				resourceType: models.ResourceTypeRole,
+				identifiers:  []string{role.ID, role.Type, role.Name},
+				via:          "group:" + group.ID,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) activeException(ruleID, userID string) *models.SoDException {
+	now := time.Now()
+	for _, exception := range s.exceptions.List() {
+		// Self-approved exceptions are rejected when recorded; older ones are
+		// ignored.
+		if exception.RuleID != ruleID || exception.UserID != userID || exception.ApprovedBy == userID {
+			continue
+		}
+		if exception.ExpiresAt == nil || exception.ExpiresAt.After(now) {
+			return &exception
+		}
+	}
+	return nil
+}
+
+func matchSide(side models.SoDSide, holdings []holding) *holding {
+	for i := range holdings {
+		if holdings[i].resourceType == side.Type && slices.Contains(holdings[i].identifiers, side.ID) {
+			return &holdings[i]
+		}
+	}
+	return nil
+}
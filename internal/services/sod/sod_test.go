@@ -0,0 +1,125 @@
+package sod_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+func TestMatchSide(t *testing.T) {
+	holdings := []holding{
+		{resourceType: models.ResourceTypeRole, identifiers: []string{"ra1", "SUPER_ADMIN", "Super Administrator"}, via: "direct"},
+		{resourceType: models.ResourceTypeGroup, identifiers: []string{"00g1", "Finance"}, via: "direct"},
+		{resourceType: models.ResourceTypeRole, identifiers: []string{"cr1", models.RoleTypeCustom, "Payments Approver"}, via: "group:00g1"},
+	}
+
+	tests := []struct {
+		name string
+		side models.SoDSide
+		want string
+	}{
+		{name: "role by type", side: models.SoDSide{Type: models.ResourceTypeRole, ID: "SUPER_ADMIN"}, want: "direct"},
+		{name: "role by label", side: models.SoDSide{Type: models.ResourceTypeRole, ID: "Super Administrator"}, want: "direct"},
+		{name: "role inherited from a group", side: models.SoDSide{Type: models.ResourceTypeRole, ID: "Payments Approver"}, want: "group:00g1"},
+		{name: "group by ID", side: models.SoDSide{Type: models.ResourceTypeGroup, ID: "00g1"}, want: "direct"},
+		{name: "group by name", side: models.SoDSide{Type: models.ResourceTypeGroup, ID: "Finance"}, want: "direct"},
+		{name: "type must match", side: models.SoDSide{Type: models.ResourceTypeGroup, ID: "SUPER_ADMIN"}},
+		{name: "names are case sensitive", side: models.SoDSide{Type: models.ResourceTypeGroup, ID: "finance"}},
+		{name: "not held", side: models.SoDSide{Type: models.ResourceTypeRole, ID: "ORG_ADMIN"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchSide(tt.side, holdings)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Fatalf("matchSide(%+v) = %+v, want no match", tt.side, got)
+			case tt.want != "" && (got == nil || got.via != tt.want):
+				t.Fatalf("matchSide(%+v) = %+v, want a match via %s", tt.side, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveException(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		exception models.SoDException
+		want      bool
+	}{
+		{
+			name:      "no expiry",
+			exception: models.SoDException{RuleID: "rule1", UserID: "00u1", ApprovedBy: "00u9"},
+			want:      true,
+		},
+		{
+			name:      "not yet expired",
+			exception: models.SoDException{RuleID: "rule1", UserID: "00u1", ApprovedBy: "00u9", ExpiresAt: &future},
+			want:      true,
+		},
+		{
+			name:      "expired",
+			exception: models.SoDException{RuleID: "rule1", UserID: "00u1", ApprovedBy: "00u9", ExpiresAt: &past},
+		},
+		{
+			name:      "self-approved",
+			exception: models.SoDException{RuleID: "rule1", UserID: "00u1", ApprovedBy: "00u1"},
+		},
+		{
+			name:      "another rule",
+			exception: models.SoDException{RuleID: "rule2", UserID: "00u1", ApprovedBy: "00u9"},
+		},
+		{
+			name:      "another user",
+			exception: models.SoDException{RuleID: "rule1", UserID: "00u2", ApprovedBy: "00u9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceptions, err := store.New[models.SoDException]("")
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+			tt.exception.ID = "exc1"
+			if err := exceptions.Put(context.Background(), tt.exception.ID, tt.exception); err != nil {
+				t.Fatalf("failed to store exception: %v", err)
+			}
+
+			svc := New(zap.NewNop().Sugar(), nil, exceptions, nil, nil, nil, nil)
+			got := svc.activeException("rule1", "00u1")
+			if (got != nil) != tt.want {
+				t.Fatalf("activeException() = %+v, want found %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	err := error(&ViolationError{
+		Rule: models.SoDRule{
+			Name:  "Pay and approve",
+			Left:  models.SoDSide{Type: models.ResourceTypeGroup, ID: "Payments"},
+			Right: models.SoDSide{Type: models.ResourceTypeRole, ID: "Payments Approver"},
+		},
+		UserID: "00u1",
+	})
+
+	if !errors.Is(err, ErrViolation) {
+		t.Fatalf("errors.Is(%v, ErrViolation) = false, want true", err)
+	}
+	want := `assignment would violate separation-of-duties rule "Pay and approve" ` +
+		`(group Payments / role Payments Approver) for user 00u1`
+	if err.Error() != want {
+		t.Fatalf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,265 @@
+package user_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+var (
+	// ErrPasswordRejected is returned for a new password that breaks the
+	// local password policy or that Okta rejects.
+	ErrPasswordRejected = errors.New("password was rejected")
+
+	// ErrNotFound is returned for a password change of an unknown user.
+	ErrNotFound = errors.New("user not found")
+)
+
+// oktaNotFound is the Okta error code for an unknown user.
+const oktaNotFound = "E0000007"
+
+// PasswordError lists why a password was rejected. Field names the request
+// field the violations are about. It is empty for reasons given by Okta,
+// which do not name one.
+type PasswordError struct {
+	Field      string
+	Violations []string
+}
+
+func (e *PasswordError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPasswordRejected, strings.Join(e.Violations, "; "))
+}
+
+func (e *PasswordError) Unwrap() error {
+	return ErrPasswordRejected
+}
+
+// UsePasswordPolicy checks every new password against policy before it is
+// sent to Okta.
+func (s *Service) UsePasswordPolicy(policy *config.PasswordPolicyConfig) {
+	s.passwordPolicy = policy
+}
+
+// ChangeUserPassword changes the password of a user who proves the old one.
+func (s *Service) ChangeUserPassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "user_service.ChangeUserPassword", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Changing user password in Okta", "userId", userID)
+
+	if err := s.checkPassword(ctx, userID, "newPassword", newPassword, oldPassword); err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.ChangePassword", "users/"+userID+"/credentials/change_password", nil))
+		return nil
+	}
+
+	changePasswordRequest := okta.ChangePasswordRequest{
+		OldPassword: &okta.PasswordCredential{Value: &oldPassword},
+		NewPassword: &okta.PasswordCredential{Value: &newPassword},
+	}
+
+	_, response, err := s.client.UserAPI.
+		ChangePassword(ctx, userID).ChangePasswordRequest(changePasswordRequest).Execute()
+	if err != nil {
+		s.log.Infow("Failed to change user password in Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return rejectedPassword(err, "failed to change user password in Okta")
+	}
+
+	s.log.Infow("User password changed successfully in Okta", "userId", userID)
+	return nil
+}
+
+// ResetUserPassword resets a password with a one-time link, which Okta can
+// email to the user, or with a one-time temporary password.
+func (s *Service) ResetUserPassword(
+	ctx context.Context, userID string, req *models.ResetPasswordRequest,
+) (*models.PasswordReset, error) {
+	ctx, span := tracing.Start(ctx, "user_service.ResetUserPassword", tracing.UserID.String(userID))
+	defer span.End()
+
+	method := req.Method
+	if method == "" {
+		method = models.PasswordResetLink
+	}
+
+	s.log.Infow("Resetting user password in Okta", "userId", userID, "method", method, "sendEmail", req.SendEmail)
+
+	reset := &models.PasswordReset{UserID: userID, Method: method}
+
+	switch method {
+	case models.PasswordResetLink:
+		if dryrun.Enabled(ctx) {
+			dryrun.Record(ctx, dryrun.Okta("UserAPI.GenerateResetPasswordToken", "users/"+userID, nil))
+			reset.Emailed = req.SendEmail
+			return reset, nil
+		}
+
+		token, response, err := s.client.UserAPI.GenerateResetPasswordToken(ctx, userID).
+			SendEmail(req.SendEmail).RevokeSessions(req.RevokeSessions).Execute()
+		if err != nil {
+			s.log.Infow("Failed to reset user password in Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to reset user password in Okta: %w", err)
+		}
+		reset.ResetURL = token.GetResetPasswordUrl()
+		reset.Emailed = req.SendEmail
+
+	case models.PasswordResetTemporary:
+		if dryrun.Enabled(ctx) {
+			dryrun.Record(ctx, dryrun.Okta("UserAPI.ExpirePasswordAndGetTemporaryPassword", "users/"+userID, nil))
+			return reset, nil
+		}
+
+		password, response, err := s.client.UserAPI.ExpirePasswordAndGetTemporaryPassword(ctx, userID).
+			RevokeSessions(req.RevokeSessions).Execute()
+		if err != nil {
+			s.log.Infow("Failed to reset user password in Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to reset user password in Okta: %w", err)
+		}
+		reset.TemporaryPassword = password.GetTempPassword()
+
+	default:
+		return nil, fmt.Errorf("unknown password reset method '%s'", method)
+	}
+
+	s.log.Infow("User password reset successfully in Okta", "userId", userID, "method", method)
+	return reset, nil
+}
+
+// checkPassword checks a new password against the local policy. The user's
+// name and login are banned as well as the configured words, and previous,
+// if given, may not be reused.
+func (s *Service) checkPassword(ctx context.Context, userID, field, password string, previous ...string) error {
+	if s.passwordPolicy == nil {
+		return nil
+	}
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return notFound(err)
+	}
+
+	violations := checkPolicy(s.passwordPolicy, password, user)
+	if slices.Contains(previous, password) {
+		violations = append(violations, "must differ from the old password")
+	}
+	if len(violations) > 0 {
+		s.log.Infow("New password breaks the password policy", "userId", userID, "violations", len(violations))
+		return &PasswordError{Field: field, Violations: violations}
+	}
+	return nil
+}
+
+func checkPolicy(policy *config.PasswordPolicyConfig, password string, user *models.User) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", policy.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if policy.RequireUppercase && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if policy.RequireLowercase && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, word := range policy.BannedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(lowered, word) {
+			violations = append(violations, fmt.Sprintf("must not contain '%s'", word))
+		}
+	}
+
+	// Parts of the user's identity are not echoed back, and names shorter
+	// than three characters would ban too much.
+	login, _, _ := strings.Cut(user.Login, "@")
+	email, _, _ := strings.Cut(user.Email, "@")
+	for _, part := range []string{user.FirstName, user.LastName, login, email} {
+		if part = strings.ToLower(part); len(part) >= 3 && strings.Contains(lowered, part) {
+			violations = append(violations, "must not contain the user's name or login")
+			break
+		}
+	}
+
+	return violations
+}
+
+// rejectedPassword turns a rejection of a password by Okta, e.g. for a wrong
+// old password or the org's password history, into a PasswordError with
+// Okta's reasons.
+func rejectedPassword(err error, message string) error {
+	if err := notFound(err); errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	var oktaErr *okta.GenericOpenAPIError
+	if errors.As(err, &oktaErr) {
+		if model, ok := oktaErr.Model().(okta.Error); ok {
+			var violations []string
+			for _, cause := range model.ErrorCauses {
+				if summary := cause.GetErrorSummary(); summary != "" {
+					violations = append(violations, summary)
+				}
+			}
+			if len(violations) > 0 {
+				return &PasswordError{Violations: violations}
+			}
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// notFound turns the Okta error for an unknown user into ErrNotFound.
+func notFound(err error) error {
+	var oktaErr *okta.GenericOpenAPIError
+	if errors.As(err, &oktaErr) {
+		if model, ok := oktaErr.Model().(okta.Error); ok && model.GetErrorCode() == oktaNotFound {
+			return fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+package user_service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/internal/config"
+	"github.com/iamBelugaa/iam/internal/models"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	user := &models.User{
+		Login:     "ada.lovelace@example.com",
+		Email:     "countess@example.org",
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+	}
+	strict := &config.PasswordPolicyConfig{
+		MinLength:        12,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		BannedWords:      []string{" Acme ", "", "winter"},
+	}
+
+	tests := []struct {
+		name     string
+		policy   *config.PasswordPolicyConfig
+		user     *models.User
+		password string
+		want     []string
+	}{
+		{
+			name:     "meets every rule",
+			policy:   strict,
+			password: "Tr1cky-Horse-Battery",
+		},
+		{
+			name:     "too short",
+			policy:   strict,
+			password: "Sh0rt-Pass",
+			want:     []string{"must be at least 12 characters long"},
+		},
+		{
+			name:     "length counts characters, not bytes",
+			policy:   &config.PasswordPolicyConfig{MinLength: 4},
+			password: "äöü",
+			want:     []string{"must be at least 4 characters long"},
+		},
+		{
+			name:     "missing every character class",
+			policy:   strict,
+			password: "            ",
+			want: []string{
+				"must contain an uppercase letter",
+				"must contain a lowercase letter",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{
+			name:     "classes not required",
+			policy:   &config.PasswordPolicyConfig{MinLength: 8},
+			password: "abcdefgh",
+		},
+		{
+			name:     "banned words ignore case and surrounding space",
+			policy:   strict,
+			password: "ACME-Winter-2026",
+			want:     []string{"must not contain 'acme'", "must not contain 'winter'"},
+		},
+		{
+			name:     "last name",
+			policy:   strict,
+			password: "LOVELACE-rules-1!",
+			want:     []string{"must not contain the user's name or login"},
+		},
+		{
+			name:     "local part of the login",
+			policy:   strict,
+			password: "Ada.Lovelace-2026!",
+			want:     []string{"must not contain the user's name or login"},
+		},
+		{
+			name:     "local part of the email",
+			policy:   strict,
+			password: "Countess-of-1815!",
+			want:     []string{"must not contain the user's name or login"},
+		},
+		{
+			name:     "three-letter first name",
+			policy:   strict,
+			password: "Adaptive-Horse-9!",
+			want:     []string{"must not contain the user's name or login"},
+		},
+		{
+			name:     "names shorter than three characters are allowed",
+			policy:   strict,
+			user:     &models.User{Login: "al@example.com", FirstName: "Al", LastName: "Yu"},
+			password: "Always-Yummy-Horse-9!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := user
+			if tt.user != nil {
+				subject = tt.user
+			}
+			got := checkPolicy(tt.policy, tt.password, subject)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("checkPolicy(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeUserPassword(t *testing.T) {
+	const user = `{"id":"00u1","status":"ACTIVE","profile":{"login":"ada.lovelace@example.com",` +
+		`"email":"ada.lovelace@example.com","firstName":"Ada","lastName":"Lovelace"}}`
+	const rejected = `{"errorCode":"E0000001","errorSummary":"Api validation failed: password",` +
+		`"errorCauses":[{"errorSummary":"Password has been used too recently"}]}`
+	const unknown = `{"errorCode":"E0000007","errorSummary":"Not found: Resource not found: 00u2 (User)"}`
+
+	tests := []struct {
+		name        string
+		userID      string
+		oldPassword string
+		newPassword string
+		change      string
+		err         error
+		violations  *PasswordError
+	}{
+		{
+			name:        "accepted",
+			userID:      "00u1",
+			oldPassword: "Old-Password-1",
+			newPassword: "New-Password-2",
+		},
+		{
+			name:        "policy violations name the request field",
+			userID:      "00u1",
+			oldPassword: "Old-Password-1",
+			newPassword: "lovelace",
+			err:         ErrPasswordRejected,
+			violations: &PasswordError{Field: "newPassword", Violations: []string{
+				"must be at least 12 characters long",
+				"must contain an uppercase letter",
+				"must contain a digit",
+				"must not contain the user's name or login",
+			}},
+		},
+		{
+			name:        "old password reused",
+			userID:      "00u1",
+			oldPassword: "Same-Password-1",
+			newPassword: "Same-Password-1",
+			err:         ErrPasswordRejected,
+			violations:  &PasswordError{Field: "newPassword", Violations: []string{"must differ from the old password"}},
+		},
+		{
+			name:        "Okta reasons name no field",
+			userID:      "00u1",
+			oldPassword: "Old-Password-1",
+			newPassword: "New-Password-2",
+			change:      rejected,
+			err:         ErrPasswordRejected,
+			violations:  &PasswordError{Violations: []string{"Password has been used too recently"}},
+		},
+		{
+			name:        "unknown user",
+			userID:      "00u2",
+			oldPassword: "Old-Password-1",
+			newPassword: "New-Password-2",
+			err:         ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.URL.Path == "/api/v1/users/00u1" && r.Method == http.MethodGet:
+					w.Write([]byte(user))
+				case r.URL.Path == "/api/v1/users/00u1/credentials/change_password" && tt.change != "":
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(tt.change))
+				case r.URL.Path == "/api/v1/users/00u1/credentials/change_password":
+					w.Write([]byte(`{}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(unknown))
+				}
+			}))
+			defer server.Close()
+
+			svc := New(zap.NewNop().Sugar(), testClient(t, server))
+			svc.UsePasswordPolicy(&config.PasswordPolicyConfig{
+				MinLength:        12,
+				RequireUppercase: true,
+				RequireLowercase: true,
+				RequireDigit:     true,
+			})
+
+			err := svc.ChangeUserPassword(context.Background(), tt.userID, tt.oldPassword, tt.newPassword)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.violations == nil {
+				return
+			}
+
+			var passwordErr *PasswordError
+			if !errors.As(err, &passwordErr) {
+				t.Fatalf("error = %v, want a *PasswordError", err)
+			}
+			if !reflect.DeepEqual(passwordErr, tt.violations) {
+				t.Fatalf("error = %+v, want %+v", passwordErr, tt.violations)
+			}
+		})
+	}
+}
+
+func testClient(t *testing.T, server *httptest.Server) *okta.APIClient {
+	t.Helper()
+
+	cfg, err := okta.NewConfiguration(
+		okta.WithOrgUrl(server.URL),
+		okta.WithToken("test-token"),
+		okta.WithCache(false),
+		okta.WithHttpClientPtr(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("failed to configure Okta client: %v", err)
+	}
+	// The SDK keeps only the host name of the org URL, which drops the port.
+	cfg.Host = server.Listener.Addr().String()
+	return okta.NewAPIClient(cfg)
+}
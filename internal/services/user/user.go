@@ -2,16 +2,29 @@ package user_service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
+	"github.com/iamBelugaa/iam/internal/config"
 	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	oktaclient "github.com/iamBelugaa/iam/pkg/okta"
+	"github.com/iamBelugaa/iam/pkg/patch"
+	"github.com/iamBelugaa/iam/pkg/tracing"
 	"github.com/okta/okta-sdk-golang/v5/okta"
 	"go.uber.org/zap"
 )
 
+// ErrInvalidStatus is returned by dry runs of lifecycle changes that Okta
+// would reject for the user's current status.
+var ErrInvalidStatus = errors.New("user status does not allow this change")
+
 type Service struct {
-	client *okta.APIClient
-	log    *zap.SugaredLogger
+	client         *okta.APIClient
+	log            *zap.SugaredLogger
+	passwordPolicy *config.PasswordPolicyConfig
 }
 
 func New(log *zap.SugaredLogger, client *okta.APIClient) *Service {
@@ -19,6 +32,9 @@ func New(log *zap.SugaredLogger, client *okta.APIClient) *Service {
 }
 
 func (s *Service) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "user_service.CreateUser")
+	defer span.End()
+
 	var profile okta.UserProfile
 	s.log.Infow("Creating user in Okta", "email", req.Email, "login", req.Login)
 
@@ -40,10 +56,23 @@ func (s *Service) CreateUser(ctx context.Context, req *models.CreateUserRequest)
 		}
 	}
 
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.CreateUser", "users", profile))
+
+		status := "STAGED"
+		if req.Activate {
+			status = models.UserStatusProvisioned
+			if req.Password != "" {
+				status = models.UserStatusActive
+			}
+		}
+		return models.ConvertOktaUserToModel(&okta.User{Id: new(string), Status: &status, Profile: &profile}), nil
+	}
+
 	user, response, err := s.client.UserAPI.CreateUser(ctx).Body(createUserRequest).Activate(req.Activate).Execute()
 	if err != nil {
 		s.log.Infow("Failed to create user in Okta", zap.Error(err),
-			"email", req.Email, "statusCode", response.StatusCode,
+			"email", req.Email, "statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to create user in Okta: %w", err)
 	}
@@ -51,20 +80,23 @@ func (s *Service) CreateUser(ctx context.Context, req *models.CreateUserRequest)
 	s.log.Infow("User created successfully in Okta",
 		"userId", *user.Id,
 		"email", req.Email,
-		"statusCode", response.StatusCode,
+		"statusCode", oktaclient.StatusCode(response),
 	)
 
 	return models.ConvertOktaUserToModel(user), nil
 }
 
 func (s *Service) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "user_service.GetUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Getting user from Okta", "userId", userID)
 
 	user, response, err := s.client.UserAPI.GetUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to get user from Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to get user from Okta: %w", err)
 	}
@@ -92,12 +124,29 @@ func (s *Service) GetUser(ctx context.Context, userID string) (*models.User, err
 }
 
 func (s *Service) GetUsers(ctx context.Context) ([]*models.User, error) {
+	ctx, span := tracing.Start(ctx, "user_service.GetUsers")
+	defer span.End()
+
 	s.log.Infow("Getting users from Okta")
 
-	users, _, err := s.client.UserAPI.ListUsers(ctx).Execute()
-	if err != nil {
-		s.log.Infow("Failed to get users from Okta", zap.Error(err))
-		return nil, fmt.Errorf("failed to get users from Okta: %w", err)
+	var users []okta.User
+	request := s.client.UserAPI.ListUsers(ctx)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get users from Okta", zap.Error(err), "statusCode", oktaclient.StatusCode(response))
+			return nil, fmt.Errorf("failed to get users from Okta: %w", err)
+		}
+
+		users = append(users, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
 	}
 
 	result := make([]*models.User, len(users))
@@ -110,6 +159,9 @@ func (s *Service) GetUsers(ctx context.Context) ([]*models.User, error) {
 }
 
 func (s *Service) UpdateUser(ctx context.Context, userID string, req *models.UpdateUserRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "user_service.UpdateUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Info("Updating user in Okta", zap.String("userId", userID))
 
 	var profile okta.UserProfile
@@ -134,6 +186,30 @@ func (s *Service) UpdateUser(ctx context.Context, userID string, req *models.Upd
 		return s.GetUser(ctx, userID)
 	}
 
+	if dryrun.Enabled(ctx) {
+		user, err := s.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.UpdateUser", "users/"+userID, profile))
+
+		if req.FirstName != "" {
+			user.FirstName = req.FirstName
+		}
+		if req.LastName != "" {
+			user.LastName = req.LastName
+		}
+		if len(req.Profile) > 0 {
+			if user.Profile == nil {
+				user.Profile = map[string]any{}
+			}
+			for key, value := range req.Profile {
+				user.Profile[key] = value
+			}
+		}
+		return user, nil
+	}
+
 	user, response, err := s.client.UserAPI.
 		UpdateUser(ctx, userID).User(okta.UpdateUserRequest{Profile: &profile}).Execute()
 
@@ -141,7 +217,7 @@ func (s *Service) UpdateUser(ctx context.Context, userID string, req *models.Upd
 		s.log.Infow("Failed to update user in Okta",
 			zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return nil, fmt.Errorf("failed to update user in Okta: %w", err)
 	}
@@ -150,14 +226,132 @@ func (s *Service) UpdateUser(ctx context.Context, userID string, req *models.Upd
 	return models.ConvertOktaUserToModel(user), nil
 }
 
+// PatchUser applies a JSON Merge Patch or JSON Patch to the user's current
+// profile and replaces the whole profile in Okta, so removed attributes are cleared.
+func (s *Service) PatchUser(ctx context.Context, userID, contentType string, body []byte) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "user_service.PatchUser", tracing.UserID.String(userID))
+	defer span.End()
+
+	s.log.Infow("Patching user in Okta", "userId", userID, "contentType", contentType)
+
+	current, response, err := s.client.UserAPI.GetUser(ctx, userID).Execute()
+	if err != nil {
+		s.log.Infow("Failed to get user from Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to get user from Okta: %w", err)
+	}
+
+	document, err := userPatchDocument(current.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := patch.Apply(contentType, document, body)
+	if err != nil {
+		s.log.Infow("Failed to apply patch to user", zap.Error(err), "userId", userID)
+		return nil, fmt.Errorf("failed to apply patch to user: %w", err)
+	}
+
+	profile, err := userProfileFromPatchDocument(patched)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.ReplaceUser", "users/"+userID, profile))
+		return models.ConvertOktaUserToModel(&okta.User{Id: current.Id, Status: current.Status, Profile: profile}), nil
+	}
+
+	user, response, err := s.client.UserAPI.
+		ReplaceUser(ctx, userID).User(okta.User{Profile: profile}).Execute()
+	if err != nil {
+		s.log.Infow("Failed to replace user in Okta", zap.Error(err),
+			"userId", userID,
+			"statusCode", oktaclient.StatusCode(response),
+		)
+		return nil, fmt.Errorf("failed to replace user in Okta: %w", err)
+	}
+
+	s.log.Infow("User patched successfully in Okta", "userId", userID)
+	return models.ConvertOktaUserToModel(user), nil
+}
+
+func userPatchDocument(profile *okta.UserProfile) ([]byte, error) {
+	attributes := map[string]any{}
+	if profile != nil {
+		raw, err := json.Marshal(profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode user profile: %w", err)
+		}
+		if err := json.Unmarshal(raw, &attributes); err != nil {
+			return nil, fmt.Errorf("failed to decode user profile: %w", err)
+		}
+	}
+
+	document := models.UserPatchDocument{Profile: attributes}
+	document.Email, _ = attributes["email"].(string)
+	document.Login, _ = attributes["login"].(string)
+	document.LastName, _ = attributes["lastName"].(string)
+	document.FirstName, _ = attributes["firstName"].(string)
+
+	for _, key := range []string{"email", "login", "lastName", "firstName"} {
+		delete(attributes, key)
+	}
+
+	return json.Marshal(document)
+}
+
+func userProfileFromPatchDocument(raw []byte) (*okta.UserProfile, error) {
+	var document models.UserPatchDocument
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+	}
+
+	attributes := make(map[string]any, len(document.Profile)+4)
+	for key, value := range document.Profile {
+		attributes[key] = value
+	}
+
+	attributes["email"] = document.Email
+	attributes["login"] = document.Login
+	attributes["lastName"] = document.LastName
+	attributes["firstName"] = document.FirstName
+
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched profile: %w", err)
+	}
+
+	var profile okta.UserProfile
+	if err := json.Unmarshal(encoded, &profile); err != nil {
+		return nil, fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+	}
+
+	return &profile, nil
+}
+
 func (s *Service) DeleteUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.DeleteUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Info("Deleting user in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		if _, err := s.GetUser(ctx, userID); err != nil {
+			return err
+		}
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.DeactivateUser", "users/"+userID, nil))
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.DeleteUser", "users/"+userID, nil))
+		return nil
+	}
+
 	response, err := s.client.UserAPI.DeactivateUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to deactivate user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to deactivate user in Okta: %w", err)
 	}
@@ -166,7 +360,7 @@ func (s *Service) DeleteUser(ctx context.Context, userID string) error {
 	if err != nil {
 		s.log.Infow("Failed to delete user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to delete user in Okta: %w", err)
 	}
@@ -176,13 +370,20 @@ func (s *Service) DeleteUser(ctx context.Context, userID string) error {
 }
 
 func (s *Service) ActivateUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.ActivateUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Info("Activating user in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		return s.planLifecycle(ctx, userID, "ActivateUser", models.UserStatusActive, "STAGED", models.UserStatusDeprovisioned)
+	}
+
 	_, response, err := s.client.UserAPI.ActivateUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to activate user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to activate user in Okta: %w", err)
 	}
@@ -192,13 +393,20 @@ func (s *Service) ActivateUser(ctx context.Context, userID string) error {
 }
 
 func (s *Service) DeactivateUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.DeactivateUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Info("Deactivating user in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		return s.planLifecycle(ctx, userID, "DeactivateUser", models.UserStatusDeprovisioned)
+	}
+
 	response, err := s.client.UserAPI.DeactivateUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to deactivate user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to deactivate user in Okta: %w", err)
 	}
@@ -207,23 +415,38 @@ func (s *Service) DeactivateUser(ctx context.Context, userID string) error {
 	return nil
 }
 
+// SetUserPassword sets a new password for a user without the old one, as an
+// administrator.
 func (s *Service) SetUserPassword(ctx context.Context, userID, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "user_service.SetUserPassword", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Setting user password in Okta", "userId", userID)
 
-	changePasswordRequest := okta.ChangePasswordRequest{
-		NewPassword: &okta.PasswordCredential{
-			Value: &newPassword,
+	if err := s.checkPassword(ctx, userID, "password", newPassword); err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Okta("UserAPI.UpdateUser", "users/"+userID, nil))
+		return nil
+	}
+
+	updateUserRequest := okta.UpdateUserRequest{
+		Credentials: &okta.UserCredentials{
+			Password: &okta.PasswordCredential{
+				Value: &newPassword,
+			},
 		},
 	}
 
-	_, response, err := s.client.UserAPI.
-		ChangePassword(ctx, userID).ChangePasswordRequest(changePasswordRequest).Execute()
+	_, response, err := s.client.UserAPI.UpdateUser(ctx, userID).User(updateUserRequest).Execute()
 	if err != nil {
 		s.log.Infow("Failed to set user password in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
-		return fmt.Errorf("failed to set user password in Okta: %w", err)
+		return rejectedPassword(err, "failed to set user password in Okta")
 	}
 
 	s.log.Infow("User password set successfully in Okta", "userId", userID)
@@ -231,13 +454,20 @@ func (s *Service) SetUserPassword(ctx context.Context, userID, newPassword strin
 }
 
 func (s *Service) ExpireUserPassword(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.ExpireUserPassword", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Expiring user password in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		return s.planLifecycle(ctx, userID, "ExpirePassword", models.UserStatusPasswordExpired)
+	}
+
 	_, response, err := s.client.UserAPI.ExpirePassword(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to expire user password in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to expire user password in Okta: %w", err)
 	}
@@ -247,15 +477,32 @@ func (s *Service) ExpireUserPassword(ctx context.Context, userID string) error {
 }
 
 func (s *Service) GetUserGroups(ctx context.Context, userID string) ([]*models.Group, error) {
+	ctx, span := tracing.Start(ctx, "user_service.GetUserGroups", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Getting user groups from Okta", "userId", userID)
 
-	groups, response, err := s.client.UserAPI.ListUserGroups(ctx, userID).Execute()
-	if err != nil {
-		s.log.Infow("Failed to get user groups from Okta", zap.Error(err),
-			"userId", userID,
-			"statusCode", response.StatusCode,
-		)
-		return nil, fmt.Errorf("failed to get user groups from Okta: %w", err)
+	var groups []okta.Group
+	request := s.client.UserAPI.ListUserGroups(ctx, userID)
+	for {
+		page, response, err := request.Execute()
+		after := ""
+		if err == nil {
+			after, err = oktaclient.NextCursor(response)
+		}
+		if err != nil {
+			s.log.Infow("Failed to get user groups from Okta", zap.Error(err),
+				"userId", userID,
+				"statusCode", oktaclient.StatusCode(response),
+			)
+			return nil, fmt.Errorf("failed to get user groups from Okta: %w", err)
+		}
+
+		groups = append(groups, page...)
+		if after == "" {
+			break
+		}
+		request = request.After(after)
 	}
 
 	result := make([]*models.Group, len(groups))
@@ -268,13 +515,20 @@ func (s *Service) GetUserGroups(ctx context.Context, userID string) ([]*models.G
 }
 
 func (s *Service) SuspendUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.SuspendUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Suspending user in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		return s.planLifecycle(ctx, userID, "SuspendUser", models.UserStatusSuspended, models.UserStatusActive)
+	}
+
 	response, err := s.client.UserAPI.SuspendUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to suspend user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to suspend user in Okta: %w", err)
 	}
@@ -284,13 +538,20 @@ func (s *Service) SuspendUser(ctx context.Context, userID string) error {
 }
 
 func (s *Service) UnsuspendUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "user_service.UnsuspendUser", tracing.UserID.String(userID))
+	defer span.End()
+
 	s.log.Infow("Unsuspending user in Okta", "userId", userID)
 
+	if dryrun.Enabled(ctx) {
+		return s.planLifecycle(ctx, userID, "UnsuspendUser", models.UserStatusActive, models.UserStatusSuspended)
+	}
+
 	response, err := s.client.UserAPI.UnsuspendUser(ctx, userID).Execute()
 	if err != nil {
 		s.log.Infow("Failed to unsuspend user in Okta", zap.Error(err),
 			"userId", userID,
-			"statusCode", response.StatusCode,
+			"statusCode", oktaclient.StatusCode(response),
 		)
 		return fmt.Errorf("failed to unsuspend user in Okta: %w", err)
 	}
@@ -298,3 +559,23 @@ func (s *Service) UnsuspendUser(ctx context.Context, userID string) error {
 	s.log.Infow("User unsuspended successfully in Okta", "userId", userID)
 	return nil
 }
+
+// planLifecycle checks that a dry-run lifecycle change is allowed from the
+// user's current status and records the Okta call it would make. An empty
+// from allows every status other than the target one.
+func (s *Service) planLifecycle(ctx context.Context, userID, operation, status string, from ...string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if (len(from) > 0 && !slices.Contains(from, user.Status)) || user.Status == status {
+		return fmt.Errorf("%w: user is %s", ErrInvalidStatus, user.Status)
+	}
+
+	dryrun.Record(ctx, dryrun.Okta("UserAPI."+operation, "users/"+userID, nil))
+
+	user.Status = status
+	dryrun.SetResult(ctx, user)
+	return nil
+}
@@ -0,0 +1,33 @@
+package actor
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header carries the Okta user ID of the caller. It is expected to be set by
+// the authenticating gateway in front of this service.
+const Header = "X-Actor-ID"
+
+type contextKey struct{}
+
+// Middleware stores the caller identity from the Header in the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(Header); id != "" {
+			r = r.WithContext(WithActor(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithActor returns a copy of ctx that carries the caller identity.
+func WithActor(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the caller identity, or an empty string when unknown.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
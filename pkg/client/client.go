@@ -0,0 +1,344 @@
+// Package client is a typed Go client for the IAM platform API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/iamBelugaa/iam/pkg/actor"
+	"github.com/iamBelugaa/iam/pkg/org"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	contentTypeJSON       = "application/json"
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Config configures a Client. Only BaseURL is required.
+type Config struct {
+	// BaseURL is the address of the API, e.g. https://iam.internal:8080.
+	BaseURL string
+
+	// HTTPClient sends the requests. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+
+	// Auth authenticates every request, e.g. with BearerToken or Actor.
+	Auth Authenticator
+
+	// Org names the Okta org requests are served by. The server's default
+	// org is used when empty.
+	Org string
+
+	// UserAgent is sent with every request.
+	UserAgent string
+
+	Retry RetryPolicy
+}
+
+// RetryPolicy retries GET, HEAD, PUT and DELETE requests that failed with a
+// network error or with a 429, 502, 503 or 504. POST and PATCH requests may
+// have changed something before failing, so they are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first. A value
+	// below 2 disables retries.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the jittered exponential backoff
+	// between attempts. A Retry-After header takes precedence.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy makes up to three attempts.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// Authenticator adds credentials to a request before it is sent.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// BearerToken authenticates with a static bearer token, as checked by a
+// gateway in front of the API.
+func BearerToken(token string) Authenticator {
+	return Header("Authorization", "Bearer "+token)
+}
+
+// Actor identifies the caller by Okta user ID in the X-Actor-ID header.
+func Actor(userID string) Authenticator {
+	return Header(actor.Header, userID)
+}
+
+// Header sets a fixed request header.
+func Header(name, value string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(name, value)
+		return nil
+	})
+}
+
+// Error is a failed API call. ErrorCode is the errorCode of the error
+// envelope, e.g. API_ERROR or VALIDATION_ERROR.
+type Error struct {
+	StatusCode int
+	ErrorCode  string
+	Message    string
+	Details    json.RawMessage
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("iam api: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("iam api: %d %s: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+// IsNotFound reports whether err is an API error with status 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an API error with status 409.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+type requestIDKey struct{}
+
+// WithRequestID sets the request ID sent with calls made with ctx. Without
+// it, the ID chi's RequestID middleware stored in ctx is propagated.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return middleware.GetReqID(ctx)
+}
+
+// Client calls the IAM platform API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	cfg     Config
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{baseURL: baseURL, http: httpClient, cfg: cfg}, nil
+}
+
+type envelope struct {
+	Success   bool            `json:"success"`
+	Data      json.RawMessage `json:"data"`
+	Message   string          `json:"message"`
+	ErrorCode string          `json:"errorCode"`
+	Details   json.RawMessage `json:"details"`
+}
+
+// do sends a request and decodes the data of the success envelope into out,
+// when out is not nil.
+func (c *Client) do(ctx context.Context, method, path, contentType string, in, out any) error {
+	var body []byte
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = encoded
+		if contentType == "" {
+			contentType = contentTypeJSON
+		}
+	}
+
+	resp, err := c.send(ctx, method, c.resolve(path), contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var env envelope
+	decodeErr := json.Unmarshal(data, &env)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Request.Header.Get(middleware.RequestIDHeader)}
+		if decodeErr == nil && env.ErrorCode != "" {
+			apiErr.ErrorCode, apiErr.Message, apiErr.Details = env.ErrorCode, env.Message, env.Details
+		} else {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode response: %w", decodeErr)
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+	return nil
+}
+
+// send makes the request, retrying it according to the retry policy.
+func (c *Client) send(ctx context.Context, method, target, contentType string, body []byte) (*http.Response, error) {
+	policy := c.cfg.Retry
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", contentTypeJSON)
+		if c.cfg.UserAgent != "" {
+			req.Header.Set("User-Agent", c.cfg.UserAgent)
+		}
+		if c.cfg.Org != "" {
+			req.Header.Set(org.Header, c.cfg.Org)
+		}
+		if id := requestID(ctx); id != "" {
+			req.Header.Set(middleware.RequestIDHeader, id)
+		}
+		if c.cfg.Auth != nil {
+			if err := c.cfg.Auth.Authenticate(req); err != nil {
+				return nil, fmt.Errorf("failed to authenticate request: %w", err)
+			}
+		}
+
+		resp, err := c.http.Do(req)
+		if attempt >= policy.MaxAttempts || !retryable(method, resp, err) {
+			if err != nil {
+				return nil, fmt.Errorf("%s %s failed: %w", method, target, err)
+			}
+			return resp, nil
+		}
+
+		wait := policy.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return idempotent(method) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	wait := p.MinBackoff << (attempt - 1)
+	if wait <= 0 || (p.MaxBackoff > 0 && wait > p.MaxBackoff) {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// Full jitter keeps clients that failed together from retrying together.
+	return rand.N(wait) + 1
+}
+
+func (c *Client) resolve(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.baseURL.String() + apiPrefix + path
+}
+
+// list iterates over the items of a list endpoint. The API returns whole
+// listings, so a single request is made.
+func list[T any](ctx context.Context, c *Client, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var items []T
+		if err := c.do(ctx, http.MethodGet, path, "", nil, &items); err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
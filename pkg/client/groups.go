@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// ListGroups iterates over all groups.
+func (c *Client) ListGroups(ctx context.Context) iter.Seq2[*Group, error] {
+	return list[*Group](ctx, c, "/groups")
+}
+
+func (c *Client) GetGroup(ctx context.Context, groupID string) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(groupID), "", nil, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (c *Client) CreateGroup(ctx context.Context, req *CreateGroupRequest) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPost, "/groups", "", req, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (c *Client) UpdateGroup(ctx context.Context, groupID string, req *UpdateGroupRequest) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPut, "/groups/"+escape(groupID), "", req, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// PatchGroup applies a JSON merge patch to a group.
+func (c *Client) PatchGroup(ctx context.Context, groupID string, patch map[string]any) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPatch, "/groups/"+escape(groupID), contentTypeMergePatch, patch, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// PatchGroupOperations applies JSON Patch operations to a group.
+func (c *Client) PatchGroupOperations(ctx context.Context, groupID string, operations []PatchOperation) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPatch, "/groups/"+escape(groupID), contentTypeJSONPatch, operations, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (c *Client) DeleteGroup(ctx context.Context, groupID string) error {
+	err := c.do(ctx, http.MethodDelete, "/groups/"+escape(groupID), "", nil, nil)
+	return err
+}
+
+// ListGroupMembers iterates over the members of a group.
+func (c *Client) ListGroupMembers(ctx context.Context, groupID string) iter.Seq2[*User, error] {
+	return list[*User](ctx, c, "/groups/"+escape(groupID)+"/members")
+}
+
+// AddUserToGroup adds a user to a group. With an expiry in opts the
+// membership is a grant that is removed when it expires, and the grant is
+// returned. A permanent membership returns a nil grant.
+func (c *Client) AddUserToGroup(ctx context.Context, groupID, userID string, opts *AssignmentOptions) (*Grant, error) {
+	return c.assign(ctx, "/groups/"+escape(groupID)+"/members/"+escape(userID), opts)
+}
+
+func (c *Client) RemoveUserFromGroup(ctx context.Context, groupID, userID string) error {
+	err := c.do(ctx, http.MethodDelete, "/groups/"+escape(groupID)+"/members/"+escape(userID), "", nil, nil)
+	return err
+}
+
+// assign makes a membership or role assignment, returning its grant when
+// it is time-bound.
+func (c *Client) assign(ctx context.Context, path string, opts *AssignmentOptions) (*Grant, error) {
+	var body any
+	if opts != nil {
+		body = opts
+	}
+
+	var grant *Grant
+	if err := c.do(ctx, http.MethodPut, path, "", body, &grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"github.com/iamBelugaa/iam/internal/models"
+	"github.com/iamBelugaa/iam/pkg/patch"
+)
+
+// The API models, aliased so that callers outside this module can name them.
+type (
+	User              = models.User
+	CreateUserRequest = models.CreateUserRequest
+	UpdateUserRequest = models.UpdateUserRequest
+
+	Group              = models.Group
+	CreateGroupRequest = models.CreateGroupRequest
+	UpdateGroupRequest = models.UpdateGroupRequest
+
+	Role              = models.Role
+	CreateRoleRequest = models.CreateRoleRequest
+	UpdateRoleRequest = models.UpdateRoleRequest
+
+	Grant             = models.Grant
+	AssignmentOptions = models.AssignmentOptions
+
+	// PatchOperation is a JSON Patch operation.
+	PatchOperation = patch.Operation
+)
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// ListRoles iterates over all roles.
+func (c *Client) ListRoles(ctx context.Context) iter.Seq2[*Role, error] {
+	return list[*Role](ctx, c, "/roles")
+}
+
+func (c *Client) GetRole(ctx context.Context, roleID string) (*Role, error) {
+	var role Role
+	if err := c.do(ctx, http.MethodGet, "/roles/"+escape(roleID), "", nil, &role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (c *Client) CreateRole(ctx context.Context, req *CreateRoleRequest) (*Role, error) {
+	var role Role
+	if err := c.do(ctx, http.MethodPost, "/roles", "", req, &role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (c *Client) UpdateRole(ctx context.Context, roleID string, req *UpdateRoleRequest) (*Role, error) {
+	var role Role
+	if err := c.do(ctx, http.MethodPut, "/roles/"+escape(roleID), "", req, &role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (c *Client) DeleteRole(ctx context.Context, roleID string) error {
+	err := c.do(ctx, http.MethodDelete, "/roles/"+escape(roleID), "", nil, nil)
+	return err
+}
+
+// ListUserRoles iterates over the roles assigned to a user.
+func (c *Client) ListUserRoles(ctx context.Context, userID string) iter.Seq2[*Role, error] {
+	return list[*Role](ctx, c, "/users/"+escape(userID)+"/roles")
+}
+
+// AssignRoleToUser assigns a role to a user. With an expiry in opts the
+// assignment is a grant that is revoked when it expires, and the grant is
+// returned. A permanent assignment returns a nil grant.
+func (c *Client) AssignRoleToUser(ctx context.Context, userID, roleID string, opts *AssignmentOptions) (*Grant, error) {
+	return c.assign(ctx, "/users/"+escape(userID)+"/roles/"+escape(roleID), opts)
+}
+
+func (c *Client) UnassignRoleFromUser(ctx context.Context, userID, roleID string) error {
+	err := c.do(ctx, http.MethodDelete, "/users/"+escape(userID)+"/roles/"+escape(roleID), "", nil, nil)
+	return err
+}
+
+// ListGroupRoles iterates over the roles assigned to a group.
+func (c *Client) ListGroupRoles(ctx context.Context, groupID string) iter.Seq2[*Role, error] {
+	return list[*Role](ctx, c, "/groups/"+escape(groupID)+"/roles")
+}
+
+func (c *Client) AssignRoleToGroup(ctx context.Context, groupID, roleID string) error {
+	err := c.do(ctx, http.MethodPut, "/groups/"+escape(groupID)+"/roles/"+escape(roleID), "", nil, nil)
+	return err
+}
+
+func (c *Client) UnassignRoleFromGroup(ctx context.Context, groupID, roleID string) error {
+	err := c.do(ctx, http.MethodDelete, "/groups/"+escape(groupID)+"/roles/"+escape(roleID), "", nil, nil)
+	return err
+}
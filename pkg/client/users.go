@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// ListUsers iterates over all users.
+func (c *Client) ListUsers(ctx context.Context) iter.Seq2[*User, error] {
+	return list[*User](ctx, c, "/users")
+}
+
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/users/"+escape(userID), "", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPost, "/users", "", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) UpdateUser(ctx context.Context, userID string, req *UpdateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPut, "/users/"+escape(userID), "", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// PatchUser applies a JSON merge patch: fields set in patch are replaced,
+// fields set to nil are removed and other fields are left unchanged.
+func (c *Client) PatchUser(ctx context.Context, userID string, patch map[string]any) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPatch, "/users/"+escape(userID), contentTypeMergePatch, patch, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// PatchUserOperations applies JSON Patch operations. Nothing is changed when
+// a test operation fails.
+func (c *Client) PatchUserOperations(ctx context.Context, userID string, operations []PatchOperation) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPatch, "/users/"+escape(userID), contentTypeJSONPatch, operations, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser deactivates and deletes a user.
+func (c *Client) DeleteUser(ctx context.Context, userID string) error {
+	err := c.do(ctx, http.MethodDelete, "/users/"+escape(userID), "", nil, nil)
+	return err
+}
+
+func (c *Client) ActivateUser(ctx context.Context, userID string) error {
+	return c.lifecycle(ctx, userID, "activate")
+}
+
+func (c *Client) DeactivateUser(ctx context.Context, userID string) error {
+	return c.lifecycle(ctx, userID, "deactivate")
+}
+
+func (c *Client) SuspendUser(ctx context.Context, userID string) error {
+	return c.lifecycle(ctx, userID, "suspend")
+}
+
+func (c *Client) UnsuspendUser(ctx context.Context, userID string) error {
+	return c.lifecycle(ctx, userID, "unsuspend")
+}
+
+func (c *Client) lifecycle(ctx context.Context, userID, action string) error {
+	err := c.do(ctx, http.MethodPost, "/users/"+escape(userID)+"/"+action, "", nil, nil)
+	return err
+}
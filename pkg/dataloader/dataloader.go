@@ -0,0 +1,108 @@
+// Package dataloader batches and deduplicates lookups made while resolving
+// one request, such as the Okta calls behind the fields of a GraphQL query.
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Loader loads values by key for the lifetime of one request. Every key is
+// fetched at most once. Keys requested before any of their values is needed
+// are fetched together, concurrently, with bounded parallelism.
+type Loader[K comparable, V any] struct {
+	ctx            context.Context
+	fetch          func(ctx context.Context, key K) (V, error)
+	maxConcurrency int
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending []K
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New returns a loader that fetches keys with fetch, using at most
+// maxConcurrency calls at a time.
+func New[K comparable, V any](ctx context.Context, maxConcurrency int, fetch func(ctx context.Context, key K) (V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:            ctx,
+		fetch:          fetch,
+		maxConcurrency: max(maxConcurrency, 1),
+		results:        make(map[K]*result[V]),
+	}
+}
+
+// Load queues key and returns a thunk that waits for its value. The first
+// thunk called fetches every key queued until then.
+func (l *Loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.dispatch()
+		<-r.done
+		return r.value, r.err
+	}
+}
+
+// Prime stores a value fetched another way, e.g. as part of a list, unless
+// the key is already loaded or queued.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.results[key]; ok {
+		return
+	}
+	r := &result[V]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.results[key] = r
+}
+
+func (l *Loader[K, V]) dispatch() {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	batch := make([]*result[V], len(keys))
+	for i, key := range keys {
+		batch[i] = l.results[key]
+	}
+	l.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, l.maxConcurrency)
+	for i, key := range keys {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(r *result[V]) {
+			// A panicking fetch fails its key instead of the process, as
+			// the request's recoverer does not cover this goroutine.
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					r.err = fmt.Errorf("failed to load %v: %v", key, recovered)
+				}
+				close(r.done)
+				<-slots
+				wg.Done()
+			}()
+			r.value, r.err = l.fetch(l.ctx, key)
+		}(batch[i])
+	}
+	wg.Wait()
+}
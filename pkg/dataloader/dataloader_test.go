@@ -0,0 +1,164 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestLoadBatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []string
+		failed []string
+		panics []string
+		calls  int
+	}{
+		{
+			name:  "every key succeeds",
+			keys:  []string{"a", "b", "c"},
+			calls: 3,
+		},
+		{
+			name:  "duplicate keys are fetched once",
+			keys:  []string{"a", "b", "a", "a", "b"},
+			calls: 2,
+		},
+		{
+			name:   "an error fails only its key",
+			keys:   []string{"a", "b", "c"},
+			failed: []string{"b"},
+			calls:  3,
+		},
+		{
+			name:   "an error reaches every load of its key",
+			keys:   []string{"b", "a", "b", "b"},
+			failed: []string{"b"},
+			calls:  2,
+		},
+		{
+			name:   "every key fails",
+			keys:   []string{"a", "b"},
+			failed: []string{"a", "b"},
+			calls:  2,
+		},
+		{
+			name:   "a panic fails only its key",
+			keys:   []string{"a", "b", "c"},
+			panics: []string{"c"},
+			calls:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			loader := New(context.Background(), 2, func(_ context.Context, key string) (string, error) {
+				calls.Add(1)
+				if slices.Contains(tt.panics, key) {
+					panic("boom")
+				}
+				if slices.Contains(tt.failed, key) {
+					return "", errNotFound
+				}
+				return "value-" + key, nil
+			})
+
+			thunks := make([]func() (string, error), len(tt.keys))
+			for i, key := range tt.keys {
+				thunks[i] = loader.Load(key)
+			}
+
+			for i, key := range tt.keys {
+				value, err := thunks[i]()
+				switch {
+				case slices.Contains(tt.failed, key):
+					if !errors.Is(err, errNotFound) {
+						t.Fatalf("Load(%s) error = %v, want %v", key, err, errNotFound)
+					}
+				case slices.Contains(tt.panics, key):
+					if err == nil {
+						t.Fatalf("Load(%s) returned no error for a panicking fetch", key)
+					}
+				default:
+					if err != nil || value != "value-"+key {
+						t.Fatalf("Load(%s) = %q, %v, want %q", key, value, err, "value-"+key)
+					}
+				}
+			}
+
+			if got := int(calls.Load()); got != tt.calls {
+				t.Fatalf("fetch called %d times, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestLoadConcurrency(t *testing.T) {
+	const maxConcurrency = 3
+
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+		release = make(chan struct{})
+		once    sync.Once
+	)
+	loader := New(context.Background(), maxConcurrency, func(_ context.Context, key int) (int, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		if running == maxConcurrency {
+			once.Do(func() { close(release) })
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return key, nil
+	})
+
+	thunks := make([]func() (int, error), 10)
+	for i := range thunks {
+		thunks[i] = loader.Load(i)
+	}
+	for i, thunk := range thunks {
+		if value, err := thunk(); err != nil || value != i {
+			t.Fatalf("Load(%d) = %d, %v", i, value, err)
+		}
+	}
+
+	if peak != maxConcurrency {
+		t.Fatalf("peak concurrency = %d, want %d", peak, maxConcurrency)
+	}
+}
+
+func TestPrime(t *testing.T) {
+	var calls atomic.Int32
+	loader := New(context.Background(), 1, func(_ context.Context, key string) (string, error) {
+		calls.Add(1)
+		return "fetched", nil
+	})
+
+	loader.Prime("a", "primed")
+	queued := loader.Load("b")
+	loader.Prime("b", "primed")
+
+	if value, err := loader.Load("a")(); err != nil || value != "primed" {
+		t.Fatalf("Load(a) = %q, %v, want primed", value, err)
+	}
+	if value, err := queued(); err != nil || value != "fetched" {
+		t.Fatalf("Load(b) = %q, %v, want fetched", value, err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("fetch called %d times, want 1", got)
+	}
+}
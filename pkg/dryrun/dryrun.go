@@ -0,0 +1,111 @@
+// Package dryrun lets a request run through validation, authorization and
+// conflict checks without changing anything. Services record the calls they
+// would have made on the request's Plan instead of making them.
+package dryrun
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	SystemOkta   = "okta"
+	SystemStore  = "store"
+	SystemNotify = "notify"
+)
+
+// Call is a change that would have been made outside the process.
+type Call struct {
+	System    string `json:"system"`
+	Operation string `json:"operation"`
+	Resource  string `json:"resource,omitempty"`
+	Body      any    `json:"body,omitempty"`
+}
+
+// Plan collects the calls a dry-run request would have made and the state
+// it would have produced.
+type Plan struct {
+	mu       sync.Mutex
+	calls    []Call
+	result   any
+	overlays map[any]any
+}
+
+type contextKey struct{}
+
+// WithPlan returns a copy of ctx that marks the request as a dry run.
+func WithPlan(ctx context.Context) (context.Context, *Plan) {
+	plan := &Plan{}
+	return context.WithValue(ctx, contextKey{}, plan), plan
+}
+
+// FromContext returns the plan of a dry-run request, or nil for a real one.
+func FromContext(ctx context.Context) *Plan {
+	plan, _ := ctx.Value(contextKey{}).(*Plan)
+	return plan
+}
+
+// Enabled reports whether ctx belongs to a dry-run request.
+func Enabled(ctx context.Context) bool {
+	return FromContext(ctx) != nil
+}
+
+// Record adds call to the plan of a dry-run request. It does nothing otherwise.
+func Record(ctx context.Context, call Call) {
+	if plan := FromContext(ctx); plan != nil {
+		plan.Record(call)
+	}
+}
+
+// SetResult sets the state a dry-run request would have produced when the
+// response data does not already show it, e.g. the status after a lifecycle change.
+func SetResult(ctx context.Context, result any) {
+	if plan := FromContext(ctx); plan != nil {
+		plan.mu.Lock()
+		plan.result = result
+		plan.mu.Unlock()
+	}
+}
+
+// Okta describes a call to a mutating Okta API method.
+func Okta(operation, resource string, body any) Call {
+	return Call{System: SystemOkta, Operation: operation, Resource: resource, Body: body}
+}
+
+func (p *Plan) Record(call Call) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+// Calls returns the recorded calls in order.
+func (p *Plan) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Call{}, p.calls...)
+}
+
+// Result returns the state set with SetResult.
+func (p *Plan) Result() any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.result
+}
+
+// Overlay returns the per-request value stored under key, creating it with
+// init on first use. Stores use it to keep a request's uncommitted writes so
+// later reads in the same request see them.
+func (p *Plan) Overlay(key any, init func() any) any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.overlays == nil {
+		p.overlays = map[any]any{}
+	}
+	value, ok := p.overlays[key]
+	if !ok {
+		value = init()
+		p.overlays[key] = value
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+const (
+	Header     = "Dry-Run"
+	QueryParam = "dryRun"
+)
+
+// Middleware turns POST, PUT, PATCH and DELETE requests that set the Dry-Run
+// header or the dryRun query parameter into dry runs. The JSON response gets
+// a "dryRun" member with the planned calls and resulting state.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requested, err := requested(r)
+		if err != nil {
+			response.RespondError(w, http.StatusBadRequest, "API_ERROR", "Dry-Run must be true or false", nil)
+			return
+		}
+		if !requested {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, plan := WithPlan(r.Context())
+		buffer := &bufferedWriter{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(buffer, r.WithContext(ctx))
+
+		for key, values := range buffer.header {
+			w.Header()[key] = values
+		}
+		w.Header().Set(Header, "true")
+
+		body := buffer.body.Bytes()
+		if annotated, ok := annotate(body, plan); ok {
+			body = annotated
+			w.Header().Del("Content-Length")
+		}
+
+		w.WriteHeader(buffer.status)
+		w.Write(body)
+	})
+}
+
+func requested(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get(QueryParam)
+	if value == "" {
+		value = r.Header.Get(Header)
+	}
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(value))
+}
+
+// annotate appends the plan to a JSON object response body, keeping the
+// members the handler wrote in their original order.
+func annotate(body []byte, plan *Plan) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &document); err != nil {
+		return nil, false
+	}
+
+	summary := struct {
+		Calls  []Call `json:"calls"`
+		Result any    `json:"result,omitempty"`
+	}{Calls: plan.Calls(), Result: plan.Result()}
+
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		return nil, false
+	}
+
+	var b bytes.Buffer
+	b.Write(trimmed[:len(trimmed)-1])
+	if len(document) > 0 {
+		b.WriteByte(',')
+	}
+	b.WriteString(`"dryRun":`)
+	b.Write(encoded)
+	b.WriteString("}\n")
+	return b.Bytes(), true
+}
+
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
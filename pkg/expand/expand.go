@@ -0,0 +1,80 @@
+// Package expand fills related resources into a response on request, e.g.
+// GET /users/{userID}?expand=groups,roles.
+package expand
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// QueryParam lists the relationships to expand, separated by commas.
+const QueryParam = "expand"
+
+// Parse returns the relationships named in the expand query parameter of r.
+// Names other than allowed are an error.
+func Parse(r *http.Request, allowed ...string) ([]string, error) {
+	var names []string
+	for _, value := range r.URL.Query()[QueryParam] {
+		for name := range strings.SplitSeq(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || slices.Contains(names, name) {
+				continue
+			}
+			if !slices.Contains(allowed, name) {
+				return nil, fmt.Errorf("cannot expand '%s', expected one of %s", name, strings.Join(allowed, ", "))
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Load runs the loader of every name, at most maxConcurrency at a time, and
+// returns the first error. The other loaders are cancelled on an error.
+func Load(ctx context.Context, maxConcurrency int, names []string, loaders map[string]func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	slots := make(chan struct{}, max(maxConcurrency, 1))
+	for _, name := range names {
+		load, ok := loaders[name]
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			// A panicking loader fails the request instead of the process, as
+			// the request's recoverer does not cover this goroutine.
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					fail(fmt.Errorf("failed to expand %s: %v", name, recovered))
+				}
+				<-slots
+				wg.Done()
+			}()
+			if err := load(ctx); err != nil {
+				fail(fmt.Errorf("failed to expand %s: %w", name, err))
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
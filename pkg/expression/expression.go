@@ -0,0 +1,503 @@
+// Package expression parses and evaluates the subset of the Okta Expression
+// Language used by group rules, so rules can be validated and previewed
+// against a sample profile before they are sent to Okta.
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrSyntax     = errors.New("invalid expression")
+	ErrEvaluation = errors.New("failed to evaluate expression")
+)
+
+// Group is a group the sample user belongs to, matched by the isMemberOf functions.
+type Group struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Env is the data an expression is evaluated against.
+type Env struct {
+	User   map[string]any
+	Groups []Group
+}
+
+// Expression is a parsed expression.
+type Expression struct {
+	root node
+}
+
+// Parse parses src and checks that every attribute belongs to the user and
+// every function is known and called with a valid number of arguments.
+func Parse(src string) (*Expression, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("%w: expression is empty", ErrSyntax)
+	}
+
+	p := &parser{lex: lexer{src: src}}
+	p.next()
+
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	return &Expression{root: root}, nil
+}
+
+// Attributes returns the user profile attributes the expression reads.
+func (e *Expression) Attributes() []string {
+	var attrs []string
+	walk(e.root, func(n node) {
+		if attr, ok := n.(attrNode); ok && !slices.Contains(attrs, attr.name) {
+			attrs = append(attrs, attr.name)
+		}
+	})
+	slices.Sort(attrs)
+	return attrs
+}
+
+// Match evaluates the expression and reports whether it is true for env.
+func (e *Expression) Match(env Env) (bool, error) {
+	value, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: expression does not evaluate to a boolean", ErrEvaluation)
+	}
+	return matched, nil
+}
+
+// Tokens.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case c == '"' || c == '\'':
+		var b strings.Builder
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] != c {
+			if l.src[l.pos] == '\\' && l.pos+1 < len(l.src) {
+				l.pos++
+			}
+			b.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("%w: unterminated string at position %d", ErrSyntax, start)
+		}
+		l.pos++
+		return token{kind: tokString, text: b.String(), pos: start}, nil
+
+	case unicode.IsDigit(rune(c)):
+		for l.pos < len(l.src) && (unicode.IsDigit(rune(l.src[l.pos])) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+
+	case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.src) {
+			r := rune(l.src[l.pos])
+			if r != '_' && r != '$' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range []string{"==", "!=", ">=", "<=", "&&", "||", ">", "<", "!", "(", ")", ","} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+
+	return token{}, fmt.Errorf("%w: unexpected character %q at position %d", ErrSyntax, c, start)
+}
+
+// Parser.
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at position %d", ErrSyntax, fmt.Sprintf(format, args...), p.tok.pos)
+}
+
+// operator normalises the current token to a binary operator, accepting the
+// keyword forms AND and OR.
+func (p *parser) operator() string {
+	switch {
+	case p.tok.kind == tokOp:
+		return p.tok.text
+	case p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, "and"):
+		return "&&"
+	case p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, "or"):
+		return "||"
+	}
+	return ""
+}
+
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	">": 4, "<": 4, ">=": 4, "<=": 4,
+}
+
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.operator()
+		prec, ok := precedence[op]
+		if !ok || prec <= minPrec {
+			return left, p.err
+		}
+		p.next()
+
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if (p.tok.kind == tokOp && p.tok.text == "!") || (p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, "not")) {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokString:
+		p.next()
+		return literalNode{value: tok.text}, p.err
+
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		p.next()
+		return literalNode{value: value}, p.err
+
+	case tokOp:
+		if tok.text != "(" {
+			return nil, p.errorf("unexpected %q", tok.text)
+		}
+		p.next()
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokOp || p.tok.text != ")" {
+			return nil, p.errorf("expected ')'")
+		}
+		p.next()
+		return inner, p.err
+
+	case tokIdent:
+		p.next()
+		if p.err != nil {
+			return nil, p.err
+		}
+		if p.tok.kind == tokOp && p.tok.text == "(" {
+			return p.parseCall(tok)
+		}
+		return identifier(tok)
+
+	default:
+		return nil, p.errorf("unexpected end of expression")
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q at position %d", ErrSyntax, name.text, name.pos)
+	}
+	p.next()
+
+	var args []node
+	for !(p.tok.kind == tokOp && p.tok.text == ")") {
+		if len(args) > 0 {
+			if p.tok.kind != tokOp || p.tok.text != "," {
+				return nil, p.errorf("expected ',' or ')'")
+			}
+			p.next()
+		}
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%w: wrong number of arguments to %s at position %d", ErrSyntax, name.text, name.pos)
+	}
+
+	return callNode{name: name.text, fn: fn, args: args}, p.err
+}
+
+func identifier(tok token) (node, error) {
+	switch strings.ToLower(tok.text) {
+	case "true":
+		return literalNode{value: true}, nil
+	case "false":
+		return literalNode{value: false}, nil
+	case "null":
+		return literalNode{value: nil}, nil
+	}
+
+	attr, ok := strings.CutPrefix(tok.text, "user.")
+	if !ok || attr == "" {
+		return nil, fmt.Errorf("%w: %q is not a user attribute at position %d, use user.<attribute>", ErrSyntax, tok.text, tok.pos)
+	}
+	return attrNode{name: strings.TrimPrefix(attr, "profile.")}, nil
+}
+
+// Nodes.
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literalNode struct{ value any }
+
+type attrNode struct{ name string }
+
+type notNode struct{ operand node }
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case notNode:
+		walk(n.operand, fn)
+	case binaryNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case callNode:
+		for _, arg := range n.args {
+			walk(arg, fn)
+		}
+	}
+}
+
+func (n literalNode) eval(Env) (any, error) {
+	return n.value, nil
+}
+
+func (n attrNode) eval(env Env) (any, error) {
+	value := env.User[n.name]
+	if number, ok := toNumber(value); ok {
+		return number, nil
+	}
+	return value, nil
+}
+
+func (n notNode) eval(env Env) (any, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+func (n binaryNode) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short-circuit like the Okta evaluator.
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		if left == nil || right == nil {
+			return false, nil
+		}
+		return nil, fmt.Errorf("%w: %s needs numeric operands", ErrEvaluation, n.op)
+	}
+
+	switch n.op {
+	case ">":
+		return l > r, nil
+	case "<":
+		return l < r, nil
+	case ">=":
+		return l >= r, nil
+	default:
+		return l <= r, nil
+	}
+}
+
+func (n callNode) eval(env Env) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	value, err := n.fn.call(env, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrEvaluation, n.name, err)
+	}
+	return value, nil
+}
+
+// Values.
+
+func truthy(value any) bool {
+	b, ok := value.(bool)
+	return ok && b
+}
+
+func equal(left, right any) bool {
+	if l, ok := toNumber(left); ok {
+		r, ok := toNumber(right)
+		return ok && l == r
+	}
+	switch left.(type) {
+	case string, bool, nil:
+		return left == right
+	}
+	return false
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func toString(value any) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,131 @@
+package expression
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "empty", src: "  "},
+		{name: "unterminated string", src: `user.department == "Eng`},
+		{name: "unexpected character", src: `user.department # "Eng"`},
+		{name: "not a user attribute", src: `department == "Eng"`},
+		{name: "bare user", src: `user. == "Eng"`},
+		{name: "unknown function", src: `String.reverse(user.login)`},
+		{name: "too few arguments", src: `String.startsWith(user.login)`},
+		{name: "too many arguments", src: `Arrays.isEmpty(user.tags, user.groups)`},
+		{name: "missing closing paren", src: `(user.level > 3`},
+		{name: "missing comma", src: `String.append(user.firstName user.lastName)`},
+		{name: "trailing tokens", src: `user.level > 3 4`},
+		{name: "dangling operator", src: `user.level >`},
+		{name: "invalid number", src: `user.level > 1.2.3`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.src); !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.src, err, ErrSyntax)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	env := Env{
+		User: map[string]any{
+			"department": "Engineering",
+			"level":      5,
+			"login":      "ada@example.com",
+			"tags":       []any{"oncall", "admin"},
+			"costCenter": "42",
+		},
+		Groups: []Group{{ID: "00g1", Name: "Engineering Leads"}},
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{name: "equality", src: `user.department == "Engineering"`, want: true},
+		{name: "profile prefix", src: `user.profile.department == "Engineering"`, want: true},
+		{name: "inequality", src: `user.department != "Sales"`, want: true},
+		{name: "numeric comparison", src: `user.level >= 5 && user.level < 6`, want: true},
+		{name: "keyword operators", src: `user.level > 10 OR user.department == 'Engineering'`, want: true},
+		{name: "and binds tighter than or", src: `true || false && false`, want: true},
+		{name: "parentheses", src: `(true || false) && false`, want: false},
+		{name: "not", src: `!(user.level > 10) and not false`, want: true},
+		{name: "missing attribute is null", src: `user.manager == null`, want: true},
+		{name: "missing attribute comparison", src: `user.age > 18`, want: false},
+		{name: "string function", src: `String.endsWith(user.login, "@example.com")`, want: true},
+		{name: "string length", src: `String.len(user.department) == 11`, want: true},
+		{name: "substring", src: `String.substring(user.login, 0, 3) == "ada"`, want: true},
+		{name: "join", src: `String.join("-", "a", "b") == "a-b"`, want: true},
+		{name: "array contains", src: `Arrays.contains(user.tags, "admin")`, want: true},
+		{name: "array size", src: `Arrays.size(user.tags) == 2`, want: true},
+		{name: "convert", src: `Convert.toInt(user.costCenter) == 42`, want: true},
+		{name: "member of group", src: `isMemberOfGroup("00g1")`, want: true},
+		{name: "member of any group", src: `isMemberOfAnyGroup("00g2", "00g3")`, want: false},
+		{name: "member of group name prefix", src: `isMemberOfGroupNameStartsWith("Engineering")`, want: true},
+		{name: "short circuit skips errors", src: `false && Convert.toInt("x") > 1`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			got, err := expr.Match(env)
+			if err != nil {
+				t.Fatalf("Match(%q): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Match(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	env := Env{User: map[string]any{"department": "Engineering", "costCenter": "n/a"}}
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "not a boolean", src: `user.department`},
+		{name: "non-numeric comparison", src: `user.department > 3`},
+		{name: "conversion failure", src: `Convert.toNum(user.costCenter) > 1`},
+		{name: "substring out of range", src: `String.substring(user.department, 0, 99) == "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			if _, err := expr.Match(env); !errors.Is(err, ErrEvaluation) {
+				t.Fatalf("Match(%q) error = %v, want %v", tt.src, err, ErrEvaluation)
+			}
+		})
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	expr, err := Parse(`user.profile.title == "x" || String.startsWith(user.department, user.title) && user.level > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"department", "level", "title"}
+	if got := expr.Attributes(); !slices.Equal(got, want) {
+		t.Fatalf("Attributes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type function struct {
+	minArgs int
+	maxArgs int // -1 for variadic
+	call    func(env Env, args []any) (any, error)
+}
+
+var functions = map[string]function{
+	"String.stringContains": stringPredicate(strings.Contains),
+	"String.startsWith":     stringPredicate(strings.HasPrefix),
+	"String.endsWith":       stringPredicate(strings.HasSuffix),
+	"String.toUpperCase":    stringTransform(strings.ToUpper),
+	"String.toLowerCase":    stringTransform(strings.ToLower),
+	"String.len": {1, 1, func(_ Env, args []any) (any, error) {
+		return float64(len(toString(args[0]))), nil
+	}},
+	"String.append": {2, 2, func(_ Env, args []any) (any, error) {
+		return toString(args[0]) + toString(args[1]), nil
+	}},
+	"String.join": {2, -1, func(_ Env, args []any) (any, error) {
+		parts := make([]string, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			parts = append(parts, toString(arg))
+		}
+		return strings.Join(parts, toString(args[0])), nil
+	}},
+	"String.substring": {3, 3, func(_ Env, args []any) (any, error) {
+		s := toString(args[0])
+		start, sok := toNumber(args[1])
+		end, eok := toNumber(args[2])
+		if !sok || !eok || start < 0 || end > float64(len(s)) || start > end {
+			return nil, errors.New("index out of range")
+		}
+		return s[int(start):int(end)], nil
+	}},
+
+	"Arrays.contains": {2, 2, func(_ Env, args []any) (any, error) {
+		return slices.ContainsFunc(toList(args[0]), func(item any) bool { return equal(item, args[1]) }), nil
+	}},
+	"Arrays.isEmpty": {1, 1, func(_ Env, args []any) (any, error) {
+		return len(toList(args[0])) == 0, nil
+	}},
+	"Arrays.size": {1, 1, func(_ Env, args []any) (any, error) {
+		return float64(len(toList(args[0]))), nil
+	}},
+
+	"Convert.toInt": {1, 1, convertNumber},
+	"Convert.toNum": {1, 1, convertNumber},
+
+	"isMemberOfGroup": {1, 1, func(env Env, args []any) (any, error) {
+		return memberOf(env, func(g Group) bool { return g.ID == toString(args[0]) }), nil
+	}},
+	"isMemberOfAnyGroup": {1, -1, func(env Env, args []any) (any, error) {
+		return memberOf(env, func(g Group) bool {
+			return slices.ContainsFunc(args, func(id any) bool { return g.ID == toString(id) })
+		}), nil
+	}},
+	"isMemberOfGroupName": {1, 1, func(env Env, args []any) (any, error) {
+		return memberOf(env, func(g Group) bool { return g.Name == toString(args[0]) }), nil
+	}},
+	"isMemberOfGroupNameStartsWith": {1, 1, func(env Env, args []any) (any, error) {
+		return memberOf(env, func(g Group) bool { return strings.HasPrefix(g.Name, toString(args[0])) }), nil
+	}},
+	"isMemberOfGroupNameContains": {1, 1, func(env Env, args []any) (any, error) {
+		return memberOf(env, func(g Group) bool { return strings.Contains(g.Name, toString(args[0])) }), nil
+	}},
+}
+
+func stringPredicate(fn func(s, substr string) bool) function {
+	return function{2, 2, func(_ Env, args []any) (any, error) {
+		if args[0] == nil {
+			return false, nil
+		}
+		return fn(toString(args[0]), toString(args[1])), nil
+	}}
+}
+
+func stringTransform(fn func(s string) string) function {
+	return function{1, 1, func(_ Env, args []any) (any, error) {
+		return fn(toString(args[0])), nil
+	}}
+}
+
+func convertNumber(_ Env, args []any) (any, error) {
+	if n, ok := toNumber(args[0]); ok {
+		return n, nil
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(toString(args[0])), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", toString(args[0]))
+	}
+	return n, nil
+}
+
+func memberOf(env Env, match func(Group) bool) bool {
+	return slices.ContainsFunc(env.Groups, match)
+}
+
+func toList(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return out
+	case nil:
+		return nil
+	}
+	return []any{value}
+}
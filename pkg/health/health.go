@@ -0,0 +1,193 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusUnknown  string = "UNKNOWN"
+	StatusUp       string = "UP"
+	StatusDegraded string = "DEGRADED"
+	StatusDown     string = "DOWN"
+)
+
+// Check probes one component, such as an Okta org or a store.
+type Check struct {
+	Name     string
+	Interval time.Duration
+
+	// Critical components make the service unready while they are down.
+	// Other components only degrade it.
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Component is the result of the last probe of a check.
+type Component struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMs   int64      `json:"latencyMs"`
+	LastChecked *time.Time `json:"lastChecked,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// Report is the readiness of the service and of each of its components.
+type Report struct {
+	Status     string       `json:"status"`
+	Ready      bool         `json:"ready"`
+	Components []*Component `json:"components"`
+}
+
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string { return e.err.Error() }
+func (e *degradedError) Unwrap() error { return e.err }
+
+// Degraded marks a check error as degrading the component rather than
+// taking it down.
+func Degraded(err error) error {
+	return &degradedError{err: err}
+}
+
+// Checker probes its checks in the background and keeps their last results,
+// so reading the report never waits on a dependency.
+type Checker struct {
+	log     *zap.SugaredLogger
+	timeout time.Duration
+
+	mu         sync.RWMutex
+	checks     []Check
+	components map[string]*Component
+}
+
+func New(log *zap.SugaredLogger, timeout time.Duration) *Checker {
+	return &Checker{log: log, timeout: timeout, components: map[string]*Component{}}
+}
+
+// Register adds a check. Checks registered after Run has started are not
+// probed.
+func (c *Checker) Register(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check)
+	c.components[check.Name] = &Component{Name: check.Name, Status: StatusUnknown, Critical: check.Critical}
+}
+
+// Run probes every check right away and then at its interval until ctx is
+// cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.loop(ctx, check)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Checker) loop(ctx context.Context, check Check) {
+	c.probe(ctx, check)
+
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.probe(ctx, check)
+		}
+	}
+}
+
+func (c *Checker) probe(ctx context.Context, check Check) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.run(ctx, check)
+	now := time.Now().UTC()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	component := c.components[check.Name]
+	previous := component.Status
+	component.LatencyMs = time.Since(start).Milliseconds()
+	component.LastChecked = &now
+
+	var degraded *degradedError
+	switch {
+	case err == nil:
+		component.Status = StatusUp
+		component.LastSuccess = &now
+	case errors.As(err, &degraded):
+		component.Status = StatusDegraded
+		component.LastError = err.Error()
+	default:
+		component.Status = StatusDown
+		component.LastError = err.Error()
+	}
+
+	if component.Status != previous {
+		c.log.Infow("Component health changed", "component", check.Name,
+			"from", previous, "to", component.Status, zap.Error(err),
+		)
+	}
+}
+
+// run runs a check, reporting a panic as the check failing so that one broken
+// check neither stops its loop nor takes the server down.
+func (c *Checker) run(ctx context.Context, check Check) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			c.log.Infow("Panic while running health check", "component", check.Name,
+				"panic", recovered, "stack", string(debug.Stack()),
+			)
+			err = fmt.Errorf("health check panicked: %v", recovered)
+		}
+	}()
+	return check.Run(ctx)
+}
+
+// Report returns the last results. The service is ready unless a critical
+// component is down or has not been probed yet. It is degraded while any
+// component is not up.
+func (c *Checker) Report() *Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := &Report{Status: StatusUp, Ready: true, Components: make([]*Component, 0, len(c.checks))}
+	for _, check := range c.checks {
+		component := *c.components[check.Name]
+		report.Components = append(report.Components, &component)
+
+		switch {
+		case component.Critical && (component.Status == StatusDown || component.Status == StatusUnknown):
+			report.Ready = false
+			report.Status = StatusDown
+		case component.Status != StatusUp && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "iam"
+
+// unmatchedRoute labels requests that matched no route, so unknown paths do
+// not each get their own series.
+const unmatchedRoute = "unmatched"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls served, by full method name and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of gRPC calls, by full method name and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Error responses, by error code and status code.",
+	}, []string{"code", "status"})
+
+	oktaRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "okta_requests_total",
+		Help:      "Calls to the Okta API, by org, operation and status code.",
+	}, []string{"org", "operation", "status"})
+
+	oktaDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "okta_request_duration_seconds",
+		Help:      "Latency of calls to the Okta API, by org and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"org", "operation"})
+
+	oktaErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "okta_errors_total",
+		Help:      "Failed calls to the Okta API, by org, operation and Okta error code.",
+	}, []string{"org", "operation", "code"})
+
+	oktaRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "okta_rate_limit_remaining",
+		Help:      "Requests left in the current Okta rate limit window, by org and bucket.",
+	}, []string{"org", "bucket"})
+
+	oktaRateLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "okta_rate_limit",
+		Help:      "Requests allowed per Okta rate limit window, by org and bucket.",
+	}, []string{"org", "bucket"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		httpInFlight,
+		grpcRequests,
+		grpcDuration,
+		apiErrors,
+		oktaRequests,
+		oktaDuration,
+		oktaErrors,
+		oktaRateLimitRemaining,
+		oktaRateLimit,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times requests by their chi route pattern, e.g.
+// /api/v1/users/{userID}, and tracks the requests in flight.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" && !strings.HasSuffix(pattern, "/*") {
+					route = pattern
+				}
+			}
+
+			labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+			httpRequests.With(labels).Inc()
+			httpDuration.With(labels).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// UnaryServerInterceptor counts and times gRPC calls by their full method
+// name, e.g. /iam.v1.Users/GetUser, and status code.
+func UnaryServerInterceptor(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	labels := prometheus.Labels{"method": info.FullMethod, "code": status.Code(err).String()}
+	grpcRequests.With(labels).Inc()
+	grpcDuration.With(labels).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// RecordError counts an error response with its error code.
+func RecordError(code string, status int) {
+	apiErrors.WithLabelValues(code, strconv.Itoa(status)).Inc()
+}
+
+// RecordOktaRequest counts and times a call to the Okta API. The status is 0
+// when the call failed before a response arrived.
+func RecordOktaRequest(org, operation string, status int, duration time.Duration) {
+	oktaRequests.WithLabelValues(org, operation, strconv.Itoa(status)).Inc()
+	oktaDuration.WithLabelValues(org, operation).Observe(duration.Seconds())
+}
+
+// RecordOktaError counts a failed call to the Okta API with the error code
+// from the Okta error response, such as E0000007.
+func RecordOktaError(org, operation, code string) {
+	oktaErrors.WithLabelValues(org, operation, code).Inc()
+}
+
+// RecordOktaRateLimit records the rate limit of the bucket a call counted
+// against.
+func RecordOktaRateLimit(org, bucket string, limit, remaining int) {
+	oktaRateLimit.WithLabelValues(org, bucket).Set(float64(limit))
+	oktaRateLimitRemaining.WithLabelValues(org, bucket).Set(float64(remaining))
+}
+
+var staticSegment = regexp.MustCompile(`^([a-z][a-zA-Z_-]*|v[0-9]+)$`)
+
+// OktaOperation names an Okta API call by its method and path, with IDs,
+// logins and other variable segments replaced by {id}, e.g.
+// "GET /api/v1/users/{id}/roles".
+func OktaOperation(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if !staticSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return method + " /" + strings.Join(segments, "/")
+}
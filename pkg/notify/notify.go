@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+)
+
+// Event describes something that happened which people or systems should hear about.
+type Event struct {
+	Type       string    `json:"type"`
+	Subject    string    `json:"subject"`
+	Message    string    `json:"message"`
+	Recipients []string  `json:"recipients,omitempty"`
+	Data       any       `json:"data,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Notifier delivers events to an external channel.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+type logNotifier struct {
+	log *zap.SugaredLogger
+}
+
+// NewLogNotifier returns a Notifier that writes events to the logger.
+func NewLogNotifier(log *zap.SugaredLogger) Notifier {
+	return &logNotifier{log: log}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, event Event) error {
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Call{System: dryrun.SystemNotify, Operation: "log", Resource: event.Type, Body: event})
+		return nil
+	}
+
+	n.log.Infow("Notification", "type", event.Type, "subject", event.Subject,
+		"message", event.Message, "recipients", event.Recipients,
+	)
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier returns a Notifier that POSTs events as JSON to url.
+func NewWebhookNotifier(url string) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, event Event) error {
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.Call{System: dryrun.SystemNotify, Operation: "webhook", Resource: event.Type, Body: event})
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type multiNotifier []Notifier
+
+// Multi returns a Notifier that delivers every event to all notifiers.
+func Multi(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
+
+func (m multiNotifier) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+// maxOutboxBackoff caps the wait between delivery attempts of an event.
+const maxOutboxBackoff = time.Hour
+
+// OutboxItem is an event waiting to be delivered. An item that failed
+// maxAttempts times is marked Failed and kept, but no longer retried.
+type OutboxItem struct {
+	ID            string    `json:"id"`
+	Event         Event     `json:"event"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt,omitempty"`
+	Failed        bool      `json:"failed,omitempty"`
+	Created       time.Time `json:"created"`
+}
+
+// Outbox is a Notifier that persists every event before delivering it and
+// retries failed deliveries with exponential backoff, so events survive
+// restarts and outages of the downstream channel. Delivery is at-least-once.
+type Outbox struct {
+	log         *zap.SugaredLogger
+	store       *store.Store[OutboxItem]
+	notifier    Notifier
+	interval    time.Duration
+	maxAttempts int
+
+	// mu guards claimed, the items being delivered. An item is claimed before
+	// the notifier is called and released after, so the same event is not
+	// sent twice at once while other events are delivered in parallel.
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+// NewOutbox returns an outbox that retries pending events every interval.
+// The wait before a retry starts at interval and doubles after every failed
+// attempt, and an event is given up after maxAttempts attempts.
+func NewOutbox(
+	log *zap.SugaredLogger, store *store.Store[OutboxItem], notifier Notifier, interval time.Duration, maxAttempts int,
+) *Outbox {
+	return &Outbox{
+		log:         log,
+		store:       store,
+		notifier:    notifier,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		claimed:     map[string]bool{},
+	}
+}
+
+// Notify persists the event and attempts to deliver it right away. It only
+// fails when the event could not be persisted; delivery failures are retried.
+func (o *Outbox) Notify(ctx context.Context, event Event) error {
+	item := OutboxItem{ID: store.NewID(), Event: event, Created: time.Now().UTC()}
+	if err := o.store.Put(ctx, item.ID, item); err != nil {
+		return err
+	}
+
+	// A dry run leaves nothing in the outbox, so hand the event straight to
+	// the notifier, which records it on the plan.
+	if dryrun.Enabled(ctx) {
+		return o.notifier.Notify(ctx, event)
+	}
+
+	o.deliver(ctx, item.ID)
+	return nil
+}
+
+// Flush attempts to deliver every pending event that is due.
+func (o *Outbox) Flush(ctx context.Context) {
+	for _, item := range o.store.List() {
+		o.deliver(ctx, item.ID)
+	}
+}
+
+// Pending returns the number of events that have not been delivered yet and
+// are still being retried.
+func (o *Outbox) Pending() int {
+	pending := 0
+	for _, item := range o.store.List() {
+		if !item.Failed {
+			pending++
+		}
+	}
+	return pending
+}
+
+// Run retries pending events every interval until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	o.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.tick(ctx)
+		}
+	}
+}
+
+// tick retries pending events once. A panic is logged instead of stopping the
+// loop, so the next tick tries again.
+func (o *Outbox) tick(ctx context.Context) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			o.log.Infow("Panic while delivering pending notifications", "panic", recovered,
+				"stack", string(debug.Stack()),
+			)
+		}
+	}()
+	o.Flush(ctx)
+}
+
+// deliver sends the event of a pending item that is due and not already
+// being delivered. The notifier is called without holding any lock.
+func (o *Outbox) deliver(ctx context.Context, id string) {
+	item, ok := o.claim(id)
+	if !ok {
+		return
+	}
+	defer o.release(id)
+
+	err := o.notifier.Notify(ctx, item.Event)
+	if err == nil {
+		if err := o.store.Delete(ctx, id); err != nil {
+			o.log.Infow("Failed to remove delivered notification from outbox", zap.Error(err), "id", id)
+		}
+		return
+	}
+
+	updated, updateErr := o.store.Update(ctx, id, func(record *OutboxItem) error {
+		record.Attempts++
+		record.LastError = err.Error()
+		if o.maxAttempts > 0 && record.Attempts >= o.maxAttempts {
+			record.Failed = true
+			record.NextAttemptAt = time.Time{}
+			return nil
+		}
+		record.NextAttemptAt = time.Now().UTC().Add(o.backoff(record.Attempts))
+		return nil
+	})
+	if updateErr != nil {
+		o.log.Infow("Failed to record notification delivery failure", zap.Error(updateErr), "id", id)
+		return
+	}
+
+	if updated.Failed {
+		o.log.Infow("Giving up on delivering notification", zap.Error(err),
+			"id", id, "type", item.Event.Type, "attempts", updated.Attempts,
+		)
+		return
+	}
+	o.log.Infow("Failed to deliver notification, will retry", zap.Error(err),
+		"id", id, "type", item.Event.Type, "attempts", updated.Attempts, "nextAttemptAt", updated.NextAttemptAt,
+	)
+}
+
+// claim marks a pending item as being delivered and returns it, unless it is
+// gone, given up, not due yet or already claimed.
+func (o *Outbox) claim(id string) (OutboxItem, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	item, ok := o.store.Get(id)
+	if !ok || item.Failed || o.claimed[id] || time.Now().Before(item.NextAttemptAt) {
+		return item, false
+	}
+	o.claimed[id] = true
+	return item, true
+}
+
+func (o *Outbox) release(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.claimed, id)
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	wait := o.interval
+	for i := 1; i < attempts && wait < maxOutboxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxOutboxBackoff)
+}
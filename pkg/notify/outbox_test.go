@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/iam/pkg/store"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	outbox := NewOutbox(zap.NewNop().Sugar(), nil, nil, time.Minute, 0)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 7, want: 60 * time.Minute},
+		{attempts: 8, want: maxOutboxBackoff},
+		{attempts: 1000, want: maxOutboxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := outbox.backoff(tt.attempts); got != tt.want {
+			t.Fatalf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxDeliver(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    time.Duration
+		failures    int
+		maxAttempts int
+		calls       int
+		pending     int
+		want        *OutboxItem
+	}{
+		{
+			name:        "delivered right away",
+			interval:    time.Hour,
+			maxAttempts: 3,
+			calls:       1,
+		},
+		{
+			name:        "not retried before the backoff",
+			interval:    time.Hour,
+			failures:    1,
+			maxAttempts: 3,
+			calls:       1,
+			pending:     1,
+			want:        &OutboxItem{Attempts: 1, LastError: "channel down"},
+		},
+		{
+			name:        "retried once due",
+			failures:    1,
+			maxAttempts: 3,
+			calls:       2,
+		},
+		{
+			name:        "given up after the last attempt",
+			failures:    1,
+			maxAttempts: 1,
+			calls:       1,
+			want:        &OutboxItem{Attempts: 1, LastError: "channel down", Failed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			items, err := store.New[OutboxItem]("")
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+			notifier := &flakyNotifier{failures: tt.failures}
+			outbox := NewOutbox(zap.NewNop().Sugar(), items, notifier, tt.interval, tt.maxAttempts)
+
+			if err := outbox.Notify(ctx, Event{Type: "test.event"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			outbox.Flush(ctx)
+
+			if notifier.calls != tt.calls {
+				t.Fatalf("notifier called %d times, want %d", notifier.calls, tt.calls)
+			}
+
+			stored := items.List()
+			if tt.want == nil {
+				if len(stored) != 0 {
+					t.Fatalf("outbox = %+v, want it empty", stored)
+				}
+				return
+			}
+			if len(stored) != 1 {
+				t.Fatalf("outbox = %+v, want one item", stored)
+			}
+
+			got := stored[0]
+			if got.Attempts != tt.want.Attempts || got.LastError != tt.want.LastError || got.Failed != tt.want.Failed {
+				t.Fatalf("item = %+v, want %+v", got, tt.want)
+			}
+			if got := outbox.Pending(); got != tt.pending {
+				t.Fatalf("Pending() = %d, want %d", got, tt.pending)
+			}
+		})
+	}
+}
+
+// flakyNotifier fails its first failures calls.
+type flakyNotifier struct {
+	failures int
+	calls    int
+}
+
+func (n *flakyNotifier) Notify(ctx context.Context, event Event) error {
+	n.calls++
+	if n.calls <= n.failures {
+		return errors.New("channel down")
+	}
+	return nil
+}
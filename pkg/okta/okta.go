@@ -11,7 +11,11 @@ import (
 )
 
 type Client struct {
-	sdk *okta.APIClient
+	sdk        *okta.APIClient
+	name       string
+	domain     string
+	health     *healthTracker
+	rateLimits *rateLimitTracker
 }
 
 func NewClient(cfg *config.OktaConfig) (*Client, error) {
@@ -23,35 +27,85 @@ func NewClient(cfg *config.OktaConfig) (*Client, error) {
 		return nil, fmt.Errorf("failed to create okta config : %w", err)
 	}
 
+	health := &healthTracker{}
+	rateLimits := &rateLimitTracker{}
 	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:          100,
-			MaxIdleConnsPerHost:   10,
-			ExpectContinueTimeout: 1 * time.Second,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ResponseHeaderTimeout: 20 * time.Second,
+		Timeout: cfg.RequestTimeout,
+		Transport: &transport{
+			org: cfg.Name,
+			next: &http.Transport{
+				MaxIdleConns:          100,
+				MaxIdleConnsPerHost:   10,
+				ExpectContinueTimeout: 1 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 20 * time.Second,
+			},
+			limiter:    newLimiter(cfg.RateLimit, cfg.MaxConcurrent),
+			health:     health,
+			rateLimits: rateLimits,
 		},
 	}
 
 	oktaConfig.HTTPClient = httpClient
-	return &Client{sdk: okta.NewAPIClient(oktaConfig)}, nil
+	return &Client{
+		sdk:        okta.NewAPIClient(oktaConfig),
+		name:       cfg.Name,
+		domain:     cfg.Domain,
+		health:     health,
+		rateLimits: rateLimits,
+	}, nil
 }
 
 func (c *Client) SDK() *okta.APIClient {
 	return c.sdk
 }
 
+// Name returns the name of the org the client talks to.
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) Domain() string {
+	return c.domain
+}
+
+// Health returns the health of the org as seen by the calls made so far.
+func (c *Client) Health() Health {
+	return c.health.get()
+}
+
 func (c *Client) TestConnection(ctx context.Context) error {
 	_, resp, err := c.sdk.OrgSettingAPI.GetOrgSettings(ctx).Execute()
 	if err != nil {
-		return fmt.Errorf("failed to validate Okta connection: %w", err)
+		err = fmt.Errorf("failed to validate Okta connection: %w", err)
+		c.health.failure(err)
+		return err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("okta api returned unexpected status code: %d", resp.StatusCode)
+	if code := StatusCode(resp); code < 200 || code >= 300 {
+		err := fmt.Errorf("okta api returned unexpected status code: %d", code)
+		c.health.failure(err)
+		return err
 	}
 
+	c.health.success()
 	return nil
 }
+
+// StatusCode returns the HTTP status of an SDK response for logging. It is 0
+// when the request failed before Okta answered, e.g. on a network error or a
+// cancelled context, as the SDK then returns no HTTP response.
+func StatusCode(response *okta.APIResponse) int {
+	if response == nil || response.Response == nil {
+		return 0
+	}
+	return response.StatusCode
+}
+
+// RateLimitHeadroom returns the rate limit bucket with the smallest share of
+// its limit left, and that share. The share is 1 when Okta has reported no
+// bucket being used up in its current window.
+func (c *Client) RateLimitHeadroom() (string, float64) {
+	return c.rateLimits.lowest()
+}
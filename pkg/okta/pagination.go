@@ -0,0 +1,41 @@
+package okta
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/okta/okta-sdk-golang/v5/okta"
+)
+
+// NextCursor returns the after cursor of the next page of a listing, which
+// Okta links to in the Link header, or "" on the last page. Listings are
+// paged by repeating the request with After(cursor) until it is "". A next
+// link without a cursor is an error rather than the last page, so a listing
+// is never silently cut short.
+func NextCursor(response *okta.APIResponse) (string, error) {
+	if response == nil || response.Response == nil || !response.HasNextPage() {
+		return "", nil
+	}
+	return cursor(response.NextPage())
+}
+
+// LinkedCursor is NextCursor for listings that link to their next page in
+// the body, such as the custom roles.
+func LinkedCursor(links *okta.LinksNext) (string, error) {
+	if links == nil || links.Next == nil || links.Next.GetHref() == "" {
+		return "", nil
+	}
+	return cursor(links.Next.GetHref())
+}
+
+func cursor(link string) (string, error) {
+	next, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page link %q: %w", link, err)
+	}
+	after := next.Query().Get("after")
+	if after == "" {
+		return "", fmt.Errorf("next page link %q has no after cursor", link)
+	}
+	return after, nil
+}
@@ -0,0 +1,292 @@
+package okta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iamBelugaa/iam/pkg/metrics"
+	"github.com/iamBelugaa/iam/pkg/tracing"
+)
+
+const (
+	HealthUnknown   string = "UNKNOWN"
+	HealthHealthy   string = "HEALTHY"
+	HealthUnhealthy string = "UNHEALTHY"
+)
+
+// unhealthyAfter is the number of consecutive failed calls after which an org
+// is reported unhealthy.
+const unhealthyAfter = 3
+
+// Health describes how calls to an org have been going.
+type Health struct {
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`
+	LastFailure         *time.Time `json:"lastFailure,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+type healthTracker struct {
+	mu     sync.Mutex
+	health Health
+}
+
+func (t *healthTracker) get() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := t.health
+	switch {
+	case health.LastSuccess == nil && health.LastFailure == nil:
+		health.Status = HealthUnknown
+	case health.ConsecutiveFailures >= unhealthyAfter || health.LastSuccess == nil:
+		health.Status = HealthUnhealthy
+	default:
+		health.Status = HealthHealthy
+	}
+	return health
+}
+
+func (t *healthTracker) success() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	t.health.LastSuccess = &now
+	t.health.ConsecutiveFailures = 0
+}
+
+func (t *healthTracker) failure(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	t.health.LastFailure = &now
+	t.health.LastError = err.Error()
+	t.health.ConsecutiveFailures++
+}
+
+// transport applies the limits of an org to every call and records the
+// outcome in its health, metrics and a client span. Client errors say nothing about the
+// org's health, while network errors and server errors do.
+type transport struct {
+	org        string
+	next       http.RoundTripper
+	limiter    *limiter
+	health     *healthTracker
+	rateLimits *rateLimitTracker
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := metrics.OktaOperation(req.Method, req.URL.Path)
+	_, span := tracing.Tracer().Start(req.Context(), "okta "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			tracing.Org.String(t.org),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.full", req.URL.String()),
+		),
+	)
+	defer span.End()
+
+	release, err := t.limiter.acquire(req.Context())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	switch {
+	case err != nil:
+		t.health.failure(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.health.failure(fmt.Errorf("okta api returned status code %d", resp.StatusCode))
+	default:
+		t.health.success()
+	}
+
+	if err != nil {
+		metrics.RecordOktaRequest(t.org, operation, 0, duration)
+		metrics.RecordOktaError(t.org, operation, "network")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	metrics.RecordOktaRequest(t.org, operation, resp.StatusCode, duration)
+	t.recordRateLimit(operation, resp)
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", resp.StatusCode),
+		tracing.OktaRequestID.String(resp.Header.Get("X-Okta-Request-Id")),
+	)
+	if resp.StatusCode >= http.StatusBadRequest {
+		code := errorCode(resp)
+		metrics.RecordOktaError(t.org, operation, code)
+		span.SetAttributes(tracing.OktaErrorCode.String(code))
+		span.SetStatus(codes.Error, code)
+	}
+	return resp, nil
+}
+
+// recordRateLimit records the X-Rate-Limit headers, which Okta sends for the
+// bucket of the endpoint called. The bucket is named by the operation path.
+func (t *transport) recordRateLimit(operation string, resp *http.Response) {
+	limit, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	_, bucket, _ := strings.Cut(operation, " ")
+	metrics.RecordOktaRateLimit(t.org, bucket, limit, remaining)
+
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-Rate-Limit-Reset"), 10, 64); err == nil {
+		t.rateLimits.record(bucket, limit, remaining, time.Unix(reset, 0))
+	}
+}
+
+// errorCode returns the errorCode of an Okta error response, or the status
+// code when the body is not an Okta error. The body is restored for the SDK.
+func errorCode(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return strconv.Itoa(resp.StatusCode)
+	}
+
+	var oktaError struct {
+		ErrorCode string `json:"errorCode"`
+	}
+	if json.Unmarshal(body, &oktaError) != nil || oktaError.ErrorCode == "" {
+		return strconv.Itoa(resp.StatusCode)
+	}
+	return oktaError.ErrorCode
+}
+
+// rateLimitTracker keeps the last seen rate limit of each bucket until its
+// window resets.
+type rateLimitTracker struct {
+	mu      sync.Mutex
+	buckets map[string]rateLimit
+}
+
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+func (t *rateLimitTracker) record(bucket string, limit, remaining int, reset time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.buckets == nil {
+		t.buckets = map[string]rateLimit{}
+	}
+	t.buckets[bucket] = rateLimit{limit: limit, remaining: remaining, reset: reset}
+}
+
+// lowest returns the bucket with the smallest share of its limit left in the
+// current window. The headroom is 1 when no bucket is being used up.
+func (t *rateLimitTracker) lowest() (string, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	lowestBucket, lowest := "", 1.0
+	for bucket, rl := range t.buckets {
+		if !rl.reset.After(now) {
+			delete(t.buckets, bucket)
+			continue
+		}
+		if rl.limit <= 0 {
+			continue
+		}
+		if headroom := float64(rl.remaining) / float64(rl.limit); headroom < lowest {
+			lowestBucket, lowest = bucket, headroom
+		}
+	}
+	return lowestBucket, lowest
+}
+
+// limiter spaces requests to at most rate per second and bounds the requests
+// in flight.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	slots    chan struct{}
+}
+
+func newLimiter(rate float64, maxConcurrent int) *limiter {
+	l := &limiter{}
+	if rate > 0 {
+		l.interval = time.Duration(float64(time.Second) / rate)
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.interval > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		at := l.next
+		if at.Before(now) {
+			at = now
+		}
+		l.next = at.Add(l.interval)
+		l.mu.Unlock()
+
+		if wait := at.Sub(now); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	return release, nil
+}
@@ -0,0 +1,421 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+const (
+	Version             = "3.0.3"
+	ContentTypeJSON     = "application/json"
+	ContentTypeText     = "text/plain"
+	ContentTypeNDJSON   = "application/x-ndjson"
+	successResponseName = "SuccessResponse"
+	errorResponseName   = "ErrorResponse"
+)
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path keyed by lower-case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the subset of the OpenAPI schema object used by the API.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// Route documents one API operation.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	Params  []Param
+	Request *Body
+
+	// Status is the success status code, 200 by default. Response is the
+	// data of the success envelope, or nil when the envelope has no data.
+	// Raw responses are written without the envelope.
+	Status   int
+	Response any
+	Raw      bool
+
+	// Alternatives are other representations of the response chosen by the
+	// Accept header or a query parameter.
+	Alternatives []Content
+}
+
+// Param is a query or header parameter. Path parameters are taken from the
+// route path.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Format      string
+	Enum        []string
+	Required    bool
+}
+
+// Body is a request body in one or more content types. Alternatives are
+// content types with a schema of their own.
+type Body struct {
+	Schema       any
+	ContentTypes []string
+	Alternatives []Content
+	Optional     bool
+}
+
+// Content is a representation of a response. A nil Schema is a string.
+type Content struct {
+	ContentType string
+	Schema      any
+}
+
+// Generator builds a Document, deriving schemas from Go types by their json
+// and validate struct tags.
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+}
+
+func New(info Info) *Generator {
+	g := &Generator{
+		doc: &Document{
+			OpenAPI:    Version,
+			Info:       info,
+			Paths:      map[string]PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	g.named(successResponseName, reflect.TypeOf(response.SuccessResponse{}))
+	g.named(errorResponseName, reflect.TypeOf(response.ErrorResponse{}))
+	return g
+}
+
+// Document returns the document built so far.
+func (g *Generator) Document() *Document {
+	return g.doc
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Add documents a route.
+func (g *Generator) Add(route Route) {
+	method := strings.ToLower(route.Method)
+	operation := &Operation{
+		OperationID: operationID(route.Method, route.Path),
+		Summary:     route.Summary,
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	for _, param := range route.Params {
+		schema := &Schema{Type: param.Type, Format: param.Format, Enum: param.Enum}
+		if schema.Type == "" {
+			schema.Type = "string"
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        param.Name,
+			In:          param.In,
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      schema,
+		})
+	}
+
+	if route.Request != nil {
+		contentTypes := route.Request.ContentTypes
+		if len(contentTypes) == 0 {
+			contentTypes = []string{ContentTypeJSON}
+		}
+		schema := g.Schema(route.Request.Schema)
+		body := &RequestBody{Required: !route.Request.Optional, Content: map[string]*MediaType{}}
+		for _, contentType := range contentTypes {
+			body.Content[contentType] = &MediaType{Schema: schema}
+		}
+		for _, alternative := range route.Request.Alternatives {
+			body.Content[alternative.ContentType] = &MediaType{Schema: g.Schema(alternative.Schema)}
+		}
+		operation.RequestBody = body
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := &Response{Description: http.StatusText(status), Content: map[string]*MediaType{}}
+	switch {
+	case route.Raw:
+		success.Content[ContentTypeJSON] = &MediaType{Schema: g.Schema(route.Response)}
+	case route.Response == nil:
+		success.Content[ContentTypeJSON] = &MediaType{Schema: ref(successResponseName)}
+	default:
+		success.Content[ContentTypeJSON] = &MediaType{Schema: &Schema{AllOf: []*Schema{
+			ref(successResponseName),
+			{Type: "object", Properties: map[string]*Schema{"data": g.Schema(route.Response)}},
+		}}}
+	}
+	for _, alternative := range route.Alternatives {
+		schema := &Schema{Type: "string"}
+		if alternative.Schema != nil {
+			schema = g.Schema(alternative.Schema)
+		}
+		success.Content[alternative.ContentType] = &MediaType{Schema: schema}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+	operation.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{ContentTypeJSON: {Schema: ref(errorResponseName)}},
+	}
+
+	item, ok := g.doc.Paths[route.Path]
+	if !ok {
+		item = PathItem{}
+		g.doc.Paths[route.Path] = item
+	}
+	item[method] = operation
+}
+
+// operationID names an operation by its method and the static segments of
+// its path, e.g. getUsersRoles for GET /api/v1/users/{userID}/roles.
+func operationID(method, routePath string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(routePath, "/") {
+		if segment == "" || segment == "api" || strings.HasPrefix(segment, "v") && len(segment) == 2 {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by-" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schema returns the schema of the type of v. Named struct types are added
+// to the components and referenced.
+func (g *Generator) Schema(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := g.schemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if name, ok := g.names[t]; ok {
+			return ref(name)
+		}
+		return ref(g.named(g.schemaName(t), t))
+	default:
+		return &Schema{}
+	}
+}
+
+// named adds the schema of a struct type to the components.
+func (g *Generator) named(name string, t reflect.Type) string {
+	g.names[t] = name
+	// Reserve the name before building the properties, so recursive types
+	// refer to themselves.
+	g.doc.Components.Schemas[name] = &Schema{}
+	g.doc.Components.Schemas[name] = g.structSchema(t)
+	return name
+}
+
+// schemaName names a type after itself, prefixed with its package when
+// another type already took the name.
+func (g *Generator) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.doc.Components.Schemas[name]; !taken {
+		return name
+	}
+
+	pkg := strings.TrimSuffix(path.Base(t.PkgPath()), "_service")
+	prefixed := strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	for i := 2; ; i++ {
+		if _, taken := g.doc.Components.Schemas[prefixed]; !taken {
+			return prefixed
+		}
+		prefixed = fmt.Sprintf("%s%s%d", strings.ToUpper(pkg[:1])+pkg[1:], name, i)
+	}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaOf(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			switch {
+			case rule == "required":
+				schema.Required = append(schema.Required, name)
+				one := 1
+				switch property.Type {
+				case "string":
+					property.MinLength = &one
+				case "array":
+					property.MinItems = &one
+				}
+			case strings.HasPrefix(rule, "oneof="):
+				property.Enum = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			}
+		}
+		schema.Properties[name] = property
+	}
+}
@@ -0,0 +1,322 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/iam/pkg/response"
+)
+
+// FieldError describes one way a request does not match the specification.
+type FieldError struct {
+	In      string `json:"in"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the mismatches of a request. Status is the HTTP
+// status to answer with.
+type ValidationError struct {
+	Status int
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fmt.Sprintf("%s %s: %s", fieldError.In, fieldError.Field, fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator checks requests against the operations of a document.
+type Validator struct {
+	doc    *Document
+	routes []route
+}
+
+type route struct {
+	method    string
+	segments  []string
+	literals  int
+	operation *Operation
+}
+
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{doc: doc}
+	for routePath, item := range doc.Paths {
+		segments := strings.Split(strings.Trim(routePath, "/"), "/")
+		literals := 0
+		for _, segment := range segments {
+			if !strings.HasPrefix(segment, "{") {
+				literals++
+			}
+		}
+		for method, operation := range item {
+			v.routes = append(v.routes, route{
+				method: strings.ToUpper(method), segments: segments, literals: literals, operation: operation,
+			})
+		}
+	}
+
+	// Prefer the most specific route, e.g. /snapshots/diff over
+	// /snapshots/{snapshotID}.
+	sort.SliceStable(v.routes, func(i, j int) bool {
+		return v.routes[i].literals > v.routes[j].literals
+	})
+	return v
+}
+
+// Middleware rejects requests that do not match their operation with a 400,
+// or a 415 for an unsupported content type. path returns the path to look
+// the operation up by. Requests for paths the document does not describe
+// are passed on.
+func (v *Validator) Middleware(path func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := v.Validate(r, path(r)); err != nil {
+				response.RespondError(w, err.Status, "VALIDATION_ERROR", "Request does not match the API specification", err.Errors)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Validate checks the query parameters and body of r against the operation
+// of its method and path. The body is restored for the handler.
+func (v *Validator) Validate(r *http.Request, requestPath string) *ValidationError {
+	operation := v.find(r.Method, requestPath)
+	if operation == nil {
+		return nil
+	}
+
+	var errs []FieldError
+	query := r.URL.Query()
+	for _, param := range operation.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		value := query.Get(param.Name)
+		if value == "" {
+			if param.Required {
+				errs = append(errs, FieldError{In: "query", Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+		if message := checkParam(param.Schema, value); message != "" {
+			errs = append(errs, FieldError{In: "query", Field: param.Name, Message: message})
+		}
+	}
+
+	if operation.RequestBody != nil {
+		status, bodyErrs := v.checkBody(r, operation.RequestBody)
+		if status == http.StatusUnsupportedMediaType {
+			return &ValidationError{Status: status, Errors: bodyErrs}
+		}
+		errs = append(errs, bodyErrs...)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Status: http.StatusBadRequest, Errors: errs}
+	}
+	return nil
+}
+
+func (v *Validator) find(method, requestPath string) *Operation {
+	segments := strings.Split(strings.Trim(requestPath, "/"), "/")
+	for _, candidate := range v.routes {
+		if candidate.method != method || len(candidate.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range candidate.segments {
+			if !strings.HasPrefix(segment, "{") && segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return candidate.operation
+		}
+	}
+	return nil
+}
+
+func (v *Validator) checkBody(r *http.Request, body *RequestBody) (int, []FieldError) {
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return http.StatusBadRequest, []FieldError{{In: "body", Field: "", Message: "could not be read"}}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return http.StatusBadRequest, []FieldError{{In: "body", Field: "", Message: "is required"}}
+		}
+		return http.StatusOK, nil
+	}
+
+	contentType := ContentTypeJSON
+	if header := r.Header.Get("Content-Type"); header != "" {
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			mediaType = header
+		}
+		contentType = mediaType
+	}
+
+	media, ok := body.Content[contentType]
+	if !ok {
+		supported := make([]string, 0, len(body.Content))
+		for name := range body.Content {
+			supported = append(supported, name)
+		}
+		slices.Sort(supported)
+		return http.StatusUnsupportedMediaType, []FieldError{{
+			In: "header", Field: "Content-Type",
+			Message: fmt.Sprintf("must be one of %s", strings.Join(supported, ", ")),
+		}}
+	}
+	if contentType != ContentTypeJSON && !strings.HasSuffix(contentType, "+json") {
+		return http.StatusOK, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return http.StatusBadRequest, []FieldError{{In: "body", Field: "", Message: "is not valid JSON"}}
+	}
+
+	var errs []FieldError
+	v.check(media.Schema, value, "", &errs)
+	return http.StatusOK, errs
+}
+
+// check validates a decoded JSON value. A null value is accepted wherever
+// the property is not required, as the JSON decoder of the handlers does.
+func (v *Validator) check(schema *Schema, value any, field string, errs *[]FieldError) {
+	if schema == nil || value == nil {
+		return
+	}
+	if schema.Ref != "" {
+		v.check(v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, field, errs)
+		return
+	}
+	for _, part := range schema.AllOf {
+		v.check(part, value, field, errs)
+	}
+
+	fail := func(message string) {
+		*errs = append(*errs, FieldError{In: "body", Field: field, Message: message})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if object[name] == nil {
+				*errs = append(*errs, FieldError{In: "body", Field: join(field, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			property := object[name]
+			if propertySchema, ok := schema.Properties[name]; ok {
+				v.check(propertySchema, property, join(field, name), errs)
+			} else if schema.AdditionalProperties != nil {
+				v.check(schema.AdditionalProperties, property, join(field, name), errs)
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			fail(fmt.Sprintf("must have at least %d item(s)", *schema.MinItems))
+		}
+		for i, item := range items {
+			v.check(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if message := checkString(schema, text); message != "" {
+			fail(message)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			fail("must be an integer")
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			fail("must be a number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+func checkString(schema *Schema, value string) string {
+	switch {
+	case schema.MinLength != nil && len(strings.TrimSpace(value)) < *schema.MinLength:
+		return "must not be empty"
+	case value != "" && len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value):
+		return fmt.Sprintf("must be one of %s", strings.Join(schema.Enum, ", "))
+	case schema.Format == "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 timestamp"
+		}
+	}
+	return ""
+}
+
+func checkParam(schema *Schema, value string) string {
+	switch schema.Type {
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an integer"
+		}
+	case "string":
+		return checkString(schema, value)
+	}
+	return ""
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
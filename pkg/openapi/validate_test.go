@@ -0,0 +1,224 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMember struct {
+	Login string `json:"login" validate:"required"`
+	Admin bool   `json:"admin"`
+}
+
+type testGroupRequest struct {
+	Name     string            `json:"name" validate:"required"`
+	Type     string            `json:"type" validate:"oneof=OKTA_GROUP APP_GROUP"`
+	Limit    int               `json:"limit"`
+	Expires  time.Time         `json:"expires"`
+	Members  []testMember      `json:"members" validate:"required"`
+	Profile  map[string]string `json:"profile"`
+	Priority *float64          `json:"priority"`
+}
+
+func testValidator() *Validator {
+	g := New(Info{Title: "test", Version: "1"})
+	g.Add(Route{
+		Method:  http.MethodPost,
+		Path:    "/api/v1/groups",
+		Request: &Body{Schema: testGroupRequest{}, ContentTypes: []string{ContentTypeJSON, "application/merge-patch+json"}},
+		Params: []Param{
+			{Name: "dryRun", In: "query", Type: "boolean"},
+			{Name: "limit", In: "query", Type: "integer"},
+			{Name: "mode", In: "query", Enum: []string{"fast", "safe"}, Required: true},
+		},
+	})
+	g.Add(Route{Method: http.MethodPost, Path: "/api/v1/groups/{groupID}/archive", Request: &Body{Schema: testGroupRequest{}}})
+	g.Add(Route{Method: http.MethodPost, Path: "/api/v1/groups/{groupID}/members", Request: &Body{Schema: testMember{}, Optional: true}})
+	return NewValidator(g.Document())
+}
+
+const validGroup = `{"name":"Engineering","type":"OKTA_GROUP","limit":3,"expires":"2026-01-02T03:04:05Z",` +
+	`"members":[{"login":"ada@example.com"}],"profile":{"costCenter":"42"},"priority":1.5}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		query       string
+		contentType string
+		body        string
+		status      int
+		errors      []FieldError
+	}{
+		{
+			name:  "valid",
+			path:  "/api/v1/groups",
+			query: "mode=fast&dryRun=true&limit=5",
+			body:  validGroup,
+		},
+		{
+			name:  "null optional properties",
+			path:  "/api/v1/groups",
+			query: "mode=safe",
+			body:  `{"name":"Engineering","members":[{"login":"a"}],"type":null,"expires":null}`,
+		},
+		{
+			name:        "content type with parameters",
+			path:        "/api/v1/groups",
+			query:       "mode=fast",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        validGroup,
+		},
+		{
+			name: "undescribed path is passed on",
+			path: "/api/v1/unknown",
+			body: `not json`,
+		},
+		{
+			name: "optional body may be empty",
+			path: "/api/v1/groups/00g1/members",
+		},
+		{
+			name:   "invalid query parameters",
+			path:   "/api/v1/groups",
+			query:  "dryRun=maybe&limit=1.5&mode=slow",
+			body:   validGroup,
+			status: http.StatusBadRequest,
+			errors: []FieldError{
+				{In: "query", Field: "dryRun", Message: "must be true or false"},
+				{In: "query", Field: "limit", Message: "must be an integer"},
+				{In: "query", Field: "mode", Message: "must be one of fast, safe"},
+			},
+		},
+		{
+			name:   "missing required query parameter",
+			path:   "/api/v1/groups",
+			body:   validGroup,
+			status: http.StatusBadRequest,
+			errors: []FieldError{{In: "query", Field: "mode", Message: "is required"}},
+		},
+		{
+			name:   "missing required body",
+			path:   "/api/v1/groups",
+			query:  "mode=fast",
+			status: http.StatusBadRequest,
+			errors: []FieldError{{In: "body", Field: "", Message: "is required"}},
+		},
+		{
+			name:   "invalid JSON",
+			path:   "/api/v1/groups",
+			query:  "mode=fast",
+			body:   `{"name":`,
+			status: http.StatusBadRequest,
+			errors: []FieldError{{In: "body", Field: "", Message: "is not valid JSON"}},
+		},
+		{
+			name:        "unsupported content type",
+			path:        "/api/v1/groups",
+			query:       "dryRun=maybe",
+			contentType: "text/plain",
+			body:        "hello",
+			status:      http.StatusUnsupportedMediaType,
+			errors: []FieldError{{
+				In: "header", Field: "Content-Type",
+				Message: "must be one of application/json, application/merge-patch+json",
+			}},
+		},
+		{
+			name:  "invalid body fields",
+			path:  "/api/v1/groups",
+			query: "mode=fast",
+			body: `{"name":" ","type":"BUILT_IN","limit":1.5,"expires":"yesterday",` +
+				`"members":[{"admin":"yes"},"ada"],"profile":{"costCenter":42},"priority":"high"}`,
+			status: http.StatusBadRequest,
+			errors: []FieldError{
+				{In: "body", Field: "expires", Message: "must be an RFC 3339 timestamp"},
+				{In: "body", Field: "limit", Message: "must be an integer"},
+				{In: "body", Field: "members[0].login", Message: "is required"},
+				{In: "body", Field: "members[0].admin", Message: "must be a boolean"},
+				{In: "body", Field: "members[1]", Message: "must be an object"},
+				{In: "body", Field: "name", Message: "must not be empty"},
+				{In: "body", Field: "priority", Message: "must be a number"},
+				{In: "body", Field: "profile.costCenter", Message: "must be a string"},
+				{In: "body", Field: "type", Message: "must be one of OKTA_GROUP, APP_GROUP"},
+			},
+		},
+		{
+			name:   "missing required fields and empty arrays",
+			path:   "/api/v1/groups/00g1/archive",
+			body:   `{"members":[]}`,
+			status: http.StatusBadRequest,
+			errors: []FieldError{
+				{In: "body", Field: "name", Message: "is required"},
+				{In: "body", Field: "members", Message: "must have at least 1 item(s)"},
+			},
+		},
+		{
+			name:   "wrong top-level type",
+			path:   "/api/v1/groups/00g1/archive",
+			body:   `[]`,
+			status: http.StatusBadRequest,
+			errors: []FieldError{{In: "body", Field: "", Message: "must be an object"}},
+		},
+	}
+
+	validator := testValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path+"?"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			err := validator.Validate(r, tt.path)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected a %d, got none", tt.status)
+			}
+			if err.Status != tt.status {
+				t.Fatalf("status = %d, want %d", err.Status, tt.status)
+			}
+			if !reflect.DeepEqual(err.Errors, tt.errors) {
+				t.Fatalf("errors = %+v, want %+v", err.Errors, tt.errors)
+			}
+		})
+	}
+}
+
+func TestValidateRestoresBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/groups?mode=fast", strings.NewReader(validGroup))
+	if err := testValidator().Validate(r, "/api/v1/groups"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != validGroup {
+		t.Fatalf("body = %s, want %s", body, validGroup)
+	}
+}
+
+func TestValidatePrefersLiteralSegments(t *testing.T) {
+	g := New(Info{Title: "test", Version: "1"})
+	g.Add(Route{Method: http.MethodPost, Path: "/api/v1/snapshots/{snapshotID}", Request: &Body{Schema: testMember{}}})
+	g.Add(Route{Method: http.MethodPost, Path: "/api/v1/snapshots/import", Request: &Body{Schema: testGroupRequest{}}})
+	validator := NewValidator(g.Document())
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/snapshots/import", strings.NewReader(`{"login":"ada"}`))
+	err := validator.Validate(r, "/api/v1/snapshots/import")
+	if err == nil {
+		t.Fatal("expected the import schema to reject a member body")
+	}
+}
@@ -0,0 +1,21 @@
+package org
+
+import "context"
+
+// Header selects the Okta org a request is for when the path does not name
+// one. Requests without either go to the default org.
+const Header = "X-Okta-Org"
+
+type contextKey struct{}
+
+// WithName returns a copy of ctx that carries the name of the org.
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext returns the name of the org of the request, or an empty string
+// outside a request.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(contextKey{}).(string)
+	return name
+}
@@ -0,0 +1,369 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrTestFailed   = errors.New("patch test operation failed")
+)
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies patch to doc using the format selected by contentType.
+// doc is the JSON encoding of the current entity and the patched JSON is returned.
+func Apply(contentType string, doc, patch []byte) ([]byte, error) {
+	switch mediaType(contentType) {
+	case ContentTypeJSONPatch:
+		return ApplyJSONPatch(doc, patch)
+	case ContentTypeMergePatch, "application/json", "":
+		return ApplyMergePatch(doc, patch)
+	default:
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrInvalidPatch, contentType)
+	}
+}
+
+// Supported reports whether contentType names a patch format handled by Apply.
+func Supported(contentType string) bool {
+	switch mediaType(contentType) {
+	case ContentTypeJSONPatch, ContentTypeMergePatch, "application/json", "":
+		return true
+	}
+	return false
+}
+
+// ApplyMergePatch applies an RFC 7386 JSON Merge Patch to doc.
+// Object members set to null in the patch are removed from the result.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch to doc.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var err error
+	for i, op := range ops {
+		if target, err = applyOperation(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	switch op.Op {
+	case "add":
+		value, err := decodeValue(op)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+
+	case "replace":
+		value, err := decodeValue(op)
+		if err != nil {
+			return nil, err
+		}
+		doc, _, err = remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+		}
+		doc, value, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(value))
+
+	case "test":
+		value, err := decodeValue(op)
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+func decodeValue(op Operation) (any, error) {
+	if op.Value == nil {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+	}
+
+	var value any
+	if err := json.Unmarshal(op.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with '/'", ErrInvalidPatch, path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, path string) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, path)
+			}
+			current = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, path)
+		}
+	}
+
+	return current, nil
+}
+
+func add(doc any, path string, value any) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+
+	case []any:
+		index := len(node)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		updated := append(node[:index:index], append([]any{value}, node[index:]...)...)
+		return replaceAt(doc, tokens[:len(tokens)-1], updated)
+
+	default:
+		return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, path)
+	}
+}
+
+func remove(doc any, path string) (any, any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, path)
+		}
+		delete(node, last)
+		return doc, value, nil
+
+	case []any:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[index]
+		updated := append(node[:index:index], node[index+1:]...)
+		doc, err = replaceAt(doc, tokens[:len(tokens)-1], updated)
+		return doc, value, err
+
+	default:
+		return nil, nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, path)
+	}
+}
+
+// replaceAt swaps the node at tokens for value. Arrays change length on
+// add/remove, so their parent has to be updated with the new slice.
+func replaceAt(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+	case []any:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func pointerOf(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		b.WriteString("/" + strings.ReplaceAll(token, "/", "~1"))
+	}
+	return b.String()
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = deepCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
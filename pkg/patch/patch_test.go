@@ -0,0 +1,237 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"profile":{"firstName":"Ada","tags":["a","b"],"a/b":1,"m~n":2}}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "add member",
+			patch: `[{"op":"add","path":"/profile/lastName","value":"Lovelace"}]`,
+			want:  `{"profile":{"firstName":"Ada","lastName":"Lovelace","tags":["a","b"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "add to array end",
+			patch: `[{"op":"add","path":"/profile/tags/-","value":"c"}]`,
+			want:  `{"profile":{"firstName":"Ada","tags":["a","b","c"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "insert into array",
+			patch: `[{"op":"add","path":"/profile/tags/0","value":"z"}]`,
+			want:  `{"profile":{"firstName":"Ada","tags":["z","a","b"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "remove array element",
+			patch: `[{"op":"remove","path":"/profile/tags/0"}]`,
+			want:  `{"profile":{"firstName":"Ada","tags":["b"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "replace escaped keys",
+			patch: `[{"op":"replace","path":"/profile/a~1b","value":3},{"op":"replace","path":"/profile/m~0n","value":4}]`,
+			want:  `{"profile":{"firstName":"Ada","tags":["a","b"],"a/b":3,"m~n":4}}`,
+		},
+		{
+			name:  "move",
+			patch: `[{"op":"move","from":"/profile/firstName","path":"/profile/nickName"}]`,
+			want:  `{"profile":{"nickName":"Ada","tags":["a","b"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "copy",
+			patch: `[{"op":"copy","from":"/profile/tags","path":"/profile/labels"}]`,
+			want:  `{"profile":{"firstName":"Ada","tags":["a","b"],"labels":["a","b"],"a/b":1,"m~n":2}}`,
+		},
+		{
+			name:  "test passes",
+			patch: `[{"op":"test","path":"/profile/firstName","value":"Ada"}]`,
+			want:  doc,
+		},
+		{
+			name:  "test fails",
+			patch: `[{"op":"test","path":"/profile/firstName","value":"Grace"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "path without leading slash",
+			patch: `[{"op":"add","path":"profile/lastName","value":"Lovelace"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing parent",
+			patch: `[{"op":"add","path":"/missing/lastName","value":"Lovelace"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "remove missing member",
+			patch: `[{"op":"remove","path":"/profile/lastName"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "array index out of range",
+			patch: `[{"op":"replace","path":"/profile/tags/2","value":"c"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "array index with leading zero",
+			patch: `[{"op":"remove","path":"/profile/tags/01"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "negative array index",
+			patch: `[{"op":"remove","path":"/profile/tags/-1"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "path through a scalar",
+			patch: `[{"op":"add","path":"/profile/firstName/x","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move into own child",
+			patch: `[{"op":"move","from":"/profile","path":"/profile/inner"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing value",
+			patch: `[{"op":"add","path":"/profile/lastName"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "unknown operation",
+			patch: `[{"op":"append","path":"/profile/lastName","value":"x"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "not an array",
+			patch: `{"op":"add","path":"/profile/lastName","value":"x"}`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "later operation fails",
+			patch: `[{"op":"add","path":"/profile/lastName","value":"x"},{"op":"remove","path":"/missing"}]`,
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := `{"profile":{"firstName":"Ada","lastName":"Lovelace","tags":["a"]}}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "set member",
+			patch: `{"profile":{"firstName":"Grace"}}`,
+			want:  `{"profile":{"firstName":"Grace","lastName":"Lovelace","tags":["a"]}}`,
+		},
+		{
+			name:  "null removes member",
+			patch: `{"profile":{"lastName":null}}`,
+			want:  `{"profile":{"firstName":"Ada","tags":["a"]}}`,
+		},
+		{
+			name:  "arrays are replaced",
+			patch: `{"profile":{"tags":["b","c"]}}`,
+			want:  `{"profile":{"firstName":"Ada","lastName":"Lovelace","tags":["b","c"]}}`,
+		},
+		{
+			name:  "invalid json",
+			patch: `{"profile":`,
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMergePatch([]byte(doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		err         error
+	}{
+		{name: "json patch", contentType: "application/json-patch+json", patch: `[{"op":"remove","path":"/a"}]`},
+		{name: "merge patch with charset", contentType: "application/merge-patch+json; charset=utf-8", patch: `{"a":null}`},
+		{name: "plain json", contentType: "application/json", patch: `{"a":null}`},
+		{name: "unsupported", contentType: "text/plain", patch: `{"a":null}`, err: ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.contentType, []byte(`{"a":1}`), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				if Supported(tt.contentType) {
+					t.Fatalf("Supported(%q) = true", tt.contentType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSONEqual(t, got, `{}`)
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid expectation %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
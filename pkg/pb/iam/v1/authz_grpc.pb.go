@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: iam/v1/authz.proto
+
+package iamv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Authz_HasRole_FullMethodName         = "/iam.v1.Authz/HasRole"
+	Authz_IsMember_FullMethodName        = "/iam.v1.Authz/IsMember"
+	Authz_CheckAssignment_FullMethodName = "/iam.v1.Authz/CheckAssignment"
+)
+
+// AuthzClient is the client API for Authz service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Authz answers authorization questions about users without changing
+// anything.
+type AuthzClient interface {
+	// HasRole reports whether a user holds a role, directly or through a
+	// group. The role is matched by ID, type or name.
+	HasRole(ctx context.Context, in *HasRoleRequest, opts ...grpc.CallOption) (*HasRoleResponse, error)
+	// IsMember reports whether a user belongs to a group.
+	IsMember(ctx context.Context, in *IsMemberRequest, opts ...grpc.CallOption) (*IsMemberResponse, error)
+	// CheckAssignment reports whether giving a user a role or group would be
+	// allowed by the separation-of-duties rules.
+	CheckAssignment(ctx context.Context, in *CheckAssignmentRequest, opts ...grpc.CallOption) (*CheckAssignmentResponse, error)
+}
+
+type authzClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthzClient(cc grpc.ClientConnInterface) AuthzClient {
+	return &authzClient{cc}
+}
+
+func (c *authzClient) HasRole(ctx context.Context, in *HasRoleRequest, opts ...grpc.CallOption) (*HasRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HasRoleResponse)
+	err := c.cc.Invoke(ctx, Authz_HasRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authzClient) IsMember(ctx context.Context, in *IsMemberRequest, opts ...grpc.CallOption) (*IsMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IsMemberResponse)
+	err := c.cc.Invoke(ctx, Authz_IsMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authzClient) CheckAssignment(ctx context.Context, in *CheckAssignmentRequest, opts ...grpc.CallOption) (*CheckAssignmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckAssignmentResponse)
+	err := c.cc.Invoke(ctx, Authz_CheckAssignment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthzServer is the server API for Authz service.
+// All implementations must embed UnimplementedAuthzServer
+// for forward compatibility.
+//
+// Authz answers authorization questions about users without changing
+// anything.
+type AuthzServer interface {
+	// HasRole reports whether a user holds a role, directly or through a
+	// group. The role is matched by ID, type or name.
+	HasRole(context.Context, *HasRoleRequest) (*HasRoleResponse, error)
+	// IsMember reports whether a user belongs to a group.
+	IsMember(context.Context, *IsMemberRequest) (*IsMemberResponse, error)
+	// CheckAssignment reports whether giving a user a role or group would be
+	// allowed by the separation-of-duties rules.
+	CheckAssignment(context.Context, *CheckAssignmentRequest) (*CheckAssignmentResponse, error)
+	mustEmbedUnimplementedAuthzServer()
+}
+
+// UnimplementedAuthzServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuthzServer struct{}
+
+func (UnimplementedAuthzServer) HasRole(context.Context, *HasRoleRequest) (*HasRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HasRole not implemented")
+}
+func (UnimplementedAuthzServer) IsMember(context.Context, *IsMemberRequest) (*IsMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsMember not implemented")
+}
+func (UnimplementedAuthzServer) CheckAssignment(context.Context, *CheckAssignmentRequest) (*CheckAssignmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAssignment not implemented")
+}
+func (UnimplementedAuthzServer) mustEmbedUnimplementedAuthzServer() {}
+func (UnimplementedAuthzServer) testEmbeddedByValue()               {}
+
+// UnsafeAuthzServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthzServer will
+// result in compilation errors.
+type UnsafeAuthzServer interface {
+	mustEmbedUnimplementedAuthzServer()
+}
+
+func RegisterAuthzServer(s grpc.ServiceRegistrar, srv AuthzServer) {
+	// If the following call pancis, it indicates UnimplementedAuthzServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Authz_ServiceDesc, srv)
+}
+
+func _Authz_HasRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HasRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthzServer).HasRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authz_HasRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthzServer).HasRole(ctx, req.(*HasRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authz_IsMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthzServer).IsMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authz_IsMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthzServer).IsMember(ctx, req.(*IsMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authz_CheckAssignment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckAssignmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthzServer).CheckAssignment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authz_CheckAssignment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthzServer).CheckAssignment(ctx, req.(*CheckAssignmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Authz_ServiceDesc is the grpc.ServiceDesc for Authz service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Authz_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iam.v1.Authz",
+	HandlerType: (*AuthzServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "HasRole",
+			Handler:    _Authz_HasRole_Handler,
+		},
+		{
+			MethodName: "IsMember",
+			Handler:    _Authz_IsMember_Handler,
+		},
+		{
+			MethodName: "CheckAssignment",
+			Handler:    _Authz_CheckAssignment_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iam/v1/authz.proto",
+}
@@ -3,6 +3,8 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/iamBelugaa/iam/pkg/metrics"
 )
 
 type SuccessResponse struct {
@@ -25,6 +27,7 @@ func RespondSuccess(w http.ResponseWriter, code int, msg string, data any) {
 }
 
 func RespondError(w http.ResponseWriter, status int, code, msg string, details any) {
+	metrics.RecordError(code, status)
 	response := ErrorResponse{Success: false, Code: status, Message: msg, ErrorCode: code, Details: details}
 	respond(w, status, response)
 }
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+)
+
+// ErrInvalidID is returned for an id that cannot name a file.
+var ErrInvalidID = errors.New("invalid record id")
+
+// Files keeps every record in its own JSON file in a directory, for records
+// too large to rewrite together with all the others on each write, such as
+// snapshot archives. An empty directory keeps the records in memory only.
+// Writes made with a dry-run context are recorded on the plan and not made.
+type Files[T any] struct {
+	mu      sync.RWMutex
+	dir     string
+	records map[string]T
+}
+
+func NewFiles[T any](dir string) (*Files[T], error) {
+	f := &Files[T]{dir: dir, records: map[string]T{}}
+	if dir == "" {
+		return f, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return f, nil
+}
+
+// Get reads the record stored under id.
+func (f *Files[T]) Get(id string) (T, error) {
+	var record T
+	if !validID(id) {
+		return record, ErrNotFound
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.dir == "" {
+		record, ok := f.records[id]
+		if !ok {
+			return record, ErrNotFound
+		}
+		return record, nil
+	}
+
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return record, ErrNotFound
+	}
+	if err != nil {
+		return record, fmt.Errorf("failed to read store %s: %w", f.path(id), err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to decode store %s: %w", f.path(id), err)
+	}
+	return record, nil
+}
+
+// Put stores record under id, replacing any existing record.
+func (f *Files[T]) Put(ctx context.Context, id string, record T) error {
+	if !validID(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		plan.Record(f.call("put", id))
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dir == "" {
+		f.records[id] = record
+		return nil
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store %s: %w", f.path(id), err)
+	}
+
+	tmp := f.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write store %s: %w", f.path(id), err)
+	}
+	if err := os.Rename(tmp, f.path(id)); err != nil {
+		return fmt.Errorf("failed to replace store %s: %w", f.path(id), err)
+	}
+	return nil
+}
+
+// Delete removes the record stored under id.
+func (f *Files[T]) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		plan.Record(f.call("delete", id))
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dir == "" {
+		if _, ok := f.records[id]; !ok {
+			return ErrNotFound
+		}
+		delete(f.records, id)
+		return nil
+	}
+
+	err := os.Remove(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete from store %s: %w", f.path(id), err)
+	}
+	return nil
+}
+
+// Name returns the name of the directory, or "memory" for an in-memory store.
+func (f *Files[T]) Name() string {
+	if f.dir == "" {
+		return "memory"
+	}
+	return filepath.Base(f.dir)
+}
+
+// Check reports whether the directory can still be written, by writing and
+// removing a probe file in it.
+func (f *Files[T]) Check() error {
+	if f.dir == "" {
+		return nil
+	}
+
+	probe := filepath.Join(f.dir, ".check")
+	if err := os.WriteFile(probe, nil, 0o600); err != nil {
+		return fmt.Errorf("store %s is not writable: %w", f.dir, err)
+	}
+	if err := os.Remove(probe); err != nil {
+		return fmt.Errorf("failed to remove probe of store %s: %w", f.dir, err)
+	}
+	return nil
+}
+
+func (f *Files[T]) path(id string) string {
+	return filepath.Join(f.dir, id+".json")
+}
+
+func (f *Files[T]) call(operation, id string) dryrun.Call {
+	return dryrun.Call{System: dryrun.SystemStore, Operation: operation, Resource: f.Name() + "/" + id}
+}
+
+// validID reports whether id names a file inside the directory.
+func validID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
@@ -0,0 +1,304 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iamBelugaa/iam/pkg/dryrun"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// Store is a keyed collection of records persisted as a single JSON file.
+// Every write rewrites the file atomically, so the collection survives restarts.
+// An empty path keeps the records in memory only. Writes made with a dry-run
+// context are recorded on the plan and kept out of the collection.
+type Store[T any] struct {
+	mu      sync.RWMutex
+	path    string
+	records map[string]T
+}
+
+func New[T any](path string) (*Store[T], error) {
+	s := &Store[T]{path: path, records: map[string]T{}}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store %s: %w", path, err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.records); err != nil {
+			return nil, fmt.Errorf("failed to decode store %s: %w", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Get returns the record stored under id.
+func (s *Store[T]) Get(id string) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	return record, ok
+}
+
+// List returns all records ordered by id.
+func (s *Store[T]) List() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]T, len(ids))
+	for i, id := range ids {
+		result[i] = s.records[id]
+	}
+	return result
+}
+
+// Put stores record under id, replacing any existing record.
+func (s *Store[T]) Put(ctx context.Context, id string, record T) error {
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		s.overlay(plan)[id] = &record
+		plan.Record(s.call("put", id))
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.records[id]
+	s.records[id] = record
+
+	if err := s.flush(); err != nil {
+		if existed {
+			s.records[id] = previous
+		} else {
+			delete(s.records, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// PutAll stores every record under its id in a single write, replacing any
+// existing records. Either all of them are stored or none is.
+func (s *Store[T]) PutAll(ctx context.Context, records map[string]T) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		ids := make([]string, 0, len(records))
+		for id := range records {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			record := records[id]
+			s.overlay(plan)[id] = &record
+			plan.Record(s.call("put", id))
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := make(map[string]T, len(records))
+	for id, record := range records {
+		if existing, ok := s.records[id]; ok {
+			previous[id] = existing
+		}
+		s.records[id] = record
+	}
+
+	if err := s.flush(); err != nil {
+		for id := range records {
+			if existing, ok := previous[id]; ok {
+				s.records[id] = existing
+			} else {
+				delete(s.records, id)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Update applies fn to the record stored under id and persists the result.
+// The record is left unchanged when fn returns an error.
+func (s *Store[T]) Update(ctx context.Context, id string, fn func(record *T) error) (T, error) {
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		return s.planUpdate(plan, id, fn)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.records[id]
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+
+	record := previous
+	if err := fn(&record); err != nil {
+		return previous, err
+	}
+
+	s.records[id] = record
+	if err := s.flush(); err != nil {
+		s.records[id] = previous
+		return previous, err
+	}
+	return record, nil
+}
+
+// Delete removes the record stored under id.
+func (s *Store[T]) Delete(ctx context.Context, id string) error {
+	if plan := dryrun.FromContext(ctx); plan != nil {
+		if _, ok := s.planned(plan, id); !ok {
+			return ErrNotFound
+		}
+		s.overlay(plan)[id] = nil
+		plan.Record(s.call("delete", id))
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(s.records, id)
+	if err := s.flush(); err != nil {
+		s.records[id] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *Store[T]) planUpdate(plan *dryrun.Plan, id string, fn func(record *T) error) (T, error) {
+	previous, ok := s.planned(plan, id)
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+
+	record := previous
+	if err := fn(&record); err != nil {
+		return previous, err
+	}
+
+	s.overlay(plan)[id] = &record
+	plan.Record(s.call("update", id))
+	return record, nil
+}
+
+// planned returns the record as the dry-run request last left it.
+func (s *Store[T]) planned(plan *dryrun.Plan, id string) (T, bool) {
+	if record, ok := s.overlay(plan)[id]; ok {
+		if record == nil {
+			var zero T
+			return zero, false
+		}
+		return *record, true
+	}
+	return s.Get(id)
+}
+
+// overlay holds a dry-run request's writes; a nil entry marks a delete.
+// Plans belong to a single request, which does not write concurrently.
+func (s *Store[T]) overlay(plan *dryrun.Plan) map[string]*T {
+	return plan.Overlay(s, func() any { return map[string]*T{} }).(map[string]*T)
+}
+
+func (s *Store[T]) call(operation, id string) dryrun.Call {
+	return dryrun.Call{System: dryrun.SystemStore, Operation: operation, Resource: s.Name() + "/" + id}
+}
+
+// Name returns the name of the backing file without its extension, or
+// "memory" for an in-memory store.
+func (s *Store[T]) Name() string {
+	if s.path == "" {
+		return "memory"
+	}
+	return strings.TrimSuffix(filepath.Base(s.path), filepath.Ext(s.path))
+}
+
+// Check reports whether the store can still persist writes, by writing and
+// removing a probe file next to the backing file.
+func (s *Store[T]) Check() error {
+	if s.path == "" {
+		return nil
+	}
+
+	probe := s.path + ".check"
+	if err := os.WriteFile(probe, nil, 0o600); err != nil {
+		return fmt.Errorf("store %s is not writable: %w", s.path, err)
+	}
+	if err := os.Remove(probe); err != nil {
+		return fmt.Errorf("failed to remove probe of store %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *Store[T]) flush() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store %s: %w", s.path, err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write store %s: %w", s.path, err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace store %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// NewID returns a random 128-bit identifier encoded as hex.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
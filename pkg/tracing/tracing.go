@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/iamBelugaa/iam/internal/config"
+)
+
+const (
+	ExporterNone   string = "none"
+	ExporterOTLP   string = "otlp"
+	ExporterStdout string = "stdout"
+	ExporterFile   string = "file"
+)
+
+const instrumentationName = "github.com/iamBelugaa/iam"
+
+// Attribute keys for the resources a span works on.
+const (
+	UserID          = attribute.Key("iam.user.id")
+	GroupID         = attribute.Key("iam.group.id")
+	RoleID          = attribute.Key("iam.role.id")
+	RuleID          = attribute.Key("iam.rule.id")
+	GrantID         = attribute.Key("iam.grant.id")
+	AccessRequestID = attribute.Key("iam.access_request.id")
+	CampaignID      = attribute.Key("iam.campaign.id")
+	ItemID          = attribute.Key("iam.item.id")
+	ElevationID     = attribute.Key("iam.elevation.id")
+	ExceptionID     = attribute.Key("iam.exception.id")
+	SnapshotID      = attribute.Key("iam.snapshot.id")
+	FactorID        = attribute.Key("iam.factor.id")
+	DiffFrom        = attribute.Key("iam.diff.from")
+	DiffTo          = attribute.Key("iam.diff.to")
+	Org             = attribute.Key("iam.org")
+	OktaRequestID   = attribute.Key("okta.request_id")
+	OktaErrorCode   = attribute.Key("okta.error_code")
+)
+
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Setup installs the tracer provider configured by cfg. The returned
+// function flushes and stops the exporter. With the "none" exporter spans
+// are not recorded at all.
+func Setup(ctx context.Context, cfg *config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	var err error
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		// The endpoint, headers and protocol options come from the standard
+		// OTEL_EXPORTER_OTLP_* variables.
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer of the service.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span for a service method, named like
+// "user_service.CreateUser". The caller must end the span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Middleware starts a server span for every request, continuing the trace of
+// the caller's W3C traceparent header. The span is named by the chi route
+// pattern once the request has been routed, e.g. GET /api/v1/users/{userID}.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("http.request_id", middleware.GetReqID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status)+" "+http.StatusText(status))
+		}
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(attribute.String("http.route", pattern))
+			}
+		}
+	})
+}
+
+// UnaryServerInterceptor starts a server span for every gRPC call, named by
+// its full method, continuing the trace of the caller's traceparent metadata.
+func UnaryServerInterceptor(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = propagator.Extract(ctx, metadataCarrier(md))
+	ctx, span := Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+			attribute.String("rpc.request_id", middleware.GetReqID(ctx)),
+		),
+	)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if err != nil {
+		span.SetStatus(codes.Error, code.String())
+	}
+	return resp, err
+}
+
+// metadataCarrier reads the trace context from incoming gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}